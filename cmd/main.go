@@ -1,7 +1,24 @@
 package main
 
-import "github.com/JesusG2000/hexsatisfaction_purchase/internal/app"
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/app"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+)
 
 func main() {
-	app.Run()
+	log := logger.New(os.Stdout, logger.InfoLevel)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+
+	err := app.Run(ctx, log)
+	stop()
+	if err != nil {
+		log.Error("service stopped with error", "error", err)
+		os.Exit(1)
+	}
+	log.Info("service stopped")
 }
@@ -2,86 +2,208 @@ package app
 
 import (
 	"context"
-	"log"
 	"net"
 	"net/http"
 	"os"
-	"os/signal"
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/grpc"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/handler"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/outbox"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/server"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/watcher"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/webhook"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/cache"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
-	"github.com/go-openapi/runtime/middleware"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/filter"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/health"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/lifecycle"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/metrics"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/storage"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/tracing"
+	openapi "github.com/go-openapi/runtime/middleware"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-// Run runs hexsatisfaction_purchase service
-func Run() {
-	ctx := context.Background()
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, os.Interrupt)
-
+// Run runs hexsatisfaction_purchase service until the context is done.
+// Components are stopped in reverse order of their start, in-flight requests are drained first.
+func Run(ctx context.Context, log *logger.Logger) error {
 	cfg, err := config.Init()
 	if err != nil {
-		log.Fatal("Init config error: ", err)
+		return errors.Wrap(err, "couldn't init config")
+	}
+	log = log.WithLevel(cfg.Log.Level)
+	app := lifecycle.New(cfg.HTTP.ShutdownTimeout, log)
+
+	provider, err := tracing.New(ctx, cfg.Trace, os.Stdout)
+	if err != nil {
+		return errors.Wrap(err, "couldn't init tracing")
+	}
+	app.Append(lifecycle.Hook{Name: "tracing", Stop: provider.Shutdown})
+	tracer := provider.Tracer(tracing.InstrumentationName)
+
+	db, err := mongo.NewMongo(ctx, cfg.Mongo, options.Client().SetMonitor(tracing.CommandMonitor(tracer)))
+	if err != nil {
+		return app.Stop(errors.Wrap(err, "couldn't init db"))
+	}
+	app.Append(lifecycle.Hook{Name: "mongo", Stop: func(ctx context.Context) error {
+		return mongo.Close(ctx, db)
+	}})
+
+	moved, err := repository.SplitPurchaseCollection(ctx, db, log)
+	if err != nil {
+		return app.Stop(errors.Wrap(err, "couldn't move legacy documents"))
+	}
+	if moved != 0 {
+		log.Info("legacy comments and files moved out of purchase collection", "count", moved)
 	}
 
-	db, err := mongo.NewMongo(ctx, cfg.Mongo)
+	tokenManager, err := auth.NewManager(cfg.Auth.SigningKey, cfg.Auth.AdminIDs...)
 	if err != nil {
-		log.Fatal("Init db error: ", err)
+		return app.Stop(errors.Wrap(err, "couldn't init jwt-token"))
 	}
 
-	tokenManager, err := auth.NewManager(cfg.Auth.SigningKey)
+	systemClock := clock.System{}
+	linkSigner, err := auth.NewLinkSigner(cfg.Auth.SigningKey, cfg.Auth.LinkTTL, systemClock)
 	if err != nil {
-		log.Fatal("Init jwt-token error: ", err)
+		return app.Stop(errors.Wrap(err, "couldn't init link signer"))
 	}
+
+	collector := metrics.New()
+
 	addr := net.JoinHostPort(cfg.GRPC.Host, cfg.GRPC.Port)
-	grpcClient, err := grpc.NewGRPCClient(addr)
+	grpcClient, err := grpc.NewGRPCClient(addr, collector.UnaryClientInterceptor, tracing.UnaryClientInterceptor(tracer))
 	if err != nil {
-		log.Fatal("Init grpc client error: ", err)
+		return app.Stop(errors.Wrap(err, "couldn't init grpc client"))
+	}
+	app.Append(lifecycle.Hook{Name: "grpc client", Stop: func(ctx context.Context) error {
+		return grpcClient.Close()
+	}})
+	repos := repository.Instrument(repository.NewRepositories(db, log), collector)
+	// Writes of other instances are invalidated by change stream watchers, so caches are used only along with them.
+	var purchases, files *cache.Cache
+	if cfg.Watch.Enabled {
+		purchases = cache.New(cfg.Watch.CacheSize, cfg.Watch.CacheTTL, systemClock)
+		files = cache.New(cfg.Watch.CacheSize, cfg.Watch.CacheTTL, systemClock)
+		repos = repository.Cache(repos, purchases, files)
+	}
+	tx, err := repository.NewUnitOfWork(ctx, db, cfg.Mongo.Transactions, log)
+	if err != nil {
+		return app.Stop(errors.Wrap(err, "couldn't init unit of work"))
 	}
-	repos := repository.NewRepositories(db)
 
-	services := service.NewServices(service.Deps{
+	backend, err := storage.New(cfg.Storage, db)
+	if err != nil {
+		return app.Stop(errors.Wrap(err, "couldn't init storage"))
+	}
+
+	services := service.Instrument(service.NewServices(service.Deps{
 		Repos:        repos,
+		UnitOfWork:   tx,
 		TokenManager: tokenManager,
+		Links:        linkSigner,
 		GRPCClient:   grpcClient,
-	})
+		Filter:       filter.NewBannedWords(cfg.Filter.BannedWords, cfg.Filter.ReviewWords),
+		Storage:      backend,
+		Clock:        systemClock,
+		Log:          log,
+	}), collector)
 
-	router := handler.NewHandler(services, tokenManager)
-	routeSwagger(router)
-
-	srv := server.NewServer(cfg, router)
-	go startService(ctx, srv)
-	log.Printf("server started")
+	publisher, err := outbox.New(cfg.Outbox)
+	if err != nil {
+		return app.Stop(errors.Wrap(err, "couldn't init event publisher"))
+	}
+	// Enqueueing to webhook subscriptions is idempotent, so an event retried after failed publishing
+	// makes no duplicate deliveries.
+	events := outbox.NewMemory()
+	events.Subscribe(services.Webhook.Enqueue)
+	events.Subscribe(publisher.Publish)
+	dispatcher := outbox.NewDispatcher(repos.Outbox, events, systemClock, cfg.Outbox, log)
+	app.Append(lifecycle.Hook{
+		Name: "event dispatcher",
+		Run:  dispatcher.Run,
+		Stop: dispatcher.Stop,
+	})
 
-	<-stop
+	deliverer := webhook.NewDeliverer(repos.Webhook, repos.Delivery, systemClock, cfg.Webhook, log)
+	app.Append(lifecycle.Hook{
+		Name: "webhook deliverer",
+		Run:  deliverer.Run,
+		Stop: deliverer.Stop,
+	})
 
-	const timeout = 5 * time.Second
+	if cfg.Watch.Enabled {
+		invalidated := map[string]*cache.Cache{"purchase": purchases, "file": files}
+		for _, collection := range []string{"purchase", "comment", "file"} {
+			w := watcher.NewWatcher(collection, watcher.Collection(db.Collection(collection)), repos.ResumeToken, systemClock, cfg.Watch, log)
+			w.Subscribe(watcher.Observe(collector))
+			if c, ok := invalidated[collection]; ok {
+				w.Subscribe(watcher.Invalidate(c))
+			}
+			if cfg.Watch.Publish {
+				w.Subscribe(watcher.Publish(publisher, systemClock))
+			}
+			app.Append(lifecycle.Hook{
+				Name: collection + " watcher",
+				Run:  w.Run,
+				Stop: w.Stop,
+			})
+		}
+	}
 
-	ctx, shutdown := context.WithTimeout(context.Background(), timeout)
-	defer shutdown()
+	router := handler.NewHandler(services, tokenManager, handler.Limits{
+		Body:   cfg.HTTP.MaxBodyBytes,
+		Upload: cfg.HTTP.MaxUploadBytes,
+	}, systemClock, log)
+	router.Use(middleware.Instrument(collector), middleware.Trace(tracer))
+	router.Handle("/metrics", collector.Handler())
 
-	if err := srv.Stop(ctx); err != nil {
-		log.Printf("failed to stop server: %v", err)
-	}
+	probes := health.New()
+	probes.Add("mongo", cfg.Health.MongoTimeout, func(ctx context.Context) error {
+		return mongo.Ping(ctx, db)
+	})
+	probes.Add("grpc", cfg.Health.GRPCTimeout, grpcClient.Check)
+	router.Handle("/healthz", probes.Live())
+	router.Handle("/readyz", probes.Ready())
+	routeSwagger(router)
 
-	log.Printf("shutting down server...")
+	srv := server.NewServer(cfg, router)
+	app.Append(lifecycle.Hook{
+		Name: "http server",
+		Start: func(ctx context.Context) error {
+			if err := srv.Listen(ctx); err != nil {
+				return err
+			}
+			log.Info("server started", "addr", srv.Addr())
+			return nil
+		},
+		Run:  srv.Run,
+		Stop: srv.Stop,
+	})
+	// Readiness is turned false before the server stops, so no new requests are routed to it.
+	app.Append(lifecycle.Hook{Name: "readiness", Stop: func(ctx context.Context) error {
+		probes.Shutdown()
+		select {
+		case <-time.After(cfg.Health.ShutdownDelay):
+		case <-ctx.Done():
+		}
+		return nil
+	}})
+
+	return app.Run(ctx)
 }
 
-func startService(ctx context.Context, coreService *server.Server) {
-	if err := coreService.Run(); err != nil {
-		log.Fatal(ctx, "service shutdown: ", err.Error())
-	}
-}
 func routeSwagger(router *handler.API) {
-	ops := middleware.RedocOpts{SpecURL: "/swagger.yaml"}
-	sh := middleware.Redoc(ops, nil)
+	ops := openapi.RedocOpts{SpecURL: "/swagger.yaml"}
+	sh := openapi.Redoc(ops, nil)
 
 	router.Handle("/docs", sh)
 	router.Handle("/swagger.yaml", http.FileServer(http.Dir("./docs/")))
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/pkg/errors"
 )
@@ -10,43 +13,149 @@ import (
 type (
 	// Config represents a structure with configs for this microservice.
 	Config struct {
-		Mongo MongoConfig
-		Auth  JWTConfig
-		HTTP  HTTPConfig
-		GRPC  GRPCConfig
+		Mongo   MongoConfig
+		Auth    JWTConfig
+		HTTP    HTTPConfig
+		GRPC    GRPCConfig
+		Filter  FilterConfig
+		Storage StorageConfig
+		Log     LogConfig
+		Trace   TraceConfig
+		Health  HealthConfig
+		Outbox  OutboxConfig
+		Webhook WebhookConfig
+		Watch   WatchConfig
 	}
 	// MongoConfig represents a structure with configs for mongo database.
+	// Connection is set either by URI or by dialect, host and port. Explicit options override the ones of URI,
+	// zero values keep defaults of URI or the driver.
 	MongoConfig struct {
 		URI             string
-		Host            string `required:"true"`
-		Port            int    `required:"true"`
+		Host            string
+		Port            int
 		DatabaseName    string `split_words:"true" required:"true"`
-		DatabaseDialect string `split_words:"true" required:"true"`
+		DatabaseDialect string `split_words:"true" default:"mongodb"`
+
+		Username      string
+		Password      string
+		AuthSource    string `split_words:"true"`
+		AuthMechanism string `split_words:"true"`
+		ReplicaSet    string `split_words:"true"`
+
+		TLS                   bool
+		TLSCAFile             string `envconfig:"TLS_CA_FILE"`
+		TLSCertificateKeyFile string `envconfig:"TLS_CERTIFICATE_KEY_FILE"`
+		TLSInsecure           bool   `envconfig:"TLS_INSECURE"`
+
+		ReadConcern         string        `split_words:"true"`
+		ReadPreference      string        `split_words:"true"`
+		WriteConcern        string        `split_words:"true"`
+		WriteConcernTimeout time.Duration `split_words:"true"`
+		Journal             bool
+
+		MinPoolSize            uint64        `split_words:"true"`
+		MaxPoolSize            uint64        `split_words:"true"`
+		MaxConnIdleTime        time.Duration `split_words:"true"`
+		ConnectTimeout         time.Duration `split_words:"true"`
+		ServerSelectionTimeout time.Duration `split_words:"true"`
+		SocketTimeout          time.Duration `split_words:"true"`
+
+		Transactions string `default:"auto"`
 	}
 	// JWTConfig represents a structure with configs for jwt-token.
 	JWTConfig struct {
-		SigningKey string `split_words:"true" required:"true"`
+		SigningKey string        `split_words:"true" required:"true"`
+		AdminIDs   []string      `split_words:"true"`
+		LinkTTL    time.Duration `split_words:"true" default:"15m"`
 	}
 	// HTTPConfig represents a structure with configs for http server.
 	HTTPConfig struct {
-		Host           string        `required:"true"`
-		Port           int           `required:"true"`
-		MaxHeaderBytes int           `split_words:"true" required:"true"`
-		ReadTimeout    time.Duration `split_words:"true" required:"true"`
-		WriteTimeout   time.Duration `split_words:"true" required:"true"`
+		Host            string        `required:"true"`
+		Port            int           `required:"true"`
+		MaxHeaderBytes  int           `split_words:"true" required:"true"`
+		ReadTimeout     time.Duration `split_words:"true" required:"true"`
+		WriteTimeout    time.Duration `split_words:"true" required:"true"`
+		MaxBodyBytes    int64         `split_words:"true" default:"1048576"`
+		MaxUploadBytes  int64         `split_words:"true" default:"104857600"`
+		ShutdownTimeout time.Duration `split_words:"true" default:"10s"`
 	}
 	// GRPCConfig represents a structure with configs for grpc.
 	GRPCConfig struct {
 		Host string `required:"true"`
 		Port string `required:"true"`
 	}
+	// FilterConfig represents a structure with configs for comment content filter.
+	FilterConfig struct {
+		BannedWords []string `split_words:"true"`
+		ReviewWords []string `split_words:"true"`
+	}
+	// StorageConfig represents a structure with configs for file content storage.
+	StorageConfig struct {
+		Backend string `default:"local"`
+		Dir     string `default:"data/files"`
+		Bucket  string `default:"files"`
+	}
+	// LogConfig represents a structure with configs for logger.
+	LogConfig struct {
+		Level logger.Level `default:"info"`
+	}
+	// TraceConfig represents a structure with configs for tracing.
+	TraceConfig struct {
+		Exporter    string  `default:"none"`
+		Endpoint    string  `default:"http://localhost:4318/v1/traces"`
+		ServiceName string  `split_words:"true" default:"hexsatisfaction_purchase"`
+		SampleRatio float64 `split_words:"true" default:"1"`
+	}
+	// HealthConfig represents a structure with configs for health checks.
+	HealthConfig struct {
+		MongoTimeout  time.Duration `split_words:"true" default:"2s"`
+		GRPCTimeout   time.Duration `split_words:"true" default:"2s"`
+		ShutdownDelay time.Duration `split_words:"true" default:"0s"`
+	}
+	// OutboxConfig represents a structure with configs for dispatching of domain events.
+	OutboxConfig struct {
+		Publisher    string        `default:"memory"`
+		WebhookURL   string        `envconfig:"WEBHOOK_URL"`
+		Timeout      time.Duration `default:"10s"`
+		PollInterval time.Duration `split_words:"true" default:"1s"`
+		Lease        time.Duration `default:"30s"`
+		Backoff      time.Duration `default:"1s"`
+		MaxBackoff   time.Duration `split_words:"true" default:"5m"`
+	}
+	// WebhookConfig represents a structure with configs for deliveries to webhook subscriptions.
+	WebhookConfig struct {
+		Timeout      time.Duration `default:"10s"`
+		PollInterval time.Duration `split_words:"true" default:"1s"`
+		Lease        time.Duration `default:"1m"`
+		Backoff      time.Duration `default:"10s"`
+		MaxBackoff   time.Duration `split_words:"true" default:"1h"`
+		MaxAttempts  int           `split_words:"true" default:"10"`
+	}
+	// WatchConfig represents a structure with configs for change stream watchers and the read caches they invalidate.
+	// Change streams require a replica set, so watchers are off by default and caches are used only along with them.
+	WatchConfig struct {
+		Enabled   bool
+		Name      string        `default:"hexsatisfaction_purchase"`
+		Retry     time.Duration `default:"5s"`
+		Publish   bool
+		CacheSize int           `split_words:"true" default:"10000"`
+		CacheTTL  time.Duration `envconfig:"CACHE_TTL" default:"1m"`
+	}
 )
 
 const (
-	MONGO = "MONGO"
-	JWT   = "JWT"
-	HTTP  = "HTTP"
-	GRPC  = "GRPC"
+	MONGO   = "MONGO"
+	JWT     = "JWT"
+	HTTP    = "HTTP"
+	GRPC    = "GRPC"
+	FILTER  = "FILTER"
+	STORAGE = "STORAGE"
+	LOG     = "LOG"
+	TRACE   = "TRACE"
+	HEALTH  = "HEALTH"
+	OUTBOX  = "OUTBOX"
+	WEBHOOK = "WEBHOOK"
+	WATCH   = "WATCH"
 )
 
 // Init populates Config struct with values.
@@ -56,6 +165,9 @@ func Init() (*Config, error) {
 	if err := envconfig.Process(MONGO, &cfg.Mongo); err != nil {
 		return nil, errors.Wrap(err, "couldn't process mongo")
 	}
+	if err := cfg.Mongo.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid mongo config")
+	}
 
 	if err := envconfig.Process(JWT, &cfg.Auth); err != nil {
 		return nil, errors.Wrap(err, "couldn't process jwt")
@@ -69,5 +181,186 @@ func Init() (*Config, error) {
 		return nil, errors.Wrap(err, "couldn't process grpc")
 	}
 
+	if err := envconfig.Process(FILTER, &cfg.Filter); err != nil {
+		return nil, errors.Wrap(err, "couldn't process filter")
+	}
+
+	if err := envconfig.Process(STORAGE, &cfg.Storage); err != nil {
+		return nil, errors.Wrap(err, "couldn't process storage")
+	}
+
+	if err := envconfig.Process(LOG, &cfg.Log); err != nil {
+		return nil, errors.Wrap(err, "couldn't process log")
+	}
+
+	if err := envconfig.Process(TRACE, &cfg.Trace); err != nil {
+		return nil, errors.Wrap(err, "couldn't process trace")
+	}
+
+	if err := envconfig.Process(HEALTH, &cfg.Health); err != nil {
+		return nil, errors.Wrap(err, "couldn't process health")
+	}
+
+	if err := envconfig.Process(OUTBOX, &cfg.Outbox); err != nil {
+		return nil, errors.Wrap(err, "couldn't process outbox")
+	}
+	if err := cfg.Outbox.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid outbox config")
+	}
+
+	if err := envconfig.Process(WEBHOOK, &cfg.Webhook); err != nil {
+		return nil, errors.Wrap(err, "couldn't process webhook")
+	}
+	if err := cfg.Webhook.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid webhook config")
+	}
+
+	if err := envconfig.Process(WATCH, &cfg.Watch); err != nil {
+		return nil, errors.Wrap(err, "couldn't process watch")
+	}
+	if err := cfg.Watch.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid watch config")
+	}
+
 	return &cfg, nil
 }
+
+var (
+	mongoReadConcerns    = []string{"local", "available", "majority", "linearizable", "snapshot"}
+	mongoReadPreferences = []string{"primary", "primaryPreferred", "secondary", "secondaryPreferred", "nearest"}
+	mongoTransactions    = []string{"auto", "on", "off"}
+)
+
+// Validate checks that mongo connection is set once and its options are consistent.
+func (c MongoConfig) Validate() error {
+	for _, validate := range []func() error{c.validateConnection, c.validateAuth, c.validateConcerns, c.validatePool} {
+		if err := validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c MongoConfig) validateConnection() error {
+	switch {
+	case c.URI != "" && c.Host != "":
+		return errors.New("uri and host are mutually exclusive")
+	case c.URI == "" && c.Host == "":
+		return errors.New("uri or host is required")
+	case c.URI != "":
+		if !strings.HasPrefix(c.URI, "mongodb://") && !strings.HasPrefix(c.URI, "mongodb+srv://") {
+			return errors.New("uri must start with mongodb:// or mongodb+srv://")
+		}
+	case c.Port <= 0 || c.Port > 65535:
+		return errors.Errorf("port %d is out of range", c.Port)
+	case c.DatabaseDialect != "mongodb" && c.DatabaseDialect != "mongodb+srv":
+		return errors.Errorf("unknown dialect %q", c.DatabaseDialect)
+	}
+
+	return nil
+}
+
+func (c MongoConfig) validateAuth() error {
+	switch {
+	case c.Username == "" && (c.Password != "" || c.AuthSource != "" || c.AuthMechanism != ""):
+		return errors.New("password, auth source and mechanism require username")
+	case !c.TLS && (c.TLSCAFile != "" || c.TLSCertificateKeyFile != "" || c.TLSInsecure):
+		return errors.New("tls options require tls")
+	}
+
+	return nil
+}
+
+func (c MongoConfig) validateConcerns() error {
+	switch {
+	case c.ReadConcern != "" && !contains(mongoReadConcerns, c.ReadConcern):
+		return errors.Errorf("unknown read concern %q", c.ReadConcern)
+	case c.ReadPreference != "" && !contains(mongoReadPreferences, c.ReadPreference):
+		return errors.Errorf("unknown read preference %q", c.ReadPreference)
+	case c.WriteConcern != "" && c.WriteConcern != "majority" && !isNumber(c.WriteConcern):
+		return errors.Errorf("write concern %q is neither majority nor number of nodes", c.WriteConcern)
+	case c.WriteConcernTimeout < 0:
+		return errors.New("write concern timeout must not be negative")
+	case !contains(mongoTransactions, c.Transactions):
+		return errors.Errorf("unknown transactions mode %q", c.Transactions)
+	}
+
+	return nil
+}
+
+func (c MongoConfig) validatePool() error {
+	if c.MaxPoolSize != 0 && c.MinPoolSize > c.MaxPoolSize {
+		return errors.New("min pool size exceeds max pool size")
+	}
+	for _, timeout := range []time.Duration{c.MaxConnIdleTime, c.ConnectTimeout, c.ServerSelectionTimeout, c.SocketTimeout} {
+		if timeout < 0 {
+			return errors.New("pool timeouts must not be negative")
+		}
+	}
+
+	return nil
+}
+
+// Validate checks that the publisher is known and intervals are positive.
+func (c OutboxConfig) Validate() error {
+	switch {
+	case c.Publisher != "memory" && c.Publisher != "webhook":
+		return errors.Errorf("unknown publisher %q", c.Publisher)
+	case c.Publisher == "webhook" && c.WebhookURL == "":
+		return errors.New("webhook publisher requires url")
+	case c.Timeout <= 0 || c.PollInterval <= 0 || c.Lease <= 0 || c.Backoff <= 0:
+		return errors.New("timeout, poll interval, lease and backoff must be positive")
+	case c.MaxBackoff < c.Backoff:
+		return errors.New("max backoff is less than backoff")
+	}
+
+	return nil
+}
+
+// Validate checks that intervals and attempts are positive.
+func (c WebhookConfig) Validate() error {
+	switch {
+	case c.Timeout <= 0 || c.PollInterval <= 0 || c.Lease <= 0 || c.Backoff <= 0:
+		return errors.New("timeout, poll interval, lease and backoff must be positive")
+	case c.MaxBackoff < c.Backoff:
+		return errors.New("max backoff is less than backoff")
+	case c.MaxAttempts <= 0:
+		return errors.New("max attempts must be positive")
+	case c.Lease <= c.Timeout:
+		return errors.New("lease must be longer than timeout")
+	}
+
+	return nil
+}
+
+// Validate checks that enabled watchers have a name to keep resume tokens by and positive intervals and cache size.
+func (c WatchConfig) Validate() error {
+	switch {
+	case !c.Enabled:
+		return nil
+	case c.Name == "":
+		return errors.New("name is required")
+	case c.Retry <= 0 || c.CacheTTL <= 0:
+		return errors.New("retry and cache ttl must be positive")
+	case c.CacheSize <= 0:
+		return errors.New("cache size must be positive")
+	}
+
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isNumber(s string) bool {
+	n, err := strconv.Atoi(s)
+	return err == nil && n >= 0
+}
@@ -0,0 +1,127 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	testAssert "github.com/stretchr/testify/assert"
+)
+
+func TestMongoConfig_Validate(t *testing.T) {
+	assert := testAssert.New(t)
+
+	valid := MongoConfig{Host: "localhost", Port: 27017, DatabaseName: "test", DatabaseDialect: "mongodb", Transactions: "auto"}
+
+	type test struct {
+		name   string
+		change func(c *MongoConfig)
+		expErr string
+	}
+
+	tt := []test{
+		{
+			name:   "host",
+			change: func(c *MongoConfig) {},
+		},
+		{
+			name: "uri with options",
+			change: func(c *MongoConfig) {
+				c.Host = ""
+				c.URI = "mongodb://user:pass@a:27017,b:27017/?replicaSet=rs0"
+				c.Username = "admin"
+				c.AuthSource = "admin"
+				c.TLS = true
+				c.TLSInsecure = true
+				c.ReadConcern = "majority"
+				c.ReadPreference = "secondaryPreferred"
+				c.WriteConcern = "2"
+				c.WriteConcernTimeout = time.Second
+				c.MinPoolSize = 5
+				c.MaxPoolSize = 50
+			},
+		},
+		{
+			name:   "uri and host",
+			change: func(c *MongoConfig) { c.URI = "mongodb://localhost" },
+			expErr: "uri and host are mutually exclusive",
+		},
+		{
+			name:   "no connection",
+			change: func(c *MongoConfig) { c.Host = "" },
+			expErr: "uri or host is required",
+		},
+		{
+			name: "uri scheme",
+			change: func(c *MongoConfig) {
+				c.Host = ""
+				c.URI = "http://localhost"
+			},
+			expErr: "uri must start with mongodb:// or mongodb+srv://",
+		},
+		{
+			name:   "port",
+			change: func(c *MongoConfig) { c.Port = 0 },
+			expErr: "port 0 is out of range",
+		},
+		{
+			name:   "dialect",
+			change: func(c *MongoConfig) { c.DatabaseDialect = "postgres" },
+			expErr: `unknown dialect "postgres"`,
+		},
+		{
+			name:   "password without username",
+			change: func(c *MongoConfig) { c.Password = "pass" },
+			expErr: "password, auth source and mechanism require username",
+		},
+		{
+			name:   "tls options without tls",
+			change: func(c *MongoConfig) { c.TLSCAFile = "ca.pem" },
+			expErr: "tls options require tls",
+		},
+		{
+			name:   "read concern",
+			change: func(c *MongoConfig) { c.ReadConcern = "strong" },
+			expErr: `unknown read concern "strong"`,
+		},
+		{
+			name:   "read preference",
+			change: func(c *MongoConfig) { c.ReadPreference = "any" },
+			expErr: `unknown read preference "any"`,
+		},
+		{
+			name:   "write concern",
+			change: func(c *MongoConfig) { c.WriteConcern = "all" },
+			expErr: `write concern "all" is neither majority nor number of nodes`,
+		},
+		{
+			name:   "transactions",
+			change: func(c *MongoConfig) { c.Transactions = "always" },
+			expErr: `unknown transactions mode "always"`,
+		},
+		{
+			name: "pool size",
+			change: func(c *MongoConfig) {
+				c.MinPoolSize = 10
+				c.MaxPoolSize = 5
+			},
+			expErr: "min pool size exceeds max pool size",
+		},
+		{
+			name:   "timeout",
+			change: func(c *MongoConfig) { c.ConnectTimeout = -time.Second },
+			expErr: "pool timeouts must not be negative",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := valid
+			tc.change(&cfg)
+			err := cfg.Validate()
+			if tc.expErr == "" {
+				assert.NoError(err)
+			} else {
+				assert.EqualError(err, tc.expErr)
+			}
+		})
+	}
+}
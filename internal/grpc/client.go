@@ -1,20 +1,54 @@
 package grpc
 
 import (
+	"context"
+	"strings"
+
 	"github.com/JesusG2000/hexsatisfaction/pkg/grpc/api"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/metadata"
 )
 
 type Checker struct {
 	api.ExistanceClient
+	conn *grpc.ClientConn
 }
 
-func NewGRPCClient(addr string) (*Checker, error) {
-	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+func NewGRPCClient(addr string, interceptors ...grpc.UnaryClientInterceptor) (*Checker, error) {
+	interceptors = append([]grpc.UnaryClientInterceptor{requestID}, interceptors...)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure(), grpc.WithChainUnaryInterceptor(interceptors...))
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't dial connection with gprc")
 	}
 
-	return &Checker{api.NewExistanceClient(conn)}, nil
+	return &Checker{api.NewExistanceClient(conn), conn}, nil
+}
+
+// Check waits until the connection is ready and returns error if it isn't ready before the context is done.
+func (c *Checker) Check(ctx context.Context) error {
+	for state := c.conn.GetState(); state != connectivity.Ready; state = c.conn.GetState() {
+		if state == connectivity.Shutdown || !c.conn.WaitForStateChange(ctx, state) {
+			return errors.Errorf("grpc connection is %s", strings.ToLower(state.String()))
+		}
+	}
+
+	return nil
+}
+
+// Close closes the connection.
+func (c *Checker) Close() error {
+	return c.conn.Close()
+}
+
+// requestID propagates request id of the context to the called service in metadata.
+func requestID(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	if id, ok := logger.RequestID(ctx); ok {
+		ctx = metadata.AppendToOutgoingContext(ctx, strings.ToLower(middleware.RequestIDHeader), id)
+	}
+
+	return invoker(ctx, method, req, reply, cc, opts...)
 }
@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,26 +11,36 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type commentRouter struct {
 	*mux.Router
 	services     *service.Services
 	tokenManager auth.TokenManager
+	limits       Limits
+	clock        clock.Clock
 }
 
-func newComment(services *service.Services, tokenManager auth.TokenManager) commentRouter {
+func newComment(services *service.Services, tokenManager auth.TokenManager, limits Limits, clock clock.Clock) commentRouter {
 	router := mux.NewRouter().PathPrefix(commentPath).Subrouter()
+	router.Use(middleware.Route)
 	handler := commentRouter{
 		router,
 		services,
 		tokenManager,
+		limits,
+		clock,
 	}
 
+	router.Path("/period").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findByPeriodComment)
+
 	router.Path("/user/{id}").
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findByUserIDComment)
@@ -40,17 +49,25 @@ func newComment(services *service.Services, tokenManager auth.TokenManager) comm
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findByPurchaseIDComment)
 
+	router.Path("/purchase/{id}/tree").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findTreeByPurchaseIDComment)
+
+	router.Path("/file/{id}/tree").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findTreeByFileIDComment)
+
 	router.Path("/user/{userID}/purchase/{purchaseID}").
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findByUserIDAndPurchaseIDComment)
 
 	router.Path("/text").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.findByTextComment)
+		Handler(jsonBody(handler.limits.Body, handler.findByTextComment))
 
 	router.Path("/period").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.findByPeriodComment)
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findByPeriodComment)))
 
 	secure := router.PathPrefix("/api").Subrouter()
 	secure.Use(handler.tokenManager.UserIdentity)
@@ -61,11 +78,15 @@ func newComment(services *service.Services, tokenManager auth.TokenManager) comm
 
 	secure.Path("/").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.createComment)
+		Handler(jsonBody(handler.limits.Body, handler.createComment))
 
 	secure.Path("/{id}").
 		Methods(http.MethodPut).
-		HandlerFunc(handler.updateComment)
+		Handler(jsonBody(handler.limits.Body, handler.updateComment))
+
+	secure.Path("/{id}").
+		Methods(http.MethodPatch).
+		Handler(jsonBody(handler.limits.Body, handler.patchComment, middleware.MIMEMergePatchJSON))
 
 	secure.Path("/{id}").
 		Methods(http.MethodDelete).
@@ -75,6 +96,29 @@ func newComment(services *service.Services, tokenManager auth.TokenManager) comm
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findByIDComment)
 
+	secure.Path("/{id}/revisions").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findRevisionsComment)
+
+	secure.Path("/{id}/flag").
+		Methods(http.MethodPost).
+		HandlerFunc(handler.flagComment)
+
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(handler.tokenManager.AdminIdentity)
+
+	admin.Path("/queue").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findForReviewComment)
+
+	admin.Path("/{id}/status").
+		Methods(http.MethodPut).
+		Handler(jsonBody(handler.limits.Body, handler.reviewComment))
+
+	admin.Path("/import").
+		Methods(http.MethodPost).
+		Handler(jsonBody(handler.limits.Body, handler.importComment))
+
 	return handler
 }
 
@@ -82,9 +126,9 @@ type createCommentRequest struct {
 	model.CreateCommentRequest
 }
 
-// Build builds request for create comment.
+// Build builds request to create comment of the current user. User id of the body is ignored.
 func (req *createCommentRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.CreateCommentRequest)
+	err := middleware.DecodeJSON(r.Body, &req.CreateCommentRequest)
 	if err != nil {
 		return err
 	}
@@ -92,27 +136,37 @@ func (req *createCommentRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
-// Validate validates request for create comment.
+// Validate validates fields of request for create comment which depend on its parent.
 func (req *createCommentRequest) Validate() error {
-	switch {
-	case req.UserID == 0:
-		return fmt.Errorf("not correct user id")
-	case req.PurchaseID == "":
-		return fmt.Errorf("not correct purchase id")
-	case req.Date == time.Time{}:
-		return fmt.Errorf("date is required")
-	case req.Text == "":
-		return fmt.Errorf("text is required")
-	default:
-		return nil
+	return validateReply(req.CreateCommentRequest)
+}
+
+// validateReply validates that comment has either purchase or parent and the reply has no rating.
+func validateReply(req model.CreateCommentRequest) error {
+	var errs middleware.ValidationErrors
+	if req.PurchaseID == "" && req.ParentID == "" {
+		errs = append(errs, middleware.FieldError{Field: "purchaseID", Rule: "required", Message: "purchase id is required"})
+	}
+	if req.Rating != 0 && req.ParentID != "" {
+		errs = append(errs, middleware.FieldError{Field: "rating", Rule: "reply", Message: "reply couldn't have rating"})
 	}
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
 }
 
 // @Summary Create
@@ -123,7 +177,10 @@ func (req *createCommentRequest) Validate() error {
 // @Produce  json
 // @Param comment body model.CreateCommentRequest true "Comment"
 // @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 409 {object} middleware.SwagError
+// @Failure 422 {object} middleware.SwagError
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/api/ [post]
 func (c *commentRouter) createComment(w http.ResponseWriter, r *http.Request) {
@@ -135,6 +192,18 @@ func (c *commentRouter) createComment(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, err := c.services.Comment.Create(r.Context(), req.CreateCommentRequest)
+	if errors.Is(err, service.ErrRatingNotAllowed) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrParentDeleted) || errors.Is(err, service.ErrAlreadyRated) {
+		middleware.JSONError(w, err, http.StatusConflict)
+		return
+	}
+	if errors.Is(err, service.ErrCommentRejected) {
+		middleware.JSONError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusInternalServerError)
 		return
@@ -147,9 +216,9 @@ type updateCommentRequest struct {
 	model.UpdateCommentRequest
 }
 
-// Build builds request for update comment.
+// Build builds request to update comment of the current user.
 func (req *updateCommentRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.UpdateCommentRequest)
+	err := middleware.DecodeJSON(r.Body, &req.UpdateCommentRequest)
 	if err != nil {
 		return err
 	}
@@ -157,7 +226,7 @@ func (req *updateCommentRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
@@ -166,27 +235,20 @@ func (req *updateCommentRequest) Build(r *http.Request) error {
 		return fmt.Errorf("no id")
 	}
 
-	req.ID = vID
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
 
-	return nil
-}
+	req.ID = vID
 
-// Validate validates request for update comment.
-func (req *updateCommentRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	case req.UserID == 0:
-		return fmt.Errorf("not correct user id")
-	case !primitive.IsValidObjectID(req.PurchaseID):
-		return fmt.Errorf("not correct purchase id")
-	case req.Date == time.Time{}:
-		return fmt.Errorf("date is required")
-	case req.Text == "":
-		return fmt.Errorf("text is required")
-	default:
-		return nil
+	revision, err := ifMatch(r)
+	if err != nil {
+		return err
 	}
+	req.Revision = revision
+
+	return nil
 }
 
 // @Summary Update
@@ -196,21 +258,51 @@ func (req *updateCommentRequest) Validate() error {
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Comment id"
+// @Param If-Match header string true "Revision entity tag"
 // @Param comment body model.UpdateCommentRequest true "Comment"
 // @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 409 {object} middleware.SwagError
 // @Failure 404 {object} middleware.SwagEmptyError "No comment"
+// @Failure 422 {object} middleware.SwagError
+// @Failure 412 {object} middleware.SwagError
+// @Failure 428 {object} middleware.SwagError
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/api/{id} [put]
 func (c *commentRouter) updateComment(w http.ResponseWriter, r *http.Request) {
 	var req updateCommentRequest
 	err := middleware.ParseRequest(r, &req)
+	if errors.Is(err, errIfMatchRequired) {
+		middleware.JSONError(w, err, http.StatusPreconditionRequired)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
 		return
 	}
 
 	id, err := c.services.Comment.Update(r.Context(), req.UpdateCommentRequest)
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, service.ErrRatingNotAllowed) || errors.Is(err, service.ErrNotCommentOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrAlreadyRated) {
+		middleware.JSONError(w, err, http.StatusConflict)
+		return
+	}
+	if errors.Is(err, service.ErrCommentRejected) || errors.Is(err, service.ErrReplyRating) {
+		middleware.JSONError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusInternalServerError)
 		return
@@ -224,53 +316,199 @@ func (c *commentRouter) updateComment(w http.ResponseWriter, r *http.Request) {
 	middleware.JSONReturn(w, http.StatusOK, id)
 }
 
-type deleteCommentRequest struct {
-	model.DeleteCommentRequest
+type patchCommentRequest struct {
+	model.PatchCommentRequest
 }
 
-// Build builds request for delete comment.
-func (req *deleteCommentRequest) Build(r *http.Request) error {
+// Build builds request to patch comment of the current user from JSON Merge Patch document.
+// Removed rating means that comment isn't rated.
+func (req *patchCommentRequest) Build(r *http.Request) error {
+	patch, err := middleware.DecodeMergePatch(r.Body)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	for name, value := range patch {
+		switch {
+		case name == "text" && middleware.IsNull(value):
+			return fmt.Errorf("text couldn't be removed")
+		case name == "text":
+			err = json.Unmarshal(value, &req.Text)
+		case name == "rating" && middleware.IsNull(value):
+			req.Rating = new(int)
+		case name == "rating":
+			err = json.Unmarshal(value, &req.Rating)
+		default:
+			return fmt.Errorf("unknown field %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("not correct %s", name)
+		}
+	}
+
 	vID, ok := mux.Vars(r)["id"]
 	if !ok {
 		return fmt.Errorf("no id")
 	}
 
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
 	req.ID = vID
 
+	revision, err := ifMatch(r)
+	if err != nil {
+		return err
+	}
+	req.Revision = revision
+
 	return nil
 }
 
-// Validate validates request for delete comment.
-func (req *deleteCommentRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
+// @Summary Patch
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description Update provided text and rating of comment with JSON Merge Patch
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Comment id"
+// @Param If-Match header string true "Revision entity tag"
+// @Param comment body model.PatchCommentRequest true "Comment"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 409 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No comment"
+// @Failure 422 {object} middleware.SwagError
+// @Failure 412 {object} middleware.SwagError
+// @Failure 428 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/api/{id} [patch]
+func (c *commentRouter) patchComment(w http.ResponseWriter, r *http.Request) {
+	var req patchCommentRequest
+	err := middleware.ParseRequest(r, &req)
+	if errors.Is(err, errIfMatchRequired) {
+		middleware.JSONError(w, err, http.StatusPreconditionRequired)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.services.Comment.Patch(r.Context(), req.PatchCommentRequest)
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, service.ErrRatingNotAllowed) || errors.Is(err, service.ErrNotCommentOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrAlreadyRated) {
+		middleware.JSONError(w, err, http.StatusConflict)
+		return
+	}
+	if errors.Is(err, service.ErrCommentRejected) || errors.Is(err, service.ErrReplyRating) {
+		middleware.JSONError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type deleteCommentRequest struct {
+	model.DeleteCommentRequest
+}
+
+// Build builds request of the current user to delete comment.
+func (req *deleteCommentRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
 	}
+
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	req.ID = vID
+	req.Admin = auth.IsAdmin(r.Context())
+
+	revision, err := ifMatch(r)
+	if err != nil {
+		return err
+	}
+	req.Revision = revision
+
+	return nil
 }
 
 // @Summary Delete
 // @Security ApiKeyAuth
 // @Tags comment
-// @Description Delete comment
+// @Description Delete comment of the current user, admins could delete any comment
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Comment id"
+// @Param If-Match header string true "Revision entity tag"
 // @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
 // @Failure 404 {object} middleware.SwagEmptyError "No comment"
+// @Failure 412 {object} middleware.SwagError
+// @Failure 428 {object} middleware.SwagError
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/api/{id} [delete]
 func (c *commentRouter) deleteComment(w http.ResponseWriter, r *http.Request) {
 	var req deleteCommentRequest
 	err := middleware.ParseRequest(r, &req)
+	if errors.Is(err, errIfMatchRequired) {
+		middleware.JSONError(w, err, http.StatusPreconditionRequired)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
 		return
 	}
 
 	id, err := c.services.Comment.Delete(r.Context(), req.DeleteCommentRequest)
+	if errors.Is(err, service.ErrNotCommentOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusInternalServerError)
 		return
@@ -288,37 +526,35 @@ type idCommentRequest struct {
 	model.IDCommentRequest
 }
 
-// Build builds request to find comment by id.
+// Build builds request of the current user to find comment by id.
 func (req *idCommentRequest) Build(r *http.Request) error {
 	vID, ok := mux.Vars(r)["id"]
 	if !ok {
 		return fmt.Errorf("no id")
 	}
 
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
 	req.ID = vID
+	req.Admin = auth.IsAdmin(r.Context())
 
 	return nil
 }
 
-// Validate validates request to find comment by id.
-func (req *idCommentRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByID
 // @Security ApiKeyAuth
 // @Tags comment
-// @Description Find comment by id
+// @Description Find published comment by id, hidden comments are found for their author and admins
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Comment id"
 // @Success 200 {object} model.Comment
-// @Failure 400 {object} middleware.SwagError
+// @Header 200 {string} ETag "Revision entity tag"
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No comment"
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/api/{id} [get]
@@ -341,9 +577,44 @@ func (c *commentRouter) findByIDComment(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	w.Header().Set("ETag", eTag(comment.Revision))
 	middleware.JSONReturn(w, http.StatusOK, comment)
 }
 
+// @Summary FindRevisions
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description Find previous versions of comment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Comment id"
+// @Success 200 {array} model.CommentRevisionDTO
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No revisions"
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/api/{id}/revisions [get]
+func (c *commentRouter) findRevisionsComment(w http.ResponseWriter, r *http.Request) {
+	var req idCommentRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	revisions, err := c.services.Comment.FindRevisions(r.Context(), req.IDCommentRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(revisions) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, revisions)
+}
+
 type userIDCommentRequest struct {
 	model.UserIDCommentRequest
 }
@@ -364,24 +635,14 @@ func (req *userIDCommentRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find comment by user id.
-func (req *userIDCommentRequest) Validate() error {
-	switch {
-	case req.ID == 0:
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindAllByUserID
 // @Tags comment
-// @Description Find comments by user id
+// @Description Find published comments by user id
 // @Accept  json
 // @Produce  json
 // @Param id path string true "User id"
 // @Success 200 {array} model.Comment
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No comments"
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/user/{id} [get]
@@ -423,16 +684,6 @@ func (req *purchaseIDCommentRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find comment by user id.
-func (req *purchaseIDCommentRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByPurchaseID
 // @Tags comment
 // @Description Find comments by purchase id
@@ -440,7 +691,7 @@ func (req *purchaseIDCommentRequest) Validate() error {
 // @Produce  json
 // @Param id path string true "Purchase id"
 // @Success 200 {array} model.Comment
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No comments"
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/purchase/{id} [get]
@@ -466,6 +717,88 @@ func (c *commentRouter) findByPurchaseIDComment(w http.ResponseWriter, r *http.R
 	middleware.JSONReturn(w, http.StatusOK, comments)
 }
 
+// @Summary FindTreeByPurchaseID
+// @Tags comment
+// @Description Find comment threads by purchase id
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Purchase id"
+// @Success 200 {array} model.CommentNode
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No comments"
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/purchase/{id}/tree [get]
+func (c *commentRouter) findTreeByPurchaseIDComment(w http.ResponseWriter, r *http.Request) {
+	var req purchaseIDCommentRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	comments, err := c.services.Comment.FindTreeByPurchaseID(r.Context(), req.PurchaseIDCommentRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(comments) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, comments)
+}
+
+type fileIDCommentRequest struct {
+	model.FileIDCommentRequest
+}
+
+// Build builds request to find comments by file id.
+func (req *fileIDCommentRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	req.ID = vID
+
+	return nil
+}
+
+// @Summary FindTreeByFileID
+// @Tags comment
+// @Description Find comment threads by file id
+// @Accept  json
+// @Produce  json
+// @Param id path string true "File id"
+// @Success 200 {array} model.CommentNode
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No comments"
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/file/{id}/tree [get]
+func (c *commentRouter) findTreeByFileIDComment(w http.ResponseWriter, r *http.Request) {
+	var req fileIDCommentRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	comments, err := c.services.Comment.FindTreeByFileID(r.Context(), req.FileIDCommentRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(comments) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, comments)
+}
+
 type userPurchaseIDCommentRequest struct {
 	model.UserPurchaseIDCommentRequest
 }
@@ -493,27 +826,15 @@ func (req *userPurchaseIDCommentRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find comment by user id and purchase id.
-func (req *userPurchaseIDCommentRequest) Validate() error {
-	switch {
-	case req.UserID == 0:
-		return fmt.Errorf("not correct user id")
-	case !primitive.IsValidObjectID(req.PurchaseID):
-		return fmt.Errorf("not correct purchase id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByUserIDAndPurchaseID
 // @Tags comment
-// @Description Find comments by purchase and user ids
+// @Description Find published comments by purchase and user ids
 // @Accept  json
 // @Produce  json
 // @Param userID path string true "User id"
 // @Param purchaseID path string true "Purchase id"
 // @Success 200 {array} model.Comment
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No comments"
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/user/{userID}/purchase/{purchaseID} [get]
@@ -542,11 +863,11 @@ func (c *commentRouter) findByUserIDAndPurchaseIDComment(w http.ResponseWriter,
 // @Summary FindAll
 // @Security ApiKeyAuth
 // @Tags comment
-// @Description Find all comments
+// @Description Find all published comments
 // @Accept  json
 // @Produce  json
 // @Success 200 {array} model.Comment
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No comments"
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/api/ [get]
@@ -571,7 +892,7 @@ type textCommentRequest struct {
 
 // Build builds request to find comment by text.
 func (req *textCommentRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.TextCommentRequest)
+	err := middleware.DecodeJSON(r.Body, &req.TextCommentRequest)
 	if err != nil {
 		return err
 	}
@@ -579,31 +900,21 @@ func (req *textCommentRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
 	return nil
 }
 
-// Validate validates request to find comment by text.
-func (req *textCommentRequest) Validate() error {
-	switch {
-	case req.Text == "":
-		return fmt.Errorf("text is required")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByText
 // @Tags comment
-// @Description Find comments by text
+// @Description Find comments by text ranked by relevance
 // @Accept  json
 // @Produce  json
 // @Param text body model.TextCommentRequest true "Comment text"
 // @Success 200 {array} model.Comment
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No comments"
 // @Failure 500 {object} middleware.SwagError
 // @Router /comment/text [post]
@@ -631,11 +942,16 @@ func (c *commentRouter) findByTextComment(w http.ResponseWriter, r *http.Request
 
 type periodCommentRequest struct {
 	model.PeriodCommentRequest
+	now time.Time
 }
 
 // Build builds request to find comment by date period.
 func (req *periodCommentRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.PeriodCommentRequest)
+	if r.Method == http.MethodGet {
+		return queryPeriod(r, req.now, &req.Start, &req.End)
+	}
+
+	err := middleware.DecodeJSON(r.Body, &req.PeriodCommentRequest)
 	if err != nil {
 		return err
 	}
@@ -643,38 +959,29 @@ func (req *periodCommentRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
 	return nil
 }
 
-// Validate validates request to find comment by date period.
-func (req *periodCommentRequest) Validate() error {
-	switch {
-	case req.Start == time.Time{}:
-		return fmt.Errorf("invalid start")
-	case req.End == time.Time{}:
-		return fmt.Errorf("invalid end")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByPeriod
 // @Tags comment
-// @Description Find comments by period
+// @Description Find comments by period, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.PeriodCommentRequest true "Comment period"
+// @Param period body model.PeriodCommentRequest false "Comment period, deprecated POST only"
+// @Param start query string false "Start as RFC3339 time, now, today or last N with m, h, d or w unit"
+// @Param end query string false "End, now by default, as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Success 200 {array} model.Comment
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No comments"
 // @Failure 500 {object} middleware.SwagError
+// @Router /comment/period [get]
 // @Router /comment/period [post]
 func (c *commentRouter) findByPeriodComment(w http.ResponseWriter, r *http.Request) {
-	var req periodCommentRequest
+	req := periodCommentRequest{now: c.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -694,3 +1001,218 @@ func (c *commentRouter) findByPeriodComment(w http.ResponseWriter, r *http.Reque
 
 	middleware.JSONReturn(w, http.StatusOK, comments)
 }
+
+type flagCommentRequest struct {
+	model.FlagCommentRequest
+}
+
+// Build builds request to flag comment.
+func (req *flagCommentRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	req.ID = vID
+
+	return nil
+}
+
+// @Summary Flag
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description Flag comment for moderator review
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Comment id"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No comment"
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/api/{id}/flag [post]
+func (c *commentRouter) flagComment(w http.ResponseWriter, r *http.Request) {
+	var req flagCommentRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.services.Comment.Flag(r.Context(), req.FlagCommentRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type importCommentRequest struct {
+	model.ImportCommentRequest
+}
+
+// Build builds request to import comment.
+func (req *importCommentRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.ImportCommentRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	return nil
+}
+
+// Validate validates fields of request for import comment which depend on its parent.
+func (req *importCommentRequest) Validate() error {
+	return validateReply(req.CreateCommentRequest)
+}
+
+// @Summary Import
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description Import comment with its original date
+// @Accept  json
+// @Produce  json
+// @Param comment body model.ImportCommentRequest true "Comment"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 409 {object} middleware.SwagError
+// @Failure 422 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/admin/import [post]
+func (c *commentRouter) importComment(w http.ResponseWriter, r *http.Request) {
+	var req importCommentRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.services.Comment.Import(r.Context(), req.ImportCommentRequest)
+	if errors.Is(err, service.ErrRatingNotAllowed) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrParentDeleted) || errors.Is(err, service.ErrAlreadyRated) {
+		middleware.JSONError(w, err, http.StatusConflict)
+		return
+	}
+	if errors.Is(err, service.ErrCommentRejected) {
+		middleware.JSONError(w, err, http.StatusUnprocessableEntity)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+// @Summary FindForReview
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description Find pending and flagged comments
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.Comment
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No comments"
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/admin/queue [get]
+func (c *commentRouter) findForReviewComment(w http.ResponseWriter, r *http.Request) {
+	comments, err := c.services.Comment.FindForReview(r.Context())
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(comments) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, comments)
+}
+
+type reviewCommentRequest struct {
+	model.ReviewCommentRequest
+}
+
+// Build builds request to review comment.
+func (req *reviewCommentRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.ReviewCommentRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	req.ID = vID
+
+	return nil
+}
+
+// @Summary Review
+// @Security ApiKeyAuth
+// @Tags comment
+// @Description Approve or reject comment
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Comment id"
+// @Param review body model.ReviewCommentRequest true "Review"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No comment"
+// @Failure 500 {object} middleware.SwagError
+// @Router /comment/admin/{id}/status [put]
+func (c *commentRouter) reviewComment(w http.ResponseWriter, r *http.Request) {
+	var req reviewCommentRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := c.services.Comment.Review(r.Context(), req.ReviewCommentRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/handler/mock"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/pkg/errors"
 	testAssert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -27,6 +30,13 @@ const (
 	user                = "user"
 	purchase            = "purchase"
 	period              = "period"
+	tree                = "tree"
+	flag                = "flag"
+	admin               = "admin"
+	imports             = "import"
+	queue               = "queue"
+	status              = "status"
+	revisions           = "revisions"
 	authorizationHeader = "Authorization"
 )
 
@@ -35,7 +45,7 @@ func TestComment_Create(t *testing.T) {
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("1")
 	require.NoError(t, err)
 
 	type test struct {
@@ -50,19 +60,80 @@ func TestComment_Create(t *testing.T) {
 
 	tt := []test{
 		{
-			name:   "invalid user id",
+			name:   "no purchase",
 			path:   fmt.Sprintf("/%s/%s/", comment, api),
 			method: http.MethodPost,
 			req: model.CreateCommentRequest{
-				Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Text: "some text",
 			},
+			expCode: http.StatusBadRequest,
+			expBody: "purchase id is required",
+		},
+		{
+			name:   "user id of body ignored",
+			path:   fmt.Sprintf("/%s/%s/", comment, api),
+			method: http.MethodPost,
+			req: model.CreateCommentRequest{
+				UserID:     2,
+				PurchaseID: id,
+				Text:       "some text",
+				Rating:     5,
+			},
 			fn: func(commentService *m.Comment, data test) {
-				commentService.On("Create", mock.Anything, data.req).
-					Return("", nil)
+				req := data.req
+				req.UserID = 1
+				commentService.On("Create", mock.Anything, req).
+					Return("", service.ErrRatingNotAllowed)
+			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrRatingNotAllowed.Error(),
+		},
+		{
+			name:   "invalid rating",
+			path:   fmt.Sprintf("/%s/%s/", comment, api),
+			method: http.MethodPost,
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: id,
+				Text:       "some text",
+				Rating:     6,
 			},
 			expCode: http.StatusBadRequest,
-			expBody: "not correct user id",
+			expBody: "rating must be between 1 and 5",
+		},
+		{
+			name:   "rating not allowed",
+			path:   fmt.Sprintf("/%s/%s/", comment, api),
+			method: http.MethodPost,
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: id,
+				Text:       "some text",
+				Rating:     5,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Create", mock.Anything, data.req).
+					Return("", service.ErrRatingNotAllowed)
+			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrRatingNotAllowed.Error(),
+		},
+		{
+			name:   "already rated",
+			path:   fmt.Sprintf("/%s/%s/", comment, api),
+			method: http.MethodPost,
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: id,
+				Text:       "some text",
+				Rating:     5,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Create", mock.Anything, data.req).
+					Return("", service.ErrAlreadyRated)
+			},
+			expCode: http.StatusConflict,
+			expBody: service.ErrAlreadyRated.Error(),
 		},
 		{
 			name:   "create err",
@@ -71,7 +142,6 @@ func TestComment_Create(t *testing.T) {
 			req: model.CreateCommentRequest{
 				UserID:     1,
 				PurchaseID: id,
-				Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Text:       "some text",
 			},
 			fn: func(commentService *m.Comment, data test) {
@@ -87,7 +157,6 @@ func TestComment_Create(t *testing.T) {
 			req: model.CreateCommentRequest{
 				UserID:     1,
 				PurchaseID: id,
-				Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Text:       "some text",
 			},
 			fn: func(commentService *m.Comment, data test) {
@@ -103,7 +172,7 @@ func TestComment_Create(t *testing.T) {
 			var r string
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -114,6 +183,7 @@ func TestComment_Create(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -121,8 +191,7 @@ func TestComment_Create(t *testing.T) {
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
 
-			err = json.NewDecoder(res.Body).Decode(&r)
-			assert.Nil(err)
+			r = decodeMessage(t, res.Body)
 			assert.Equal(tc.expBody, r)
 		})
 	}
@@ -133,11 +202,12 @@ func TestComment_Update(t *testing.T) {
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("1")
 	require.NoError(t, err)
 
 	type test struct {
 		name    string
+		ifMatch string
 		path    string
 		method  string
 		isOkRes bool
@@ -150,12 +220,12 @@ func TestComment_Update(t *testing.T) {
 	tt := []test{
 		{
 			name:    "invalid id",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", comment, api),
 			method:  http.MethodPut,
 			isOkRes: true,
 			req: model.UpdateCommentRequest{
 				ID:   "some",
-				Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Text: "some text",
 			},
 			fn: func(commentService *m.Comment, data test) {
@@ -167,15 +237,14 @@ func TestComment_Update(t *testing.T) {
 		},
 		{
 			name:    "update err",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", comment, api),
 			method:  http.MethodPut,
 			isOkRes: true,
 			req: model.UpdateCommentRequest{
-				ID:         id,
-				UserID:     1,
-				PurchaseID: id,
-				Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				Text:       "some text",
+				ID:     id,
+				UserID: 1,
+				Text:   "some text",
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("Update", mock.Anything, data.req).
@@ -184,15 +253,30 @@ func TestComment_Update(t *testing.T) {
 			expCode: http.StatusInternalServerError,
 		},
 		{
-			name:   "not found",
-			path:   fmt.Sprintf("/%s/%s/", comment, api),
-			method: http.MethodPut,
+			name:    "not owner",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodPut,
 			req: model.UpdateCommentRequest{
-				ID:         id,
-				UserID:     1,
-				PurchaseID: id,
-				Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				Text:       "some text",
+				ID:     id,
+				UserID: 1,
+				Text:   "some text",
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Update", mock.Anything, data.req).
+					Return("", service.ErrNotCommentOwner)
+			},
+			expCode: http.StatusForbidden,
+		},
+		{
+			name:    "not found",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodPut,
+			req: model.UpdateCommentRequest{
+				ID:     id,
+				UserID: 1,
+				Text:   "some text",
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("Update", mock.Anything, data.req).
@@ -202,15 +286,14 @@ func TestComment_Update(t *testing.T) {
 		},
 		{
 			name:    "all ok",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", comment, api),
 			method:  http.MethodPut,
 			isOkRes: true,
 			req: model.UpdateCommentRequest{
-				ID:         id,
-				UserID:     1,
-				PurchaseID: id,
-				Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				Text:       "some text",
+				ID:     id,
+				UserID: 1,
+				Text:   "some text",
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("Update", mock.Anything, data.req).
@@ -219,13 +302,59 @@ func TestComment_Update(t *testing.T) {
 			expCode: http.StatusOK,
 			expBody: id,
 		},
+		{
+			name:    "no If-Match",
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req: model.UpdateCommentRequest{
+				ID:     id,
+				UserID: 1,
+				Text:   "some text",
+			},
+			expCode: http.StatusPreconditionRequired,
+			expBody: errIfMatchRequired.Error(),
+		},
+		{
+			name:    "not correct If-Match",
+			ifMatch: "0",
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req: model.UpdateCommentRequest{
+				ID:     id,
+				UserID: 1,
+				Text:   "some text",
+			},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct If-Match header",
+		},
+		{
+			name:    "modified",
+			ifMatch: `"1"`,
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req: model.UpdateCommentRequest{
+				ID:       id,
+				Revision: 1,
+				UserID:   1,
+				Text:     "some text",
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Update", mock.Anything, data.req).
+					Return("", service.ErrModified)
+			},
+			expCode: http.StatusPreconditionFailed,
+			expBody: service.ErrModified.Error(),
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			var r string
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -236,16 +365,186 @@ func TestComment_Update(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.ID), body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req.Header.Set("If-Match", tc.ifMatch)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
 
 			if tc.isOkRes {
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
+			}
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
+
+func TestComment_Patch(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+	text := "some text"
+	noRating := 0
+
+	type test struct {
+		name    string
+		ifMatch string
+		id      string
+		body    string
+		fn      func(commentService *m.Comment, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid id",
+			ifMatch: `"0"`,
+			id:      "some",
+			body:    `{"text":"some text"}`,
+			expCode: http.StatusBadRequest,
+			expBody: "not correct id",
+		},
+		{
+			name:    "unknown field",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"userID":2}`,
+			expCode: http.StatusBadRequest,
+			expBody: "unknown field userID",
+		},
+		{
+			name:    "removed text",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"text":null}`,
+			expCode: http.StatusBadRequest,
+			expBody: "text couldn't be removed",
+		},
+		{
+			name:    "invalid rating",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"rating":6}`,
+			expCode: http.StatusBadRequest,
+			expBody: fmt.Sprintf("rating must be between %d and %d", model.MinRating, model.MaxRating),
+		},
+		{
+			name:    "not owner",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"text":"some text"}`,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Patch", mock.Anything, model.PatchCommentRequest{ID: data.id, UserID: 1, Text: &text}).
+					Return("", service.ErrNotCommentOwner)
+			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrNotCommentOwner.Error(),
+		},
+		{
+			name:    "reply rated",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"rating":5}`,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Patch", mock.Anything, mock.Anything).
+					Return("", service.ErrReplyRating)
+			},
+			expCode: http.StatusUnprocessableEntity,
+			expBody: service.ErrReplyRating.Error(),
+		},
+		{
+			name:    "already rated",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"rating":5}`,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Patch", mock.Anything, mock.Anything).
+					Return("", service.ErrAlreadyRated)
+			},
+			expCode: http.StatusConflict,
+			expBody: service.ErrAlreadyRated.Error(),
+		},
+		{
+			name:    "not found",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"text":"some text"}`,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Patch", mock.Anything, model.PatchCommentRequest{ID: data.id, UserID: 1, Text: &text}).
+					Return("", nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "rating removed",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"rating":null}`,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Patch", mock.Anything, model.PatchCommentRequest{ID: data.id, UserID: 1, Rating: &noRating}).
+					Return(data.id, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+		{
+			name:    "no If-Match",
+			id:      id,
+			body:    `{"rating":null}`,
+			expCode: http.StatusPreconditionRequired,
+			expBody: errIfMatchRequired.Error(),
+		},
+		{
+			name:    "not correct If-Match",
+			ifMatch: "0",
+			id:      id,
+			body:    `{"rating":null}`,
+			expCode: http.StatusBadRequest,
+			expBody: "not correct If-Match header",
+		},
+		{
+			name:    "modified",
+			ifMatch: `"1"`,
+			id:      id,
+			body:    `{"rating":null}`,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Patch", mock.Anything, model.PatchCommentRequest{ID: data.id, UserID: 1, Rating: &noRating, Revision: 1}).
+					Return("", service.ErrModified)
+			},
+			expCode: http.StatusPreconditionFailed,
+			expBody: service.ErrModified.Error(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			path := fmt.Sprintf("/%s/%s/%s", comment, api, tc.id)
+			comment := new(m.Comment)
+			testAPI.Services.Comment = comment
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodPatch, path, strings.NewReader(tc.body))
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req.Header.Set("If-Match", tc.ifMatch)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			if res.Body.Len() != 0 {
+				r = decodeMessage(t, res.Body)
 			}
 			assert.Equal(tc.expBody, r)
 		})
@@ -257,14 +556,18 @@ func TestComment_Delete(t *testing.T) {
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("2")
+	require.NoError(t, err)
+	adminToken, err := testAPI.TokenManager.NewJWT("1")
 	require.NoError(t, err)
 
 	type test struct {
 		name    string
+		ifMatch string
 		path    string
 		method  string
 		isOkRes bool
+		admin   bool
 		req     model.DeleteCommentRequest
 		fn      func(commentService *m.Comment, data test)
 		expCode int
@@ -274,10 +577,11 @@ func TestComment_Delete(t *testing.T) {
 	tt := []test{
 		{
 			name:    "invalid id",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", comment, api),
 			method:  http.MethodDelete,
 			isOkRes: true,
-			req:     model.DeleteCommentRequest{ID: "some"},
+			req:     model.DeleteCommentRequest{ID: "some", UserID: 2},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("Delete", mock.Anything, data.req).
 					Return("", nil)
@@ -287,11 +591,13 @@ func TestComment_Delete(t *testing.T) {
 		},
 		{
 			name:    "delete err",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", comment, api),
 			method:  http.MethodDelete,
 			isOkRes: true,
 			req: model.DeleteCommentRequest{
-				ID: id,
+				ID:     id,
+				UserID: 2,
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("Delete", mock.Anything, data.req).
@@ -300,11 +606,13 @@ func TestComment_Delete(t *testing.T) {
 			expCode: http.StatusInternalServerError,
 		},
 		{
-			name:   "not found",
-			path:   fmt.Sprintf("/%s/%s/", comment, api),
-			method: http.MethodDelete,
+			name:    "not found",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodDelete,
 			req: model.DeleteCommentRequest{
-				ID: id,
+				ID:     id,
+				UserID: 2,
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("Delete", mock.Anything, data.req).
@@ -314,11 +622,49 @@ func TestComment_Delete(t *testing.T) {
 		},
 		{
 			name:    "all ok",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", comment, api),
 			method:  http.MethodDelete,
 			isOkRes: true,
 			req: model.DeleteCommentRequest{
-				ID: id,
+				ID:     id,
+				UserID: 2,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Delete", mock.Anything, data.req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+		{
+			name:    "not owner",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteCommentRequest{
+				ID:     id,
+				UserID: 2,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Delete", mock.Anything, data.req).
+					Return("", service.ErrNotCommentOwner)
+			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrNotCommentOwner.Error(),
+		},
+		{
+			name:    "all ok by admin",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			admin:   true,
+			req: model.DeleteCommentRequest{
+				ID:     id,
+				UserID: 1,
+				Admin:  true,
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("Delete", mock.Anything, data.req).
@@ -327,13 +673,56 @@ func TestComment_Delete(t *testing.T) {
 			expCode: http.StatusOK,
 			expBody: id,
 		},
+		{
+			name:    "no If-Match",
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteCommentRequest{
+				ID:     id,
+				UserID: 2,
+			},
+			expCode: http.StatusPreconditionRequired,
+			expBody: errIfMatchRequired.Error(),
+		},
+		{
+			name:    "not correct If-Match",
+			ifMatch: "0",
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteCommentRequest{
+				ID:     id,
+				UserID: 2,
+			},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct If-Match header",
+		},
+		{
+			name:    "modified",
+			ifMatch: `"1"`,
+			path:    fmt.Sprintf("/%s/%s/", comment, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteCommentRequest{
+				ID:       id,
+				UserID:   2,
+				Revision: 1,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Delete", mock.Anything, data.req).
+					Return("", service.ErrModified)
+			},
+			expCode: http.StatusPreconditionFailed,
+			expBody: service.ErrModified.Error(),
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			var r string
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -341,15 +730,19 @@ func TestComment_Delete(t *testing.T) {
 			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.ID), nil)
 			assert.Nil(err)
 
-			req.Header.Set(authorizationHeader, "Bearer "+token)
+			if tc.admin {
+				req.Header.Set(authorizationHeader, "Bearer "+adminToken)
+			} else {
+				req.Header.Set(authorizationHeader, "Bearer "+token)
+			}
+			req.Header.Set("If-Match", tc.ifMatch)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
 
 			if tc.isOkRes {
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 			}
 			assert.Equal(tc.expBody, r)
 		})
@@ -361,7 +754,7 @@ func TestComment_FindByID(t *testing.T) {
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("2")
 	require.NoError(t, err)
 
 	type test struct {
@@ -383,7 +776,7 @@ func TestComment_FindByID(t *testing.T) {
 			path:        fmt.Sprintf("/%s/%s/", comment, api),
 			method:      http.MethodGet,
 			isOkMessage: true,
-			req:         model.IDCommentRequest{ID: "some"},
+			req:         model.IDCommentRequest{ID: "some", UserID: 2},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("FindByID", mock.Anything, data.req).
 					Return(&data.expRes, nil)
@@ -397,7 +790,8 @@ func TestComment_FindByID(t *testing.T) {
 			method:      http.MethodGet,
 			isOkMessage: true,
 			req: model.IDCommentRequest{
-				ID: id,
+				ID:     id,
+				UserID: 2,
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("FindByID", mock.Anything, data.req).
@@ -410,7 +804,8 @@ func TestComment_FindByID(t *testing.T) {
 			path:   fmt.Sprintf("/%s/%s/", comment, api),
 			method: http.MethodGet,
 			req: model.IDCommentRequest{
-				ID: id,
+				ID:     id,
+				UserID: 2,
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("FindByID", mock.Anything, data.req).
@@ -424,7 +819,8 @@ func TestComment_FindByID(t *testing.T) {
 			method:  http.MethodGet,
 			isOkRes: true,
 			req: model.IDCommentRequest{
-				ID: id,
+				ID:     id,
+				UserID: 2,
 			},
 			fn: func(commentService *m.Comment, data test) {
 				commentService.On("FindByID", mock.Anything, data.req).
@@ -437,6 +833,7 @@ func TestComment_FindByID(t *testing.T) {
 				PurchaseID: id,
 				Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Text:       "some text",
+				Revision:   3,
 			},
 		},
 	}
@@ -446,7 +843,7 @@ func TestComment_FindByID(t *testing.T) {
 			var c model.CommentDTO
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -462,13 +859,13 @@ func TestComment_FindByID(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&c)
 				assert.Nil(err)
 				assert.Equal(tc.expRes, c)
+				assert.Equal(`"3"`, res.Header().Get("ETag"))
 			default:
 				assert.Equal(tc.message, r)
 			}
@@ -545,7 +942,7 @@ func TestComment_FindAll(t *testing.T) {
 			var c []model.CommentDTO
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -561,8 +958,7 @@ func TestComment_FindAll(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&c)
@@ -663,7 +1059,7 @@ func TestComment_FindAllByUserID(t *testing.T) {
 			var c []model.CommentDTO
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -677,8 +1073,7 @@ func TestComment_FindAllByUserID(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&c)
@@ -781,7 +1176,7 @@ func TestComment_FindByPurchaseID(t *testing.T) {
 			var c []model.CommentDTO
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -795,8 +1190,269 @@ func TestComment_FindByPurchaseID(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
+				assert.Equal(tc.message, r)
+			case tc.isOkRes:
+				err = json.NewDecoder(res.Body).Decode(&c)
+				assert.Nil(err)
+				assert.Equal(tc.expRes, c)
+			default:
+				assert.Equal(tc.message, r)
+			}
+		})
+	}
+}
+
+func TestComment_FindTreeByPurchaseID(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		path        string
+		method      string
+		isOkRes     bool
+		isOkMessage bool
+		req         model.PurchaseIDCommentRequest
+		fn          func(commentService *m.Comment, data test)
+		expCode     int
+		expRes      []model.CommentNode
+		message     string
+	}
+
+	tt := []test{
+		{
+			name:        "invalid id",
+			path:        fmt.Sprintf("/%s/%s/", comment, purchase),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req:         model.PurchaseIDCommentRequest{ID: "some"},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByPurchaseID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusBadRequest,
+			message: "not correct id",
+		},
+		{
+			name:        "find err",
+			path:        fmt.Sprintf("/%s/%s/", comment, purchase),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req: model.PurchaseIDCommentRequest{
+				ID: id,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByPurchaseID", mock.Anything, data.req).
+					Return(data.expRes, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/", comment, purchase),
+			method: http.MethodGet,
+			req: model.PurchaseIDCommentRequest{
+				ID: id,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByPurchaseID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/", comment, purchase),
+			method:  http.MethodGet,
+			isOkRes: true,
+			req: model.PurchaseIDCommentRequest{
+				ID: id,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByPurchaseID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusOK,
+			expRes: []model.CommentNode{
+				{
+					CommentDTO: model.CommentDTO{
+						ID:         id,
+						UserID:     1,
+						PurchaseID: id,
+						Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+						Text:       "some text",
+					},
+					Replies: []model.CommentNode{
+						{
+							CommentDTO: model.CommentDTO{
+								ID:         id,
+								UserID:     2,
+								PurchaseID: id,
+								ParentID:   id,
+								Date:       time.Date(2009, time.November, 11, 23, 0, 0, 0, time.Local),
+								Text:       "some reply",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			var c []model.CommentNode
+			comment := new(m.Comment)
+			testAPI.Services.Comment = comment
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+
+			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s/%s", tc.path, tc.req.ID, tree), nil)
+			assert.Nil(err)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			switch {
+			case tc.isOkMessage:
+				r = decodeMessage(t, res.Body)
+				assert.Equal(tc.message, r)
+			case tc.isOkRes:
+				err = json.NewDecoder(res.Body).Decode(&c)
+				assert.Nil(err)
+				assert.Equal(tc.expRes, c)
+			default:
+				assert.Equal(tc.message, r)
+			}
+		})
+	}
+}
+
+func TestComment_FindTreeByFileID(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		path        string
+		method      string
+		isOkRes     bool
+		isOkMessage bool
+		req         model.FileIDCommentRequest
+		fn          func(commentService *m.Comment, data test)
+		expCode     int
+		expRes      []model.CommentNode
+		message     string
+	}
+
+	tt := []test{
+		{
+			name:        "invalid id",
+			path:        fmt.Sprintf("/%s/%s/", comment, file),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req:         model.FileIDCommentRequest{ID: "some"},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByFileID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusBadRequest,
+			message: "not correct id",
+		},
+		{
+			name:        "find err",
+			path:        fmt.Sprintf("/%s/%s/", comment, file),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req: model.FileIDCommentRequest{
+				ID: id,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByFileID", mock.Anything, data.req).
+					Return(data.expRes, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/", comment, file),
+			method: http.MethodGet,
+			req: model.FileIDCommentRequest{
+				ID: id,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByFileID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/", comment, file),
+			method:  http.MethodGet,
+			isOkRes: true,
+			req: model.FileIDCommentRequest{
+				ID: id,
+			},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindTreeByFileID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusOK,
+			expRes: []model.CommentNode{
+				{
+					CommentDTO: model.CommentDTO{
+						ID:         id,
+						UserID:     1,
+						PurchaseID: id,
+						Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+						Text:       "some text",
+					},
+					Replies: []model.CommentNode{
+						{
+							CommentDTO: model.CommentDTO{
+								ID:         id,
+								UserID:     2,
+								PurchaseID: id,
+								ParentID:   id,
+								Date:       time.Date(2009, time.November, 11, 23, 0, 0, 0, time.Local),
+								Text:       "some reply",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			var c []model.CommentNode
+			comment := new(m.Comment)
+			testAPI.Services.Comment = comment
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+
+			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s/%s", tc.path, tc.req.ID, tree), nil)
+			assert.Nil(err)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			switch {
+			case tc.isOkMessage:
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&c)
@@ -840,7 +1496,7 @@ func TestComment_FindByUserIDAndPurchaseID(t *testing.T) {
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusBadRequest,
-			message: "not correct user id",
+			message: "not correct user id; not correct purchase id",
 		},
 		{
 			name:        "find err",
@@ -902,7 +1558,7 @@ func TestComment_FindByUserIDAndPurchaseID(t *testing.T) {
 			var c []model.CommentDTO
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -915,8 +1571,7 @@ func TestComment_FindByUserIDAndPurchaseID(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&c)
@@ -1021,7 +1676,7 @@ func TestComment_FindByText(t *testing.T) {
 			var c []model.CommentDTO
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -1032,6 +1687,7 @@ func TestComment_FindByText(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
@@ -1039,8 +1695,7 @@ func TestComment_FindByText(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&c)
@@ -1086,7 +1741,7 @@ func TestComment_FindByPeriod(t *testing.T) {
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusBadRequest,
-			message: "invalid start",
+			message: "start is required; end is required",
 		},
 		{
 			name:        "find err",
@@ -1148,7 +1803,7 @@ func TestComment_FindByPeriod(t *testing.T) {
 			var c []model.CommentDTO
 			comment := new(m.Comment)
 			testAPI.Services.Comment = comment
-			router := newComment(testAPI.Services, testAPI.TokenManager)
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -1159,6 +1814,7 @@ func TestComment_FindByPeriod(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
@@ -1166,8 +1822,7 @@ func TestComment_FindByPeriod(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&c)
@@ -1179,3 +1834,520 @@ func TestComment_FindByPeriod(t *testing.T) {
 		})
 	}
 }
+
+func TestComment_Flag(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name    string
+		path    string
+		method  string
+		isOkRes bool
+		req     model.FlagCommentRequest
+		fn      func(commentService *m.Comment, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid id",
+			path:    fmt.Sprintf("/%s/%s/%s/%s", comment, api, "some", flag),
+			method:  http.MethodPost,
+			isOkRes: true,
+			req:     model.FlagCommentRequest{ID: "some", UserID: 1},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct id",
+		},
+		{
+			name:    "flag err",
+			path:    fmt.Sprintf("/%s/%s/%s/%s", comment, api, id, flag),
+			method:  http.MethodPost,
+			isOkRes: true,
+			req:     model.FlagCommentRequest{ID: id, UserID: 1},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Flag", mock.Anything, data.req).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/%s/%s", comment, api, id, flag),
+			method: http.MethodPost,
+			req:    model.FlagCommentRequest{ID: id, UserID: 1},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Flag", mock.Anything, data.req).
+					Return("", nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/%s/%s", comment, api, id, flag),
+			method:  http.MethodPost,
+			isOkRes: true,
+			req:     model.FlagCommentRequest{ID: id, UserID: 1},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Flag", mock.Anything, data.req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			comment := new(m.Comment)
+			testAPI.Services.Comment = comment
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			if tc.isOkRes {
+				r = decodeMessage(t, res.Body)
+			}
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
+
+func TestComment_FindForReview(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	tokenManager, err := auth.NewManager(mock.Anything, "1")
+	require.NoError(t, err)
+	adminToken, err := tokenManager.NewJWT("1")
+	require.NoError(t, err)
+	userToken, err := tokenManager.NewJWT("2")
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		path        string
+		method      string
+		token       string
+		isOkRes     bool
+		isOkMessage bool
+		fn          func(commentService *m.Comment, data test)
+		expCode     int
+		expRes      []model.CommentDTO
+		message     string
+	}
+
+	tt := []test{
+		{
+			name:        "not admin",
+			path:        fmt.Sprintf("/%s/%s/%s", comment, admin, queue),
+			method:      http.MethodGet,
+			token:       userToken,
+			isOkMessage: true,
+			expCode:     http.StatusForbidden,
+			message:     "admin access required",
+		},
+		{
+			name:        "find err",
+			path:        fmt.Sprintf("/%s/%s/%s", comment, admin, queue),
+			method:      http.MethodGet,
+			token:       adminToken,
+			isOkMessage: true,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindForReview", mock.Anything).
+					Return(data.expRes, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/%s", comment, admin, queue),
+			method: http.MethodGet,
+			token:  adminToken,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindForReview", mock.Anything).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/%s", comment, admin, queue),
+			method:  http.MethodGet,
+			token:   adminToken,
+			isOkRes: true,
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindForReview", mock.Anything).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusOK,
+			expRes: []model.CommentDTO{
+				{
+					ID:         id,
+					UserID:     1,
+					PurchaseID: id,
+					Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Text:       "some text",
+					Status:     model.CommentFlagged,
+					FlaggedBy:  []int{2},
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			var c []model.CommentDTO
+			comment := new(m.Comment)
+			testAPI.Services.Comment = comment
+			router := newComment(testAPI.Services, tokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+tc.token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			switch {
+			case tc.isOkMessage:
+				r = decodeMessage(t, res.Body)
+				assert.Equal(tc.message, r)
+			case tc.isOkRes:
+				err = json.NewDecoder(res.Body).Decode(&c)
+				assert.Nil(err)
+				assert.Equal(tc.expRes, c)
+			default:
+				assert.Equal(tc.message, r)
+			}
+		})
+	}
+}
+
+func TestComment_Review(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	tokenManager, err := auth.NewManager(mock.Anything, "1")
+	require.NoError(t, err)
+	token, err := tokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name    string
+		path    string
+		method  string
+		isOkRes bool
+		req     model.ReviewCommentRequest
+		fn      func(commentService *m.Comment, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid status",
+			path:    fmt.Sprintf("/%s/%s/%s/%s", comment, admin, id, status),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req:     model.ReviewCommentRequest{ID: id, Status: model.CommentFlagged},
+			expCode: http.StatusBadRequest,
+			expBody: "status must be approved or rejected",
+		},
+		{
+			name:    "review err",
+			path:    fmt.Sprintf("/%s/%s/%s/%s", comment, admin, id, status),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req:     model.ReviewCommentRequest{ID: id, Status: model.CommentRejected},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Review", mock.Anything, data.req).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/%s/%s", comment, admin, id, status),
+			method: http.MethodPut,
+			req:    model.ReviewCommentRequest{ID: id, Status: model.CommentApproved},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Review", mock.Anything, data.req).
+					Return("", nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/%s/%s", comment, admin, id, status),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req:     model.ReviewCommentRequest{ID: id, Status: model.CommentApproved},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Review", mock.Anything, data.req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			comment := new(m.Comment)
+			testAPI.Services.Comment = comment
+			router := newComment(testAPI.Services, tokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+
+			body := new(bytes.Buffer)
+			err := json.NewEncoder(body).Encode(&tc.req)
+			assert.Nil(err)
+
+			req, err := http.NewRequest(tc.method, tc.path, body)
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			if tc.isOkRes {
+				r = decodeMessage(t, res.Body)
+			}
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
+
+func TestComment_FindRevisions(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("2")
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		path        string
+		method      string
+		isOkRes     bool
+		isOkMessage bool
+		req         model.IDCommentRequest
+		fn          func(commentService *m.Comment, data test)
+		expCode     int
+		expRes      []model.CommentRevisionDTO
+		message     string
+	}
+
+	tt := []test{
+		{
+			name:        "invalid id",
+			path:        fmt.Sprintf("/%s/%s/%s/%s", comment, api, "some", revisions),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req:         model.IDCommentRequest{ID: "some", UserID: 2},
+			expCode:     http.StatusBadRequest,
+			message:     "not correct id",
+		},
+		{
+			name:        "find err",
+			path:        fmt.Sprintf("/%s/%s/%s/%s", comment, api, id, revisions),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req:         model.IDCommentRequest{ID: id, UserID: 2},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindRevisions", mock.Anything, data.req).
+					Return(data.expRes, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/%s/%s", comment, api, id, revisions),
+			method: http.MethodGet,
+			req:    model.IDCommentRequest{ID: id, UserID: 2},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindRevisions", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/%s/%s", comment, api, id, revisions),
+			method:  http.MethodGet,
+			isOkRes: true,
+			req:     model.IDCommentRequest{ID: id, UserID: 2},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("FindRevisions", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusOK,
+			expRes: []model.CommentRevisionDTO{
+				{
+					ID:         primitive.NewObjectID().Hex(),
+					CommentID:  id,
+					Text:       "some text",
+					Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+					ReplacedAt: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			var c []model.CommentRevisionDTO
+			comment := new(m.Comment)
+			testAPI.Services.Comment = comment
+			router := newComment(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+
+			req, err := http.NewRequest(tc.method, tc.path, nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			switch {
+			case tc.isOkMessage:
+				r = decodeMessage(t, res.Body)
+				assert.Equal(tc.message, r)
+			case tc.isOkRes:
+				err = json.NewDecoder(res.Body).Decode(&c)
+				assert.Nil(err)
+				assert.Equal(tc.expRes, c)
+			default:
+				assert.Equal(tc.message, r)
+			}
+		})
+	}
+}
+
+func TestComment_Import(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	tokenManager, err := auth.NewManager(mock.Anything, "1")
+	require.NoError(t, err)
+	adminToken, err := tokenManager.NewJWT("1")
+	require.NoError(t, err)
+	userToken, err := tokenManager.NewJWT("2")
+	require.NoError(t, err)
+	path := fmt.Sprintf("/%s/%s/%s", comment, admin, imports)
+
+	type test struct {
+		name    string
+		token   string
+		req     model.ImportCommentRequest
+		fn      func(commentService *m.Comment, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "not admin",
+			token:   userToken,
+			req:     model.ImportCommentRequest{CreateCommentRequest: model.CreateCommentRequest{UserID: 1, PurchaseID: id, Text: "some text"}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusForbidden,
+			expBody: "admin access required",
+		},
+		{
+			name:    "invalid text",
+			token:   adminToken,
+			req:     model.ImportCommentRequest{CreateCommentRequest: model.CreateCommentRequest{UserID: 1, PurchaseID: id}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusBadRequest,
+			expBody: "text is required",
+		},
+		{
+			name:    "no date",
+			token:   adminToken,
+			req:     model.ImportCommentRequest{CreateCommentRequest: model.CreateCommentRequest{UserID: 1, PurchaseID: id, Text: "some text"}},
+			expCode: http.StatusBadRequest,
+			expBody: "date is required",
+		},
+		{
+			name:  "import err",
+			token: adminToken,
+			req:   model.ImportCommentRequest{CreateCommentRequest: model.CreateCommentRequest{UserID: 1, PurchaseID: id, Text: "some text"}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Import", mock.Anything, data.req).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "all ok",
+			token: adminToken,
+			req:   model.ImportCommentRequest{CreateCommentRequest: model.CreateCommentRequest{UserID: 1, PurchaseID: id, Text: "some text"}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			fn: func(commentService *m.Comment, data test) {
+				commentService.On("Import", mock.Anything, data.req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			commentService := new(m.Comment)
+			testAPI.Services.Comment = commentService
+			router := newComment(testAPI.Services, tokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(commentService, tc)
+			}
+
+			body := new(bytes.Buffer)
+			err := json.NewEncoder(body).Encode(&tc.req)
+			assert.Nil(err)
+
+			req, err := http.NewRequest(http.MethodPost, path, body)
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+
+			req.Header.Set(authorizationHeader, "Bearer "+tc.token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			r = decodeMessage(t, res.Body)
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
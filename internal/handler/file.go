@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"mime"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,26 +12,43 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const defaultContentType = "application/octet-stream"
+
 type fileRouter struct {
 	*mux.Router
 	services     *service.Services
 	tokenManager auth.TokenManager
+	limits       Limits
+	clock        clock.Clock
 }
 
-func newFile(services *service.Services, tokenManager auth.TokenManager) fileRouter {
+func newFile(services *service.Services, tokenManager auth.TokenManager, limits Limits, clock clock.Clock) fileRouter {
 	router := mux.NewRouter().PathPrefix(filePath).Subrouter()
+	router.Use(middleware.Route)
 	handler := fileRouter{
 		router,
 		services,
 		tokenManager,
+		limits,
+		clock,
 	}
 
+	router.Path("/added").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findAddedByPeriodFile)
+
+	router.Path("/updated").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findUpdatedByPeriodFile)
+
 	router.Path("/{name}").
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findByNameFile)
@@ -40,6 +57,10 @@ func newFile(services *service.Services, tokenManager auth.TokenManager) fileRou
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findActualFile)
 
+	router.Path("/rating/").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findByRatingFile)
+
 	router.Path("/expired/").
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findNotActualFile)
@@ -48,245 +69,955 @@ func newFile(services *service.Services, tokenManager auth.TokenManager) fileRou
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findAllFile)
 
+	router.Path("/text").
+		Methods(http.MethodPost).
+		Handler(jsonBody(handler.limits.Body, handler.findByTextFile))
+
 	router.Path("/added").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.findAddedByPeriodFile)
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findAddedByPeriodFile)))
 
 	router.Path("/updated").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.findUpdatedByPeriodFile)
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findUpdatedByPeriodFile)))
+
+	router.Path("/download/{id}").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.downloadByLinkFile)
 
 	secure := router.PathPrefix("/api").Subrouter()
 	secure.Use(handler.tokenManager.UserIdentity)
 
 	secure.Path("/").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.createFile)
+		Handler(jsonBody(handler.limits.Body, handler.createFile))
 
 	secure.Path("/{id}").
 		Methods(http.MethodPut).
-		HandlerFunc(handler.updateFile)
+		Handler(jsonBody(handler.limits.Body, handler.updateFile))
+
+	secure.Path("/{id}").
+		Methods(http.MethodPatch).
+		Handler(jsonBody(handler.limits.Body, handler.patchFile, middleware.MIMEMergePatchJSON))
 
 	secure.Path("/{id}").
 		Methods(http.MethodDelete).
 		HandlerFunc(handler.deleteFile)
 
+	secure.Path("/{id}/content").
+		Methods(http.MethodPut).
+		Handler(middleware.MaxBytes(handler.limits.Upload)(http.HandlerFunc(handler.uploadFile)))
+
+	secure.Path("/{id}/download").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.downloadFile)
+
+	secure.Path("/{id}/link").
+		Methods(http.MethodPost).
+		Handler(jsonBody(handler.limits.Body, handler.signDownloadFile))
+
+	secure.Path("/{id}/versions").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findVersionsFile)
+
+	secure.Path("/{id}/versions/{version}/download").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.downloadVersionFile)
+
 	secure.Path("/{id}").
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findByIDFile)
 
-	secure.Path("/author/{id}").
-		Methods(http.MethodGet).
-		HandlerFunc(handler.findByAuthorIDFile)
+	secure.Path("/author/{id}").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findByAuthorIDFile)
+
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(handler.tokenManager.AdminIdentity)
+
+	admin.Path("/import").
+		Methods(http.MethodPost).
+		Handler(jsonBody(handler.limits.Body, handler.importFile))
+
+	return handler
+}
+
+type createFileRequest struct {
+	model.CreateFileRequest
+}
+
+// Build builds request to create file of the current user.
+func (req *createFileRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.CreateFileRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	req.AuthorID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @Summary Create
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Create file of the current user
+// @Accept  json
+// @Produce  json
+// @Param file body model.CreateFileRequest true "File"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/api/ [post]
+func (f *fileRouter) createFile(w http.ResponseWriter, r *http.Request) {
+	var req createFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := f.services.File.Create(r.Context(), req.CreateFileRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type importFileRequest struct {
+	model.ImportFileRequest
+}
+
+// Build builds request to import file.
+func (req *importFileRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.ImportFileRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	return nil
+}
+
+// @Summary Import
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Import file with its original dates
+// @Accept  json
+// @Produce  json
+// @Param file body model.ImportFileRequest true "File"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/admin/import [post]
+func (f *fileRouter) importFile(w http.ResponseWriter, r *http.Request) {
+	var req importFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := f.services.File.Import(r.Context(), req.ImportFileRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type updateFileRequest struct {
+	model.UpdateFileRequest
+}
+
+// Build builds request to update file of the current user.
+func (req *updateFileRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.UpdateFileRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	req.ID = vID
+
+	req.AuthorID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	revision, err := ifMatch(r)
+	if err != nil {
+		return err
+	}
+	req.Revision = revision
+
+	return nil
+}
+
+// @Summary Update
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Update file of the current user. Author of the file couldn't be changed
+// @Accept  json
+// @Produce  json
+// @Param id path string true "File id"
+// @Param If-Match header string true "Revision entity tag"
+// @Param file body model.UpdateFileRequest true "File"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No file"
+// @Failure 412 {object} middleware.SwagError
+// @Failure 428 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/api/{id} [put]
+func (f *fileRouter) updateFile(w http.ResponseWriter, r *http.Request) {
+	var req updateFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if errors.Is(err, errIfMatchRequired) {
+		middleware.JSONError(w, err, http.StatusPreconditionRequired)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := f.services.File.Update(r.Context(), req.UpdateFileRequest)
+	if errors.Is(err, service.ErrNotFileAuthor) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type patchFileRequest struct {
+	model.PatchFileRequest
+}
+
+// Build builds request to patch file of the current user from JSON Merge Patch document.
+func (req *patchFileRequest) Build(r *http.Request) error {
+	patch, err := middleware.DecodeMergePatch(r.Body)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	for name, value := range patch {
+		if middleware.IsNull(value) {
+			return fmt.Errorf("%s couldn't be removed", name)
+		}
+
+		switch name {
+		case "name":
+			err = json.Unmarshal(value, &req.Name)
+		case "description":
+			err = json.Unmarshal(value, &req.Description)
+		case "actual":
+			err = json.Unmarshal(value, &req.Actual)
+		case "authorID":
+			return fmt.Errorf("%s couldn't be changed", name)
+		default:
+			return fmt.Errorf("unknown field %s", name)
+		}
+		if err != nil {
+			return fmt.Errorf("not correct %s", name)
+		}
+	}
+
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	req.ID = vID
+
+	req.AuthorID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	revision, err := ifMatch(r)
+	if err != nil {
+		return err
+	}
+	req.Revision = revision
+
+	return nil
+}
+
+// @Summary Patch
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Update provided fields of file of the current user with JSON Merge Patch. Author of the file couldn't be changed
+// @Accept  json
+// @Produce  json
+// @Param id path string true "File id"
+// @Param If-Match header string true "Revision entity tag"
+// @Param file body model.PatchFileRequest true "File"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No file"
+// @Failure 412 {object} middleware.SwagError
+// @Failure 428 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/api/{id} [patch]
+func (f *fileRouter) patchFile(w http.ResponseWriter, r *http.Request) {
+	var req patchFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if errors.Is(err, errIfMatchRequired) {
+		middleware.JSONError(w, err, http.StatusPreconditionRequired)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := f.services.File.Patch(r.Context(), req.PatchFileRequest)
+	if errors.Is(err, service.ErrNotFileAuthor) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type uploadFileRequest struct {
+	model.UploadFileRequest
+}
+
+// Build builds request to upload file content.
+// Content is read either from the "file" part of a multipart form or from the raw body.
+func (req *uploadFileRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	var err error
+	req.AuthorID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	req.ID = vID
+
+	req.Revision, err = ifMatch(r)
+	if err != nil {
+		return err
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		req.Name = r.URL.Query().Get("name")
+		req.ContentType = orDefaultContentType(mediaType)
+		req.Content = r.Body
+		return nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return fmt.Errorf("file is required")
+		}
+		if err != nil {
+			return err
+		}
+
+		if part.FormName() == "file" {
+			req.Name = part.FileName()
+			partType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+			req.ContentType = orDefaultContentType(partType)
+			req.Content = part
+			return nil
+		}
+	}
+}
+
+// orDefaultContentType returns media type of the content or the default one if it is unknown.
+func orDefaultContentType(mediaType string) string {
+	if mediaType == "" {
+		return defaultContentType
+	}
+
+	return mediaType
+}
+
+// @Summary Upload
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Upload file content as multipart form "file" field or raw body with name query parameter
+// @Accept  multipart/form-data
+// @Accept  application/octet-stream
+// @Produce  json
+// @Param id path string true "File id"
+// @Param If-Match header string true "Revision entity tag"
+// @Param file formData file false "File content"
+// @Param name query string false "File name for raw body upload"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No file"
+// @Failure 412 {object} middleware.SwagError
+// @Failure 428 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/api/{id}/content [put]
+func (f *fileRouter) uploadFile(w http.ResponseWriter, r *http.Request) {
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	var req uploadFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if errors.Is(err, errIfMatchRequired) {
+		middleware.JSONError(w, err, http.StatusPreconditionRequired)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := f.services.File.Upload(r.Context(), req.UploadFileRequest)
+	if errors.Is(err, service.ErrNotFileAuthor) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
+	if errors.Is(err, service.ErrEmptyContent) {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type downloadFileRequest struct {
+	model.DownloadFileRequest
+}
+
+// Build builds request to download file content.
+func (req *downloadFileRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	req.ID = vID
+
+	return nil
+}
+
+// @Summary Download
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Download content of a file bought or authored by user. Supports Range and If-None-Match requests
+// @Produce  application/octet-stream
+// @Param id path string true "File id"
+// @Param Range header string false "Byte range"
+// @Success 200 {file} file "File content"
+// @Success 206 {file} file "Partial file content"
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No file"
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/api/{id}/download [get]
+func (f *fileRouter) downloadFile(w http.ResponseWriter, r *http.Request) {
+	var req downloadFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	content, err := f.services.File.Download(r.Context(), req.DownloadFileRequest)
+	if errors.Is(err, service.ErrNotEntitled) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrNoContent) {
+		middleware.JSONError(w, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if content == nil {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	f.serveContent(w, r, content)
+}
+
+type downloadFileVersionRequest struct {
+	model.DownloadFileVersionRequest
+}
+
+// Build builds request to download content of file version.
+func (req *downloadFileVersionRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	vVersion, ok := mux.Vars(r)["version"]
+	if !ok {
+		return fmt.Errorf("no version")
+	}
+
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	req.Number, err = strconv.Atoi(vVersion)
+	if err != nil || req.Number < 1 {
+		return fmt.Errorf("not correct version")
+	}
 
-	return handler
-}
+	req.ID = vID
 
-type createFileRequest struct {
-	model.CreateFileRequest
+	return nil
 }
 
-// Build builds request for create file.
-func (req *createFileRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.CreateFileRequest)
+// @Summary Download version
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Download content of a file version. The author may download any version, a buyer the bought version and later ones
+// @Produce  application/octet-stream
+// @Param id path string true "File id"
+// @Param version path int true "Version number"
+// @Param Range header string false "Byte range"
+// @Success 200 {file} file "File content"
+// @Success 206 {file} file "Partial file content"
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No file version"
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/api/{id}/versions/{version}/download [get]
+func (f *fileRouter) downloadVersionFile(w http.ResponseWriter, r *http.Request) {
+	var req downloadFileVersionRequest
+	err := middleware.ParseRequest(r, &req)
 	if err != nil {
-		return err
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	content, err := f.services.File.DownloadVersion(r.Context(), req.DownloadFileVersionRequest)
+	if errors.Is(err, service.ErrNotEntitled) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrNoContent) {
+		middleware.JSONError(w, err, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if content == nil {
+		middleware.Empty(w, http.StatusNotFound)
+		return
 	}
 
+	f.serveContent(w, r, content)
+}
+
+type signDownloadFileRequest struct {
+	model.SignDownloadFileRequest
+}
+
+// Build builds request to create signed download link of file.
+// Request body is optional.
+func (req *signDownloadFileRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
-	return nil
-}
+	err := middleware.DecodeJSON(r.Body, &req.SignDownloadFileRequest)
+	if err != nil && !errors.Is(err, middleware.ErrEmptyBody) {
+		return err
+	}
 
-// Validate validates request for create file.
-func (req *createFileRequest) Validate() error {
-	switch {
-	case req.Name == "":
-		return fmt.Errorf("name is required")
-	case req.Description == "":
-		return fmt.Errorf("description is required")
-	case req.Size < 1:
-		return fmt.Errorf("not correct size")
-	case req.Path == "":
-		return fmt.Errorf("path is required")
-	case req.AddDate == time.Time{}:
-		return fmt.Errorf("add date is required")
-	case req.UpdateDate == time.Time{}:
-		return fmt.Errorf("update date is required")
-	case req.AuthorID == 0:
-		return fmt.Errorf("not correct author id")
-	default:
-		return nil
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
 	}
+
+	req.ID = vID
+
+	return nil
 }
 
-// @Summary Create
+// @Summary Sign download link
 // @Security ApiKeyAuth
 // @Tags file
-// @Description Create file
+// @Description Create expiring signed download link of a file bought or authored by user. The link doesn't require auth header
 // @Accept  json
 // @Produce  json
-// @Param file body model.CreateFileRequest true "File"
-// @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Param id path string true "File id"
+// @Param link body model.SignDownloadFileRequest false "Link options"
+// @Success 200 {object} model.DownloadLinkDTO
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No file"
 // @Failure 500 {object} middleware.SwagError
-// @Router /file/api/ [post]
-func (f *fileRouter) createFile(w http.ResponseWriter, r *http.Request) {
-	var req createFileRequest
+// @Router /file/api/{id}/link [post]
+func (f *fileRouter) signDownloadFile(w http.ResponseWriter, r *http.Request) {
+	var req signDownloadFileRequest
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	id, err := f.services.File.Create(r.Context(), req.CreateFileRequest)
+	link, err := f.services.File.SignDownload(r.Context(), req.SignDownloadFileRequest)
+	if errors.Is(err, service.ErrNotEntitled) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	middleware.JSONReturn(w, http.StatusOK, id)
-}
+	if link == nil {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
 
-type updateFileRequest struct {
-	model.UpdateFileRequest
+	middleware.JSONReturn(w, http.StatusOK, model.DownloadLinkDTO{
+		URL:       fmt.Sprintf("%s/download/%s?%s", filePath, link.FileID, link.Query()),
+		Expires:   link.Expires,
+		SingleUse: link.SingleUse,
+	})
 }
 
-// Build builds request for update file.
-func (req *updateFileRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.UpdateFileRequest)
-	if err != nil {
-		return err
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			log.Printf("%v", err)
-		}
-	}(r.Body)
+type downloadByLinkFileRequest struct {
+	auth.Link
+}
 
+// Build builds request to download file content by signed link.
+func (req *downloadByLinkFileRequest) Build(r *http.Request) error {
 	vID, ok := mux.Vars(r)["id"]
 	if !ok {
 		return fmt.Errorf("no id")
 	}
 
-	req.ID = vID
+	var err error
+	req.Link, err = auth.ParseLink(vID, r.URL.Query())
+	if err != nil {
+		return err
+	}
 
 	return nil
 }
 
-// Validate validates request for update file.
-func (req *updateFileRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	case req.Name == "":
-		return fmt.Errorf("name is required")
-	case req.Description == "":
-		return fmt.Errorf("description is required")
-	case req.Size < 1:
-		return fmt.Errorf("not correct size")
-	case req.Path == "":
-		return fmt.Errorf("path is required")
-	case req.AddDate == time.Time{}:
-		return fmt.Errorf("add date is required")
-	case req.UpdateDate == time.Time{}:
-		return fmt.Errorf("update date is required")
-	case req.AuthorID == 0:
-		return fmt.Errorf("not correct author id")
-	default:
-		return nil
+// Validate validates request to download file content by signed link.
+func (req *downloadByLinkFileRequest) Validate() error {
+	if !primitive.IsValidObjectID(req.FileID) {
+		return middleware.FieldError{Field: "id", Rule: "objectid", Message: "not correct id"}
 	}
+
+	return nil
 }
 
-// @Summary Update
-// @Security ApiKeyAuth
+// @Summary Download by link
 // @Tags file
-// @Description Update file
-// @Accept  json
-// @Produce  json
+// @Description Download file content by signed link. Supports Range and If-None-Match requests
+// @Produce  application/octet-stream
 // @Param id path string true "File id"
-// @Param file body model.UpdateFileRequest true "File"
-// @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Param user query string true "User id"
+// @Param expires query int true "Link expiry unix time"
+// @Param nonce query string true "Link nonce"
+// @Param once query string false "Single-use link flag"
+// @Param signature query string true "Link signature"
+// @Param Range header string false "Byte range"
+// @Success 200 {file} file "File content"
+// @Success 206 {file} file "Partial file content"
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
 // @Failure 404 {object} middleware.SwagEmptyError "No file"
+// @Failure 410 {object} middleware.SwagError
 // @Failure 500 {object} middleware.SwagError
-// @Router /file/api/{id} [put]
-func (f *fileRouter) updateFile(w http.ResponseWriter, r *http.Request) {
-	var req updateFileRequest
+// @Router /file/download/{id} [get]
+func (f *fileRouter) downloadByLinkFile(w http.ResponseWriter, r *http.Request) {
+	var req downloadByLinkFileRequest
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
 		return
 	}
 
-	id, err := f.services.File.Update(r.Context(), req.UpdateFileRequest)
+	content, err := f.services.File.DownloadByLink(r.Context(), req.Link)
+	if errors.Is(err, auth.ErrInvalidLink) || errors.Is(err, service.ErrNotEntitled) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, auth.ErrLinkExpired) || errors.Is(err, service.ErrLinkUsed) {
+		middleware.JSONError(w, err, http.StatusGone)
+		return
+	}
+	if errors.Is(err, service.ErrNoContent) {
+		middleware.JSONError(w, err, http.StatusNotFound)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusInternalServerError)
 		return
 	}
 
-	if id == "" {
+	if content == nil {
 		middleware.Empty(w, http.StatusNotFound)
 		return
 	}
 
-	middleware.JSONReturn(w, http.StatusOK, id)
+	f.serveContent(w, r, content)
+}
+
+// statusWriter captures status of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader captures status of the response.
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write captures implicit status of the response.
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// serveContent writes file content with ETag, Content-Type and Content-Disposition headers.
+// Range and conditional requests are handled by http.ServeContent, only full content responses are recorded as downloads.
+func (f *fileRouter) serveContent(w http.ResponseWriter, r *http.Request, content *model.FileContent) {
+	defer func(content io.Closer) {
+		err := content.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close file content", "error", err)
+		}
+	}(content.Content)
+
+	contentType := content.File.ContentType
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	w.Header().Set("ETag", strconv.Quote(content.File.Checksum))
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": content.File.Name,
+	}))
+
+	sw := &statusWriter{ResponseWriter: w}
+	http.ServeContent(sw, r, content.File.Name, time.Time{}, content.Content)
+	if sw.status != http.StatusOK {
+		return
+	}
+
+	err := f.services.File.RecordDownload(r.Context(), content)
+	if err != nil {
+		logger.FromContext(r.Context()).Error("couldn't record file download", "error", err)
+	}
 }
 
 type deleteFileRequest struct {
 	model.DeleteFileRequest
 }
 
-// Build builds request for delete file.
+// Build builds request of the current user to delete file.
 func (req *deleteFileRequest) Build(r *http.Request) error {
 	vID, ok := mux.Vars(r)["id"]
 	if !ok {
 		return fmt.Errorf("no id")
 	}
 
-	req.ID = vID
+	var err error
+	req.AuthorID, err = userID(r)
+	if err != nil {
+		return err
+	}
 
-	return nil
-}
+	req.ID = vID
 
-// Validate validates request for delete file.
-func (req *deleteFileRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
+	revision, err := ifMatch(r)
+	if err != nil {
+		return err
 	}
+	req.Revision = revision
+
+	return nil
 }
 
 // @Summary Delete
 // @Security ApiKeyAuth
 // @Tags file
-// @Description Delete file
+// @Description Delete file with its versions, purchases and their comments
 // @Accept  json
 // @Produce  json
 // @Param id path string true "File id"
+// @Param If-Match header string true "Revision entity tag"
 // @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
 // @Failure 404 {object} middleware.SwagEmptyError "No file"
+// @Failure 412 {object} middleware.SwagError
+// @Failure 428 {object} middleware.SwagError
 // @Failure 500 {object} middleware.SwagError
 // @Router /author/api/{id} [delete]
 func (f *fileRouter) deleteFile(w http.ResponseWriter, r *http.Request) {
 	var req deleteFileRequest
 	err := middleware.ParseRequest(r, &req)
+	if errors.Is(err, errIfMatchRequired) {
+		middleware.JSONError(w, err, http.StatusPreconditionRequired)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
 		return
 	}
 
 	id, err := f.services.File.Delete(r.Context(), req.DeleteFileRequest)
+	if errors.Is(err, service.ErrNotFileAuthor) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if errors.Is(err, service.ErrModified) {
+		middleware.JSONError(w, err, http.StatusPreconditionFailed)
+		return
+	}
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusInternalServerError)
 		return
@@ -316,16 +1047,6 @@ func (req *idFileRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find file by id.
-func (req *idFileRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByID
 // @Security ApiKeyAuth
 // @Tags file
@@ -334,7 +1055,8 @@ func (req *idFileRequest) Validate() error {
 // @Produce  json
 // @Param id path string true "File id"
 // @Success 200 {object} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Header 200 {string} ETag "Revision entity tag"
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No file"
 // @Failure 500 {object} middleware.SwagError
 // @Router /file/api/{id} [get]
@@ -357,6 +1079,7 @@ func (f *fileRouter) findByIDFile(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", eTag(file.Revision))
 	middleware.JSONReturn(w, http.StatusOK, file)
 }
 
@@ -376,16 +1099,6 @@ func (req *nameFileRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find file by name.
-func (req *nameFileRequest) Validate() error {
-	switch {
-	case req.Name == "":
-		return fmt.Errorf("name is required")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByName
 // @Tags file
 // @Description Find files by name
@@ -393,7 +1106,7 @@ func (req *nameFileRequest) Validate() error {
 // @Produce  json
 // @Param name path string true "File name"
 // @Success 200 {array} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No files"
 // @Failure 500 {object} middleware.SwagError
 // @Router /file/{name} [get]
@@ -419,13 +1132,67 @@ func (f *fileRouter) findByNameFile(w http.ResponseWriter, r *http.Request) {
 	middleware.JSONReturn(w, http.StatusOK, files)
 }
 
+type textFileRequest struct {
+	model.TextFileRequest
+}
+
+// Build builds request to find files by text.
+func (req *textFileRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.TextFileRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	return nil
+}
+
+// @Summary FindByText
+// @Tags file
+// @Description Find files by name and description text ranked by relevance
+// @Accept  json
+// @Produce  json
+// @Param text body model.TextFileRequest true "File text"
+// @Success 200 {array} model.File
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No files"
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/text [post]
+func (f *fileRouter) findByTextFile(w http.ResponseWriter, r *http.Request) {
+	var req textFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	files, err := f.services.File.FindByText(r.Context(), req.TextFileRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(files) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, files)
+}
+
 // @Summary FindAll
 // @Tags file
 // @Description Find files
 // @Accept  json
 // @Produce  json
 // @Success 200 {array} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No files"
 // @Failure 500 {object} middleware.SwagError
 // @Router /file/ [get]
@@ -444,6 +1211,65 @@ func (f *fileRouter) findAllFile(w http.ResponseWriter, r *http.Request) {
 	middleware.JSONReturn(w, http.StatusOK, files)
 }
 
+// @Summary FindByRating
+// @Tags file
+// @Description Find files sorted by rating
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.File
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No files"
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/rating/ [get]
+func (f *fileRouter) findByRatingFile(w http.ResponseWriter, r *http.Request) {
+	files, err := f.services.File.FindByRating(r.Context())
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(files) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, files)
+}
+
+// @Summary FindVersions
+// @Security ApiKeyAuth
+// @Tags file
+// @Description Find versions of file from the latest
+// @Accept  json
+// @Produce  json
+// @Param id path string true "File id"
+// @Success 200 {array} model.FileVersionDTO
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No versions"
+// @Failure 500 {object} middleware.SwagError
+// @Router /file/api/{id}/versions [get]
+func (f *fileRouter) findVersionsFile(w http.ResponseWriter, r *http.Request) {
+	var req idFileRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	versions, err := f.services.File.FindVersions(r.Context(), req.IDFileRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(versions) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, versions)
+}
+
 type authorIDFileRequest struct {
 	model.AuthorIDFileRequest
 }
@@ -464,16 +1290,6 @@ func (req *authorIDFileRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request fto find file by author id.
-func (req *authorIDFileRequest) Validate() error {
-	switch {
-	case req.ID == 0:
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByAuthorID
 // @Security ApiKeyAuth
 // @Tags file
@@ -482,7 +1298,7 @@ func (req *authorIDFileRequest) Validate() error {
 // @Produce  json
 // @Param id path string true "Author id"
 // @Success 200 {array} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No files"
 // @Failure 500 {object} middleware.SwagError
 // @Router /file/api/author/{id} [get]
@@ -514,7 +1330,7 @@ func (f *fileRouter) findByAuthorIDFile(w http.ResponseWriter, r *http.Request)
 // @Accept  json
 // @Produce  json
 // @Success 200 {array} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No files"
 // @Failure 500 {object} middleware.SwagError
 // @Router /file/expired/ [get]
@@ -539,7 +1355,7 @@ func (f *fileRouter) findNotActualFile(w http.ResponseWriter, r *http.Request) {
 // @Accept  json
 // @Produce  json
 // @Success 200 {array} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No files"
 // @Failure 500 {object} middleware.SwagError
 // @Router /file/actual/ [get]
@@ -560,11 +1376,16 @@ func (f *fileRouter) findActualFile(w http.ResponseWriter, r *http.Request) {
 
 type addedPeriodFileRequest struct {
 	model.AddedPeriodFileRequest
+	now time.Time
 }
 
 // Build builds request to find added file by date period.
 func (req *addedPeriodFileRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.AddedPeriodFileRequest)
+	if r.Method == http.MethodGet {
+		return queryPeriod(r, req.now, &req.Start, &req.End)
+	}
+
+	err := middleware.DecodeJSON(r.Body, &req.AddedPeriodFileRequest)
 	if err != nil {
 		return err
 	}
@@ -572,38 +1393,29 @@ func (req *addedPeriodFileRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
 	return nil
 }
 
-// Validate validates request to find added file by date period.
-func (req *addedPeriodFileRequest) Validate() error {
-	switch {
-	case req.Start == time.Time{}:
-		return fmt.Errorf("start is required")
-	case req.End == time.Time{}:
-		return fmt.Errorf("end is required")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindAddedByPeriod
 // @Tags file
-// @Description Find added files by date period
+// @Description Find added files by date period, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.AddedPeriodFileRequest true "Period"
+// @Param period body model.AddedPeriodFileRequest false "Period, deprecated POST only"
+// @Param start query string false "Start as RFC3339 time, now, today or last N with m, h, d or w unit"
+// @Param end query string false "End, now by default, as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Success 200 {array} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No files"
 // @Failure 500 {object} middleware.SwagError
+// @Router /file/added [get]
 // @Router /file/added [post]
 func (f *fileRouter) findAddedByPeriodFile(w http.ResponseWriter, r *http.Request) {
-	var req addedPeriodFileRequest
+	req := addedPeriodFileRequest{now: f.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -626,11 +1438,16 @@ func (f *fileRouter) findAddedByPeriodFile(w http.ResponseWriter, r *http.Reques
 
 type updatedPeriodFileRequest struct {
 	model.UpdatedPeriodFileRequest
+	now time.Time
 }
 
 // Build builds request to find updated file by date period.
 func (req *updatedPeriodFileRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.UpdatedPeriodFileRequest)
+	if r.Method == http.MethodGet {
+		return queryPeriod(r, req.now, &req.Start, &req.End)
+	}
+
+	err := middleware.DecodeJSON(r.Body, &req.UpdatedPeriodFileRequest)
 	if err != nil {
 		return err
 	}
@@ -638,38 +1455,29 @@ func (req *updatedPeriodFileRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
 	return nil
 }
 
-// Validate validates request to find updated file by date period.
-func (req *updatedPeriodFileRequest) Validate() error {
-	switch {
-	case req.Start == time.Time{}:
-		return fmt.Errorf("start is required")
-	case req.End == time.Time{}:
-		return fmt.Errorf("end is required")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindUpdatedByPeriod
 // @Tags file
-// @Description Find updated files by date period
+// @Description Find updated files by date period, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.UpdatedPeriodFileRequest true "Period"
+// @Param period body model.UpdatedPeriodFileRequest false "Period, deprecated POST only"
+// @Param start query string false "Start as RFC3339 time, now, today or last N with m, h, d or w unit"
+// @Param end query string false "End, now by default, as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Success 200 {array} model.File
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No files"
 // @Failure 500 {object} middleware.SwagError
+// @Router /file/updated [get]
 // @Router /file/updated [post]
 func (f *fileRouter) findUpdatedByPeriodFile(w http.ResponseWriter, r *http.Request) {
-	var req updatedPeriodFileRequest
+	req := updatedPeriodFileRequest{now: f.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -4,14 +4,18 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/handler/mock"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/pkg/errors"
 	testAssert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -26,6 +30,7 @@ const (
 	updated = "updated"
 	file    = "file"
 	author  = "author"
+	rating  = "rating"
 )
 
 func TestFile_Create(t *testing.T) {
@@ -33,7 +38,7 @@ func TestFile_Create(t *testing.T) {
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("1")
 	require.NoError(t, err)
 
 	type test struct {
@@ -48,24 +53,26 @@ func TestFile_Create(t *testing.T) {
 
 	tt := []test{
 		{
-			name:   "invalid author id",
+			name:   "author id of body ignored",
 			path:   fmt.Sprintf("/%s/%s/", file, api),
 			method: http.MethodPost,
 			req: model.CreateFileRequest{
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
+				AuthorID:    2,
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("Create", mock.Anything, data.req).
-					Return("", nil)
+				fileService.On("Create", mock.Anything, model.CreateFileRequest{
+					Name:        data.req.Name,
+					Description: data.req.Description,
+					Actual:      data.req.Actual,
+					AuthorID:    1,
+				}).
+					Return(data.expBody, nil)
 			},
-			expCode: http.StatusBadRequest,
-			expBody: "not correct author id",
+			expCode: http.StatusOK,
+			expBody: id,
 		},
 		{
 			name:   "create err",
@@ -74,10 +81,6 @@ func TestFile_Create(t *testing.T) {
 			req: model.CreateFileRequest{
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
@@ -94,10 +97,6 @@ func TestFile_Create(t *testing.T) {
 			req: model.CreateFileRequest{
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
@@ -114,7 +113,7 @@ func TestFile_Create(t *testing.T) {
 			var r string
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -125,6 +124,7 @@ func TestFile_Create(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -132,8 +132,7 @@ func TestFile_Create(t *testing.T) {
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
 
-			err = json.NewDecoder(res.Body).Decode(&r)
-			assert.Nil(err)
+			r = decodeMessage(t, res.Body)
 			assert.Equal(tc.expBody, r)
 		})
 	}
@@ -144,11 +143,12 @@ func TestFile_Update(t *testing.T) {
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("1")
 	require.NoError(t, err)
 
 	type test struct {
 		name    string
+		ifMatch string
 		path    string
 		method  string
 		isOkRes bool
@@ -161,6 +161,7 @@ func TestFile_Update(t *testing.T) {
 	tt := []test{
 		{
 			name:    "invalid author id",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", file, api),
 			method:  http.MethodPut,
 			isOkRes: true,
@@ -168,10 +169,6 @@ func TestFile_Update(t *testing.T) {
 				ID:          "some",
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
@@ -184,6 +181,7 @@ func TestFile_Update(t *testing.T) {
 		},
 		{
 			name:    "update err",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", file, api),
 			method:  http.MethodPut,
 			isOkRes: true,
@@ -191,10 +189,6 @@ func TestFile_Update(t *testing.T) {
 				ID:          id,
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
@@ -205,17 +199,14 @@ func TestFile_Update(t *testing.T) {
 			expCode: http.StatusInternalServerError,
 		},
 		{
-			name:   "not found",
-			path:   fmt.Sprintf("/%s/%s/", file, api),
-			method: http.MethodPut,
+			name:    "not found",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodPut,
 			req: model.UpdateFileRequest{
 				ID:          id,
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
@@ -227,6 +218,7 @@ func TestFile_Update(t *testing.T) {
 		},
 		{
 			name:    "all ok",
+			ifMatch: `"0"`,
 			path:    fmt.Sprintf("/%s/%s/", file, api),
 			method:  http.MethodPut,
 			isOkRes: true,
@@ -234,10 +226,6 @@ func TestFile_Update(t *testing.T) {
 				ID:          id,
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
@@ -248,13 +236,85 @@ func TestFile_Update(t *testing.T) {
 			expCode: http.StatusOK,
 			expBody: id,
 		},
+		{
+			name:    "no If-Match",
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req: model.UpdateFileRequest{
+				ID:          id,
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			expCode: http.StatusPreconditionRequired,
+			expBody: errIfMatchRequired.Error(),
+		},
+		{
+			name:    "not correct If-Match",
+			ifMatch: "0",
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req: model.UpdateFileRequest{
+				ID:          id,
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct If-Match header",
+		},
+		{
+			name:    "not author",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req: model.UpdateFileRequest{
+				ID:          id,
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Update", mock.Anything, data.req).
+					Return("", service.ErrNotFileAuthor)
+			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrNotFileAuthor.Error(),
+		},
+		{
+			name:    "modified",
+			ifMatch: `"1"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodPut,
+			isOkRes: true,
+			req: model.UpdateFileRequest{
+				ID:          id,
+				Revision:    1,
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Update", mock.Anything, data.req).
+					Return("", service.ErrModified)
+			},
+			expCode: http.StatusPreconditionFailed,
+			expBody: service.ErrModified.Error(),
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			var r string
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -265,35 +325,37 @@ func TestFile_Update(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.ID), body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req.Header.Set("If-Match", tc.ifMatch)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
 			if tc.isOkRes {
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 			}
 			assert.Equal(tc.expBody, r)
 		})
 	}
 }
 
-func TestFile_Delete(t *testing.T) {
+func TestFile_Patch(t *testing.T) {
 	assert := testAssert.New(t)
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("1")
 	require.NoError(t, err)
+	name := "some"
+	actual := false
 
 	type test struct {
 		name    string
-		path    string
-		method  string
-		isOkRes bool
-		req     model.DeleteFileRequest
+		ifMatch string
+		id      string
+		body    string
 		fn      func(fileService *m.File, data test)
 		expCode int
 		expBody string
@@ -301,74 +363,1355 @@ func TestFile_Delete(t *testing.T) {
 
 	tt := []test{
 		{
-			name:    "invalid author id",
-			path:    fmt.Sprintf("/%s/%s/", file, api),
-			method:  http.MethodDelete,
-			isOkRes: true,
-			req: model.DeleteFileRequest{
-				ID: "some",
+			name:    "invalid id",
+			ifMatch: `"0"`,
+			id:      "some",
+			body:    `{"actual":false}`,
+			expCode: http.StatusBadRequest,
+			expBody: "not correct id",
+		},
+		{
+			name:    "not an object",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `null`,
+			expCode: http.StatusBadRequest,
+			expBody: "patch must be an object",
+		},
+		{
+			name:    "unknown field",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"size":1}`,
+			expCode: http.StatusBadRequest,
+			expBody: "unknown field size",
+		},
+		{
+			name:    "removed field",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"name":null}`,
+			expCode: http.StatusBadRequest,
+			expBody: "name couldn't be removed",
+		},
+		{
+			name:    "invalid field",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"actual":"some"}`,
+			expCode: http.StatusBadRequest,
+			expBody: "not correct actual",
+		},
+		{
+			name:    "changed author",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"authorID":2}`,
+			expCode: http.StatusBadRequest,
+			expBody: "authorID couldn't be changed",
+		},
+		{
+			name:    "empty name",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"name":""}`,
+			expCode: http.StatusBadRequest,
+			expBody: "name is required",
+		},
+		{
+			name:    "patch err",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"actual":false}`,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Patch", mock.Anything, model.PatchFileRequest{ID: data.id, AuthorID: 1, Actual: &actual}).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "not author",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"actual":false}`,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Patch", mock.Anything, model.PatchFileRequest{ID: data.id, AuthorID: 1, Actual: &actual}).
+					Return("", service.ErrNotFileAuthor)
 			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrNotFileAuthor.Error(),
+		},
+		{
+			name:    "not found",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"actual":false}`,
 			fn: func(fileService *m.File, data test) {
-				fileService.On("Delete", mock.Anything, data.req).
+				fileService.On("Patch", mock.Anything, model.PatchFileRequest{ID: data.id, AuthorID: 1, Actual: &actual}).
 					Return("", nil)
 			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			ifMatch: `"0"`,
+			id:      id,
+			body:    `{"name":"some","actual":false}`,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Patch", mock.Anything, model.PatchFileRequest{ID: data.id, AuthorID: 1, Name: &name, Actual: &actual}).
+					Return(data.id, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+		{
+			name:    "no If-Match",
+			id:      id,
+			body:    `{"name":"some","actual":false}`,
+			expCode: http.StatusPreconditionRequired,
+			expBody: errIfMatchRequired.Error(),
+		},
+		{
+			name:    "not correct If-Match",
+			ifMatch: "0",
+			id:      id,
+			body:    `{"name":"some","actual":false}`,
 			expCode: http.StatusBadRequest,
-			expBody: "not correct id",
+			expBody: "not correct If-Match header",
 		},
 		{
-			name:    "delete err",
-			path:    fmt.Sprintf("/%s/%s/", file, api),
-			method:  http.MethodDelete,
+			name:    "modified",
+			ifMatch: `"1"`,
+			id:      id,
+			body:    `{"name":"some","actual":false}`,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Patch", mock.Anything, model.PatchFileRequest{ID: data.id, AuthorID: 1, Name: &name, Actual: &actual, Revision: 1}).
+					Return("", service.ErrModified)
+			},
+			expCode: http.StatusPreconditionFailed,
+			expBody: service.ErrModified.Error(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			path := fmt.Sprintf("/%s/%s/%s", file, api, tc.id)
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodPatch, path, strings.NewReader(tc.body))
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req.Header.Set("If-Match", tc.ifMatch)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			if res.Body.Len() != 0 {
+				r = decodeMessage(t, res.Body)
+			}
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
+
+func TestFile_Upload(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		ifMatch     string
+		revision    int
+		id          string
+		fileName    string
+		contentType string
+		isOkRes     bool
+		fn          func(fileService *m.File, data test)
+		expCode     int
+		expBody     string
+	}
+
+	upload := func(data test) interface{} {
+		return mock.MatchedBy(func(req model.UploadFileRequest) bool {
+			return req.ID == data.id &&
+				req.AuthorID == 1 &&
+				req.Revision == data.revision &&
+				req.Name == data.fileName &&
+				req.ContentType != ""
+		})
+	}
+
+	tt := []test{
+		{
+			name:     "invalid id",
+			ifMatch:  `"0"`,
+			id:       "some",
+			fileName: "some.txt",
+			isOkRes:  true,
+			expCode:  http.StatusBadRequest,
+			expBody:  "not correct id",
+		},
+		{
+			name:    "no name",
+			ifMatch: `"0"`,
+			id:      id,
 			isOkRes: true,
-			req: model.DeleteFileRequest{
-				ID: id,
+			expCode: http.StatusBadRequest,
+			expBody: "name is required",
+		},
+		{
+			name:     "not author",
+			ifMatch:  `"0"`,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Upload", mock.Anything, upload(data)).
+					Return("", service.ErrNotFileAuthor)
 			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrNotFileAuthor.Error(),
+		},
+		{
+			name:     "empty content",
+			ifMatch:  `"0"`,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
 			fn: func(fileService *m.File, data test) {
-				fileService.On("Delete", mock.Anything, data.req).
+				fileService.On("Upload", mock.Anything, upload(data)).
+					Return("", service.ErrEmptyContent)
+			},
+			expCode: http.StatusBadRequest,
+			expBody: service.ErrEmptyContent.Error(),
+		},
+		{
+			name:     "upload err",
+			ifMatch:  `"0"`,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Upload", mock.Anything, upload(data)).
 					Return("", errors.New(""))
 			},
 			expCode: http.StatusInternalServerError,
 		},
 		{
-			name:   "not found",
-			path:   fmt.Sprintf("/%s/%s/", file, api),
-			method: http.MethodDelete,
-			req: model.DeleteFileRequest{
-				ID: id,
-			},
+			name:     "not found",
+			ifMatch:  `"0"`,
+			id:       id,
+			fileName: "some.txt",
 			fn: func(fileService *m.File, data test) {
-				fileService.On("Delete", mock.Anything, data.req).
+				fileService.On("Upload", mock.Anything, upload(data)).
 					Return("", nil)
 			},
 			expCode: http.StatusNotFound,
 		},
 		{
-			name:    "all ok",
-			path:    fmt.Sprintf("/%s/%s/", file, api),
-			method:  http.MethodDelete,
-			isOkRes: true,
-			req: model.DeleteFileRequest{
-				ID: id,
+			name:        "all ok",
+			ifMatch:     `"0"`,
+			id:          id,
+			fileName:    "some.txt",
+			contentType: "text/plain",
+			isOkRes:     true,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Upload", mock.Anything, upload(data)).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+		{
+			name:     "any revision",
+			ifMatch:  "*",
+			revision: model.AnyRevision,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Upload", mock.Anything, upload(data)).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+		{
+			name:     "one of revisions",
+			ifMatch:  `W/"1", "some", "1"`,
+			revision: 1,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Upload", mock.Anything, upload(data)).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+		{
+			name:     "no If-Match",
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			expCode:  http.StatusPreconditionRequired,
+			expBody:  errIfMatchRequired.Error(),
+		},
+		{
+			name:     "not correct If-Match",
+			ifMatch:  "0",
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			expCode:  http.StatusBadRequest,
+			expBody:  "not correct If-Match header",
+		},
+		{
+			name:     "weak If-Match",
+			ifMatch:  `W/"0"`,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			expCode:  http.StatusPreconditionFailed,
+			expBody:  service.ErrModified.Error(),
+		},
+		{
+			name:     "not matched If-Match",
+			ifMatch:  `"some"`,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			expCode:  http.StatusPreconditionFailed,
+			expBody:  service.ErrModified.Error(),
+		},
+		{
+			name:     "modified",
+			ifMatch:  `"1"`,
+			revision: 1,
+			id:       id,
+			fileName: "some.txt",
+			isOkRes:  true,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Upload", mock.Anything, upload(data)).
+					Return("", service.ErrModified)
+			},
+			expCode: http.StatusPreconditionFailed,
+			expBody: service.ErrModified.Error(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			path := fmt.Sprintf("/%s/%s/%s/content?name=%s", file, api, tc.id, tc.fileName)
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodPut, path, bytes.NewBufferString("some content"))
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req.Header.Set("If-Match", tc.ifMatch)
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			if tc.isOkRes {
+				r = decodeMessage(t, res.Body)
+			}
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error { return nil }
+
+func TestFile_Download(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	content := func() *model.FileContent {
+		return &model.FileContent{
+			File: model.FileDTO{
+				ID:          id,
+				Name:        "some.txt",
+				Checksum:    "some",
+				ContentType: "text/plain",
+			},
+			Content: nopSeekCloser{strings.NewReader("some content")},
+		}
+	}
+
+	type test struct {
+		name        string
+		id          string
+		rangeBytes  string
+		ifNoneMatch string
+		fn          func(fileService *m.File, data test)
+		expCode     int
+		expBody     string
+		expHeaders  map[string]string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid id",
+			id:      "some",
+			expCode: http.StatusBadRequest,
+			expBody: `{"message":"not correct id","errors":[{"field":"id","rule":"objectid","message":"not correct id"}]}`,
+		},
+		{
+			name: "not entitled",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(nil, service.ErrNotEntitled)
+			},
+			expCode: http.StatusForbidden,
+			expBody: fmt.Sprintf("%q", service.ErrNotEntitled.Error()),
+		},
+		{
+			name: "no content",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(nil, service.ErrNoContent)
+			},
+			expCode: http.StatusNotFound,
+			expBody: fmt.Sprintf("%q", service.ErrNoContent.Error()),
+		},
+		{
+			name: "download err",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(nil, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+			expBody: `""`,
+		},
+		{
+			name: "not found",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(nil, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name: "all ok",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(content(), nil)
+				fileService.On("RecordDownload", mock.Anything, mock.Anything).
+					Return(nil)
+			},
+			expCode: http.StatusOK,
+			expBody: "some content",
+			expHeaders: map[string]string{
+				"ETag":                `"some"`,
+				"Content-Type":        "text/plain",
+				"Content-Disposition": "attachment; filename=some.txt",
+			},
+		},
+		{
+			name:       "range",
+			id:         id,
+			rangeBytes: "bytes=5-",
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(content(), nil)
+			},
+			expCode: http.StatusPartialContent,
+			expBody: "content",
+			expHeaders: map[string]string{
+				"Content-Range": "bytes 5-11/12",
+			},
+		},
+		{
+			name:        "not modified",
+			id:          id,
+			ifNoneMatch: `"some"`,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(content(), nil)
+			},
+			expCode: http.StatusNotModified,
+		},
+		{
+			name: "record err",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Download", mock.Anything, model.DownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(content(), nil)
+				fileService.On("RecordDownload", mock.Anything, mock.Anything).
+					Return(errors.New(""))
+			},
+			expCode: http.StatusOK,
+			expBody: "some content",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := fmt.Sprintf("/%s/%s/%s/download", file, api, tc.id)
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			if tc.rangeBytes != "" {
+				req.Header.Set("Range", tc.rangeBytes)
+			}
+			if tc.ifNoneMatch != "" {
+				req.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			assert.Equal(tc.expBody, strings.TrimSpace(res.Body.String()))
+			for key, value := range tc.expHeaders {
+				assert.Equal(value, res.Header().Get(key))
+			}
+			file.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFile_DownloadVersion(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name    string
+		id      string
+		version string
+		fn      func(fileService *m.File, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid version",
+			id:      id,
+			version: "some",
+			expCode: http.StatusBadRequest,
+			expBody: `"not correct version"`,
+		},
+		{
+			name:    "zero version",
+			id:      id,
+			version: "0",
+			expCode: http.StatusBadRequest,
+			expBody: `"not correct version"`,
+		},
+		{
+			name:    "not entitled",
+			id:      id,
+			version: "1",
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadVersion", mock.Anything, model.DownloadFileVersionRequest{ID: data.id, Number: 1, UserID: 1}).
+					Return(nil, service.ErrNotEntitled)
+			},
+			expCode: http.StatusForbidden,
+			expBody: fmt.Sprintf("%q", service.ErrNotEntitled.Error()),
+		},
+		{
+			name:    "download err",
+			id:      id,
+			version: "1",
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadVersion", mock.Anything, model.DownloadFileVersionRequest{ID: data.id, Number: 1, UserID: 1}).
+					Return(nil, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+			expBody: `""`,
+		},
+		{
+			name:    "not found",
+			id:      id,
+			version: "1",
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadVersion", mock.Anything, model.DownloadFileVersionRequest{ID: data.id, Number: 1, UserID: 1}).
+					Return(nil, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			id:      id,
+			version: "1",
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadVersion", mock.Anything, model.DownloadFileVersionRequest{ID: data.id, Number: 1, UserID: 1}).
+					Return(&model.FileContent{
+						File:    model.FileDTO{ID: id, Name: "some.txt", Checksum: "some", Version: 1},
+						Content: nopSeekCloser{strings.NewReader("some content")},
+					}, nil)
+				fileService.On("RecordDownload", mock.Anything, mock.Anything).
+					Return(nil)
+			},
+			expCode: http.StatusOK,
+			expBody: "some content",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := fmt.Sprintf("/%s/%s/%s/versions/%s/download", file, api, tc.id, tc.version)
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			assert.Equal(tc.expBody, strings.TrimSpace(res.Body.String()))
+		})
+	}
+}
+
+func TestFile_FindVersions(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		id          string
+		isOkRes     bool
+		fn          func(fileService *m.File, data test)
+		expCode     int
+		expVersions []model.FileVersionDTO
+	}
+
+	tt := []test{
+		{
+			name:    "invalid id",
+			id:      "some",
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name: "find err",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindVersions", mock.Anything, model.IDFileRequest{ID: data.id}).
+					Return(nil, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name: "not found",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindVersions", mock.Anything, model.IDFileRequest{ID: data.id}).
+					Return(nil, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			id:      id,
+			isOkRes: true,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindVersions", mock.Anything, model.IDFileRequest{ID: data.id}).
+					Return(data.expVersions, nil)
+			},
+			expCode: http.StatusOK,
+			expVersions: []model.FileVersionDTO{
+				{
+					ID:     primitive.NewObjectID().Hex(),
+					FileID: id,
+					Number: 2,
+					Name:   "some",
+					Date:   time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC),
+				},
+				{
+					ID:     primitive.NewObjectID().Hex(),
+					FileID: id,
+					Number: 1,
+					Name:   "some",
+					Date:   time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := fmt.Sprintf("/%s/%s/%s/versions", file, api, tc.id)
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			if tc.isOkRes {
+				var versions []model.FileVersionDTO
+				err = json.NewDecoder(res.Body).Decode(&versions)
+				assert.Nil(err)
+				assert.Equal(tc.expVersions, versions)
+			}
+		})
+	}
+}
+
+func TestFile_SignDownload(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	expires := time.Date(2030, time.November, 10, 23, 0, 0, 0, time.UTC)
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name    string
+		id      string
+		body    string
+		fn      func(fileService *m.File, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid id",
+			id:      "some",
+			expCode: http.StatusBadRequest,
+			expBody: `{"message":"not correct id","errors":[{"field":"id","rule":"objectid","message":"not correct id"}]}`,
+		},
+		{
+			name: "not entitled",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("SignDownload", mock.Anything, model.SignDownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(nil, service.ErrNotEntitled)
+			},
+			expCode: http.StatusForbidden,
+			expBody: fmt.Sprintf("%q", service.ErrNotEntitled.Error()),
+		},
+		{
+			name: "sign err",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("SignDownload", mock.Anything, model.SignDownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(nil, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+			expBody: `""`,
+		},
+		{
+			name: "not found",
+			id:   id,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("SignDownload", mock.Anything, model.SignDownloadFileRequest{ID: data.id, UserID: 1}).
+					Return(nil, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name: "all ok",
+			id:   id,
+			body: `{"singleUse":true}`,
+			fn: func(fileService *m.File, data test) {
+				fileService.On("SignDownload", mock.Anything, model.SignDownloadFileRequest{ID: data.id, UserID: 1, SingleUse: true}).
+					Return(&auth.Link{
+						FileID:    data.id,
+						UserID:    "1",
+						Expires:   expires,
+						Nonce:     "nonce",
+						SingleUse: true,
+						Signature: "signature",
+					}, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: fmt.Sprintf(
+				`{"url":"/%s/download/%s?expires=%d\u0026nonce=nonce\u0026once=1\u0026signature=signature\u0026user=1","expires":"2030-11-10T23:00:00Z","singleUse":true}`,
+				file, id, expires.Unix(),
+			),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := fmt.Sprintf("/%s/%s/%s/link", file, api, tc.id)
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodPost, path, strings.NewReader(tc.body))
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			assert.Equal(tc.expBody, strings.TrimSpace(res.Body.String()))
+		})
+	}
+}
+
+func TestFile_DownloadByLink(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	expires := time.Date(2030, time.November, 10, 23, 0, 0, 0, time.UTC)
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+
+	link := auth.Link{
+		FileID:    id,
+		UserID:    "1",
+		Expires:   expires,
+		Nonce:     "nonce",
+		Signature: "signature",
+	}
+
+	type test struct {
+		name    string
+		query   string
+		fn      func(fileService *m.File, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid link",
+			query:   "user=1",
+			expCode: http.StatusBadRequest,
+			expBody: fmt.Sprintf("%q", auth.ErrInvalidLink.Error()),
+		},
+		{
+			name:  "bad signature",
+			query: link.Query(),
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadByLink", mock.Anything, link).
+					Return(nil, auth.ErrInvalidLink)
+			},
+			expCode: http.StatusForbidden,
+			expBody: fmt.Sprintf("%q", auth.ErrInvalidLink.Error()),
+		},
+		{
+			name:  "expired",
+			query: link.Query(),
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadByLink", mock.Anything, link).
+					Return(nil, auth.ErrLinkExpired)
+			},
+			expCode: http.StatusGone,
+			expBody: fmt.Sprintf("%q", auth.ErrLinkExpired.Error()),
+		},
+		{
+			name:  "used",
+			query: link.Query(),
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadByLink", mock.Anything, link).
+					Return(nil, service.ErrLinkUsed)
+			},
+			expCode: http.StatusGone,
+			expBody: fmt.Sprintf("%q", service.ErrLinkUsed.Error()),
+		},
+		{
+			name:  "all ok",
+			query: link.Query(),
+			fn: func(fileService *m.File, data test) {
+				fileService.On("DownloadByLink", mock.Anything, link).
+					Return(&model.FileContent{
+						File:    model.FileDTO{ID: id, Name: "some.txt", Checksum: "some"},
+						Content: nopSeekCloser{strings.NewReader("some content")},
+					}, nil)
+				fileService.On("RecordDownload", mock.Anything, mock.Anything).
+					Return(nil)
+			},
+			expCode: http.StatusOK,
+			expBody: "some content",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			path := fmt.Sprintf("/%s/download/%s?%s", file, id, tc.query)
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, path, nil)
+			assert.Nil(err)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			assert.Equal(tc.expBody, strings.TrimSpace(res.Body.String()))
+		})
+	}
+}
+
+func TestFile_Delete(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name    string
+		ifMatch string
+		path    string
+		method  string
+		isOkRes bool
+		req     model.DeleteFileRequest
+		fn      func(fileService *m.File, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "invalid author id",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteFileRequest{
+				ID:       "some",
+				AuthorID: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Delete", mock.Anything, data.req).
+					Return("", nil)
+			},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct id",
+		},
+		{
+			name:    "delete err",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteFileRequest{
+				ID:       id,
+				AuthorID: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Delete", mock.Anything, data.req).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:    "not found",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			req: model.DeleteFileRequest{
+				ID:       id,
+				AuthorID: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Delete", mock.Anything, data.req).
+					Return("", nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "not author",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteFileRequest{
+				ID:       id,
+				AuthorID: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Delete", mock.Anything, data.req).
+					Return("", service.ErrNotFileAuthor)
+			},
+			expCode: http.StatusForbidden,
+			expBody: service.ErrNotFileAuthor.Error(),
+		},
+		{
+			name:    "all ok",
+			ifMatch: `"0"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteFileRequest{
+				ID:       id,
+				AuthorID: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Delete", mock.Anything, data.req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+		{
+			name:    "no If-Match",
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteFileRequest{
+				ID:       id,
+				AuthorID: 1,
+			},
+			expCode: http.StatusPreconditionRequired,
+			expBody: errIfMatchRequired.Error(),
+		},
+		{
+			name:    "not correct If-Match",
+			ifMatch: "0",
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteFileRequest{
+				ID:       id,
+				AuthorID: 1,
+			},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct If-Match header",
+		},
+		{
+			name:    "modified",
+			ifMatch: `"1"`,
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodDelete,
+			isOkRes: true,
+			req: model.DeleteFileRequest{
+				ID:       id,
+				AuthorID: 1,
+				Revision: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Delete", mock.Anything, data.req).
+					Return("", service.ErrModified)
+			},
+			expCode: http.StatusPreconditionFailed,
+			expBody: service.ErrModified.Error(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.ID), nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req.Header.Set("If-Match", tc.ifMatch)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			if tc.isOkRes {
+				r = decodeMessage(t, res.Body)
+			}
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
+
+func TestFile_FindByID(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		path        string
+		method      string
+		isOkRes     bool
+		isOkMessage bool
+		req         model.IDFileRequest
+		fn          func(fileService *m.File, data test)
+		expCode     int
+		expRes      model.FileDTO
+		message     string
+	}
+
+	tt := []test{
+		{
+			name:        "invalid author id",
+			path:        fmt.Sprintf("/%s/%s/", file, api),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req: model.IDFileRequest{
+				ID: "some",
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindByID", mock.Anything, data.req).
+					Return(&data.expRes, nil)
+			},
+			expCode: http.StatusBadRequest,
+			message: "not correct id",
+		},
+		{
+			name:        "find err",
+			path:        fmt.Sprintf("/%s/%s/", file, api),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req: model.IDFileRequest{
+				ID: id,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindByID", mock.Anything, data.req).
+					Return(&data.expRes, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/", file, api),
+			method: http.MethodGet,
+			req: model.IDFileRequest{
+				ID: id,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindByID", mock.Anything, data.req).
+					Return(&data.expRes, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/", file, api),
+			method:  http.MethodGet,
+			isOkRes: true,
+			req: model.IDFileRequest{
+				ID: id,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindByID", mock.Anything, data.req).
+					Return(&data.expRes, nil)
+			},
+			expCode: http.StatusOK,
+			expRes: model.FileDTO{
+				ID:          id,
+				Name:        "some",
+				Description: "some",
+				Size:        1,
+				Path:        "some",
+				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+				Actual:      true,
+				AuthorID:    1,
+				Revision:    3,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			var f model.FileDTO
+			file := new(m.File)
+			testAPI.Services.File = file
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+
+			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.ID), nil)
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			switch {
+			case tc.isOkMessage:
+				r = decodeMessage(t, res.Body)
+				assert.Equal(tc.message, r)
+			case tc.isOkRes:
+				err = json.NewDecoder(res.Body).Decode(&f)
+				assert.Nil(err)
+				assert.Equal(tc.expRes, f)
+				assert.Equal(`"3"`, res.Header().Get("ETag"))
+			default:
+				assert.Equal(tc.message, r)
+			}
+		})
+	}
+}
+
+func TestFile_FindByAuthorID(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		path        string
+		method      string
+		isOkRes     bool
+		isOkMessage bool
+		req         model.AuthorIDFileRequest
+		fn          func(fileService *m.File, data test)
+		expCode     int
+		expRes      []model.FileDTO
+		message     string
+	}
+
+	tt := []test{
+		{
+			name:        "invalid author id",
+			path:        fmt.Sprintf("/%s/%s/%s/", file, api, author),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req:         model.AuthorIDFileRequest{},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindByAuthorID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusBadRequest,
+			message: "not correct id",
+		},
+		{
+			name:        "find err",
+			path:        fmt.Sprintf("/%s/%s/%s/", file, api, author),
+			method:      http.MethodGet,
+			isOkMessage: true,
+			req: model.AuthorIDFileRequest{
+				ID: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindByAuthorID", mock.Anything, data.req).
+					Return(data.expRes, errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:   "not found",
+			path:   fmt.Sprintf("/%s/%s/%s/", file, api, author),
+			method: http.MethodGet,
+			req: model.AuthorIDFileRequest{
+				ID: 1,
+			},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("FindByAuthorID", mock.Anything, data.req).
+					Return(data.expRes, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name:    "all ok",
+			path:    fmt.Sprintf("/%s/%s/%s/", file, api, author),
+			method:  http.MethodGet,
+			isOkRes: true,
+			req: model.AuthorIDFileRequest{
+				ID: 1,
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("Delete", mock.Anything, data.req).
-					Return(data.expBody, nil)
+				fileService.On("FindByAuthorID", mock.Anything, data.req).
+					Return(data.expRes, nil)
 			},
 			expCode: http.StatusOK,
-			expBody: id,
+			expRes: []model.FileDTO{
+				{
+					ID:          id,
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Actual:      true,
+					AuthorID:    1,
+				},
+			},
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			var r string
+			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
 
-			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.ID), nil)
+			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%d", tc.path, tc.req.ID), nil)
 			assert.Nil(err)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
@@ -376,22 +1719,27 @@ func TestFile_Delete(t *testing.T) {
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
-			if tc.isOkRes {
-				err = json.NewDecoder(res.Body).Decode(&r)
+
+			switch {
+			case tc.isOkMessage:
+				r = decodeMessage(t, res.Body)
+				assert.Equal(tc.message, r)
+			case tc.isOkRes:
+				err = json.NewDecoder(res.Body).Decode(&f)
 				assert.Nil(err)
+				assert.Equal(tc.expRes, f)
+			default:
+				assert.Equal(tc.message, r)
 			}
-			assert.Equal(tc.expBody, r)
 		})
 	}
 }
 
-func TestFile_FindByID(t *testing.T) {
+func TestFile_FindByName(t *testing.T) {
 	assert := testAssert.New(t)
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
-	require.NoError(t, err)
 
 	type test struct {
 		name        string
@@ -399,106 +1747,90 @@ func TestFile_FindByID(t *testing.T) {
 		method      string
 		isOkRes     bool
 		isOkMessage bool
-		req         model.IDFileRequest
+		req         model.NameFileRequest
 		fn          func(fileService *m.File, data test)
 		expCode     int
-		expRes      model.FileDTO
+		expRes      []model.FileDTO
 		message     string
 	}
 
 	tt := []test{
-		{
-			name:        "invalid author id",
-			path:        fmt.Sprintf("/%s/%s/", file, api),
-			method:      http.MethodGet,
-			isOkMessage: true,
-			req: model.IDFileRequest{
-				ID: "some",
-			},
-			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByID", mock.Anything, data.req).
-					Return(&data.expRes, nil)
-			},
-			expCode: http.StatusBadRequest,
-			message: "not correct id",
-		},
 		{
 			name:        "find err",
-			path:        fmt.Sprintf("/%s/%s/", file, api),
+			path:        fmt.Sprintf("/%s/", file),
 			method:      http.MethodGet,
 			isOkMessage: true,
-			req: model.IDFileRequest{
-				ID: id,
+			req: model.NameFileRequest{
+				Name: "some",
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByID", mock.Anything, data.req).
-					Return(&data.expRes, errors.New(""))
+				fileService.On("FindByName", mock.Anything, data.req).
+					Return(data.expRes, errors.New(""))
 			},
 			expCode: http.StatusInternalServerError,
 		},
 		{
 			name:   "not found",
-			path:   fmt.Sprintf("/%s/%s/", file, api),
+			path:   fmt.Sprintf("/%s/", file),
 			method: http.MethodGet,
-			req: model.IDFileRequest{
-				ID: id,
+			req: model.NameFileRequest{
+				Name: "some",
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByID", mock.Anything, data.req).
-					Return(&data.expRes, nil)
+				fileService.On("FindByName", mock.Anything, data.req).
+					Return(data.expRes, nil)
 			},
 			expCode: http.StatusNotFound,
 		},
 		{
 			name:    "all ok",
-			path:    fmt.Sprintf("/%s/%s/", file, api),
+			path:    fmt.Sprintf("/%s/", file),
 			method:  http.MethodGet,
 			isOkRes: true,
-			req: model.IDFileRequest{
-				ID: id,
+			req: model.NameFileRequest{
+				Name: "some",
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByID", mock.Anything, data.req).
-					Return(&data.expRes, nil)
+				fileService.On("FindByName", mock.Anything, data.req).
+					Return(data.expRes, nil)
 			},
 			expCode: http.StatusOK,
-			expRes: model.FileDTO{
-				ID:          id,
-				Name:        "some",
-				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				Actual:      true,
-				AuthorID:    1,
+			expRes: []model.FileDTO{
+				{
+					ID:          id,
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Actual:      true,
+					AuthorID:    1,
+				},
 			},
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			var r string
-			var f model.FileDTO
+			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
 
-			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.ID), nil)
+			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.Name), nil)
 			assert.Nil(err)
 
-			req.Header.Set(authorizationHeader, "Bearer "+token)
-
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -511,13 +1843,11 @@ func TestFile_FindByID(t *testing.T) {
 	}
 }
 
-func TestFile_FindByAuthorID(t *testing.T) {
+func TestFile_FindByText(t *testing.T) {
 	assert := testAssert.New(t)
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
-	require.NoError(t, err)
 
 	type test struct {
 		name        string
@@ -525,7 +1855,7 @@ func TestFile_FindByAuthorID(t *testing.T) {
 		method      string
 		isOkRes     bool
 		isOkMessage bool
-		req         model.AuthorIDFileRequest
+		req         model.TextFileRequest
 		fn          func(fileService *m.File, data test)
 		expCode     int
 		expRes      []model.FileDTO
@@ -534,55 +1864,57 @@ func TestFile_FindByAuthorID(t *testing.T) {
 
 	tt := []test{
 		{
-			name:        "invalid author id",
-			path:        fmt.Sprintf("/%s/%s/%s/", file, api, author),
-			method:      http.MethodGet,
+			name:        "invalid text",
+			path:        fmt.Sprintf("/%s/%s", file, text),
+			method:      http.MethodPost,
 			isOkMessage: true,
-			req:         model.AuthorIDFileRequest{},
+			req: model.TextFileRequest{
+				Text: "",
+			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByAuthorID", mock.Anything, data.req).
+				fileService.On("FindByText", mock.Anything, data.req).
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusBadRequest,
-			message: "not correct id",
+			message: "text is required",
 		},
 		{
 			name:        "find err",
-			path:        fmt.Sprintf("/%s/%s/%s/", file, api, author),
-			method:      http.MethodGet,
+			path:        fmt.Sprintf("/%s/%s", file, text),
+			method:      http.MethodPost,
 			isOkMessage: true,
-			req: model.AuthorIDFileRequest{
-				ID: 1,
+			req: model.TextFileRequest{
+				Text: "some",
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByAuthorID", mock.Anything, data.req).
+				fileService.On("FindByText", mock.Anything, data.req).
 					Return(data.expRes, errors.New(""))
 			},
 			expCode: http.StatusInternalServerError,
 		},
 		{
 			name:   "not found",
-			path:   fmt.Sprintf("/%s/%s/%s/", file, api, author),
-			method: http.MethodGet,
-			req: model.AuthorIDFileRequest{
-				ID: 1,
+			path:   fmt.Sprintf("/%s/%s", file, text),
+			method: http.MethodPost,
+			req: model.TextFileRequest{
+				Text: "some",
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByAuthorID", mock.Anything, data.req).
+				fileService.On("FindByText", mock.Anything, data.req).
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusNotFound,
 		},
 		{
 			name:    "all ok",
-			path:    fmt.Sprintf("/%s/%s/%s/", file, api, author),
-			method:  http.MethodGet,
+			path:    fmt.Sprintf("/%s/%s", file, text),
+			method:  http.MethodPost,
 			isOkRes: true,
-			req: model.AuthorIDFileRequest{
-				ID: 1,
+			req: model.TextFileRequest{
+				Text: "some",
 			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByAuthorID", mock.Anything, data.req).
+				fileService.On("FindByText", mock.Anything, data.req).
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusOK,
@@ -597,6 +1929,7 @@ func TestFile_FindByAuthorID(t *testing.T) {
 					UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 					Actual:      true,
 					AuthorID:    1,
+					Score:       1.5,
 				},
 			},
 		},
@@ -607,15 +1940,18 @@ func TestFile_FindByAuthorID(t *testing.T) {
 			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
 
-			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%d", tc.path, tc.req.ID), nil)
+			body := new(bytes.Buffer)
+			err := json.NewEncoder(body).Encode(&tc.req)
 			assert.Nil(err)
 
-			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req, err := http.NewRequest(tc.method, tc.path, body)
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
@@ -623,8 +1959,7 @@ func TestFile_FindByAuthorID(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -637,7 +1972,7 @@ func TestFile_FindByAuthorID(t *testing.T) {
 	}
 }
 
-func TestFile_FindByName(t *testing.T) {
+func TestFile_FindActual(t *testing.T) {
 	assert := testAssert.New(t)
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
@@ -649,7 +1984,6 @@ func TestFile_FindByName(t *testing.T) {
 		method      string
 		isOkRes     bool
 		isOkMessage bool
-		req         model.NameFileRequest
 		fn          func(fileService *m.File, data test)
 		expCode     int
 		expRes      []model.FileDTO
@@ -659,41 +1993,33 @@ func TestFile_FindByName(t *testing.T) {
 	tt := []test{
 		{
 			name:        "find err",
-			path:        fmt.Sprintf("/%s/", file),
+			path:        fmt.Sprintf("/%s/%s/", file, actual),
 			method:      http.MethodGet,
 			isOkMessage: true,
-			req: model.NameFileRequest{
-				Name: "some",
-			},
+
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByName", mock.Anything, data.req).
+				fileService.On("FindActual", mock.Anything).
 					Return(data.expRes, errors.New(""))
 			},
 			expCode: http.StatusInternalServerError,
 		},
 		{
 			name:   "not found",
-			path:   fmt.Sprintf("/%s/", file),
+			path:   fmt.Sprintf("/%s/%s/", file, actual),
 			method: http.MethodGet,
-			req: model.NameFileRequest{
-				Name: "some",
-			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByName", mock.Anything, data.req).
+				fileService.On("FindActual", mock.Anything).
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusNotFound,
 		},
 		{
 			name:    "all ok",
-			path:    fmt.Sprintf("/%s/", file),
+			path:    fmt.Sprintf("/%s/%s/", file, actual),
 			method:  http.MethodGet,
 			isOkRes: true,
-			req: model.NameFileRequest{
-				Name: "some",
-			},
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindByName", mock.Anything, data.req).
+				fileService.On("FindActual", mock.Anything).
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusOK,
@@ -718,12 +2044,12 @@ func TestFile_FindByName(t *testing.T) {
 			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
 
-			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%s", tc.path, tc.req.Name), nil)
+			req, err := http.NewRequest(tc.method, tc.path, nil)
 			assert.Nil(err)
 
 			res := httptest.NewRecorder()
@@ -732,8 +2058,7 @@ func TestFile_FindByName(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -746,7 +2071,7 @@ func TestFile_FindByName(t *testing.T) {
 	}
 }
 
-func TestFile_FindActual(t *testing.T) {
+func TestFile_FindByRating(t *testing.T) {
 	assert := testAssert.New(t)
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
@@ -767,33 +2092,33 @@ func TestFile_FindActual(t *testing.T) {
 	tt := []test{
 		{
 			name:        "find err",
-			path:        fmt.Sprintf("/%s/%s/", file, actual),
+			path:        fmt.Sprintf("/%s/%s/", file, rating),
 			method:      http.MethodGet,
 			isOkMessage: true,
 
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindActual", mock.Anything).
+				fileService.On("FindByRating", mock.Anything).
 					Return(data.expRes, errors.New(""))
 			},
 			expCode: http.StatusInternalServerError,
 		},
 		{
 			name:   "not found",
-			path:   fmt.Sprintf("/%s/%s/", file, actual),
+			path:   fmt.Sprintf("/%s/%s/", file, rating),
 			method: http.MethodGet,
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindActual", mock.Anything).
+				fileService.On("FindByRating", mock.Anything).
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusNotFound,
 		},
 		{
 			name:    "all ok",
-			path:    fmt.Sprintf("/%s/%s/", file, actual),
+			path:    fmt.Sprintf("/%s/%s/", file, rating),
 			method:  http.MethodGet,
 			isOkRes: true,
 			fn: func(fileService *m.File, data test) {
-				fileService.On("FindActual", mock.Anything).
+				fileService.On("FindByRating", mock.Anything).
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusOK,
@@ -808,6 +2133,8 @@ func TestFile_FindActual(t *testing.T) {
 					UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 					Actual:      true,
 					AuthorID:    1,
+					Rating:      4.5,
+					RatingCount: 2,
 				},
 			},
 		},
@@ -818,7 +2145,7 @@ func TestFile_FindActual(t *testing.T) {
 			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -832,8 +2159,7 @@ func TestFile_FindActual(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -918,7 +2244,7 @@ func TestFile_FindNotActual(t *testing.T) {
 			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -932,8 +2258,7 @@ func TestFile_FindNotActual(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -1018,7 +2343,7 @@ func TestFile_FindAll(t *testing.T) {
 			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -1032,8 +2357,7 @@ func TestFile_FindAll(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -1146,7 +2470,7 @@ func TestFile_FindAddedByPeriod(t *testing.T) {
 			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -1157,6 +2481,7 @@ func TestFile_FindAddedByPeriod(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
@@ -1164,8 +2489,7 @@ func TestFile_FindAddedByPeriod(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -1278,7 +2602,7 @@ func TestFile_FindUpdatedByPeriod(t *testing.T) {
 			var f []model.FileDTO
 			file := new(m.File)
 			testAPI.Services.File = file
-			router := newFile(testAPI.Services, testAPI.TokenManager)
+			router := newFile(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -1289,6 +2613,7 @@ func TestFile_FindUpdatedByPeriod(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			res := httptest.NewRecorder()
 			router.ServeHTTP(res, req)
@@ -1296,8 +2621,7 @@ func TestFile_FindUpdatedByPeriod(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&f)
@@ -1309,3 +2633,106 @@ func TestFile_FindUpdatedByPeriod(t *testing.T) {
 		})
 	}
 }
+
+func TestFile_Import(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	tokenManager, err := auth.NewManager(mock.Anything, "1")
+	require.NoError(t, err)
+	adminToken, err := tokenManager.NewJWT("1")
+	require.NoError(t, err)
+	userToken, err := tokenManager.NewJWT("2")
+	require.NoError(t, err)
+	path := fmt.Sprintf("/%s/%s/%s", file, admin, imports)
+
+	type test struct {
+		name    string
+		token   string
+		req     model.ImportFileRequest
+		fn      func(fileService *m.File, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "not admin",
+			token:   userToken,
+			req:     model.ImportFileRequest{CreateFileRequest: model.CreateFileRequest{Name: "some", Description: "some", AuthorID: 1}, AddDate: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC), UpdateDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusForbidden,
+			expBody: "admin access required",
+		},
+		{
+			name:    "invalid name",
+			token:   adminToken,
+			req:     model.ImportFileRequest{CreateFileRequest: model.CreateFileRequest{Description: "some", AuthorID: 1}, AddDate: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC), UpdateDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusBadRequest,
+			expBody: "name is required",
+		},
+		{
+			name:    "no add date",
+			token:   adminToken,
+			req:     model.ImportFileRequest{CreateFileRequest: model.CreateFileRequest{Name: "some", Description: "some", AuthorID: 1}, UpdateDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusBadRequest,
+			expBody: "add date is required",
+		},
+		{
+			name:    "update before add",
+			token:   adminToken,
+			req:     model.ImportFileRequest{CreateFileRequest: model.CreateFileRequest{Name: "some", Description: "some", AuthorID: 1}, AddDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC), UpdateDate: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusBadRequest,
+			expBody: "update date couldn't be before add date",
+		},
+		{
+			name:  "import err",
+			token: adminToken,
+			req:   model.ImportFileRequest{CreateFileRequest: model.CreateFileRequest{Name: "some", Description: "some", AuthorID: 1}, AddDate: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC), UpdateDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC)},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Import", mock.Anything, data.req).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "all ok",
+			token: adminToken,
+			req:   model.ImportFileRequest{CreateFileRequest: model.CreateFileRequest{Name: "some", Description: "some", AuthorID: 1}, AddDate: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC), UpdateDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC)},
+			fn: func(fileService *m.File, data test) {
+				fileService.On("Import", mock.Anything, data.req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			fileService := new(m.File)
+			testAPI.Services.File = fileService
+			router := newFile(testAPI.Services, tokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(fileService, tc)
+			}
+
+			body := new(bytes.Buffer)
+			err := json.NewEncoder(body).Encode(&tc.req)
+			assert.Nil(err)
+
+			req, err := http.NewRequest(http.MethodPost, path, body)
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+
+			req.Header.Set(authorizationHeader, "Bearer "+tc.token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			r = decodeMessage(t, res.Body)
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
@@ -217,6 +217,182 @@ func (_m *Comment) FindByUserIDAndPurchaseID(ctx context.Context, request model.
 	return r0, r1
 }
 
+// FindForReview provides a mock function with given fields: ctx
+func (_m *Comment) FindForReview(ctx context.Context) ([]model.CommentDTO, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.CommentDTO
+	if rf, ok := ret.Get(0).(func(context.Context) []model.CommentDTO); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindRevisions provides a mock function with given fields: ctx, request
+func (_m *Comment) FindRevisions(ctx context.Context, request model.IDCommentRequest) ([]model.CommentRevisionDTO, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 []model.CommentRevisionDTO
+	if rf, ok := ret.Get(0).(func(context.Context, model.IDCommentRequest) []model.CommentRevisionDTO); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentRevisionDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.IDCommentRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindTreeByFileID provides a mock function with given fields: ctx, request
+func (_m *Comment) FindTreeByFileID(ctx context.Context, request model.FileIDCommentRequest) ([]model.CommentNode, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 []model.CommentNode
+	if rf, ok := ret.Get(0).(func(context.Context, model.FileIDCommentRequest) []model.CommentNode); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentNode)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.FileIDCommentRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindTreeByPurchaseID provides a mock function with given fields: ctx, request
+func (_m *Comment) FindTreeByPurchaseID(ctx context.Context, request model.PurchaseIDCommentRequest) ([]model.CommentNode, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 []model.CommentNode
+	if rf, ok := ret.Get(0).(func(context.Context, model.PurchaseIDCommentRequest) []model.CommentNode); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentNode)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.PurchaseIDCommentRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Flag provides a mock function with given fields: ctx, request
+func (_m *Comment) Flag(ctx context.Context, request model.FlagCommentRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.FlagCommentRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.FlagCommentRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, request
+func (_m *Comment) Import(ctx context.Context, request model.ImportCommentRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.ImportCommentRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.ImportCommentRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, request
+func (_m *Comment) Patch(ctx context.Context, request model.PatchCommentRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.PatchCommentRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.PatchCommentRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Review provides a mock function with given fields: ctx, request
+func (_m *Comment) Review(ctx context.Context, request model.ReviewCommentRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.ReviewCommentRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.ReviewCommentRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, request
 func (_m *Comment) Update(ctx context.Context, request model.UpdateCommentRequest) (string, error) {
 	ret := _m.Called(ctx, request)
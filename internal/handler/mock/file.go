@@ -6,6 +6,7 @@ import (
 	context "context"
 
 	model "github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	auth "github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
 	mock "github.com/stretchr/testify/mock"
 )
 
@@ -56,6 +57,75 @@ func (_m *File) Delete(ctx context.Context, request model.DeleteFileRequest) (st
 	return r0, r1
 }
 
+// Download provides a mock function with given fields: ctx, request
+func (_m *File) Download(ctx context.Context, request model.DownloadFileRequest) (*model.FileContent, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 *model.FileContent
+	if rf, ok := ret.Get(0).(func(context.Context, model.DownloadFileRequest) *model.FileContent); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.FileContent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.DownloadFileRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DownloadByLink provides a mock function with given fields: ctx, link
+func (_m *File) DownloadByLink(ctx context.Context, link auth.Link) (*model.FileContent, error) {
+	ret := _m.Called(ctx, link)
+
+	var r0 *model.FileContent
+	if rf, ok := ret.Get(0).(func(context.Context, auth.Link) *model.FileContent); ok {
+		r0 = rf(ctx, link)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.FileContent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, auth.Link) error); ok {
+		r1 = rf(ctx, link)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DownloadVersion provides a mock function with given fields: ctx, request
+func (_m *File) DownloadVersion(ctx context.Context, request model.DownloadFileVersionRequest) (*model.FileContent, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 *model.FileContent
+	if rf, ok := ret.Get(0).(func(context.Context, model.DownloadFileVersionRequest) *model.FileContent); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.FileContent)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.DownloadFileVersionRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindActual provides a mock function with given fields: ctx
 func (_m *File) FindActual(ctx context.Context) ([]model.FileDTO, error) {
 	ret := _m.Called(ctx)
@@ -194,6 +264,52 @@ func (_m *File) FindByName(ctx context.Context, request model.NameFileRequest) (
 	return r0, r1
 }
 
+// FindByRating provides a mock function with given fields: ctx
+func (_m *File) FindByRating(ctx context.Context) ([]model.FileDTO, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.FileDTO
+	if rf, ok := ret.Get(0).(func(context.Context) []model.FileDTO); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.FileDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByText provides a mock function with given fields: ctx, request
+func (_m *File) FindByText(ctx context.Context, request model.TextFileRequest) ([]model.FileDTO, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 []model.FileDTO
+	if rf, ok := ret.Get(0).(func(context.Context, model.TextFileRequest) []model.FileDTO); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.FileDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.TextFileRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindNotActual provides a mock function with given fields: ctx
 func (_m *File) FindNotActual(ctx context.Context) ([]model.FileDTO, error) {
 	ret := _m.Called(ctx)
@@ -240,6 +356,108 @@ func (_m *File) FindUpdatedByPeriod(ctx context.Context, request model.UpdatedPe
 	return r0, r1
 }
 
+// FindVersions provides a mock function with given fields: ctx, request
+func (_m *File) FindVersions(ctx context.Context, request model.IDFileRequest) ([]model.FileVersionDTO, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 []model.FileVersionDTO
+	if rf, ok := ret.Get(0).(func(context.Context, model.IDFileRequest) []model.FileVersionDTO); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.FileVersionDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.IDFileRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Import provides a mock function with given fields: ctx, request
+func (_m *File) Import(ctx context.Context, request model.ImportFileRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.ImportFileRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.ImportFileRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Patch provides a mock function with given fields: ctx, request
+func (_m *File) Patch(ctx context.Context, request model.PatchFileRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.PatchFileRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.PatchFileRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RecordDownload provides a mock function with given fields: ctx, content
+func (_m *File) RecordDownload(ctx context.Context, content *model.FileContent) error {
+	ret := _m.Called(ctx, content)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *model.FileContent) error); ok {
+		r0 = rf(ctx, content)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SignDownload provides a mock function with given fields: ctx, request
+func (_m *File) SignDownload(ctx context.Context, request model.SignDownloadFileRequest) (*auth.Link, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 *auth.Link
+	if rf, ok := ret.Get(0).(func(context.Context, model.SignDownloadFileRequest) *auth.Link); ok {
+		r0 = rf(ctx, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auth.Link)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.SignDownloadFileRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, request
 func (_m *File) Update(ctx context.Context, request model.UpdateFileRequest) (string, error) {
 	ret := _m.Called(ctx, request)
@@ -260,3 +478,24 @@ func (_m *File) Update(ctx context.Context, request model.UpdateFileRequest) (st
 
 	return r0, r1
 }
+
+// Upload provides a mock function with given fields: ctx, request
+func (_m *File) Upload(ctx context.Context, request model.UploadFileRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.UploadFileRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.UploadFileRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -354,3 +354,24 @@ func (_m *Purchase) FindLastByUserID(ctx context.Context, request model.UserIDPu
 
 	return r0, r1
 }
+
+// Import provides a mock function with given fields: ctx, request
+func (_m *Purchase) Import(ctx context.Context, request model.ImportPurchaseRequest) (string, error) {
+	ret := _m.Called(ctx, request)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, model.ImportPurchaseRequest) string); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, model.ImportPurchaseRequest) error); ok {
+		r1 = rf(ctx, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -1,10 +1,8 @@
 package handler
 
 import (
-	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,29 +10,47 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 type purchaseRouter struct {
 	*mux.Router
 	services     *service.Services
 	tokenManager auth.TokenManager
+	limits       Limits
+	clock        clock.Clock
 }
 
-func newPurchase(services *service.Services, tokenManager auth.TokenManager) purchaseRouter {
+func newPurchase(services *service.Services, tokenManager auth.TokenManager, limits Limits, clock clock.Clock) purchaseRouter {
 	router := mux.NewRouter().PathPrefix(purchasePath).Subrouter()
+	router.Use(middleware.Route)
 	handler := purchaseRouter{
 		router,
 		services,
 		tokenManager,
+		limits,
+		clock,
 	}
 
 	secure := router.PathPrefix("/api").Subrouter()
 	secure.Use(handler.tokenManager.UserIdentity)
 
+	secure.Path("/period").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findByPeriodPurchase)
+
+	secure.Path("/after").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findAfterDatePurchase)
+
+	secure.Path("/before").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findBeforeDatePurchase)
+
 	secure.Path("/{id}").
 		Methods(http.MethodGet).
 		HandlerFunc(handler.findByIDPurchase)
@@ -65,36 +81,55 @@ func newPurchase(services *service.Services, tokenManager auth.TokenManager) pur
 
 	secure.Path("/").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.createPurchase)
+		Handler(jsonBody(handler.limits.Body, handler.createPurchase))
 
 	secure.Path("/period/user/{id}").
-		Methods(http.MethodPost).
+		Methods(http.MethodGet).
 		HandlerFunc(handler.findByUserIDAndPeriodPurchase)
 
-	secure.Path("/after/user/{id}").
+	secure.Path("/period/user/{id}").
 		Methods(http.MethodPost).
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findByUserIDAndPeriodPurchase)))
+
+	secure.Path("/after/user/{id}").
+		Methods(http.MethodGet).
 		HandlerFunc(handler.findByUserIDAfterDatePurchase)
 
-	secure.Path("/before/user/{id}").
+	secure.Path("/after/user/{id}").
 		Methods(http.MethodPost).
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findByUserIDAfterDatePurchase)))
+
+	secure.Path("/before/user/{id}").
+		Methods(http.MethodGet).
 		HandlerFunc(handler.findByUserIDBeforeDatePurchase)
 
+	secure.Path("/before/user/{id}").
+		Methods(http.MethodPost).
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findByUserIDBeforeDatePurchase)))
+
 	secure.Path("/period").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.findByPeriodPurchase)
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findByPeriodPurchase)))
 
 	secure.Path("/after").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.findAfterDatePurchase)
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findAfterDatePurchase)))
 
 	secure.Path("/before").
 		Methods(http.MethodPost).
-		HandlerFunc(handler.findBeforeDatePurchase)
+		Handler(middleware.Deprecation(jsonBody(handler.limits.Body, handler.findBeforeDatePurchase)))
 
 	secure.Path("/{id}").
 		Methods(http.MethodDelete).
 		HandlerFunc(handler.deletePurchase)
 
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(handler.tokenManager.AdminIdentity)
+
+	admin.Path("/import").
+		Methods(http.MethodPost).
+		Handler(jsonBody(handler.limits.Body, handler.importPurchase))
+
 	return handler
 }
 
@@ -102,9 +137,9 @@ type createPurchaseRequest struct {
 	model.CreatePurchaseRequest
 }
 
-// Build builds request for create purchase.
+// Build builds request to create purchase of the current user. User id of the body is ignored.
 func (req *createPurchaseRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.CreatePurchaseRequest)
+	err := middleware.DecodeJSON(r.Body, &req.CreatePurchaseRequest)
 	if err != nil {
 		return err
 	}
@@ -112,25 +147,16 @@ func (req *createPurchaseRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
-	return nil
-}
-
-// Validate validates request for create purchase.
-func (req *createPurchaseRequest) Validate() error {
-	switch {
-	case req.UserID == 0:
-		return fmt.Errorf("not correct user id")
-	case req.Date == time.Time{}:
-		return fmt.Errorf("date is required")
-	case !primitive.IsValidObjectID(req.FileID):
-		return fmt.Errorf("file id is required")
-	default:
-		return nil
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
 	}
+
+	return nil
 }
 
 // @Summary Create
@@ -141,7 +167,7 @@ func (req *createPurchaseRequest) Validate() error {
 // @Produce  json
 // @Param purchase body model.CreatePurchaseRequest true "Purchase"
 // @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 500 {object} middleware.SwagError
 // @Router /purchase/api/ [post]
 func (p *purchaseRouter) createPurchase(w http.ResponseWriter, r *http.Request) {
@@ -161,6 +187,56 @@ func (p *purchaseRouter) createPurchase(w http.ResponseWriter, r *http.Request)
 	middleware.JSONReturn(w, http.StatusOK, id)
 }
 
+type importPurchaseRequest struct {
+	model.ImportPurchaseRequest
+}
+
+// Build builds request to import purchase.
+func (req *importPurchaseRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.ImportPurchaseRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	return nil
+}
+
+// @Summary Import
+// @Security ApiKeyAuth
+// @Tags purchase
+// @Description Import purchase with its original date
+// @Accept  json
+// @Produce  json
+// @Param purchase body model.ImportPurchaseRequest true "Purchase"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /purchase/admin/import [post]
+func (p *purchaseRouter) importPurchase(w http.ResponseWriter, r *http.Request) {
+	var req importPurchaseRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := p.services.Purchase.Import(r.Context(), req.ImportPurchaseRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
 type deletePurchaseRequest struct {
 	model.DeletePurchaseRequest
 }
@@ -177,25 +253,15 @@ func (req *deletePurchaseRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to delete purchase.
-func (req *deletePurchaseRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary Delete
 // @Security ApiKeyAuth
 // @Tags purchase
-// @Description Delete
+// @Description Delete purchase with its comments
 // @Accept  json
 // @Produce  json
 // @Param id path string true "Purchase id"
 // @Success 200 {string} string id
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchase"
 // @Failure 500 {object} middleware.SwagError
 // @Router /purchase/api/{id} [delete]
@@ -237,16 +303,6 @@ func (req *idPurchaseRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find purchase by id.
-func (req *idPurchaseRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.ID):
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByID
 // @Security ApiKeyAuth
 // @Tags purchase
@@ -255,7 +311,7 @@ func (req *idPurchaseRequest) Validate() error {
 // @Produce  json
 // @Param id path string true "Purchase id"
 // @Success 200 {object} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchase"
 // @Failure 500 {object} middleware.SwagError
 // @Router /purchase/api/{id} [get]
@@ -301,16 +357,6 @@ func (req *lastUserIDPurchaseRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find last purchase by user id.
-func (req *lastUserIDPurchaseRequest) Validate() error {
-	switch {
-	case req.ID == 0:
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindLastByUserID
 // @Security ApiKeyAuth
 // @Tags purchase
@@ -319,7 +365,7 @@ func (req *lastUserIDPurchaseRequest) Validate() error {
 // @Produce  json
 // @Param id path string true "User id"
 // @Success 200 {object} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchase"
 // @Failure 500 {object} middleware.SwagError
 // @Router /purchase/api/last/user/{id} [get]
@@ -365,16 +411,6 @@ func (req *userIDPurchaseRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find all purchases by user id.
-func (req *userIDPurchaseRequest) Validate() error {
-	switch {
-	case req.ID == 0:
-		return fmt.Errorf("not correct id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindAllByUserID
 // @Security ApiKeyAuth
 // @Tags purchase
@@ -383,7 +419,7 @@ func (req *userIDPurchaseRequest) Validate() error {
 // @Produce  json
 // @Param id path string true "User id"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
 // @Router /purchase/api/user/{id} [get]
@@ -411,22 +447,11 @@ func (p *purchaseRouter) findAllByUserIDPurchase(w http.ResponseWriter, r *http.
 
 type userIDPeriodPurchaseRequest struct {
 	model.UserIDPeriodPurchaseRequest
+	now time.Time
 }
 
 // Build builds request to find all purchases by user id and date period.
 func (req *userIDPeriodPurchaseRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.UserIDPeriodPurchaseRequest)
-	if err != nil {
-		return err
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			log.Printf("%v", err)
-		}
-	}(r.Body)
-
 	vID, ok := mux.Vars(r)["id"]
 	if !ok {
 		return fmt.Errorf("no id")
@@ -438,38 +463,43 @@ func (req *userIDPeriodPurchaseRequest) Build(r *http.Request) error {
 	}
 	req.ID = id
 
-	return nil
-}
+	if r.Method == http.MethodGet {
+		return queryPeriod(r, req.now, &req.Start, &req.End)
+	}
 
-// Validate validates request to find all purchases by user id and date period.
-func (req *userIDPeriodPurchaseRequest) Validate() error {
-	switch {
-	case req.ID == 0:
-		return fmt.Errorf("not correct id")
-	case req.Start == time.Time{}:
-		return fmt.Errorf("start date is required")
-	case req.End == time.Time{}:
-		return fmt.Errorf("end date is required")
-	default:
-		return nil
+	err = middleware.DecodeJSON(r.Body, &req.UserIDPeriodPurchaseRequest)
+	if err != nil {
+		return err
 	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	return nil
 }
 
 // @Summary FindByUserIDAndPeriod
 // @Security ApiKeyAuth
 // @Tags purchase
-// @Description Find by user id and period
+// @Description Find by user id and period, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.UserIDPeriodPurchaseRequest true "Period"
+// @Param period body model.UserIDPeriodPurchaseRequest false "Period, deprecated POST only"
+// @Param start query string false "Start as RFC3339 time, now, today or last N with m, h, d or w unit"
+// @Param end query string false "End, now by default, as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Param id path string true "User id"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
+// @Router /purchase/api/period/user/{id} [get]
 // @Router /purchase/api/period/user/{id} [post]
 func (p *purchaseRouter) findByUserIDAndPeriodPurchase(w http.ResponseWriter, r *http.Request) {
-	var req userIDPeriodPurchaseRequest
+	req := userIDPeriodPurchaseRequest{now: p.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -492,22 +522,11 @@ func (p *purchaseRouter) findByUserIDAndPeriodPurchase(w http.ResponseWriter, r
 
 type userIDAfterDatePurchaseRequest struct {
 	model.UserIDAfterDatePurchaseRequest
+	now time.Time
 }
 
 // Build builds request to find all purchases by user id after date.
 func (req *userIDAfterDatePurchaseRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.UserIDAfterDatePurchaseRequest)
-	if err != nil {
-		return err
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			log.Printf("%v", err)
-		}
-	}(r.Body)
-
 	vID, ok := mux.Vars(r)["id"]
 	if !ok {
 		return fmt.Errorf("no id")
@@ -519,36 +538,43 @@ func (req *userIDAfterDatePurchaseRequest) Build(r *http.Request) error {
 	}
 	req.ID = id
 
-	return nil
-}
+	if r.Method == http.MethodGet {
+		req.Start, err = middleware.QueryTime(r, "start", req.now)
+		return err
+	}
 
-// Validate validates request to find all purchases by user id after date.
-func (req *userIDAfterDatePurchaseRequest) Validate() error {
-	switch {
-	case req.ID == 0:
-		return fmt.Errorf("not correct id")
-	case req.Start == time.Time{}:
-		return fmt.Errorf("start date is required")
-	default:
-		return nil
+	err = middleware.DecodeJSON(r.Body, &req.UserIDAfterDatePurchaseRequest)
+	if err != nil {
+		return err
 	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	return nil
 }
 
 // @Summary FindByUserIDAfterDate
 // @Security ApiKeyAuth
 // @Tags purchase
-// @Description Find by user id after date
+// @Description Find by user id after date, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.UserIDAfterDatePurchaseRequest true "After date"
+// @Param period body model.UserIDAfterDatePurchaseRequest false "After date, deprecated POST only"
+// @Param start query string false "Start as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Param id path string true "User id"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
+// @Router /purchase/api/after/user/{id} [get]
 // @Router /purchase/api/after/user/{id} [post]
 func (p *purchaseRouter) findByUserIDAfterDatePurchase(w http.ResponseWriter, r *http.Request) {
-	var req userIDAfterDatePurchaseRequest
+	req := userIDAfterDatePurchaseRequest{now: p.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -571,22 +597,11 @@ func (p *purchaseRouter) findByUserIDAfterDatePurchase(w http.ResponseWriter, r
 
 type userIDBeforeDatePurchaseRequest struct {
 	model.UserIDBeforeDatePurchaseRequest
+	now time.Time
 }
 
 // Build builds request to find all purchases by user id before date.
 func (req *userIDBeforeDatePurchaseRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.UserIDBeforeDatePurchaseRequest)
-	if err != nil {
-		return err
-	}
-
-	defer func(body io.ReadCloser) {
-		err := body.Close()
-		if err != nil {
-			log.Printf("%v", err)
-		}
-	}(r.Body)
-
 	vID, ok := mux.Vars(r)["id"]
 	if !ok {
 		return fmt.Errorf("no id")
@@ -598,36 +613,43 @@ func (req *userIDBeforeDatePurchaseRequest) Build(r *http.Request) error {
 	}
 	req.ID = id
 
-	return nil
-}
+	if r.Method == http.MethodGet {
+		req.End, err = middleware.QueryTime(r, "end", req.now)
+		return err
+	}
 
-// Validate validates request to find all purchases by user id before date.
-func (req *userIDBeforeDatePurchaseRequest) Validate() error {
-	switch {
-	case req.ID == 0:
-		return fmt.Errorf("not correct id")
-	case req.End == time.Time{}:
-		return fmt.Errorf("end date is required")
-	default:
-		return nil
+	err = middleware.DecodeJSON(r.Body, &req.UserIDBeforeDatePurchaseRequest)
+	if err != nil {
+		return err
 	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	return nil
 }
 
 // @Summary FindByUserIDBeforeDate
 // @Security ApiKeyAuth
 // @Tags purchase
-// @Description Find by user id before date
+// @Description Find by user id before date, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.UserIDBeforeDatePurchaseRequest true "Before date"
+// @Param period body model.UserIDBeforeDatePurchaseRequest false "Before date, deprecated POST only"
+// @Param end query string false "End as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Param id path string true "User id"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
+// @Router /purchase/api/before/user/{id} [get]
 // @Router /purchase/api/before/user/{id} [post]
 func (p *purchaseRouter) findByUserIDBeforeDatePurchase(w http.ResponseWriter, r *http.Request) {
-	var req userIDBeforeDatePurchaseRequest
+	req := userIDBeforeDatePurchaseRequest{now: p.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -675,18 +697,6 @@ func (req *userIDFileIDPurchaseRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find all purchases by user id and file name.
-func (req *userIDFileIDPurchaseRequest) Validate() error {
-	switch {
-	case req.UserID == 0:
-		return fmt.Errorf("not correct user id")
-	case !primitive.IsValidObjectID(req.FileID):
-		return fmt.Errorf("not correct file id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByUserIDAndFileID
 // @Security ApiKeyAuth
 // @Tags purchase
@@ -696,7 +706,7 @@ func (req *userIDFileIDPurchaseRequest) Validate() error {
 // @Param userID path string true "User id"
 // @Param fileID path string true "File id"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
 // @Router /purchase/api/user/{userID}/file/{fileID} [get]
@@ -774,11 +784,16 @@ func (p *purchaseRouter) findAll(w http.ResponseWriter, r *http.Request) {
 
 type periodPurchaseRequest struct {
 	model.PeriodPurchaseRequest
+	now time.Time
 }
 
 // Build builds request to find all purchases by date period.
 func (req *periodPurchaseRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.PeriodPurchaseRequest)
+	if r.Method == http.MethodGet {
+		return queryPeriod(r, req.now, &req.Start, &req.End)
+	}
+
+	err := middleware.DecodeJSON(r.Body, &req.PeriodPurchaseRequest)
 	if err != nil {
 		return err
 	}
@@ -786,39 +801,30 @@ func (req *periodPurchaseRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
 	return nil
 }
 
-// Validate validates request to find all purchases by date period.
-func (req *periodPurchaseRequest) Validate() error {
-	switch {
-	case req.Start == time.Time{}:
-		return fmt.Errorf("start date is required")
-	case req.End == time.Time{}:
-		return fmt.Errorf("end date is required")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByPeriod
 // @Security ApiKeyAuth
 // @Tags purchase
-// @Description Find by period
+// @Description Find by period, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.PeriodPurchaseRequest true "Period"
+// @Param period body model.PeriodPurchaseRequest false "Period, deprecated POST only"
+// @Param start query string false "Start as RFC3339 time, now, today or last N with m, h, d or w unit"
+// @Param end query string false "End, now by default, as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
+// @Router /purchase/api/period [get]
 // @Router /purchase/api/period [post]
 func (p *purchaseRouter) findByPeriodPurchase(w http.ResponseWriter, r *http.Request) {
-	var req periodPurchaseRequest
+	req := periodPurchaseRequest{now: p.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -841,11 +847,18 @@ func (p *purchaseRouter) findByPeriodPurchase(w http.ResponseWriter, r *http.Req
 
 type afterDatePurchaseRequest struct {
 	model.AfterDatePurchaseRequest
+	now time.Time
 }
 
 // Build builds request to find all purchases after date.
 func (req *afterDatePurchaseRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.AfterDatePurchaseRequest)
+	if r.Method == http.MethodGet {
+		var err error
+		req.Start, err = middleware.QueryTime(r, "start", req.now)
+		return err
+	}
+
+	err := middleware.DecodeJSON(r.Body, &req.AfterDatePurchaseRequest)
 	if err != nil {
 		return err
 	}
@@ -853,37 +866,29 @@ func (req *afterDatePurchaseRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
 	return nil
 }
 
-// Validate validates request to find all purchases after date.
-func (req *afterDatePurchaseRequest) Validate() error {
-	switch {
-	case req.Start == time.Time{}:
-		return fmt.Errorf("start date is required")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindAfterDate
 // @Security ApiKeyAuth
 // @Tags purchase
-// @Description Find after date
+// @Description Find after date, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.AfterDatePurchaseRequest true "After date"
+// @Param period body model.AfterDatePurchaseRequest false "After date, deprecated POST only"
+// @Param start query string false "Start as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
+// @Router /purchase/api/after [get]
 // @Router /purchase/api/after [post]
 func (p *purchaseRouter) findAfterDatePurchase(w http.ResponseWriter, r *http.Request) {
-	var req afterDatePurchaseRequest
+	req := afterDatePurchaseRequest{now: p.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -906,11 +911,18 @@ func (p *purchaseRouter) findAfterDatePurchase(w http.ResponseWriter, r *http.Re
 
 type beforeDatePurchaseRequest struct {
 	model.BeforeDatePurchaseRequest
+	now time.Time
 }
 
 // Build builds request to find all purchases before date.
 func (req *beforeDatePurchaseRequest) Build(r *http.Request) error {
-	err := json.NewDecoder(r.Body).Decode(&req.BeforeDatePurchaseRequest)
+	if r.Method == http.MethodGet {
+		var err error
+		req.End, err = middleware.QueryTime(r, "end", req.now)
+		return err
+	}
+
+	err := middleware.DecodeJSON(r.Body, &req.BeforeDatePurchaseRequest)
 	if err != nil {
 		return err
 	}
@@ -918,37 +930,29 @@ func (req *beforeDatePurchaseRequest) Build(r *http.Request) error {
 	defer func(body io.ReadCloser) {
 		err := body.Close()
 		if err != nil {
-			log.Printf("%v", err)
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
 		}
 	}(r.Body)
 
 	return nil
 }
 
-// Validate validates request to find all purchases before date.
-func (req *beforeDatePurchaseRequest) Validate() error {
-	switch {
-	case req.End == time.Time{}:
-		return fmt.Errorf("end date is required")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindBeforeDate
 // @Security ApiKeyAuth
 // @Tags purchase
-// @Description Find before date
+// @Description Find before date, POST is deprecated
 // @Accept  json
 // @Produce  json
-// @Param period body model.BeforeDatePurchaseRequest true "Before date"
+// @Param period body model.BeforeDatePurchaseRequest false "Before date, deprecated POST only"
+// @Param end query string false "End as RFC3339 time, now, today or last N with m, h, d or w unit"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
+// @Router /purchase/api/before [get]
 // @Router /purchase/api/before [post]
 func (p *purchaseRouter) findBeforeDatePurchase(w http.ResponseWriter, r *http.Request) {
-	var req beforeDatePurchaseRequest
+	req := beforeDatePurchaseRequest{now: p.clock.Now()}
 	err := middleware.ParseRequest(r, &req)
 	if err != nil {
 		middleware.JSONError(w, err, http.StatusBadRequest)
@@ -985,16 +989,6 @@ func (req *fileIDPurchaseRequest) Build(r *http.Request) error {
 	return nil
 }
 
-// Validate validates request to find all purchases by file name.
-func (req *fileIDPurchaseRequest) Validate() error {
-	switch {
-	case !primitive.IsValidObjectID(req.FileID):
-		return fmt.Errorf("not correct file id")
-	default:
-		return nil
-	}
-}
-
 // @Summary FindByFileID
 // @Security ApiKeyAuth
 // @Tags purchase
@@ -1003,7 +997,7 @@ func (req *fileIDPurchaseRequest) Validate() error {
 // @Produce  json
 // @Param fileID path string true "File id"
 // @Success 200 {array} model.Purchase
-// @Failure 400 {object} middleware.SwagError
+// @Failure 400 {object} middleware.SwagValidationError
 // @Failure 404 {object} middleware.SwagEmptyError "No purchases"
 // @Failure 500 {object} middleware.SwagError
 // @Router /purchase/api/file/{fileID} [get]
@@ -12,6 +12,8 @@ import (
 	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/handler/mock"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/pkg/errors"
 	testAssert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -30,7 +32,7 @@ func TestPurchase_Create(t *testing.T) {
 	id := primitive.NewObjectID().Hex()
 	testAPI, err := service.InitTest4Mock()
 	require.NoError(t, err)
-	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	token, err := testAPI.TokenManager.NewJWT("1")
 	require.NoError(t, err)
 
 	type test struct {
@@ -45,19 +47,21 @@ func TestPurchase_Create(t *testing.T) {
 
 	tt := []test{
 		{
-			name:   "invalid user id",
+			name:   "user id of body ignored",
 			path:   fmt.Sprintf("/%s/%s/", purchase, api),
 			method: http.MethodPost,
 			req: model.CreatePurchaseRequest{
-				Date:   time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+				UserID: 2,
 				FileID: id,
 			},
 			fn: func(purchaseService *m.Purchase, data test) {
-				purchaseService.On("Create", mock.Anything, data.req).
-					Return("", nil)
+				req := data.req
+				req.UserID = 1
+				purchaseService.On("Create", mock.Anything, req).
+					Return(data.expBody, nil)
 			},
-			expCode: http.StatusBadRequest,
-			expBody: "not correct user id",
+			expCode: http.StatusOK,
+			expBody: id,
 		},
 		{
 			name:   "create err",
@@ -65,7 +69,6 @@ func TestPurchase_Create(t *testing.T) {
 			method: http.MethodPost,
 			req: model.CreatePurchaseRequest{
 				UserID: 1,
-				Date:   time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				FileID: id,
 			},
 			fn: func(purchaseService *m.Purchase, data test) {
@@ -80,7 +83,6 @@ func TestPurchase_Create(t *testing.T) {
 			method: http.MethodPost,
 			req: model.CreatePurchaseRequest{
 				UserID: 1,
-				Date:   time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				FileID: id,
 			},
 			fn: func(purchaseService *m.Purchase, data test) {
@@ -96,7 +98,7 @@ func TestPurchase_Create(t *testing.T) {
 			var r string
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -107,6 +109,7 @@ func TestPurchase_Create(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -114,8 +117,7 @@ func TestPurchase_Create(t *testing.T) {
 			router.ServeHTTP(res, req)
 			assert.Equal(tc.expCode, res.Code)
 
-			err = json.NewDecoder(res.Body).Decode(&r)
-			assert.Nil(err)
+			r = decodeMessage(t, res.Body)
 			assert.Equal(tc.expBody, r)
 		})
 	}
@@ -204,7 +206,7 @@ func TestPurchase_Delete(t *testing.T) {
 			var r string
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -219,8 +221,7 @@ func TestPurchase_Delete(t *testing.T) {
 			assert.Equal(tc.expCode, res.Code)
 
 			if tc.isOkRes {
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 			}
 			assert.Equal(tc.expBody, r)
 		})
@@ -318,7 +319,7 @@ func TestPurchase_FindById(t *testing.T) {
 			var p model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -334,8 +335,7 @@ func TestPurchase_FindById(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -435,7 +435,7 @@ func TestPurchase_FindLastByUserId(t *testing.T) {
 			var p model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -451,8 +451,7 @@ func TestPurchase_FindLastByUserId(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -560,7 +559,7 @@ func TestPurchase_FindAllByUserId(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -576,8 +575,7 @@ func TestPurchase_FindAllByUserId(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -696,7 +694,7 @@ func TestPurchase_FindByUserIdAndPeriod(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -707,6 +705,7 @@ func TestPurchase_FindByUserIdAndPeriod(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%d", tc.path, tc.req.ID), body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -716,8 +715,7 @@ func TestPurchase_FindByUserIdAndPeriod(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -832,7 +830,7 @@ func TestPurchase_FindByUserIdAfterDate(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -843,6 +841,7 @@ func TestPurchase_FindByUserIdAfterDate(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%d", tc.path, tc.req.ID), body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -852,8 +851,7 @@ func TestPurchase_FindByUserIdAfterDate(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -968,7 +966,7 @@ func TestPurchase_FindByUserIdBeforeDate(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -979,6 +977,7 @@ func TestPurchase_FindByUserIdBeforeDate(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, fmt.Sprintf("%s%d", tc.path, tc.req.ID), body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -988,8 +987,7 @@ func TestPurchase_FindByUserIdBeforeDate(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1104,7 +1102,7 @@ func TestPurchase_FindByUserIdAndFileID(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -1120,8 +1118,7 @@ func TestPurchase_FindByUserIdAndFileID(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1203,7 +1200,7 @@ func TestPurchase_FindLast(t *testing.T) {
 			var p model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -1219,8 +1216,7 @@ func TestPurchase_FindLast(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1307,7 +1303,7 @@ func TestPurchase_FindAll(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -1323,8 +1319,7 @@ func TestPurchase_FindAll(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1373,7 +1368,19 @@ func TestPurchase_FindByPeriod(t *testing.T) {
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusBadRequest,
-			message: "start date is required",
+			message: "start is required",
+		},
+		{
+			name:        "end before start",
+			path:        fmt.Sprintf("/%s/%s/%s", purchase, api, period),
+			method:      http.MethodPost,
+			isOkMessage: true,
+			req: model.PeriodPurchaseRequest{
+				Start: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC),
+				End:   time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+			},
+			expCode: http.StatusBadRequest,
+			message: "end couldn't be before start",
 		},
 		{
 			name:   "find err",
@@ -1439,7 +1446,7 @@ func TestPurchase_FindByPeriod(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -1450,6 +1457,7 @@ func TestPurchase_FindByPeriod(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -1459,8 +1467,7 @@ func TestPurchase_FindByPeriod(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1508,7 +1515,7 @@ func TestPurchase_FindAfterDate(t *testing.T) {
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusBadRequest,
-			message: "start date is required",
+			message: "start is required",
 		},
 		{
 			name:        "find err",
@@ -1572,7 +1579,7 @@ func TestPurchase_FindAfterDate(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -1583,6 +1590,7 @@ func TestPurchase_FindAfterDate(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -1592,8 +1600,7 @@ func TestPurchase_FindAfterDate(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1641,7 +1648,7 @@ func TestPurchase_FindBeforeDate(t *testing.T) {
 					Return(data.expRes, nil)
 			},
 			expCode: http.StatusBadRequest,
-			message: "end date is required",
+			message: "end is required",
 		},
 		{
 			name:        "find err",
@@ -1705,7 +1712,7 @@ func TestPurchase_FindBeforeDate(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -1716,6 +1723,7 @@ func TestPurchase_FindBeforeDate(t *testing.T) {
 
 			req, err := http.NewRequest(tc.method, tc.path, body)
 			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
 
 			req.Header.Set(authorizationHeader, "Bearer "+token)
 
@@ -1725,8 +1733,7 @@ func TestPurchase_FindBeforeDate(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1838,7 +1845,7 @@ func TestPurchase_FindByFileID(t *testing.T) {
 			var p []model.PurchaseDTO
 			purchaseService := new(m.Purchase)
 			testAPI.Services.Purchase = purchaseService
-			router := newPurchase(testAPI.Services, testAPI.TokenManager)
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
 			if tc.fn != nil {
 				tc.fn(purchaseService, tc)
 			}
@@ -1854,8 +1861,7 @@ func TestPurchase_FindByFileID(t *testing.T) {
 
 			switch {
 			case tc.isOkMessage:
-				err = json.NewDecoder(res.Body).Decode(&r)
-				assert.Nil(err)
+				r = decodeMessage(t, res.Body)
 				assert.Equal(tc.message, r)
 			case tc.isOkRes:
 				err = json.NewDecoder(res.Body).Decode(&p)
@@ -1867,3 +1873,99 @@ func TestPurchase_FindByFileID(t *testing.T) {
 		})
 	}
 }
+
+func TestPurchase_Import(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	tokenManager, err := auth.NewManager(mock.Anything, "1")
+	require.NoError(t, err)
+	adminToken, err := tokenManager.NewJWT("1")
+	require.NoError(t, err)
+	userToken, err := tokenManager.NewJWT("2")
+	require.NoError(t, err)
+	path := fmt.Sprintf("/%s/%s/%s", purchase, admin, imports)
+
+	type test struct {
+		name    string
+		token   string
+		req     model.ImportPurchaseRequest
+		fn      func(purchaseService *m.Purchase, data test)
+		expCode int
+		expBody string
+	}
+
+	tt := []test{
+		{
+			name:    "not admin",
+			token:   userToken,
+			req:     model.ImportPurchaseRequest{CreatePurchaseRequest: model.CreatePurchaseRequest{UserID: 1, FileID: id}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusForbidden,
+			expBody: "admin access required",
+		},
+		{
+			name:    "invalid user id",
+			token:   adminToken,
+			req:     model.ImportPurchaseRequest{CreatePurchaseRequest: model.CreatePurchaseRequest{FileID: id}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct user id",
+		},
+		{
+			name:    "no date",
+			token:   adminToken,
+			req:     model.ImportPurchaseRequest{CreatePurchaseRequest: model.CreatePurchaseRequest{UserID: 1, FileID: id}},
+			expCode: http.StatusBadRequest,
+			expBody: "date is required",
+		},
+		{
+			name:  "import err",
+			token: adminToken,
+			req:   model.ImportPurchaseRequest{CreatePurchaseRequest: model.CreatePurchaseRequest{UserID: 1, FileID: id}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			fn: func(purchaseService *m.Purchase, data test) {
+				purchaseService.On("Import", mock.Anything, data.req).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name:  "all ok",
+			token: adminToken,
+			req:   model.ImportPurchaseRequest{CreatePurchaseRequest: model.CreatePurchaseRequest{UserID: 1, FileID: id}, Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)},
+			fn: func(purchaseService *m.Purchase, data test) {
+				purchaseService.On("Import", mock.Anything, data.req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var r string
+			purchaseService := new(m.Purchase)
+			testAPI.Services.Purchase = purchaseService
+			router := newPurchase(testAPI.Services, tokenManager, testLimits, testClock)
+			if tc.fn != nil {
+				tc.fn(purchaseService, tc)
+			}
+
+			body := new(bytes.Buffer)
+			err := json.NewEncoder(body).Encode(&tc.req)
+			assert.Nil(err)
+
+			req, err := http.NewRequest(http.MethodPost, path, body)
+			assert.Nil(err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+
+			req.Header.Set(authorizationHeader, "Bearer "+tc.token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			r = decodeMessage(t, res.Body)
+			assert.Equal(tc.expBody, r)
+		})
+	}
+}
@@ -1,30 +1,144 @@
 package handler
 
 import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
 )
 
 const (
 	purchasePath = "/purchase"
 	commentPath  = "/comment"
 	filePath     = "/file"
+	webhookPath  = "/webhook"
 )
 
+// errIfMatchRequired is returned when a conditional request has no If-Match header.
+var errIfMatchRequired = errors.New("If-Match header is required")
+
+// Limits represents size limits of request bodies in bytes.
+type Limits struct {
+	// Body limits JSON request bodies.
+	Body int64
+	// Upload limits uploaded file content.
+	Upload int64
+}
+
 // API represents a structure with APIs.
 type API struct {
 	*mux.Router
 }
 
 // NewHandler creates and serves endpoints of API.
-func NewHandler(services *service.Services, tokenManager auth.TokenManager) *API {
+func NewHandler(services *service.Services, tokenManager auth.TokenManager, limits Limits, clock clock.Clock, log *logger.Logger) *API {
 	api := API{
 		mux.NewRouter(),
 	}
-	api.PathPrefix(purchasePath).Handler(newPurchase(services, tokenManager))
-	api.PathPrefix(commentPath).Handler(newComment(services, tokenManager))
-	api.PathPrefix(filePath).Handler(newFile(services, tokenManager))
+	api.Use(middleware.RequestID, middleware.AccessLog(log))
+	api.PathPrefix(purchasePath).Handler(newPurchase(services, tokenManager, limits, clock))
+	api.PathPrefix(commentPath).Handler(newComment(services, tokenManager, limits, clock))
+	api.PathPrefix(filePath).Handler(newFile(services, tokenManager, limits, clock))
+	api.PathPrefix(webhookPath).Handler(newWebhook(services, tokenManager, limits))
 
 	return &api
 }
+
+// jsonBody limits size of JSON request body and rejects bodies of other than JSON or additional media types.
+func jsonBody(limit int64, next http.HandlerFunc, types ...string) http.Handler {
+	types = append([]string{middleware.MIMEApplicationJSON}, types...)
+	return middleware.MaxBytes(limit)(middleware.ContentType(types...)(next))
+}
+
+// queryPeriod parses start and end of date period from query parameters of the request.
+// End defaults to now when it is absent.
+func queryPeriod(r *http.Request, now time.Time, start, end *time.Time) error {
+	var err error
+	*start, err = middleware.QueryTime(r, "start", now)
+	if err != nil {
+		return err
+	}
+	*end, err = middleware.QueryTime(r, "end", now)
+	if err != nil {
+		return err
+	}
+	if end.IsZero() {
+		*end = now
+	}
+
+	return nil
+}
+
+// ifMatch parses revision of the resource from If-Match header.
+// "*" matches any revision. Entity tags are compared strongly, so weak tags and tags
+// which aren't revisions never match and service.ErrModified is returned for them.
+func ifMatch(r *http.Request) (int, error) {
+	header := strings.TrimSpace(r.Header.Get("If-Match"))
+	if header == "" {
+		return 0, errIfMatchRequired
+	}
+	if header == "*" {
+		return model.AnyRevision, nil
+	}
+
+	var revision int
+	var found bool
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		opaque := strings.TrimPrefix(tag, "W/")
+		if !strings.HasPrefix(opaque, `"`) {
+			return 0, fmt.Errorf("not correct If-Match header")
+		}
+		value, err := strconv.Unquote(opaque)
+		if err != nil {
+			return 0, fmt.Errorf("not correct If-Match header")
+		}
+		if opaque != tag {
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			continue
+		}
+		if found && n != revision {
+			return 0, fmt.Errorf("If-Match header with several revisions isn't supported")
+		}
+		revision, found = n, true
+	}
+	if !found {
+		return 0, service.ErrModified
+	}
+
+	return revision, nil
+}
+
+// userID parses id of the user authenticated by the request token.
+func userID(r *http.Request) (int, error) {
+	vUserID, ok := auth.UserID(r.Context())
+	if !ok {
+		return 0, fmt.Errorf("no user id")
+	}
+
+	id, err := strconv.Atoi(vUserID)
+	if err != nil {
+		return 0, fmt.Errorf("not correct user id")
+	}
+
+	return id, nil
+}
+
+// eTag formats revision of the resource as an entity tag.
+func eTag(revision int) string {
+	return strconv.Quote(strconv.Itoa(revision))
+}
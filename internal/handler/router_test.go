@@ -0,0 +1,646 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/handler/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/health"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/metrics"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/tracing"
+	"github.com/pkg/errors"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	testLimits = Limits{Body: 1 << 10, Upload: 1 << 10}
+	testClock  = clock.Fixed(time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC))
+)
+
+// decodeMessage decodes string response or message of validation error response.
+func decodeMessage(t *testing.T, body io.Reader) string {
+	var raw json.RawMessage
+	require.NoError(t, json.NewDecoder(body).Decode(&raw))
+
+	var message string
+	if json.Unmarshal(raw, &message) == nil {
+		return message
+	}
+
+	var validation struct {
+		Message string `json:"message"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &validation))
+
+	return validation.Message
+}
+
+func TestValidationErrors(t *testing.T) {
+	assert := testAssert.New(t)
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+	router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/%s/%s/%s", purchase, admin, imports), strings.NewReader(`{}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+	req.Header.Set(authorizationHeader, "Bearer "+token)
+
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(http.StatusBadRequest, res.Code)
+
+	var body struct {
+		Message string                      `json:"message"`
+		Errors  middleware.ValidationErrors `json:"errors"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&body)
+	assert.NoError(err)
+	assert.Equal("not correct user id; file id is required; date is required", body.Message)
+	assert.Equal(middleware.ValidationErrors{
+		{Field: "userID", Rule: "positive", Message: "not correct user id"},
+		{Field: "fileID", Rule: "required", Message: "file id is required"},
+		{Field: "date", Rule: "required", Message: "date is required"},
+	}, body.Errors)
+}
+
+func TestJSONBody(t *testing.T) {
+	assert := testAssert.New(t)
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	type test struct {
+		name        string
+		body        string
+		contentType string
+		expCode     int
+		expBody     string
+	}
+
+	tt := []test{
+		{
+			name:        "too large",
+			body:        fmt.Sprintf(`{"fileID":"%s"}`, strings.Repeat("a", int(testLimits.Body))),
+			contentType: middleware.MIMEApplicationJSON,
+			expCode:     http.StatusRequestEntityTooLarge,
+			expBody:     middleware.ErrBodyTooLarge.Error(),
+		},
+		{
+			name:    "no content type",
+			body:    `{"userID":1}`,
+			expCode: http.StatusUnsupportedMediaType,
+			expBody: "content type must be application/json",
+		},
+		{
+			name:        "not json",
+			body:        `userID=1`,
+			contentType: "application/x-www-form-urlencoded",
+			expCode:     http.StatusUnsupportedMediaType,
+			expBody:     "content type must be application/json",
+		},
+		{
+			name:        "unknown field",
+			body:        `{"userID":1,"date":"2009-11-10T23:00:00Z"}`,
+			contentType: middleware.MIMEApplicationJSON,
+			expCode:     http.StatusBadRequest,
+			expBody:     "unknown field date",
+		},
+		{
+			name:        "not correct type",
+			body:        `{"userID":"1"}`,
+			contentType: middleware.MIMEApplicationJSON,
+			expCode:     http.StatusBadRequest,
+			expBody:     "not correct user id",
+		},
+		{
+			name:        "not correct json",
+			body:        `{"userID":1,}`,
+			contentType: middleware.MIMEApplicationJSON,
+			expCode:     http.StatusBadRequest,
+			expBody:     "not correct JSON at position 13",
+		},
+		{
+			name:        "several values",
+			body:        `{"userID":1} {"userID":2}`,
+			contentType: "application/json; charset=utf-8",
+			expCode:     http.StatusBadRequest,
+			expBody:     "request body must contain a single JSON value",
+		},
+		{
+			name:        "empty",
+			contentType: middleware.MIMEApplicationJSON,
+			expCode:     http.StatusBadRequest,
+			expBody:     middleware.ErrEmptyBody.Error(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newPurchase(testAPI.Services, testAPI.TokenManager, testLimits, testClock)
+
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/%s/%s/", purchase, api), strings.NewReader(tc.body))
+			assert.Nil(err)
+
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			req.Header.Set("Content-Type", tc.contentType)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			assert.Equal(tc.expBody, decodeMessage(t, res.Body))
+		})
+	}
+}
+
+func TestIfMatch(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name        string
+		header      string
+		expRevision int
+		expErr      error
+	}
+
+	tt := []test{
+		{
+			name:   "no header",
+			expErr: errIfMatchRequired,
+		},
+		{
+			name:        "revision",
+			header:      `"3"`,
+			expRevision: 3,
+		},
+		{
+			name:        "any revision",
+			header:      "*",
+			expRevision: model.AnyRevision,
+		},
+		{
+			name:        "list of tags",
+			header:      `"some", W/"2", "3"`,
+			expRevision: 3,
+		},
+		{
+			name:        "same revision twice",
+			header:      `"3", "3"`,
+			expRevision: 3,
+		},
+		{
+			name:   "several revisions",
+			header: `"2", "3"`,
+			expErr: errors.New("If-Match header with several revisions isn't supported"),
+		},
+		{
+			name:   "weak tag",
+			header: `W/"3"`,
+			expErr: service.ErrModified,
+		},
+		{
+			name:   "not a revision",
+			header: `"some"`,
+			expErr: service.ErrModified,
+		},
+		{
+			name:   "negative revision",
+			header: `"-1"`,
+			expErr: service.ErrModified,
+		},
+		{
+			name:   "unquoted tag",
+			header: "3",
+			expErr: errors.New("not correct If-Match header"),
+		},
+		{
+			name:   "unquoted tag in list",
+			header: `"3", 4`,
+			expErr: errors.New("not correct If-Match header"),
+		},
+		{
+			name:   "unterminated tag",
+			header: `"3`,
+			expErr: errors.New("not correct If-Match header"),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			if tc.header != "" {
+				req.Header.Set("If-Match", tc.header)
+			}
+
+			revision, err := ifMatch(req)
+			if tc.expErr != nil {
+				assert.EqualError(err, tc.expErr.Error())
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tc.expRevision, revision)
+		})
+	}
+}
+
+func TestPeriodQuery(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	now := testClock.Now()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	purchases := []model.PurchaseDTO{{ID: id, UserID: 1, Date: now, FileID: id}}
+	files := []model.FileDTO{{ID: id, Name: mock.Anything, AuthorID: 1}}
+
+	type test struct {
+		name          string
+		method        string
+		path          string
+		query         url.Values
+		body          string
+		fn            func(purchaseService *m.Purchase, fileService *m.File)
+		expCode       int
+		expDeprecated bool
+		message       string
+	}
+
+	tt := []test{
+		{
+			name:   "rfc3339 period",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/%s/%s/%s", purchase, api, period),
+			query:  url.Values{"start": {"2009-11-10T23:00:00Z"}, "end": {"2009-12-10T23:00:00Z"}},
+			fn: func(purchaseService *m.Purchase, fileService *m.File) {
+				purchaseService.On("FindByPeriod", mock.Anything, model.PeriodPurchaseRequest{
+					Start: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+					End:   now,
+				}).Return(purchases, nil)
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name:   "relative period",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/%s/%s/%s", purchase, api, period),
+			query:  url.Values{"start": {"last 7d"}},
+			fn: func(purchaseService *m.Purchase, fileService *m.File) {
+				purchaseService.On("FindByPeriod", mock.Anything, model.PeriodPurchaseRequest{
+					Start: now.AddDate(0, 0, -7),
+					End:   now,
+				}).Return(purchases, nil)
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name:   "user after today",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/%s/%s/%s/user/1", purchase, api, after),
+			query:  url.Values{"start": {"today"}},
+			fn: func(purchaseService *m.Purchase, fileService *m.File) {
+				purchaseService.On("FindByUserIDAfterDate", mock.Anything, model.UserIDAfterDatePurchaseRequest{
+					ID:    1,
+					Start: time.Date(2009, time.December, 10, 0, 0, 0, 0, time.UTC),
+				}).Return(purchases, nil)
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name:   "file added",
+			method: http.MethodGet,
+			path:   fmt.Sprintf("/%s/%s", file, added),
+			query:  url.Values{"start": {"last 12h"}, "end": {"now"}},
+			fn: func(purchaseService *m.Purchase, fileService *m.File) {
+				fileService.On("FindAddedByPeriod", mock.Anything, model.AddedPeriodFileRequest{
+					Start: now.Add(-12 * time.Hour),
+					End:   now,
+				}).Return(files, nil)
+			},
+			expCode: http.StatusOK,
+		},
+		{
+			name:    "not correct end",
+			method:  http.MethodGet,
+			path:    fmt.Sprintf("/%s/%s/%s", purchase, api, before),
+			query:   url.Values{"end": {"last week"}},
+			expCode: http.StatusBadRequest,
+			message: "not correct end",
+		},
+		{
+			name:    "no start",
+			method:  http.MethodGet,
+			path:    fmt.Sprintf("/%s/%s/%s", purchase, api, period),
+			expCode: http.StatusBadRequest,
+			message: "start is required",
+		},
+		{
+			name:   "deprecated post",
+			method: http.MethodPost,
+			path:   fmt.Sprintf("/%s/%s/%s", purchase, api, period),
+			body:   `{"start":"2009-11-10T23:00:00Z","end":"2009-12-10T23:00:00Z"}`,
+			fn: func(purchaseService *m.Purchase, fileService *m.File) {
+				purchaseService.On("FindByPeriod", mock.Anything, model.PeriodPurchaseRequest{
+					Start: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+					End:   now,
+				}).Return(purchases, nil)
+			},
+			expCode:       http.StatusOK,
+			expDeprecated: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			purchaseService := new(m.Purchase)
+			fileService := new(m.File)
+			testAPI.Services.Purchase = purchaseService
+			testAPI.Services.File = fileService
+			router := NewHandler(testAPI.Services, testAPI.TokenManager, testLimits, testClock, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(purchaseService, fileService)
+			}
+
+			req, err := http.NewRequest(tc.method, tc.path+"?"+tc.query.Encode(), strings.NewReader(tc.body))
+			require.NoError(t, err)
+			if tc.body != "" {
+				req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+			}
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			if tc.message != "" {
+				assert.Equal(tc.message, decodeMessage(t, res.Body))
+			}
+			if tc.expDeprecated {
+				assert.Equal("true", res.Header().Get("Deprecation"))
+				assert.Equal(fmt.Sprintf("<%s>; rel=\"successor-version\"", tc.path), res.Header().Get("Link"))
+			} else {
+				assert.Empty(res.Header().Get("Deprecation"))
+			}
+			purchaseService.AssertExpectations(t)
+			fileService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	type test struct {
+		name      string
+		requestID string
+		fn        func(purchaseService *m.Purchase)
+		expCode   int
+		expLevel  string
+		expError  string
+	}
+
+	tt := []test{
+		{
+			name:      "propagated request id",
+			requestID: "request-1",
+			fn: func(purchaseService *m.Purchase) {
+				purchaseService.On("FindByID", mock.Anything, model.IDPurchaseRequest{ID: id}).
+					Return(&model.PurchaseDTO{ID: id, UserID: 1, FileID: id}, nil)
+			},
+			expCode:  http.StatusOK,
+			expLevel: "info",
+		},
+		{
+			name:      "not correct request id",
+			requestID: strings.Repeat("a", 129),
+			fn: func(purchaseService *m.Purchase) {
+				purchaseService.On("FindByID", mock.Anything, model.IDPurchaseRequest{ID: id}).
+					Return(&model.PurchaseDTO{}, nil)
+			},
+			expCode:  http.StatusNotFound,
+			expLevel: "warn",
+		},
+		{
+			name: "find err",
+			fn: func(purchaseService *m.Purchase) {
+				purchaseService.On("FindByID", mock.Anything, model.IDPurchaseRequest{ID: id}).
+					Return(nil, errors.New("find error"))
+			},
+			expCode:  http.StatusInternalServerError,
+			expLevel: "error",
+			expError: "find error",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			purchaseService := new(m.Purchase)
+			testAPI.Services.Purchase = purchaseService
+			router := NewHandler(testAPI.Services, testAPI.TokenManager, testLimits, testClock, logger.New(&out, logger.DebugLevel))
+			tc.fn(purchaseService)
+
+			req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/%s/%s/%s", purchase, api, id), nil)
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			if tc.requestID != "" {
+				req.Header.Set(middleware.RequestIDHeader, tc.requestID)
+			}
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+
+			requestID := res.Header().Get(middleware.RequestIDHeader)
+			if len(tc.requestID) > 0 && len(tc.requestID) <= 128 {
+				assert.Equal(tc.requestID, requestID)
+			} else {
+				assert.Len(requestID, 32)
+			}
+
+			var entry struct {
+				Level     string `json:"level"`
+				Msg       string `json:"msg"`
+				RequestID string `json:"request_id"`
+				Method    string `json:"method"`
+				Route     string `json:"route"`
+				Status    int    `json:"status"`
+				UserID    string `json:"user_id"`
+				Error     string `json:"error"`
+			}
+			require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+			assert.Equal(tc.expLevel, entry.Level)
+			assert.Equal("request", entry.Msg)
+			assert.Equal(requestID, entry.RequestID)
+			assert.Equal(http.MethodGet, entry.Method)
+			assert.Equal("/purchase/api/{id}", entry.Route)
+			assert.Equal(tc.expCode, entry.Status)
+			assert.Equal(mock.Anything, entry.UserID)
+			assert.Contains(entry.Error, tc.expError)
+		})
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	purchaseService := new(m.Purchase)
+	purchaseService.On("FindByID", mock.Anything, model.IDPurchaseRequest{ID: id}).
+		Return(&model.PurchaseDTO{ID: id, UserID: 1, FileID: id}, nil)
+	testAPI.Services.Purchase = purchaseService
+
+	collector := metrics.New()
+	router := NewHandler(testAPI.Services, testAPI.TokenManager, testLimits, testClock, logger.Nop())
+	router.Use(middleware.Instrument(collector))
+
+	for _, path := range []string{fmt.Sprintf("/%s/%s/%s", purchase, api, id), fmt.Sprintf("/%s/%s/", purchase, api)} {
+		req, err := http.NewRequest(http.MethodGet, path, nil)
+		require.NoError(t, err)
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, req)
+		if strings.HasSuffix(path, id) {
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+			res = httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+		}
+	}
+
+	res := httptest.NewRecorder()
+	collector.Handler().ServeHTTP(res, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(http.StatusOK, res.Code)
+	body := res.Body.String()
+	assert.Contains(body, `hexsatisfaction_purchase_http_requests_total{method="GET",route="/purchase/api/{id}",status="200"} 1`)
+	assert.Contains(body, `hexsatisfaction_purchase_http_requests_total{method="GET",route="/purchase/api/{id}",status="401"} 1`)
+	assert.Contains(body, `hexsatisfaction_purchase_http_requests_total{method="GET",route="/purchase/api/",status="401"} 1`)
+	assert.Contains(body, `hexsatisfaction_purchase_http_request_duration_seconds_count{method="GET",route="/purchase/api/{id}"} 2`)
+}
+
+func TestTrace(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT(mock.Anything)
+	require.NoError(t, err)
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	var serviceSpan trace.SpanContext
+	purchaseService := new(m.Purchase)
+	purchaseService.On("FindByID", mock.Anything, model.IDPurchaseRequest{ID: id}).
+		Run(func(args mock.Arguments) {
+			serviceSpan = trace.SpanContextFromContext(args.Get(0).(context.Context))
+		}).
+		Return(&model.PurchaseDTO{ID: id, UserID: 1, FileID: id}, nil)
+	testAPI.Services.Purchase = purchaseService
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	router := NewHandler(testAPI.Services, testAPI.TokenManager, testLimits, testClock, logger.Nop())
+	router.Use(middleware.Trace(provider.Tracer(tracing.InstrumentationName)))
+
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("/%s/%s/%s", purchase, api, id), nil)
+	require.NoError(t, err)
+	req.Header.Set(authorizationHeader, "Bearer "+token)
+	req.Header.Set("traceparent", fmt.Sprintf("00-%s-%s-01", traceID, spanID))
+	res := httptest.NewRecorder()
+	router.ServeHTTP(res, req)
+	assert.Equal(http.StatusOK, res.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	span := spans[0]
+	assert.Equal("GET /purchase/api/{id}", span.Name())
+	assert.Equal(trace.SpanKindServer, span.SpanKind())
+	assert.Equal(traceID, span.SpanContext().TraceID().String())
+	assert.Equal(spanID, span.Parent().SpanID().String())
+	assert.Contains(span.Attributes(), attribute.Int("http.status_code", http.StatusOK))
+	assert.Equal(span.SpanContext(), serviceSpan)
+}
+
+func TestHealth(t *testing.T) {
+	assert := testAssert.New(t)
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+
+	probes := health.New()
+	probes.Add("mongo", time.Second, func(ctx context.Context) error {
+		return nil
+	})
+	grpcDown := false
+	probes.Add("grpc", time.Millisecond, func(ctx context.Context) error {
+		if grpcDown {
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	})
+	router := NewHandler(testAPI.Services, testAPI.TokenManager, testLimits, testClock, logger.Nop())
+	router.Handle("/healthz", probes.Live())
+	router.Handle("/readyz", probes.Ready())
+
+	probe := func(path string) (int, health.Response) {
+		res := httptest.NewRecorder()
+		router.ServeHTTP(res, httptest.NewRequest(http.MethodGet, path, nil))
+		var body health.Response
+		require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+		return res.Code, body
+	}
+
+	code, body := probe("/healthz")
+	assert.Equal(http.StatusOK, code)
+	assert.Equal(health.StatusOK, body.Status)
+
+	code, body = probe("/readyz")
+	assert.Equal(http.StatusOK, code)
+	assert.Equal(health.StatusOK, body.Status)
+	assert.Equal(health.StatusOK, body.Checks["mongo"].Status)
+	assert.Equal(health.StatusOK, body.Checks["grpc"].Status)
+
+	grpcDown = true
+	code, body = probe("/readyz")
+	assert.Equal(http.StatusServiceUnavailable, code)
+	assert.Equal(health.StatusDown, body.Status)
+	assert.Equal(health.StatusOK, body.Checks["mongo"].Status)
+	assert.Equal(health.StatusDown, body.Checks["grpc"].Status)
+	assert.Equal(context.DeadlineExceeded.Error(), body.Checks["grpc"].Error)
+
+	probes.Shutdown()
+	code, body = probe("/readyz")
+	assert.Equal(http.StatusServiceUnavailable, code)
+	assert.Equal(health.StatusShuttingDown, body.Status)
+	code, _ = probe("/healthz")
+	assert.Equal(http.StatusOK, code)
+}
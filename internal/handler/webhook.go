@@ -0,0 +1,540 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+)
+
+type webhookRouter struct {
+	*mux.Router
+	services     *service.Services
+	tokenManager auth.TokenManager
+	limits       Limits
+}
+
+func newWebhook(services *service.Services, tokenManager auth.TokenManager, limits Limits) webhookRouter {
+	router := mux.NewRouter().PathPrefix(webhookPath).Subrouter()
+	router.Use(middleware.Route)
+	handler := webhookRouter{
+		router,
+		services,
+		tokenManager,
+		limits,
+	}
+
+	secure := router.PathPrefix("/api").Subrouter()
+	secure.Use(handler.tokenManager.UserIdentity)
+
+	secure.Path("/").
+		Methods(http.MethodPost).
+		Handler(jsonBody(handler.limits.Body, handler.createWebhook))
+
+	secure.Path("/").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findByUserIDWebhook)
+
+	secure.Path("/{id}").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findByIDWebhook)
+
+	secure.Path("/{id}").
+		Methods(http.MethodPut).
+		Handler(jsonBody(handler.limits.Body, handler.updateWebhook))
+
+	secure.Path("/{id}").
+		Methods(http.MethodDelete).
+		HandlerFunc(handler.deleteWebhook)
+
+	secure.Path("/{id}/deliveries").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findDeliveriesWebhook)
+
+	secure.Path("/{id}/dead-letters").
+		Methods(http.MethodGet).
+		HandlerFunc(handler.findDeadLettersWebhook)
+
+	secure.Path("/{id}/deliveries/{deliveryID}/redeliver").
+		Methods(http.MethodPost).
+		HandlerFunc(handler.redeliverWebhook)
+
+	admin := router.PathPrefix("/admin").Subrouter()
+	admin.Use(handler.tokenManager.AdminIdentity)
+
+	admin.Path("/").
+		Methods(http.MethodPost).
+		Handler(jsonBody(handler.limits.Body, handler.createGlobalWebhook))
+
+	return handler
+}
+
+type createWebhookRequest struct {
+	model.CreateWebhookRequest
+}
+
+// Build builds request to create webhook of the user.
+func (req *createWebhookRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.CreateWebhookRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @Summary Create
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Subscribe webhook to events of files of the user. Deliveries are signed with the secret
+// @Accept  json
+// @Produce  json
+// @Param webhook body model.CreateWebhookRequest true "Webhook"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/ [post]
+func (wh *webhookRouter) createWebhook(w http.ResponseWriter, r *http.Request) {
+	wh.create(w, r, false)
+}
+
+// @Summary CreateGlobal
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Subscribe webhook of an integrator to events of all files
+// @Accept  json
+// @Produce  json
+// @Param webhook body model.CreateWebhookRequest true "Webhook"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/admin/ [post]
+func (wh *webhookRouter) createGlobalWebhook(w http.ResponseWriter, r *http.Request) {
+	wh.create(w, r, true)
+}
+
+func (wh *webhookRouter) create(w http.ResponseWriter, r *http.Request, global bool) {
+	var req createWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+	req.Global = global
+
+	id, err := wh.services.Webhook.Create(r.Context(), req.CreateWebhookRequest)
+	if errors.Is(err, service.ErrInvalidEvents) {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type updateWebhookRequest struct {
+	model.UpdateWebhookRequest
+}
+
+// Build builds request to update webhook of the user.
+func (req *updateWebhookRequest) Build(r *http.Request) error {
+	err := middleware.DecodeJSON(r.Body, &req.UpdateWebhookRequest)
+	if err != nil {
+		return err
+	}
+
+	defer func(body io.ReadCloser) {
+		err := body.Close()
+		if err != nil {
+			logger.FromContext(r.Context()).Warn("couldn't close request body", "error", err)
+		}
+	}(r.Body)
+
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+	req.ID = vID
+
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @Summary Update
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Update url, events, secret and activity of webhook. Secret is kept if it is empty
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Webhook id"
+// @Param webhook body model.UpdateWebhookRequest true "Webhook"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No webhook"
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/{id} [put]
+func (wh *webhookRouter) updateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req updateWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := wh.services.Webhook.Update(r.Context(), req.UpdateWebhookRequest)
+	if errors.Is(err, service.ErrInvalidEvents) {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, service.ErrNotWebhookOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+type idWebhookRequest struct {
+	model.IDWebhookRequest
+}
+
+// Build builds request to find or delete webhook of the user by id.
+func (req *idWebhookRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+	req.ID = vID
+
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @Summary Delete
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Delete webhook with its deliveries
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Webhook id"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No webhook"
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/{id} [delete]
+func (wh *webhookRouter) deleteWebhook(w http.ResponseWriter, r *http.Request) {
+	var req idWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := wh.services.Webhook.Delete(r.Context(), req.IDWebhookRequest)
+	if errors.Is(err, service.ErrNotWebhookOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
+
+// @Summary FindByID
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Find webhook by id
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Webhook id"
+// @Success 200 {object} model.WebhookDTO
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No webhook"
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/{id} [get]
+func (wh *webhookRouter) findByIDWebhook(w http.ResponseWriter, r *http.Request) {
+	var req idWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	webhook, err := wh.services.Webhook.FindByID(r.Context(), req.IDWebhookRequest)
+	if errors.Is(err, service.ErrNotWebhookOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if webhook == nil {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, webhook)
+}
+
+type userIDWebhookRequest struct {
+	model.UserIDWebhookRequest
+}
+
+// Build builds request to find webhooks of the user.
+func (req *userIDWebhookRequest) Build(r *http.Request) error {
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @Summary FindByUserID
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Find webhooks of the user
+// @Accept  json
+// @Produce  json
+// @Success 200 {array} model.WebhookDTO
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 404 {object} middleware.SwagEmptyError "No webhooks"
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/ [get]
+func (wh *webhookRouter) findByUserIDWebhook(w http.ResponseWriter, r *http.Request) {
+	var req userIDWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	webhooks, err := wh.services.Webhook.FindByUserID(r.Context(), req.UserIDWebhookRequest)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(webhooks) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, webhooks)
+}
+
+type deliveriesWebhookRequest struct {
+	model.DeliveriesWebhookRequest
+}
+
+// Build builds request to find deliveries of webhook of the user, optionally by status query parameter.
+func (req *deliveriesWebhookRequest) Build(r *http.Request) error {
+	vID, ok := mux.Vars(r)["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+	req.ID = vID
+	req.Status = r.URL.Query().Get("status")
+
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @Summary FindDeliveries
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Find delivery log of webhook from the latest
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Webhook id"
+// @Param status query string false "Delivery status: pending, delivered or dead"
+// @Success 200 {array} model.DeliveryDTO
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No deliveries"
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/{id}/deliveries [get]
+func (wh *webhookRouter) findDeliveriesWebhook(w http.ResponseWriter, r *http.Request) {
+	var req deliveriesWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	wh.findDeliveries(w, r, req.DeliveriesWebhookRequest)
+}
+
+// @Summary FindDeadLetters
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Find deliveries of webhook which ran out of attempts
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Webhook id"
+// @Success 200 {array} model.DeliveryDTO
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No dead deliveries"
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/{id}/dead-letters [get]
+func (wh *webhookRouter) findDeadLettersWebhook(w http.ResponseWriter, r *http.Request) {
+	var req idWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	wh.findDeliveries(w, r, model.DeliveriesWebhookRequest{
+		ID:     req.ID,
+		UserID: req.UserID,
+		Status: model.DeliveryDead,
+	})
+}
+
+func (wh *webhookRouter) findDeliveries(w http.ResponseWriter, r *http.Request, req model.DeliveriesWebhookRequest) {
+	deliveries, err := wh.services.Webhook.FindDeliveries(r.Context(), req)
+	if errors.Is(err, service.ErrNotWebhookOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if len(deliveries) == 0 {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, deliveries)
+}
+
+type redeliverWebhookRequest struct {
+	model.RedeliverWebhookRequest
+}
+
+// Build builds request to deliver a delivery of webhook of the user again.
+func (req *redeliverWebhookRequest) Build(r *http.Request) error {
+	vars := mux.Vars(r)
+	vID, ok := vars["id"]
+	if !ok {
+		return fmt.Errorf("no id")
+	}
+	vDeliveryID, ok := vars["deliveryID"]
+	if !ok {
+		return fmt.Errorf("no delivery id")
+	}
+	req.ID = vID
+	req.DeliveryID = vDeliveryID
+
+	var err error
+	req.UserID, err = userID(r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// @Summary Redeliver
+// @Security ApiKeyAuth
+// @Tags webhook
+// @Description Schedule delivery to be attempted again at once with reset attempts, e.g. to replay a dead letter
+// @Accept  json
+// @Produce  json
+// @Param id path string true "Webhook id"
+// @Param deliveryID path string true "Delivery id"
+// @Success 200 {string} string id
+// @Failure 400 {object} middleware.SwagValidationError
+// @Failure 403 {object} middleware.SwagError
+// @Failure 404 {object} middleware.SwagEmptyError "No delivery"
+// @Failure 500 {object} middleware.SwagError
+// @Router /webhook/api/{id}/deliveries/{deliveryID}/redeliver [post]
+func (wh *webhookRouter) redeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	var req redeliverWebhookRequest
+	err := middleware.ParseRequest(r, &req)
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusBadRequest)
+		return
+	}
+
+	id, err := wh.services.Webhook.Redeliver(r.Context(), req.RedeliverWebhookRequest)
+	if errors.Is(err, service.ErrNotWebhookOwner) {
+		middleware.JSONError(w, err, http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		middleware.JSONError(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	if id == "" {
+		middleware.Empty(w, http.StatusNotFound)
+		return
+	}
+
+	middleware.JSONReturn(w, http.StatusOK, id)
+}
@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/handler/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/service"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
+	"github.com/pkg/errors"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	webhook    = "webhook"
+	deliveries = "deliveries"
+)
+
+func TestWebhook_Create(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name    string
+		req     model.CreateWebhookRequest
+		fn      func(webhookService *m.Webhook, data test)
+		expCode int
+		expBody string
+	}
+	tt := []test{
+		{
+			name: "invalid url",
+			req: model.CreateWebhookRequest{
+				URL:    "ftp://example.com/hook",
+				Events: []string{model.EventPurchaseCreated},
+				Secret: "secret",
+			},
+			expCode: http.StatusBadRequest,
+			expBody: "not correct url",
+		},
+		{
+			name: "invalid events",
+			req: model.CreateWebhookRequest{
+				URL:    "https://example.com/hook",
+				Events: []string{"file.deleted"},
+				Secret: "secret",
+			},
+			fn: func(webhookService *m.Webhook, data test) {
+				webhookService.On("Create", mock.Anything, mock.Anything).
+					Return("", service.ErrInvalidEvents)
+			},
+			expCode: http.StatusBadRequest,
+			expBody: service.ErrInvalidEvents.Error(),
+		},
+		{
+			name: "create err",
+			req: model.CreateWebhookRequest{
+				URL:    "https://example.com/hook",
+				Events: []string{model.EventPurchaseCreated},
+				Secret: "secret",
+			},
+			fn: func(webhookService *m.Webhook, data test) {
+				webhookService.On("Create", mock.Anything, mock.Anything).
+					Return("", errors.New(""))
+			},
+			expCode: http.StatusInternalServerError,
+		},
+		{
+			name: "all ok",
+			req: model.CreateWebhookRequest{
+				URL:    "https://example.com/hook",
+				Events: []string{model.EventPurchaseCreated},
+				Secret: "secret",
+			},
+			fn: func(webhookService *m.Webhook, data test) {
+				req := data.req
+				req.UserID = 1
+				webhookService.On("Create", mock.Anything, req).
+					Return(data.expBody, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: id,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			webhookService := new(m.Webhook)
+			testAPI.Services.Webhook = webhookService
+			router := newWebhook(testAPI.Services, testAPI.TokenManager, testLimits)
+			if tc.fn != nil {
+				tc.fn(webhookService, tc)
+			}
+
+			body, err := json.Marshal(map[string]interface{}{
+				"url":    tc.req.URL,
+				"events": tc.req.Events,
+				"secret": tc.req.Secret,
+			})
+			require.NoError(t, err)
+
+			req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("/%s/%s/", webhook, api), bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", middleware.MIMEApplicationJSON)
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			assert.Equal(tc.expBody, decodeMessage(t, res.Body))
+		})
+	}
+}
+
+func TestWebhook_FindDeliveries(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	testAPI, err := service.InitTest4Mock()
+	require.NoError(t, err)
+	token, err := testAPI.TokenManager.NewJWT("1")
+	require.NoError(t, err)
+
+	type test struct {
+		name    string
+		path    string
+		fn      func(webhookService *m.Webhook)
+		expCode int
+		expBody []model.DeliveryDTO
+	}
+	dead := []model.DeliveryDTO{{
+		ID:        primitive.NewObjectID().Hex(),
+		WebhookID: id,
+		Body:      json.RawMessage(`{}`),
+		Status:    model.DeliveryDead,
+		Attempts:  10,
+	}}
+	tt := []test{
+		{
+			name:    "invalid status",
+			path:    fmt.Sprintf("/%s/%s/%s/%s?status=failed", webhook, api, id, deliveries),
+			expCode: http.StatusBadRequest,
+		},
+		{
+			name: "not owner",
+			path: fmt.Sprintf("/%s/%s/%s/%s", webhook, api, id, deliveries),
+			fn: func(webhookService *m.Webhook) {
+				webhookService.On("FindDeliveries", mock.Anything, model.DeliveriesWebhookRequest{ID: id, UserID: 1}).
+					Return(nil, service.ErrNotWebhookOwner)
+			},
+			expCode: http.StatusForbidden,
+		},
+		{
+			name: "no deliveries",
+			path: fmt.Sprintf("/%s/%s/%s/%s?status=%s", webhook, api, id, deliveries, model.DeliveryPending),
+			fn: func(webhookService *m.Webhook) {
+				webhookService.On("FindDeliveries", mock.Anything, model.DeliveriesWebhookRequest{ID: id, UserID: 1, Status: model.DeliveryPending}).
+					Return(nil, nil)
+			},
+			expCode: http.StatusNotFound,
+		},
+		{
+			name: "dead letters",
+			path: fmt.Sprintf("/%s/%s/%s/dead-letters", webhook, api, id),
+			fn: func(webhookService *m.Webhook) {
+				webhookService.On("FindDeliveries", mock.Anything, model.DeliveriesWebhookRequest{ID: id, UserID: 1, Status: model.DeliveryDead}).
+					Return(dead, nil)
+			},
+			expCode: http.StatusOK,
+			expBody: dead,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			webhookService := new(m.Webhook)
+			testAPI.Services.Webhook = webhookService
+			router := newWebhook(testAPI.Services, testAPI.TokenManager, testLimits)
+			if tc.fn != nil {
+				tc.fn(webhookService)
+			}
+
+			req, err := http.NewRequest(http.MethodGet, tc.path, nil)
+			require.NoError(t, err)
+			req.Header.Set(authorizationHeader, "Bearer "+token)
+
+			res := httptest.NewRecorder()
+			router.ServeHTTP(res, req)
+			assert.Equal(tc.expCode, res.Code)
+			if tc.expBody != nil {
+				var body []model.DeliveryDTO
+				require.NoError(t, json.NewDecoder(res.Body).Decode(&body))
+				assert.Equal(tc.expBody, body)
+			}
+		})
+	}
+}
@@ -8,6 +8,25 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+const (
+	// MinRating is the lowest rating of a file.
+	MinRating = 1
+	// MaxRating is the highest rating of a file.
+	MaxRating = 5
+)
+
+// Moderation states of a comment.
+const (
+	// CommentPending is a state of a comment waiting for moderator review.
+	CommentPending = "pending"
+	// CommentApproved is a state of a published comment.
+	CommentApproved = "approved"
+	// CommentRejected is a state of a comment rejected by moderator.
+	CommentRejected = "rejected"
+	// CommentFlagged is a state of a published comment flagged by users.
+	CommentFlagged = "flagged"
+)
+
 // Comments represents a slice of a comment model.
 type Comments []Comment
 
@@ -19,19 +38,33 @@ type Comment mongo.Comment
 
 // CommentDTO represents dto of a comment model.
 type CommentDTO struct {
-	ID         string    `json:"id,omitempty"`
-	UserID     int       `json:"userID"`
-	PurchaseID string    `json:"purchaseID"`
-	Date       time.Time `json:"date"`
-	Text       string    `json:"text"`
+	ID         string     `json:"id,omitempty"`
+	UserID     int        `json:"userID"`
+	PurchaseID string     `json:"purchaseID"`
+	ParentID   string     `json:"parentID,omitempty"`
+	Date       time.Time  `json:"date"`
+	EditedAt   *time.Time `json:"editedAt,omitempty"`
+	Text       string     `json:"text"`
+	Rating     int        `json:"rating,omitempty"`
+	Deleted    bool       `json:"deleted,omitempty"`
+	Status     string     `json:"status,omitempty"`
+	FlaggedBy  []int      `json:"flaggedBy,omitempty"`
+	Score      float64    `json:"score,omitempty"`
+	Revision   int        `json:"revision"`
 }
 
 // Entity converts CommentDTO to Comment.
 func (c CommentDTO) Entity() (*Comment, error) {
 	comment := Comment{
-		UserID: c.UserID,
-		Date:   c.Date,
-		Text:   c.Text,
+		UserID:    c.UserID,
+		Date:      c.Date,
+		EditedAt:  c.EditedAt,
+		Text:      c.Text,
+		Rating:    c.Rating,
+		Deleted:   c.Deleted,
+		Status:    c.Status,
+		FlaggedBy: c.FlaggedBy,
+		Revision:  c.Revision,
 	}
 	var err error
 	if c.ID != "" {
@@ -46,6 +79,12 @@ func (c CommentDTO) Entity() (*Comment, error) {
 			return nil, errors.Wrap(err, "invalid purchase id")
 		}
 	}
+	if c.ParentID != "" {
+		comment.ParentID, err = primitive.ObjectIDFromHex(c.ParentID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid parent id")
+		}
+	}
 
 	return &comment, nil
 }
@@ -57,7 +96,16 @@ func (c Comment) DTO() *CommentDTO {
 		UserID:     c.UserID,
 		PurchaseID: c.PurchaseID.Hex(),
 		Date:       c.Date,
+		EditedAt:   c.EditedAt,
 		Text:       c.Text,
+		Rating:     c.Rating,
+		Deleted:    c.Deleted,
+		Status:     c.Status,
+		FlaggedBy:  c.FlaggedBy,
+		Revision:   c.Revision,
+	}
+	if !c.ParentID.IsZero() {
+		comment.ParentID = c.ParentID.Hex()
 	}
 
 	return &comment
@@ -84,3 +132,81 @@ func (c Comments) DTO() CommentsDTO {
 	}
 	return comments
 }
+
+// CommentRevisions represents a slice of a comment revision model.
+type CommentRevisions []CommentRevision
+
+// CommentRevision represents a comment revision model.
+type CommentRevision mongo.CommentRevision
+
+// CommentRevisionDTO represents dto of a comment revision model.
+type CommentRevisionDTO struct {
+	ID         string    `json:"id"`
+	CommentID  string    `json:"commentID"`
+	Text       string    `json:"text"`
+	Rating     int       `json:"rating,omitempty"`
+	Status     string    `json:"status,omitempty"`
+	Date       time.Time `json:"date"`
+	ReplacedAt time.Time `json:"replacedAt"`
+}
+
+// DTO converts CommentRevision to CommentRevisionDTO.
+func (c CommentRevision) DTO() *CommentRevisionDTO {
+	return &CommentRevisionDTO{
+		ID:         c.ID.Hex(),
+		CommentID:  c.CommentID.Hex(),
+		Text:       c.Text,
+		Rating:     c.Rating,
+		Status:     c.Status,
+		Date:       c.Date,
+		ReplacedAt: c.ReplacedAt,
+	}
+}
+
+// DTO converts CommentRevisions to a slice of CommentRevisionDTO.
+func (c CommentRevisions) DTO() []CommentRevisionDTO {
+	var revisions []CommentRevisionDTO
+	for _, revision := range c {
+		revisions = append(revisions, *revision.DTO())
+	}
+	return revisions
+}
+
+// CommentNode represents a comment with its replies.
+type CommentNode struct {
+	CommentDTO
+	Replies []CommentNode `json:"replies,omitempty"`
+}
+
+// Tree converts CommentsDTO to comment trees keeping the order of comments.
+// Replies whose parent is missing are returned as roots.
+func (c CommentsDTO) Tree() []CommentNode {
+	children := make(map[string][]CommentDTO)
+	ids := make(map[string]bool, len(c))
+	for _, comment := range c {
+		ids[comment.ID] = true
+	}
+
+	var roots []CommentDTO
+	for _, comment := range c {
+		if comment.ParentID == "" || !ids[comment.ParentID] {
+			roots = append(roots, comment)
+			continue
+		}
+		children[comment.ParentID] = append(children[comment.ParentID], comment)
+	}
+
+	var build func(comments []CommentDTO) []CommentNode
+	build = func(comments []CommentDTO) []CommentNode {
+		var nodes []CommentNode
+		for _, comment := range comments {
+			nodes = append(nodes, CommentNode{
+				CommentDTO: comment,
+				Replies:    build(children[comment.ID]),
+			})
+		}
+		return nodes
+	}
+
+	return build(roots)
+}
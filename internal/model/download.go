@@ -0,0 +1,81 @@
+package model
+
+import (
+	"io"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Downloads represents a slice of a download model.
+type Downloads []Download
+
+// Download represents a download model.
+type Download mongo.Download
+
+// DownloadDTO represents dto of a download model.
+type DownloadDTO struct {
+	ID      string    `json:"id,omitempty"`
+	FileID  string    `json:"fileID"`
+	UserID  int       `json:"userID"`
+	Version int       `json:"version,omitempty"`
+	Date    time.Time `json:"date"`
+}
+
+// Entity converts DownloadDTO to Download.
+func (d DownloadDTO) Entity() (*Download, error) {
+	download := Download{
+		UserID:  d.UserID,
+		Version: d.Version,
+		Date:    d.Date,
+	}
+	var err error
+	if d.ID != "" {
+		download.ID, err = primitive.ObjectIDFromHex(d.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid id")
+		}
+	}
+	download.FileID, err = primitive.ObjectIDFromHex(d.FileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid file id")
+	}
+
+	return &download, nil
+}
+
+// DTO converts Download to DownloadDTO.
+func (d Download) DTO() *DownloadDTO {
+	return &DownloadDTO{
+		ID:      d.ID.Hex(),
+		FileID:  d.FileID.Hex(),
+		UserID:  d.UserID,
+		Version: d.Version,
+		Date:    d.Date,
+	}
+}
+
+// DTO converts Downloads to a slice of DownloadDTO.
+func (d Downloads) DTO() []DownloadDTO {
+	var downloads []DownloadDTO
+	for _, download := range d {
+		downloads = append(downloads, *download.DTO())
+	}
+	return downloads
+}
+
+// FileContent represents a file opened for download by the user.
+type FileContent struct {
+	File    FileDTO
+	UserID  int
+	Content io.ReadSeekCloser
+}
+
+// DownloadLinkDTO represents a signed download link of a file.
+type DownloadLinkDTO struct {
+	URL       string    `json:"url"`
+	Expires   time.Time `json:"expires"`
+	SingleUse bool      `json:"singleUse,omitempty"`
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// EventPurchaseCreated is published when a purchase is created.
+	EventPurchaseCreated = "purchase.created"
+	// EventPurchaseRefunded is published when a purchase is deleted, which revokes access to the file.
+	EventPurchaseRefunded = "purchase.refunded"
+	// EventCommentPosted is published when a comment or a reply is posted.
+	EventCommentPosted = "comment.posted"
+	// EventFileUpdated is published when a new version of a file is made.
+	EventFileUpdated = "file.updated"
+)
+
+// Events represents a slice of an event model.
+type Events []Event
+
+// Event represents a domain event model.
+type Event mongo.Event
+
+// EventDTO represents dto of a domain event model.
+type EventDTO struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	AggregateID string          `json:"aggregateID"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	Attempts    int             `json:"-"`
+}
+
+// NewEvent creates event of the type about the aggregate with JSON encoded payload.
+func NewEvent(eventType, aggregateID string, payload interface{}, occurredAt time.Time) (EventDTO, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return EventDTO{}, errors.Wrap(err, "couldn't encode event payload")
+	}
+
+	return EventDTO{
+		Type:        eventType,
+		AggregateID: aggregateID,
+		Payload:     data,
+		OccurredAt:  occurredAt,
+	}, nil
+}
+
+// Entity converts EventDTO to Event which is due to be dispatched at once.
+func (e EventDTO) Entity() (*Event, error) {
+	event := Event{
+		Type:          e.Type,
+		AggregateID:   e.AggregateID,
+		Payload:       string(e.Payload),
+		OccurredAt:    e.OccurredAt,
+		NextAttemptAt: e.OccurredAt,
+		Attempts:      e.Attempts,
+	}
+	if e.ID != "" {
+		var err error
+		event.ID, err = primitive.ObjectIDFromHex(e.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid id")
+		}
+	}
+
+	return &event, nil
+}
+
+// DTO converts Event to EventDTO.
+func (e Event) DTO() *EventDTO {
+	return &EventDTO{
+		ID:          e.ID.Hex(),
+		Type:        e.Type,
+		AggregateID: e.AggregateID,
+		Payload:     json.RawMessage(e.Payload),
+		OccurredAt:  e.OccurredAt,
+		Attempts:    e.Attempts,
+	}
+}
+
+// DTO converts Events to a slice of EventDTO.
+func (e Events) DTO() []EventDTO {
+	var events []EventDTO
+	for _, event := range e {
+		events = append(events, *event.DTO())
+	}
+	return events
+}
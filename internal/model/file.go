@@ -24,10 +24,18 @@ type FileDTO struct {
 	Description string    `json:"description"`
 	Size        int       `json:"size"`
 	Path        string    `json:"path"`
+	Checksum    string    `json:"checksum,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	VersionID   string    `json:"versionID,omitempty"`
+	Version     int       `json:"version,omitempty"`
 	AddDate     time.Time `json:"addDate"`
 	UpdateDate  time.Time `json:"updateDate"`
 	Actual      bool      `json:"actual"`
 	AuthorID    int       `json:"authorID"`
+	Rating      float64   `json:"rating,omitempty"`
+	RatingCount int       `json:"ratingCount,omitempty"`
+	Score       float64   `json:"score,omitempty"`
+	Revision    int       `json:"revision"`
 }
 
 // Entity converts FileDTO to File.
@@ -37,10 +45,16 @@ func (f FileDTO) Entity() (*File, error) {
 		Description: f.Description,
 		Size:        f.Size,
 		Path:        f.Path,
+		Checksum:    f.Checksum,
+		ContentType: f.ContentType,
+		Version:     f.Version,
 		AddDate:     f.AddDate,
 		UpdateDate:  f.UpdateDate,
 		Actual:      f.Actual,
 		AuthorID:    f.AuthorID,
+		Rating:      f.Rating,
+		RatingCount: f.RatingCount,
+		Revision:    f.Revision,
 	}
 	var err error
 	if f.ID != "" {
@@ -49,6 +63,12 @@ func (f FileDTO) Entity() (*File, error) {
 			return nil, errors.Wrap(err, "invalid id")
 		}
 	}
+	if f.VersionID != "" {
+		file.VersionID, err = primitive.ObjectIDFromHex(f.VersionID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid version id")
+		}
+	}
 
 	return &file, nil
 }
@@ -61,15 +81,34 @@ func (f File) DTO() *FileDTO {
 		Description: f.Description,
 		Size:        f.Size,
 		Path:        f.Path,
+		Checksum:    f.Checksum,
+		ContentType: f.ContentType,
+		Version:     f.Version,
 		AddDate:     f.AddDate,
 		UpdateDate:  f.UpdateDate,
 		Actual:      f.Actual,
 		AuthorID:    f.AuthorID,
+		Rating:      f.Rating,
+		RatingCount: f.RatingCount,
+		Revision:    f.Revision,
+	}
+	if !f.VersionID.IsZero() {
+		file.VersionID = f.VersionID.Hex()
 	}
 
 	return &file
 }
 
+// FilePatch represents fields of a file to change, nil fields are left unchanged.
+// The patch is applied only to the file of the revision.
+type FilePatch struct {
+	Name        *string
+	Description *string
+	Actual      *bool
+	UpdateDate  *time.Time
+	Revision    int
+}
+
 // Entity converts FilesDTO to Files.
 func (f FilesDTO) Entity() (Files, error) {
 	var files Files
@@ -91,3 +130,75 @@ func (f Files) DTO() FilesDTO {
 	}
 	return files
 }
+
+// FileVersions represents a slice of a file version model.
+type FileVersions []FileVersion
+
+// FileVersion represents a file version model.
+type FileVersion mongo.FileVersion
+
+// FileVersionDTO represents dto of a file version model.
+type FileVersionDTO struct {
+	ID          string    `json:"id,omitempty"`
+	FileID      string    `json:"fileID"`
+	Number      int       `json:"number"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Size        int       `json:"size"`
+	Path        string    `json:"path"`
+	Checksum    string    `json:"checksum,omitempty"`
+	ContentType string    `json:"contentType,omitempty"`
+	Date        time.Time `json:"date"`
+}
+
+// Entity converts FileVersionDTO to FileVersion.
+func (f FileVersionDTO) Entity() (*FileVersion, error) {
+	version := FileVersion{
+		Number:      f.Number,
+		Name:        f.Name,
+		Description: f.Description,
+		Size:        f.Size,
+		Path:        f.Path,
+		Checksum:    f.Checksum,
+		ContentType: f.ContentType,
+		Date:        f.Date,
+	}
+	var err error
+	if f.ID != "" {
+		version.ID, err = primitive.ObjectIDFromHex(f.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid id")
+		}
+	}
+	version.FileID, err = primitive.ObjectIDFromHex(f.FileID)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid file id")
+	}
+
+	return &version, nil
+}
+
+// DTO converts FileVersion to FileVersionDTO.
+func (f FileVersion) DTO() *FileVersionDTO {
+	return &FileVersionDTO{
+		ID:          f.ID.Hex(),
+		FileID:      f.FileID.Hex(),
+		Number:      f.Number,
+		Name:        f.Name,
+		Description: f.Description,
+		Size:        f.Size,
+		Path:        f.Path,
+		Checksum:    f.Checksum,
+		ContentType: f.ContentType,
+		Date:        f.Date,
+	}
+}
+
+// DTO converts FileVersions to a slice of FileVersionDTO.
+func (f FileVersions) DTO() []FileVersionDTO {
+	var versions []FileVersionDTO
+	for _, version := range f {
+		versions = append(versions, *version.DTO())
+	}
+	return versions
+}
@@ -19,17 +19,20 @@ type Purchase mongo.Purchase
 
 // PurchaseDTO represents dto of a purchase model.
 type PurchaseDTO struct {
-	ID     string    `json:"id,omitempty"`
-	UserID int       `json:"userID"`
-	Date   time.Time `json:"date"`
-	FileID string    `json:"fileID"`
+	ID        string    `json:"id,omitempty"`
+	UserID    int       `json:"userID"`
+	Date      time.Time `json:"date"`
+	FileID    string    `json:"fileID"`
+	VersionID string    `json:"versionID,omitempty"`
+	Version   int       `json:"version,omitempty"`
 }
 
 // Entity converts PurchaseDTO to Purchase.
 func (p PurchaseDTO) Entity() (*Purchase, error) {
 	purchase := Purchase{
-		UserID: p.UserID,
-		Date:   p.Date,
+		UserID:  p.UserID,
+		Date:    p.Date,
+		Version: p.Version,
 	}
 	var err error
 	if p.ID != "" {
@@ -44,6 +47,12 @@ func (p PurchaseDTO) Entity() (*Purchase, error) {
 			return nil, errors.Wrap(err, "invalid file id")
 		}
 	}
+	if p.VersionID != "" {
+		purchase.VersionID, err = primitive.ObjectIDFromHex(p.VersionID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid version id")
+		}
+	}
 
 	return &purchase, nil
 }
@@ -51,10 +60,14 @@ func (p PurchaseDTO) Entity() (*Purchase, error) {
 // Entity converts Purchase to PurchaseDTO.
 func (p Purchase) DTO() *PurchaseDTO {
 	purchase := PurchaseDTO{
-		ID:     p.ID.Hex(),
-		UserID: p.UserID,
-		Date:   p.Date,
-		FileID: p.FileID.Hex(),
+		ID:      p.ID.Hex(),
+		UserID:  p.UserID,
+		Date:    p.Date,
+		FileID:  p.FileID.Hex(),
+		Version: p.Version,
+	}
+	if !p.VersionID.IsZero() {
+		purchase.VersionID = p.VersionID.Hex()
 	}
 
 	return &purchase
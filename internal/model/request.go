@@ -1,252 +1,433 @@
 package model
 
-import "time"
+import (
+	"io"
+	"time"
+)
+
+// AnyRevision is a revision of a file or comment requested with If-Match: *, it matches the current revision.
+const AnyRevision = -1
 
 type (
 
 	// CreatePurchaseRequest represents a request to create purchase.
+	// Purchases are created by the authenticated user, user id is taken from the body only on import.
 	CreatePurchaseRequest struct {
+		UserID int `json:"userID" validate:"positive"`
 		// required: true
-		UserID int `json:"userID"`
-		// required: true
-		Date time.Time `json:"date"`
+		FileID string `json:"fileID" validate:"required,objectid"`
+	}
+
+	// ImportPurchaseRequest represents a request to import purchase with its original date.
+	ImportPurchaseRequest struct {
+		CreatePurchaseRequest
 		// required: true
-		FileID string `json:"fileID"`
+		Date time.Time `json:"date" validate:"required"`
 	}
 
 	// IDPurchaseRequest represents a request to find the purchase by id.
 	IDPurchaseRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
 	}
 
 	// DeletePurchaseRequest represents a request to delete purchase.
 	DeletePurchaseRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
 	}
 
 	// UserIDPurchaseRequest represents a request to find last added purchase by user id.
 	UserIDPurchaseRequest struct {
 		// required: true
-		ID int `json:"-"`
+		ID int `json:"-" validate:"positive"`
 	}
 
 	// UserIDPeriodPurchaseRequest represents a request to find all purchases by user id and date period.
 	UserIDPeriodPurchaseRequest struct {
 		// required: true
-		ID int `json:"-"`
+		ID int `json:"-" validate:"positive"`
 		// required: true
-		Start time.Time `json:"start"`
+		Start time.Time `json:"start" validate:"required"`
 		// required: true
-		End time.Time `json:"end"`
+		End time.Time `json:"end" validate:"required,gtefield=Start"`
 	}
 
 	// UserIDAfterDatePurchaseRequest represents a request to find all purchases by user id after date.
 	UserIDAfterDatePurchaseRequest struct {
 		// required: true
-		ID int `json:"-"`
+		ID int `json:"-" validate:"positive"`
 		// required: true
-		Start time.Time `json:"start"`
+		Start time.Time `json:"start" validate:"required"`
 	}
 
 	// UserIDBeforeDatePurchaseRequest represents a request to find all purchases by user id before date.
 	UserIDBeforeDatePurchaseRequest struct {
 		// required: true
-		ID int `json:"-"`
+		ID int `json:"-" validate:"positive"`
 		// required: true
-		End time.Time `json:"end"`
+		End time.Time `json:"end" validate:"required"`
 	}
 
 	// UserIDFileIDPurchaseRequest represents a request to find all purchases by user id and file name.
 	UserIDFileIDPurchaseRequest struct {
 		// required: true
-		UserID int `json:"-"`
+		UserID int `json:"-" validate:"positive"`
 		// required: true
-		FileID string `json:"fileID"`
+		FileID string `json:"fileID" validate:"objectid"`
 	}
 
 	// PeriodPurchaseRequest represents a request to find all purchases by date period.
 	PeriodPurchaseRequest struct {
 		// required: true
-		Start time.Time `json:"start"`
+		Start time.Time `json:"start" validate:"required"`
 		// required: true
-		End time.Time `json:"end"`
+		End time.Time `json:"end" validate:"required,gtefield=Start"`
 	}
 
 	// AfterDatePurchaseRequest represents a request to find all purchases after date.
 	AfterDatePurchaseRequest struct {
 		// required: true
-		Start time.Time `json:"start"`
+		Start time.Time `json:"start" validate:"required"`
 	}
 
 	// BeforeDatePurchaseRequest represents a request to find all purchases before date.
 	BeforeDatePurchaseRequest struct {
 		// required: true
-		End time.Time `json:"end"`
+		End time.Time `json:"end" validate:"required"`
 	}
 
 	// FileIDPurchaseRequest represents a request to find all purchases by file name.
 	FileIDPurchaseRequest struct {
 		// required: true
-		FileID string `json:"-"`
+		FileID string `json:"-" validate:"objectid"`
 	}
 )
 
 type (
 
 	// CreateCommentRequest represents a request to create comment.
+	// Comments are created by the authenticated user, user id is taken from the body only on import.
 	CreateCommentRequest struct {
-		// required: true
-		UserID int `json:"userID"`
+		UserID int `json:"userID" validate:"positive"`
 		// required: true
 		PurchaseID string `json:"purchaseID"`
 		// required: true
-		Date time.Time `json:"Date"`
+		Text     string `json:"Text" validate:"required"`
+		Rating   int    `json:"rating" validate:"omitempty,min=1,max=5"`
+		ParentID string `json:"parentID" validate:"omitempty,objectid"`
+	}
+
+	// ImportCommentRequest represents a request to import comment with its original date.
+	ImportCommentRequest struct {
+		CreateCommentRequest
 		// required: true
-		Text string `json:"Text"`
+		Date time.Time `json:"Date" validate:"required"`
+	}
+
+	// PatchCommentRequest represents a request to partially update comment.
+	// Nil fields are left unchanged.
+	PatchCommentRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
+		// required: true
+		UserID int `json:"-" validate:"positive"`
+		// required: true
+		Revision int     `json:"-"`
+		Text     *string `json:"text,omitempty" validate:"omitnil,required"`
+		Rating   *int    `json:"rating,omitempty" validate:"omitempty,min=1,max=5"`
 	}
 
 	// UpdateCommentRequest represents a request to update comment.
 	UpdateCommentRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
 		// required: true
-		UserID int `json:"userID"`
+		UserID int `json:"-" validate:"positive"`
 		// required: true
-		PurchaseID string `json:"purchaseID"`
+		Revision int `json:"-"`
 		// required: true
-		Date time.Time `json:"date"`
-		// required: true
-		Text string `json:"text"`
+		Text   string `json:"text" validate:"required"`
+		Rating int    `json:"rating" validate:"omitempty,min=1,max=5"`
 	}
 
-	// DeleteCommentRequest represents a request to delete comment.
+	// DeleteCommentRequest represents a request of the user to delete comment.
 	DeleteCommentRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
+		// required: true
+		UserID int  `json:"-" validate:"positive"`
+		Admin  bool `json:"-"`
+		// required: true
+		Revision int `json:"-"`
 	}
 
-	// IDCommentRequest represents a request to find comment by id.
+	// IDCommentRequest represents a request of the user to find comment by id.
 	IDCommentRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID     string `json:"-" validate:"objectid"`
+		UserID int    `json:"-"`
+		Admin  bool   `json:"-"`
 	}
 
 	// UserIDCommentRequest represents a request to find comments by user id.
 	UserIDCommentRequest struct {
 		// required: true
-		ID int `json:"-"`
+		ID int `json:"-" validate:"positive"`
 	}
 
 	// PurchaseIDCommentRequest represents a request to find comments by purchase id.
 	PurchaseIDCommentRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
+	}
+
+	// FileIDCommentRequest represents a request to find comments by file id.
+	FileIDCommentRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
 	}
 
 	// UserPurchaseIDCommentRequest represents a request to find comments by purchase and user ids.
 	UserPurchaseIDCommentRequest struct {
 		// required: true
-		UserID     int    `json:"-"`
-		PurchaseID string `json:"-"`
+		UserID     int    `json:"-" validate:"positive"`
+		PurchaseID string `json:"-" validate:"objectid"`
 	}
 
 	// TextCommentRequest represents a request to find comments by text.
 	TextCommentRequest struct {
 		// required: true
-		Text string `json:"text"`
+		Text     string `json:"text" validate:"required"`
+		Language string `json:"language"`
 	}
 
 	// PeriodCommentRequest represents a request to find comments by date period.
 	PeriodCommentRequest struct {
 		// required: true
-		Start time.Time `json:"start"`
+		Start time.Time `json:"start" validate:"required"`
+		// required: true
+		End time.Time `json:"end" validate:"required,gtefield=Start"`
+	}
+
+	// FlagCommentRequest represents a request to flag comment.
+	FlagCommentRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
 		// required: true
-		End time.Time `json:"end"`
+		UserID int `json:"-" validate:"positive"`
+	}
+
+	// ReviewCommentRequest represents a request to set moderation state of comment.
+	ReviewCommentRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
+		// required: true
+		Status string `json:"status" validate:"oneof=approved rejected"`
 	}
 )
 
 type (
 	// CreateFileRequest represents a request to create file.
+	// Files are created by the authenticated user, author id is taken from the body only on import.
 	CreateFileRequest struct {
 		// required: true
-		Name string `json:"name"`
+		Name string `json:"name" validate:"required"`
 		// required: true
-		Description string `json:"description"`
+		Description string `json:"description" validate:"required"`
 		// required: true
-		Size int `json:"size"`
+		Actual bool `json:"actual"`
 		// required: true
-		Path string `json:"path"`
+		AuthorID int `json:"authorID" validate:"positive"`
+	}
+
+	// ImportFileRequest represents a request to import file with its original dates.
+	ImportFileRequest struct {
+		CreateFileRequest
 		// required: true
-		AddDate time.Time `json:"addDate"`
+		AddDate time.Time `json:"addDate" validate:"required"`
 		// required: true
-		UpdateDate time.Time `json:"updateDate"`
+		UpdateDate time.Time `json:"updateDate" validate:"required,gtefield=AddDate"`
+	}
+
+	// UpdateFileRequest represents a request of the file author to update file.
+	UpdateFileRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
+		// required: true
+		Name string `json:"name" validate:"required"`
+		// required: true
+		Description string `json:"description" validate:"required"`
 		// required: true
 		Actual bool `json:"actual"`
 		// required: true
-		AuthorID int `json:"authorID"`
+		AuthorID int `json:"-" validate:"positive"`
+		// required: true
+		Revision int `json:"-"`
 	}
 
-	// UpdateFileRequest represents a request to update file.
-	UpdateFileRequest struct {
+	// PatchFileRequest represents a request of the file author to partially update file.
+	// Nil fields are left unchanged.
+	PatchFileRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
 		// required: true
-		Name string `json:"name"`
+		AuthorID int `json:"-" validate:"positive"`
 		// required: true
-		Description string `json:"description"`
+		Revision    int     `json:"-"`
+		Name        *string `json:"name,omitempty" validate:"omitnil,required"`
+		Description *string `json:"description,omitempty" validate:"omitnil,required"`
+		Actual      *bool   `json:"actual,omitempty"`
+	}
+
+	// UploadFileRequest represents a request to upload file content.
+	UploadFileRequest struct {
 		// required: true
-		Size int `json:"size"`
+		ID string `json:"-" validate:"objectid"`
 		// required: true
-		Path string `json:"path"`
+		AuthorID int `json:"-" validate:"positive"`
 		// required: true
-		AddDate time.Time `json:"addDate"`
+		Revision int `json:"-"`
 		// required: true
-		UpdateDate time.Time `json:"updateDate"`
+		Name        string    `json:"-" validate:"required"`
+		ContentType string    `json:"-"`
+		Content     io.Reader `json:"-"`
+	}
+
+	// DownloadFileRequest represents a request to download file content.
+	DownloadFileRequest struct {
 		// required: true
-		Actual bool `json:"actual"`
+		ID string `json:"-" validate:"objectid"`
+		// required: true
+		UserID int `json:"-" validate:"positive"`
+	}
+
+	// DownloadFileVersionRequest represents a request to download content of file version.
+	DownloadFileVersionRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
+		// required: true
+		Number int `json:"-"`
+		// required: true
+		UserID int `json:"-" validate:"positive"`
+	}
+
+	// SignDownloadFileRequest represents a request to create signed download link of file.
+	SignDownloadFileRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
 		// required: true
-		AuthorID int `json:"authorID"`
+		UserID    int  `json:"-" validate:"positive"`
+		SingleUse bool `json:"singleUse"`
 	}
 
-	// DeleteFileRequest represents a request to delete file.
+	// DeleteFileRequest represents a request of the file author to delete file.
 	DeleteFileRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
+		// required: true
+		AuthorID int `json:"-" validate:"positive"`
+		// required: true
+		Revision int `json:"-"`
 	}
 
 	// IDFileRequest represents a request to find file by id.
 	IDFileRequest struct {
 		// required: true
-		ID string `json:"-"`
+		ID string `json:"-" validate:"objectid"`
 	}
 
 	// NameFileRequest represents a request to find files by name.
 	NameFileRequest struct {
 		// required: true
-		Name string `json:"-"`
+		Name string `json:"-" validate:"required"`
+	}
+
+	// TextFileRequest represents a request to find files by name and description text.
+	TextFileRequest struct {
+		// required: true
+		Text     string `json:"text" validate:"required"`
+		Language string `json:"language"`
 	}
 
 	// AuthorIDFileRequest represents a request to find files by author id.
 	AuthorIDFileRequest struct {
 		// required: true
-		ID int `json:"-"`
+		ID int `json:"-" validate:"positive"`
 	}
 
 	// AddedPeriodFileRequest represents a request to find added files by date period.
 	AddedPeriodFileRequest struct {
 		// required: true
-		Start time.Time `json:"start"`
+		Start time.Time `json:"start" validate:"required"`
 		// required: true
-		End time.Time `json:"end"`
+		End time.Time `json:"end" validate:"required,gtefield=Start"`
 	}
 
 	// UpdatedPeriodFileRequest represents a request to find updated files by date period.
 	UpdatedPeriodFileRequest struct {
 		// required: true
-		Start time.Time `json:"start"`
+		Start time.Time `json:"start" validate:"required"`
+		// required: true
+		End time.Time `json:"end" validate:"required,gtefield=Start"`
+	}
+)
+
+type (
+
+	// CreateWebhookRequest represents a request to subscribe a webhook to events.
+	// Webhooks of users get events of their files, global webhooks of admins get all events.
+	CreateWebhookRequest struct {
+		UserID int `json:"-" validate:"positive"`
+		// required: true
+		URL string `json:"url" validate:"required,url"`
+		// required: true
+		Events []string `json:"events" validate:"required"`
+		// required: true
+		Secret string `json:"secret" validate:"required"`
+		Global bool   `json:"-"`
+	}
+
+	// UpdateWebhookRequest represents a request to update webhook subscription.
+	UpdateWebhookRequest struct {
+		// required: true
+		ID     string `json:"-" validate:"objectid"`
+		UserID int    `json:"-" validate:"positive"`
+		// required: true
+		URL string `json:"url" validate:"required,url"`
+		// required: true
+		Events []string `json:"events" validate:"required"`
+		// Secret is kept if it is empty.
+		Secret string `json:"secret"`
+		Active bool   `json:"active"`
+	}
+
+	// IDWebhookRequest represents a request to find or delete webhook of the user by id.
+	IDWebhookRequest struct {
+		// required: true
+		ID     string `json:"-" validate:"objectid"`
+		UserID int    `json:"-" validate:"positive"`
+	}
+
+	// UserIDWebhookRequest represents a request to find webhooks of the user.
+	UserIDWebhookRequest struct {
+		UserID int `json:"-" validate:"positive"`
+	}
+
+	// DeliveriesWebhookRequest represents a request to find deliveries of webhook of the user.
+	DeliveriesWebhookRequest struct {
+		// required: true
+		ID     string `json:"-" validate:"objectid"`
+		UserID int    `json:"-" validate:"positive"`
+		Status string `json:"-" validate:"omitempty,oneof=pending delivered dead"`
+	}
+
+	// RedeliverWebhookRequest represents a request to deliver a delivery of webhook of the user again.
+	RedeliverWebhookRequest struct {
+		// required: true
+		ID string `json:"-" validate:"objectid"`
 		// required: true
-		End time.Time `json:"end"`
+		DeliveryID string `json:"-" validate:"objectid"`
+		UserID     int    `json:"-" validate:"positive"`
 	}
 )
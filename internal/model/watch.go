@@ -0,0 +1,6 @@
+package model
+
+import "github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+
+// ResumeToken represents a model of the last change stream position of a watcher.
+type ResumeToken mongo.ResumeToken
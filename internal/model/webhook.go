@@ -0,0 +1,177 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// DeliveryPending is a status of a delivery which is due to be attempted.
+	DeliveryPending = "pending"
+	// DeliveryDelivered is a status of a delivery accepted by the receiver.
+	DeliveryDelivered = "delivered"
+	// DeliveryDead is a status of a delivery which ran out of attempts. Dead deliveries are kept until redelivered.
+	DeliveryDead = "dead"
+)
+
+// EventTypes lists event types which webhooks subscribe to.
+var EventTypes = []string{EventPurchaseCreated, EventPurchaseRefunded, EventCommentPosted, EventFileUpdated}
+
+// Webhooks represents a slice of a webhook model.
+type Webhooks []Webhook
+
+// Webhook represents a webhook subscription model.
+type Webhook mongo.Webhook
+
+// WebhookDTO represents dto of a webhook subscription model. Secret is never returned to clients.
+type WebhookDTO struct {
+	ID      string    `json:"id,omitempty"`
+	UserID  int       `json:"userID"`
+	URL     string    `json:"url"`
+	Events  []string  `json:"events"`
+	Secret  string    `json:"-"`
+	Global  bool      `json:"global"`
+	Active  bool      `json:"active"`
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// Subscribed reports whether the webhook is subscribed to the event type.
+func (w WebhookDTO) Subscribed(eventType string) bool {
+	for _, event := range w.Events {
+		if event == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Entity converts WebhookDTO to Webhook.
+func (w WebhookDTO) Entity() (*Webhook, error) {
+	webhook := Webhook{
+		UserID:  w.UserID,
+		URL:     w.URL,
+		Events:  w.Events,
+		Secret:  w.Secret,
+		Global:  w.Global,
+		Active:  w.Active,
+		Created: w.Created,
+		Updated: w.Updated,
+	}
+	if w.ID != "" {
+		var err error
+		webhook.ID, err = primitive.ObjectIDFromHex(w.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid id")
+		}
+	}
+
+	return &webhook, nil
+}
+
+// DTO converts Webhook to WebhookDTO.
+func (w Webhook) DTO() *WebhookDTO {
+	return &WebhookDTO{
+		ID:      w.ID.Hex(),
+		UserID:  w.UserID,
+		URL:     w.URL,
+		Events:  w.Events,
+		Secret:  w.Secret,
+		Global:  w.Global,
+		Active:  w.Active,
+		Created: w.Created,
+		Updated: w.Updated,
+	}
+}
+
+// DTO converts Webhooks to a slice of WebhookDTO.
+func (w Webhooks) DTO() []WebhookDTO {
+	var webhooks []WebhookDTO
+	for _, webhook := range w {
+		webhooks = append(webhooks, *webhook.DTO())
+	}
+	return webhooks
+}
+
+// Deliveries represents a slice of a delivery model.
+type Deliveries []Delivery
+
+// Delivery represents a delivery model of an event to a webhook.
+type Delivery mongo.Delivery
+
+// DeliveryDTO represents dto of a delivery model. Body is the event as it is posted to the webhook.
+type DeliveryDTO struct {
+	ID             string          `json:"id,omitempty"`
+	WebhookID      string          `json:"webhookID"`
+	EventID        string          `json:"eventID"`
+	EventType      string          `json:"eventType"`
+	Body           json.RawMessage `json:"body"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  time.Time       `json:"nextAttemptAt"`
+	ResponseStatus int             `json:"responseStatus,omitempty"`
+	LastError      string          `json:"lastError,omitempty"`
+	Created        time.Time       `json:"created"`
+	Updated        time.Time       `json:"updated"`
+}
+
+// Entity converts DeliveryDTO to Delivery.
+func (d DeliveryDTO) Entity() (*Delivery, error) {
+	delivery := Delivery{
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Body:           string(d.Body),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		Created:        d.Created,
+		Updated:        d.Updated,
+	}
+	var err error
+	if d.ID != "" {
+		delivery.ID, err = primitive.ObjectIDFromHex(d.ID)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid id")
+		}
+	}
+	delivery.WebhookID, err = primitive.ObjectIDFromHex(d.WebhookID)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid webhook id")
+	}
+
+	return &delivery, nil
+}
+
+// DTO converts Delivery to DeliveryDTO.
+func (d Delivery) DTO() *DeliveryDTO {
+	return &DeliveryDTO{
+		ID:             d.ID.Hex(),
+		WebhookID:      d.WebhookID.Hex(),
+		EventID:        d.EventID,
+		EventType:      d.EventType,
+		Body:           json.RawMessage(d.Body),
+		Status:         d.Status,
+		Attempts:       d.Attempts,
+		NextAttemptAt:  d.NextAttemptAt,
+		ResponseStatus: d.ResponseStatus,
+		LastError:      d.LastError,
+		Created:        d.Created,
+		Updated:        d.Updated,
+	}
+}
+
+// DTO converts Deliveries to a slice of DeliveryDTO.
+func (d Deliveries) DTO() []DeliveryDTO {
+	var deliveries []DeliveryDTO
+	for _, delivery := range d {
+		deliveries = append(deliveries, *delivery.DTO())
+	}
+	return deliveries
+}
@@ -0,0 +1,147 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Dispatcher publishes events of the outbox in order of their occurrence.
+// Delivery is at least once: an event is published again if the dispatcher stops before it is marked as dispatched.
+type Dispatcher struct {
+	outbox    repository.Outbox
+	publisher Publisher
+	clock     clock.Clock
+	cfg       config.OutboxConfig
+	log       *logger.Logger
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	once    sync.Once
+	running int32
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewDispatcher is a Dispatcher constructor.
+func NewDispatcher(outbox repository.Outbox, publisher Publisher, clock clock.Clock, cfg config.OutboxConfig, log *logger.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		outbox:    outbox,
+		publisher: publisher,
+		clock:     clock,
+		cfg:       cfg,
+		log:       log,
+		ctx:       ctx,
+		cancel:    cancel,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+}
+
+// Run dispatches pending events every poll interval until the dispatcher is stopped.
+func (d *Dispatcher) Run() error {
+	atomic.StoreInt32(&d.running, 1)
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.dispatchPending()
+
+		select {
+		case <-d.stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops the dispatcher after the event in flight is handled.
+// The event is abandoned if it isn't handled before the context is done.
+func (d *Dispatcher) Stop(ctx context.Context) error {
+	d.once.Do(func() { close(d.stop) })
+	defer d.cancel()
+	if atomic.LoadInt32(&d.running) == 0 {
+		return nil
+	}
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// dispatchPending dispatches events until none is due or the dispatcher is stopped.
+func (d *Dispatcher) dispatchPending() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		ok, err := d.Dispatch(d.ctx)
+		if err != nil {
+			d.log.Error("couldn't dispatch event", "error", err)
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// Dispatch claims the oldest due event and publishes it. It reports whether any event was due.
+// A failed event is scheduled for retry with exponential backoff, which isn't an error of Dispatch.
+func (d *Dispatcher) Dispatch(ctx context.Context) (bool, error) {
+	event, err := d.outbox.Claim(ctx, d.clock.Now(), d.cfg.Lease)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "couldn't claim event")
+	}
+
+	if err := d.publisher.Publish(ctx, *event); err != nil {
+		next := d.clock.Now().Add(Backoff(d.cfg.Backoff, d.cfg.MaxBackoff, event.Attempts))
+		d.log.Warn("couldn't publish event", "id", event.ID, "type", event.Type,
+			"attempts", event.Attempts, "next", next, "error", err)
+
+		if _, err := d.outbox.Retry(ctx, event.ID, next, err.Error()); err != nil {
+			return true, errors.Wrap(err, "couldn't schedule retry")
+		}
+		return true, nil
+	}
+
+	if _, err := d.outbox.MarkDispatched(ctx, event.ID, d.clock.Now()); err != nil {
+		return true, errors.Wrap(err, "couldn't mark event as dispatched")
+	}
+	d.log.Debug("event dispatched", "id", event.ID, "type", event.Type)
+
+	return true, nil
+}
+
+// Backoff returns delay before the next attempt after the failed ones.
+// The delay starts at base and doubles with every failed attempt up to max.
+func Backoff(base, max time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+
+	return delay
+}
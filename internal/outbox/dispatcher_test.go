@@ -0,0 +1,164 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/service/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	testClock = clock.Fixed(time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC))
+	testCfg   = config.OutboxConfig{
+		PollInterval: time.Millisecond,
+		Lease:        30 * time.Second,
+		Backoff:      time.Second,
+		MaxBackoff:   time.Minute,
+	}
+)
+
+func TestDispatcher_Dispatch(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name    string
+		event   model.EventDTO
+		fn      func(outbox *m.Outbox, publisher *Memory, data test)
+		expOK   bool
+		expErr  error
+		expSeen int
+	}
+	now := time.Time(testClock)
+	tt := []test{
+		{
+			name: "No event",
+			fn: func(outbox *m.Outbox, publisher *Memory, data test) {
+				outbox.On("Claim", mock.Anything, now, testCfg.Lease).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Claim errors",
+			fn: func(outbox *m.Outbox, publisher *Memory, data test) {
+				outbox.On("Claim", mock.Anything, now, testCfg.Lease).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't claim event"),
+		},
+		{
+			name: "Publish errors",
+			event: model.EventDTO{
+				ID:       primitive.NewObjectID().Hex(),
+				Type:     model.EventPurchaseCreated,
+				Attempts: 3,
+			},
+			fn: func(outbox *m.Outbox, publisher *Memory, data test) {
+				outbox.On("Claim", mock.Anything, now, testCfg.Lease).
+					Return(&data.event, nil)
+				publisher.Subscribe(func(ctx context.Context, event model.EventDTO) error {
+					return errors.New("unavailable")
+				})
+				outbox.On("Retry", mock.Anything, data.event.ID, now.Add(4*time.Second), "unavailable").
+					Return(data.event.ID, nil)
+			},
+			expOK: true,
+		},
+		{
+			name: "All ok",
+			event: model.EventDTO{
+				ID:       primitive.NewObjectID().Hex(),
+				Type:     model.EventPurchaseCreated,
+				Attempts: 1,
+			},
+			fn: func(outbox *m.Outbox, publisher *Memory, data test) {
+				outbox.On("Claim", mock.Anything, now, testCfg.Lease).
+					Return(&data.event, nil)
+				outbox.On("MarkDispatched", mock.Anything, data.event.ID, now).
+					Return(data.event.ID, nil)
+			},
+			expOK:   true,
+			expSeen: 1,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			outbox := new(m.Outbox)
+			publisher := NewMemory()
+			var seen int
+			publisher.Subscribe(func(ctx context.Context, event model.EventDTO) error {
+				seen++
+				return nil
+			})
+			if tc.fn != nil {
+				tc.fn(outbox, publisher, tc)
+			}
+			dispatcher := NewDispatcher(outbox, publisher, testClock, testCfg, logger.Nop())
+
+			ok, err := dispatcher.Dispatch(context.Background())
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expOK, ok)
+			outbox.AssertExpectations(t)
+			if tc.expSeen != 0 {
+				assert.Equal(tc.expSeen, seen)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	assert := testAssert.New(t)
+
+	assert.Equal(time.Second, Backoff(time.Second, time.Minute, 1))
+	assert.Equal(2*time.Second, Backoff(time.Second, time.Minute, 2))
+	assert.Equal(32*time.Second, Backoff(time.Second, time.Minute, 6))
+	assert.Equal(time.Minute, Backoff(time.Second, time.Minute, 7))
+	assert.Equal(time.Minute, Backoff(time.Second, time.Minute, 100))
+}
+
+func TestDispatcher_Run(t *testing.T) {
+	assert := testAssert.New(t)
+
+	event := model.EventDTO{ID: primitive.NewObjectID().Hex(), Type: model.EventCommentPosted}
+	outbox := new(m.Outbox)
+	outbox.On("Claim", mock.Anything, mock.Anything, testCfg.Lease).
+		Return(&event, nil).Once()
+	outbox.On("Claim", mock.Anything, mock.Anything, testCfg.Lease).
+		Return(nil, mongo.ErrNoDocuments)
+	outbox.On("MarkDispatched", mock.Anything, event.ID, mock.Anything).
+		Return(event.ID, nil)
+
+	published := make(chan model.EventDTO, 1)
+	publisher := NewMemory()
+	publisher.Subscribe(func(ctx context.Context, event model.EventDTO) error {
+		published <- event
+		return nil
+	})
+
+	dispatcher := NewDispatcher(outbox, publisher, testClock, testCfg, logger.Nop())
+	done := make(chan error, 1)
+	go func() { done <- dispatcher.Run() }()
+
+	select {
+	case got := <-published:
+		assert.Equal(event, got)
+	case <-time.After(time.Second):
+		t.Fatal("event isn't published")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(dispatcher.Stop(ctx))
+	assert.NoError(<-done)
+}
@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+)
+
+// Handler consumes a domain event.
+type Handler func(ctx context.Context, event model.EventDTO) error
+
+// Memory is a Publisher which passes events to in-process handlers.
+type Memory struct {
+	mu       sync.RWMutex
+	handlers []Handler
+}
+
+// NewMemory is a Memory constructor.
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Subscribe adds a handler of all events.
+func (m *Memory) Subscribe(handler Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.handlers = append(m.handlers, handler)
+}
+
+// Publish passes the event to handlers in order of subscription. It stops on the first error,
+// so the event is published to all handlers again on retry.
+func (m *Memory) Publish(ctx context.Context, event model.EventDTO) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, handler := range m.handlers {
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// MemoryPublisher is a name of the in-process publisher.
+	MemoryPublisher = "memory"
+	// WebhookPublisher is a name of the publisher which posts events to a URL.
+	WebhookPublisher = "webhook"
+)
+
+// Publisher delivers domain events to their consumers.
+// An event may be published more than once, so consumers must handle it idempotently by its id.
+type Publisher interface {
+	Publish(ctx context.Context, event model.EventDTO) error
+}
+
+// New creates a Publisher chosen by config.
+func New(cfg config.OutboxConfig) (Publisher, error) {
+	switch cfg.Publisher {
+	case MemoryPublisher:
+		return NewMemory(), nil
+	case WebhookPublisher:
+		return NewWebhook(cfg.WebhookURL, cfg.Timeout), nil
+	default:
+		return nil, errors.Errorf("unknown publisher %q", cfg.Publisher)
+	}
+}
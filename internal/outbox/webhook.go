@@ -0,0 +1,62 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/pkg/errors"
+)
+
+const (
+	// EventTypeHeader is a header of a webhook request with the event type.
+	EventTypeHeader = "X-Event-Type"
+	// EventIDHeader is a header of a webhook request with the event id, which consumers deduplicate deliveries by.
+	EventIDHeader = "X-Event-ID"
+)
+
+// Webhook is a Publisher which posts events as JSON to a URL.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+// NewWebhook is a Webhook constructor. Each request is limited by timeout.
+func NewWebhook(url string, timeout time.Duration) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// Publish posts the event. Any response but 2xx is an error.
+func (w *Webhook) Publish(ctx context.Context, event model.EventDTO) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode event")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "couldn't create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventTypeHeader, event.Type)
+	req.Header.Set(EventIDHeader, event.ID)
+
+	res, err := w.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "couldn't post event")
+	}
+	defer res.Body.Close()
+	// The body is drained, so the connection is reused.
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return errors.Errorf("webhook responded with %d", res.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWebhook_Publish(t *testing.T) {
+	assert := testAssert.New(t)
+
+	event := model.EventDTO{
+		ID:          primitive.NewObjectID().Hex(),
+		Type:        model.EventFileUpdated,
+		AggregateID: primitive.NewObjectID().Hex(),
+		Payload:     json.RawMessage(`{"number":2}`),
+		OccurredAt:  time.Time(testClock),
+	}
+
+	type test struct {
+		name   string
+		status int
+		expErr string
+	}
+	tt := []test{
+		{
+			name:   "Not 2xx",
+			status: http.StatusServiceUnavailable,
+			expErr: "webhook responded with 503",
+		},
+		{
+			name:   "All ok",
+			status: http.StatusNoContent,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got model.EventDTO
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(http.MethodPost, r.Method)
+				assert.Equal(event.Type, r.Header.Get(EventTypeHeader))
+				assert.Equal(event.ID, r.Header.Get(EventIDHeader))
+				assert.NoError(json.NewDecoder(r.Body).Decode(&got))
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			err := NewWebhook(srv.URL, time.Second).Publish(context.Background(), event)
+			if tc.expErr != "" {
+				require.Error(t, err)
+				assert.Equal(tc.expErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(event, got)
+		})
+	}
+}
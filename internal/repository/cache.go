@@ -0,0 +1,117 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/cache"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Cache returns repositories which find purchases and files by id through the caches.
+// Own writes delete the documents from the caches, writes of other instances have to be invalidated
+// by the caller, e.g. by a change stream watcher. Reads in a transaction bypass the caches,
+// so they see writes of the transaction and don't cache them before commit.
+func Cache(repos *Repositories, purchases, files *cache.Cache) *Repositories {
+	cached := *repos
+	cached.Purchase = cachedPurchase{repos.Purchase, purchases}
+	cached.File = cachedFile{repos.File, files}
+
+	return &cached
+}
+
+type cachedPurchase struct {
+	Purchase
+	cache *cache.Cache
+}
+
+// Delete deletes the purchase and its cached copy.
+func (p cachedPurchase) Delete(ctx context.Context, id string) (string, error) {
+	defer p.cache.Delete(id)
+	return p.Purchase.Delete(ctx, id)
+}
+
+// DeleteByFileID deletes purchases of the file and purges the cache.
+func (p cachedPurchase) DeleteByFileID(ctx context.Context, id string) (int, error) {
+	defer p.cache.Purge()
+	return p.Purchase.DeleteByFileID(ctx, id)
+}
+
+// FindByID finds the purchase in the cache or in the repository.
+func (p cachedPurchase) FindByID(ctx context.Context, id string) (*model.PurchaseDTO, error) {
+	if mongo.SessionFromContext(ctx) != nil {
+		return p.Purchase.FindByID(ctx, id)
+	}
+	if purchase, ok := p.cache.Get(id); ok {
+		res := purchase.(model.PurchaseDTO)
+		return &res, nil
+	}
+
+	res, err := p.Purchase.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	p.cache.Set(id, *res)
+
+	return res, nil
+}
+
+type cachedFile struct {
+	File
+	cache *cache.Cache
+}
+
+// Update updates the file and deletes its cached copy.
+func (f cachedFile) Update(ctx context.Context, id string, file model.FileDTO) (string, error) {
+	defer f.cache.Delete(id)
+	return f.File.Update(ctx, id, file)
+}
+
+// Patch patches the file and deletes its cached copy.
+func (f cachedFile) Patch(ctx context.Context, id string, patch model.FilePatch) (string, error) {
+	defer f.cache.Delete(id)
+	return f.File.Patch(ctx, id, patch)
+}
+
+// SetVersion sets version of the file and deletes its cached copy.
+func (f cachedFile) SetVersion(ctx context.Context, id string, version model.FileVersionDTO) (string, error) {
+	defer f.cache.Delete(id)
+	return f.File.SetVersion(ctx, id, version)
+}
+
+// UpdateRating updates rating of the file and deletes its cached copy.
+func (f cachedFile) UpdateRating(ctx context.Context, id string, sum, count int) (string, error) {
+	defer f.cache.Delete(id)
+	return f.File.UpdateRating(ctx, id, sum, count)
+}
+
+// Delete deletes the file and its cached copy.
+func (f cachedFile) Delete(ctx context.Context, id string, revision int) (string, error) {
+	defer f.cache.Delete(id)
+	return f.File.Delete(ctx, id, revision)
+}
+
+// DeleteByAuthorID deletes files of the author and purges the cache.
+func (f cachedFile) DeleteByAuthorID(ctx context.Context, id int) (int, error) {
+	defer f.cache.Purge()
+	return f.File.DeleteByAuthorID(ctx, id)
+}
+
+// FindByID finds the file in the cache or in the repository.
+func (f cachedFile) FindByID(ctx context.Context, id string) (*model.FileDTO, error) {
+	if mongo.SessionFromContext(ctx) != nil {
+		return f.File.FindByID(ctx, id)
+	}
+	if file, ok := f.cache.Get(id); ok {
+		res := file.(model.FileDTO)
+		return &res, nil
+	}
+
+	res, err := f.File.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	f.cache.Set(id, *res)
+
+	return res, nil
+}
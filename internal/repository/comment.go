@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -12,29 +14,73 @@ import (
 	"go.mongodb.org/mongo-driver/x/bsonx"
 )
 
+// errEditTimeRequired is returned when the comment is updated without edit time.
+var errEditTimeRequired = errors.New("edit time is required")
+
+// published matches comments visible to everyone, including ones created before moderation.
+var published = bson.M{"$nin": []string{model.CommentPending, model.CommentRejected}}
+
 // CommentRepo is a purchase repository.
 type CommentRepo struct {
 	collection *mongo.Collection
+	revisions  *mongo.Collection
 }
 
 // NewCommentRepo is a CommentRepo constructor.
-func NewCommentRepo(db *mongo.Database) *CommentRepo {
-	c := db.Collection("purchase")
+func NewCommentRepo(db *mongo.Database, log *logger.Logger) *CommentRepo {
+	c := db.Collection("comment")
 	indexes := []mongo.IndexModel{
 		{
 			Keys: bson.D{{
-				Key:   "_id",
+				Key:   "text",
+				Value: bsonx.String("text"),
+			}},
+			Options: options.Index().
+				SetName("text").
+				SetDefaultLanguage(defaultSearchLanguage),
+		},
+		{
+			Keys: bson.D{{
+				Key:   "parentID",
 				Value: bsonx.Int64(1),
 			}},
-			Options: options.Index().SetName("userID"),
+			Options: options.Index().SetName("parentID"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "purchaseID", Value: bsonx.Int64(1)},
+				{Key: "date", Value: bsonx.Int64(1)},
+			},
+			Options: options.Index().SetName("purchaseID"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: bsonx.Int64(1)},
+				{Key: "date", Value: bsonx.Int64(1)},
+			},
+			Options: options.Index().SetName("status"),
 		},
 	}
 	_, err := c.Indexes().CreateMany(context.Background(), indexes)
 	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
+		return nil
+	}
+
+	r := db.Collection("comment_revision")
+	_, err = r.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.D{
+			{Key: "commentID", Value: bsonx.Int64(1)},
+			{Key: "replacedAt", Value: bsonx.Int64(-1)},
+		},
+		Options: options.Index().SetName("commentID"),
+	})
+	if err != nil {
+		log.Error("couldn't create indexes", "collection", r.Name(), "error", err)
 		return nil
 	}
 
-	return &CommentRepo{collection: c}
+	return &CommentRepo{collection: c, revisions: r}
 }
 
 // Create creates purchase and returns userID.
@@ -52,26 +98,102 @@ func (c CommentRepo) Create(context context.Context, comment model.CommentDTO) (
 	return res.InsertedID.(primitive.ObjectID).Hex(), nil
 }
 
-// Update updates purchase and returns userID.
+// Update updates text, rating and moderation state of the comment revision,
+// saves its previous version to the revision history and returns id.
+// Edit time is taken from the comment and is required.
+// Update of a changed comment returns ErrModified.
 func (c CommentRepo) Update(context context.Context, id string, comment model.CommentDTO) (string, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return "", err
 	}
 
-	commentEntity, err := comment.Entity()
+	if comment.EditedAt == nil {
+		return "", errEditTimeRequired
+	}
+	editedAt := *comment.EditedAt
+	query := revisionQuery(objID, comment.Revision)
+	update := bson.M{
+		"$set": bson.M{
+			"text":     comment.Text,
+			"rating":   comment.Rating,
+			"status":   comment.Status,
+			"editedAt": editedAt,
+		},
+		"$inc": bson.M{"revision": 1},
+	}
+	var oldComment model.Comment
+	err = c.collection.FindOneAndUpdate(context, query, update).Decode(&oldComment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", notMatched(context, c.collection, objID)
+	}
 	if err != nil {
 		return "", err
 	}
 
-	query := bson.M{
-		"_id": objID,
+	err = c.saveRevision(context, oldComment, editedAt)
+	if err != nil {
+		return "", err
 	}
+
+	return oldComment.ID.Hex(), nil
+}
+
+// Delete deletes the comment revision deleted at the time and returns id.
+// The revision history is kept along with the deleted version of the comment.
+// Deletion of a changed comment returns ErrModified.
+func (c CommentRepo) Delete(context context.Context, id string, revision int, at time.Time) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	query := revisionQuery(objID, revision)
+	var delComment model.Comment
+	err = c.collection.FindOneAndDelete(context, query).Decode(&delComment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", notMatched(context, c.collection, objID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = c.saveRevision(context, delComment, at)
+	if err != nil {
+		return "", err
+	}
+
+	return delComment.ID.Hex(), nil
+}
+
+// Tombstone clears text and rating of the comment revision deleted at the time keeping it in the thread and returns id.
+// The original text and rating are saved to the revision history before being cleared.
+// Tombstone of a changed comment returns ErrModified.
+func (c CommentRepo) Tombstone(context context.Context, id string, revision int, at time.Time) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	query := revisionQuery(objID, revision)
 	update := bson.M{
-		"$set": commentEntity,
+		"$set": bson.M{
+			"deleted": true,
+			"text":    "",
+			"rating":  0,
+		},
+		"$inc": bson.M{"revision": 1},
 	}
 	var updateComment model.Comment
 	err = c.collection.FindOneAndUpdate(context, query, update).Decode(&updateComment)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", notMatched(context, c.collection, objID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	err = c.saveRevision(context, updateComment, at)
 	if err != nil {
 		return "", err
 	}
@@ -79,58 +201,141 @@ func (c CommentRepo) Update(context context.Context, id string, comment model.Co
 	return updateComment.ID.Hex(), nil
 }
 
-// Delete deletes purchase and returns userID.
-func (c CommentRepo) Delete(context context.Context, id string) (string, error) {
-	opts := options.FindOneAndDelete().SetProjection(bson.D{{"_id", 1}})
+// saveRevision saves version of the comment replaced at the time to the revision history.
+func (c CommentRepo) saveRevision(context context.Context, comment model.Comment, at time.Time) error {
+	revision := model.CommentRevision{
+		CommentID:  comment.ID,
+		Text:       comment.Text,
+		Rating:     comment.Rating,
+		Status:     comment.Status,
+		Date:       comment.Date,
+		ReplacedAt: at,
+	}
+	if comment.EditedAt != nil {
+		revision.Date = *comment.EditedAt
+	}
+	_, err := c.revisions.InsertOne(context, revision)
+
+	return err
+}
+
+// Flag adds user to the comment flaggers and marks published comment as flagged and returns id.
+func (c CommentRepo) Flag(context context.Context, id string, userID int) (string, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return "", err
 	}
 
 	query := bson.M{
-		"_id": objID,
+		"_id":    objID,
+		"status": published,
 	}
-	var delComment model.Comment
-	err = c.collection.FindOneAndDelete(context, query, opts).Decode(&delComment)
+	update := bson.A{
+		bson.M{"$set": bson.M{
+			"status":    model.CommentFlagged,
+			"flaggedBy": bson.M{"$setUnion": bson.A{bson.M{"$ifNull": bson.A{"$flaggedBy", bson.A{}}}, bson.A{userID}}},
+			"revision":  bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$revision", 0}}, 1}},
+		}},
+	}
+	var updateComment model.Comment
+	err = c.collection.FindOneAndUpdate(context, query, update).Decode(&updateComment)
 	if err != nil {
 		return "", err
 	}
 
-	return delComment.ID.Hex(), nil
+	return updateComment.ID.Hex(), nil
 }
 
-// DeleteByPurchaseID deletes purchase by purchase userID and returns purchase userID.
-func (c CommentRepo) DeleteByPurchaseID(context context.Context, id string) (string, error) {
-	opts := options.FindOneAndDelete().SetProjection(bson.D{{"purchaseID", 1}})
+// UpdateStatus sets moderation state of the comment and returns id.
+// Approving the comment clears its flags.
+func (c CommentRepo) UpdateStatus(context context.Context, id, status string) (string, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return "", err
 	}
 
 	query := bson.M{
-		"purchaseID": objID,
+		"_id": objID,
 	}
-	var delComment model.Comment
-	err = c.collection.FindOneAndDelete(context, query, opts).Decode(&delComment)
+	update := bson.M{
+		"$set": bson.M{"status": status},
+		"$inc": bson.M{"revision": 1},
+	}
+	if status == model.CommentApproved {
+		update["$unset"] = bson.M{"flaggedBy": ""}
+	}
+	var updateComment model.Comment
+	err = c.collection.FindOneAndUpdate(context, query, update).Decode(&updateComment)
 	if err != nil {
 		return "", err
 	}
 
-	return delComment.PurchaseID.Hex(), nil
+	return updateComment.ID.Hex(), nil
 }
 
-// FindByID finds purchase by userID.
+// DeleteByPurchaseID deletes all comments of the purchase and returns count of deleted comments.
+func (c CommentRepo) DeleteByPurchaseID(context context.Context, id string) (int, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+
+	query := bson.M{
+		"purchaseID": objID,
+	}
+	res, err := c.collection.DeleteMany(context, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.DeletedCount), nil
+}
+
+// FindByID finds published comment by id.
 func (c CommentRepo) FindByID(context context.Context, id string) (*model.CommentDTO, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, err
 	}
 
-	query := bson.M{
+	return c.findOne(context, bson.M{
+		"_id":    objID,
+		"status": published,
+	})
+}
+
+// FindVisibleByID finds comment by id which is published or written by the user.
+func (c CommentRepo) FindVisibleByID(context context.Context, id string, userID int) (*model.CommentDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.findOne(context, bson.M{
 		"_id": objID,
+		"$or": bson.A{
+			bson.M{"status": published},
+			bson.M{"userID": userID},
+		},
+	})
+}
+
+// FindAnyByID finds comment by id in any moderation state.
+// It is meant for moderators and for writes checking the comment owner.
+func (c CommentRepo) FindAnyByID(context context.Context, id string) (*model.CommentDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
 	}
+
+	return c.findOne(context, bson.M{
+		"_id": objID,
+	})
+}
+
+func (c CommentRepo) findOne(context context.Context, query bson.M) (*model.CommentDTO, error) {
 	var comment model.Comment
-	err = c.collection.FindOne(context, query).Decode(&comment)
+	err := c.collection.FindOne(context, query).Decode(&comment)
 	if err != nil {
 		return nil, err
 	}
@@ -138,10 +343,11 @@ func (c CommentRepo) FindByID(context context.Context, id string) (*model.Commen
 	return comment.DTO(), nil
 }
 
-// FindAllByUserID finds purchases by user userID.
+// FindAllByUserID finds published comments by user id.
 func (c CommentRepo) FindAllByUserID(context context.Context, id int) ([]model.CommentDTO, error) {
 	query := bson.M{
 		"userID": id,
+		"status": published,
 	}
 	var comments model.Comments
 	cursor, err := c.collection.Find(context, query)
@@ -166,6 +372,7 @@ func (c CommentRepo) FindByPurchaseID(context context.Context, id string) ([]mod
 
 	query := bson.M{
 		"purchaseID": objID,
+		"status":     published,
 	}
 	var comments model.Comments
 	cursor, err := c.collection.Find(context, query)
@@ -181,7 +388,100 @@ func (c CommentRepo) FindByPurchaseID(context context.Context, id string) ([]mod
 	return comments.DTO(), nil
 }
 
-// FindByUserIDAndPurchaseID finds purchases by purchase and user userID.
+// FindAnyByPurchaseID finds comments of the purchase in any moderation state.
+func (c CommentRepo) FindAnyByPurchaseID(context context.Context, id string) ([]model.CommentDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{
+		"purchaseID": objID,
+	}
+	var comments model.Comments
+	cursor, err := c.collection.Find(context, query)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &comments)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments.DTO(), nil
+}
+
+// FindByPurchaseIDs finds comments by purchase ids sorted by date.
+func (c CommentRepo) FindByPurchaseIDs(context context.Context, ids []string) ([]model.CommentDTO, error) {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		objID, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, err
+		}
+		objIDs = append(objIDs, objID)
+	}
+
+	opts := options.Find().SetSort(bson.M{"date": 1})
+	query := bson.M{
+		"purchaseID": bson.M{"$in": objIDs},
+		"status":     published,
+	}
+	var comments model.Comments
+	cursor, err := c.collection.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &comments)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments.DTO(), nil
+}
+
+// FindRevisions finds previous versions of the comment, newest first.
+func (c CommentRepo) FindRevisions(context context.Context, id string) ([]model.CommentRevisionDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"replacedAt": -1})
+	query := bson.M{
+		"commentID": objID,
+	}
+	var revisions model.CommentRevisions
+	cursor, err := c.revisions.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &revisions)
+	if err != nil {
+		return nil, err
+	}
+
+	return revisions.DTO(), nil
+}
+
+// CountReplies counts replies to the comment.
+func (c CommentRepo) CountReplies(context context.Context, id string) (int64, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+
+	query := bson.M{
+		"parentID": objID,
+	}
+
+	return c.collection.CountDocuments(context, query)
+}
+
+// FindByUserIDAndPurchaseID finds published comments by purchase and user id.
 func (c CommentRepo) FindByUserIDAndPurchaseID(context context.Context, userID int, purchaseID string) ([]model.CommentDTO, error) {
 	objPurchaseID, err := primitive.ObjectIDFromHex(purchaseID)
 	if err != nil {
@@ -191,6 +491,7 @@ func (c CommentRepo) FindByUserIDAndPurchaseID(context context.Context, userID i
 	query := bson.M{
 		"userID":     userID,
 		"purchaseID": objPurchaseID,
+		"status":     published,
 	}
 	var comments model.Comments
 	cursor, err := c.collection.Find(context, query)
@@ -206,9 +507,11 @@ func (c CommentRepo) FindByUserIDAndPurchaseID(context context.Context, userID i
 	return comments.DTO(), nil
 }
 
-// FindAll finds purchases.
+// FindAll finds published comments.
 func (c CommentRepo) FindAll(context context.Context) ([]model.CommentDTO, error) {
-	query := bson.M{}
+	query := bson.M{
+		"status": published,
+	}
 	var comments model.Comments
 	cursor, err := c.collection.Find(context, query)
 	if err != nil {
@@ -223,13 +526,57 @@ func (c CommentRepo) FindAll(context context.Context) ([]model.CommentDTO, error
 	return comments.DTO(), nil
 }
 
-// FindByText finds purchases by text.
-func (c CommentRepo) FindByText(context context.Context, text string) ([]model.CommentDTO, error) {
+// FindByStatus finds comments in given moderation states sorted by date.
+func (c CommentRepo) FindByStatus(context context.Context, statuses []string) ([]model.CommentDTO, error) {
+	opts := options.Find().SetSort(bson.M{"date": 1})
 	query := bson.M{
-		"text": bson.M{"$regex": text},
+		"status": bson.M{"$in": statuses},
 	}
 	var comments model.Comments
-	cursor, err := c.collection.Find(context, query)
+	cursor, err := c.collection.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &comments)
+	if err != nil {
+		return nil, err
+	}
+
+	return comments.DTO(), nil
+}
+
+// FindByText finds comments by text ranked by relevance score.
+// If full-text search finds nothing it falls back to a case-insensitive prefix search.
+func (c CommentRepo) FindByText(context context.Context, text, language string) ([]model.CommentDTO, error) {
+	opts := options.Find().
+		SetProjection(scoreProjection).
+		SetSort(scoreProjection)
+	query := bson.M{
+		"$text":  textSearch(text, language),
+		"status": published,
+	}
+	var scored scoredComments
+	cursor, err := c.collection.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &scored)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scored) != 0 {
+		return scored.DTO(), nil
+	}
+
+	query = bson.M{
+		"text":   prefixRegex(text),
+		"status": published,
+	}
+	var comments model.Comments
+	cursor, err = c.collection.Find(context, query)
 	if err != nil {
 		return nil, err
 	}
@@ -245,7 +592,8 @@ func (c CommentRepo) FindByText(context context.Context, text string) ([]model.C
 // FindByPeriod finds purchases by date period.
 func (c CommentRepo) FindByPeriod(context context.Context, start, end time.Time) ([]model.CommentDTO, error) {
 	query := bson.M{
-		"date": bson.M{"$gte": start, "$lte": end},
+		"date":   bson.M{"$gte": start, "$lte": end},
+		"status": published,
 	}
 	var comments model.Comments
 	cursor, err := c.collection.Find(context, query)
@@ -9,6 +9,7 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	assertTest "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,7 +28,7 @@ func Connect2CommentMongo() (context.Context, *CommentRepo, error) {
 		return nil, nil, err
 	}
 
-	return ctx, NewCommentRepo(db), nil
+	return ctx, NewCommentRepo(db, logger.Nop()), nil
 }
 func TestCommentRepo_Create(t *testing.T) {
 	assert := assertTest.New(t)
@@ -63,6 +64,611 @@ func TestCommentRepo_Create(t *testing.T) {
 }
 
 func TestCommentRepo_Update(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name     string
+		isOk     bool
+		id       string
+		comment  model.CommentDTO
+		revision int
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:   "not correct userID",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not found",
+			id:     primitive.NewObjectID().Hex(),
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name:     "modified",
+			revision: 1,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commentID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk || tc.revision != 0 {
+				commentID, err = repo.Create(ctx, tc.comment)
+			}
+			editedAt := time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC)
+			update := tc.comment
+			update.Text = "updated"
+			update.Revision = tc.revision
+			update.EditedAt = &editedAt
+			id, err := repo.Update(ctx, commentID, update)
+			assert.Equal(tc.expErr, err)
+			if tc.isOk {
+				assert.Equal(commentID, id)
+				comment, err := repo.FindByID(ctx, commentID)
+				assert.NoError(err)
+				assert.Equal(update.Text, comment.Text)
+				assert.Equal(tc.comment.Date, comment.Date)
+				assert.NotNil(comment.EditedAt)
+				assert.Equal(1, comment.Revision)
+				revisions, err := repo.FindRevisions(ctx, commentID)
+				assert.NoError(err)
+				assert.Len(revisions, 1)
+				assert.Equal(tc.comment.Text, revisions[0].Text)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			_, err = repo.revisions.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_Delete(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name     string
+		isOk     bool
+		id       string
+		comment  model.CommentDTO
+		revision int
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:   "not correct userID",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not found",
+			id:     primitive.NewObjectID().Hex(),
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name:     "modified",
+			revision: 1,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commentID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk || tc.revision != 0 {
+				commentID, err = repo.Create(ctx, tc.comment)
+			}
+			id, err := repo.Delete(ctx, commentID, tc.revision, time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC))
+			assert.Equal(tc.expErr, err)
+			if tc.isOk {
+				assert.Equal(commentID, id)
+				revisions, err := repo.FindRevisions(ctx, commentID)
+				assert.NoError(err)
+				assert.Len(revisions, 1)
+				assert.Equal(tc.comment.Text, revisions[0].Text)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			_, err = repo.revisions.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_Tombstone(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name     string
+		isOk     bool
+		id       string
+		comment  model.CommentDTO
+		revision int
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not found",
+			id:     primitive.NewObjectID().Hex(),
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name:     "modified",
+			revision: 1,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+				Rating:     5,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commentID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk || tc.revision != 0 {
+				commentID, err = repo.Create(ctx, tc.comment)
+				assert.NoError(err)
+			}
+			id, err := repo.Tombstone(ctx, commentID, tc.revision, time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC))
+			assert.Equal(tc.expErr, err)
+			if tc.isOk {
+				assert.Equal(commentID, id)
+				comment, err := repo.FindByID(ctx, commentID)
+				assert.NoError(err)
+				assert.True(comment.Deleted)
+				assert.Empty(comment.Text)
+				assert.Zero(comment.Rating)
+				revisions, err := repo.FindRevisions(ctx, commentID)
+				assert.NoError(err)
+				assert.Len(revisions, 1)
+				assert.Equal(tc.comment.Text, revisions[0].Text)
+				assert.Equal(tc.comment.Rating, revisions[0].Rating)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			_, err = repo.revisions.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_Flag(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name      string
+		isOk      bool
+		id        string
+		comment   model.CommentDTO
+		flaggedBy []int
+		expErr    error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not found",
+			id:     primitive.NewObjectID().Hex(),
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name: "pending",
+			isOk: true,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+				Status:     model.CommentPending,
+			},
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+				Status:     model.CommentFlagged,
+				FlaggedBy:  []int{2},
+			},
+			flaggedBy: []int{2, 3},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commentID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk {
+				commentID, err = repo.Create(ctx, tc.comment)
+				assert.NoError(err)
+			}
+			id, err := repo.Flag(ctx, commentID, 3)
+			assert.Equal(tc.expErr, err)
+			if tc.expErr == nil {
+				assert.Equal(commentID, id)
+				comment, err := repo.FindByID(ctx, commentID)
+				assert.NoError(err)
+				assert.Equal(model.CommentFlagged, comment.Status)
+				assert.ElementsMatch(tc.flaggedBy, comment.FlaggedBy)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_UpdateStatus(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name    string
+		isOk    bool
+		id      string
+		status  string
+		comment model.CommentDTO
+		expErr  error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			status: model.CommentApproved,
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not found",
+			id:     primitive.NewObjectID().Hex(),
+			status: model.CommentApproved,
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name:   "all ok",
+			isOk:   true,
+			status: model.CommentApproved,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+				Status:     model.CommentFlagged,
+				FlaggedBy:  []int{2},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commentID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk {
+				commentID, err = repo.Create(ctx, tc.comment)
+				assert.NoError(err)
+			}
+			id, err := repo.UpdateStatus(ctx, commentID, tc.status)
+			assert.Equal(tc.expErr, err)
+			if tc.isOk {
+				assert.Equal(commentID, id)
+				comment, err := repo.FindByID(ctx, commentID)
+				assert.NoError(err)
+				assert.Equal(tc.status, comment.Status)
+				assert.Empty(comment.FlaggedBy)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_FindByStatus(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name     string
+		comments []model.CommentDTO
+		exp      int
+	}
+	tt := []test{
+		{
+			name: "not found",
+			comments: []model.CommentDTO{
+				{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:       "some",
+					Status:     model.CommentApproved,
+				},
+			},
+		},
+		{
+			name: "all ok",
+			comments: []model.CommentDTO{
+				{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:       "some",
+					Status:     model.CommentApproved,
+				},
+				{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC),
+					Text:       "some",
+					Status:     model.CommentPending,
+				},
+				{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2020, time.December, 12, 23, 10, 34, 0, time.UTC),
+					Text:       "some",
+					Status:     model.CommentFlagged,
+				},
+			},
+			exp: 2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			for _, comment := range tc.comments {
+				_, err = repo.Create(ctx, comment)
+				assert.NoError(err)
+			}
+			comments, err := repo.FindByStatus(ctx, []string{model.CommentPending, model.CommentFlagged})
+			assert.NoError(err)
+			assert.Len(comments, tc.exp)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_FindRevisions(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name    string
+		isOk    bool
+		id      string
+		texts   []string
+		expErr  error
+		expText []string
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "not found",
+			id:   primitive.NewObjectID().Hex(),
+		},
+		{
+			name:    "all ok",
+			isOk:    true,
+			texts:   []string{"second", "third"},
+			expText: []string{"second", "first"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commentID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk {
+				commentID, err = repo.Create(ctx, model.CommentDTO{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:       "first",
+				})
+				assert.NoError(err)
+				for i, text := range tc.texts {
+					editedAt := time.Date(2020, time.December, 11+i, 23, 10, 34, 0, time.UTC)
+					_, err = repo.Update(ctx, commentID, model.CommentDTO{Text: text, Revision: i, EditedAt: &editedAt})
+					assert.NoError(err)
+				}
+			}
+			revisions, err := repo.FindRevisions(ctx, commentID)
+			assert.Equal(tc.expErr, err)
+			var texts []string
+			for _, revision := range revisions {
+				texts = append(texts, revision.Text)
+			}
+			assert.Equal(tc.expText, texts)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			_, err = repo.revisions.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_CountReplies(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name    string
+		isOk    bool
+		id      string
+		replies int
+		expErr  error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "no replies",
+			id:   primitive.NewObjectID().Hex(),
+		},
+		{
+			name:    "all ok",
+			isOk:    true,
+			replies: 2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			commentID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk {
+				purchaseID := primitive.NewObjectID().Hex()
+				commentID, err = repo.Create(ctx, model.CommentDTO{
+					UserID:     1,
+					PurchaseID: purchaseID,
+					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:       "some",
+				})
+				assert.NoError(err)
+				for i := 0; i < tc.replies; i++ {
+					_, err = repo.Create(ctx, model.CommentDTO{
+						UserID:     2,
+						PurchaseID: purchaseID,
+						ParentID:   commentID,
+						Date:       time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC),
+						Text:       "some reply",
+					})
+					assert.NoError(err)
+				}
+			}
+			count, err := repo.CountReplies(ctx, commentID)
+			assert.Equal(tc.expErr, err)
+			assert.Equal(int64(tc.replies), count)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_DeleteByPurchaseID(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	purchaseID := primitive.NewObjectID().Hex()
+	type test struct {
+		name     string
+		id       string
+		comments int
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:   "not correct userID",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "not found",
+			id:   primitive.NewObjectID().Hex(),
+		},
+		{
+			name:     "all ok",
+			id:       purchaseID,
+			comments: 2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 1; i <= tc.comments; i++ {
+				_, err = repo.Create(ctx, model.CommentDTO{
+					UserID:     i,
+					PurchaseID: purchaseID,
+					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:       "some",
+				})
+				assert.NoError(err)
+			}
+			other, err := repo.Create(ctx, model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+			})
+			assert.NoError(err)
+
+			count, err := repo.DeleteByPurchaseID(ctx, tc.id)
+			assert.Equal(tc.expErr, err)
+			assert.Equal(tc.comments, count)
+			_, err = repo.FindByID(ctx, other)
+			assert.NoError(err)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_FindByID(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2CommentMongo()
 	require.NoError(t, err)
@@ -70,7 +676,9 @@ func TestCommentRepo_Update(t *testing.T) {
 		name    string
 		isOk    bool
 		id      string
+		fn      func(data *test)
 		comment model.CommentDTO
+		exp     *model.CommentDTO
 		expErr  error
 	}
 	tt := []test{
@@ -86,26 +694,46 @@ func TestCommentRepo_Update(t *testing.T) {
 		{
 			name: "all ok",
 			isOk: true,
+			fn: func(data *test) {
+				data.exp = &data.comment
+			},
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+			},
+			exp: &model.CommentDTO{},
+		},
+		{
+			name: "pending",
+			isOk: true,
 			comment: model.CommentDTO{
 				UserID:     1,
 				PurchaseID: primitive.NewObjectID().Hex(),
 				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
 				Text:       "some",
+				Status:     model.CommentPending,
 			},
+			expErr: errors.New("mongo: no documents in result"),
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			commentID := tc.id
+			if tc.fn != nil {
+				tc.fn(&tc)
+			}
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
 			if tc.isOk {
 				commentID, err = repo.Create(ctx, tc.comment)
 			}
-			id, err := repo.Update(ctx, commentID, tc.comment)
+			comment, err := repo.FindByID(ctx, commentID)
 			assert.Equal(tc.expErr, err)
-			if tc.isOk {
-				assert.Equal(commentID, id)
+			if tc.isOk && tc.expErr == nil {
+				tc.exp.ID = comment.ID
+				assert.Equal(tc.exp, comment)
 			}
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
@@ -113,7 +741,72 @@ func TestCommentRepo_Update(t *testing.T) {
 	}
 }
 
-func TestCommentRepo_Delete(t *testing.T) {
+func TestCommentRepo_FindVisibleByID(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2CommentMongo()
+	require.NoError(t, err)
+	type test struct {
+		name    string
+		userID  int
+		comment model.CommentDTO
+		expErr  error
+	}
+	tt := []test{
+		{
+			name:   "published",
+			userID: 2,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+				Status:     model.CommentApproved,
+			},
+		},
+		{
+			name:   "pending of author",
+			userID: 1,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+				Status:     model.CommentPending,
+			},
+		},
+		{
+			name:   "rejected of other user",
+			userID: 2,
+			comment: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				Text:       "some",
+				Status:     model.CommentRejected,
+			},
+			expErr: errors.New("mongo: no documents in result"),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			id, err := repo.Create(ctx, tc.comment)
+			assert.NoError(err)
+
+			comment, err := repo.FindVisibleByID(ctx, id, tc.userID)
+			assert.Equal(tc.expErr, err)
+			if tc.expErr == nil {
+				tc.comment.ID = id
+				assert.Equal(&tc.comment, comment)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestCommentRepo_FindAnyByID(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2CommentMongo()
 	require.NoError(t, err)
@@ -135,13 +828,14 @@ func TestCommentRepo_Delete(t *testing.T) {
 			expErr: errors.New("mongo: no documents in result"),
 		},
 		{
-			name: "all ok",
+			name: "rejected",
 			isOk: true,
 			comment: model.CommentDTO{
 				UserID:     1,
 				PurchaseID: primitive.NewObjectID().Hex(),
 				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
 				Text:       "some",
+				Status:     model.CommentRejected,
 			},
 		},
 	}
@@ -152,11 +846,13 @@ func TestCommentRepo_Delete(t *testing.T) {
 			assert.NoError(err)
 			if tc.isOk {
 				commentID, err = repo.Create(ctx, tc.comment)
+				assert.NoError(err)
 			}
-			id, err := repo.Delete(ctx, commentID)
+			comment, err := repo.FindAnyByID(ctx, commentID)
 			assert.Equal(tc.expErr, err)
 			if tc.isOk {
-				assert.Equal(commentID, id)
+				tc.comment.ID = commentID
+				assert.Equal(&tc.comment, comment)
 			}
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
@@ -164,17 +860,18 @@ func TestCommentRepo_Delete(t *testing.T) {
 	}
 }
 
-func TestCommentRepo_DeleteByPurchaseID(t *testing.T) {
+func TestCommentRepo_FindByPurchaseID(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2CommentMongo()
 	require.NoError(t, err)
 	type test struct {
-		name    string
-		isOk    bool
-		id      string
-		fn      func(data *test)
-		comment model.CommentDTO
-		expErr  error
+		name     string
+		isOk     bool
+		id       string
+		fn       func(data *test)
+		comments []model.CommentDTO
+		exp      []model.CommentDTO
+		expErr   error
 	}
 	tt := []test{
 		{
@@ -182,22 +879,27 @@ func TestCommentRepo_DeleteByPurchaseID(t *testing.T) {
 			expErr: errors.New("the provided hex string is not a valid ObjectID"),
 		},
 		{
-			name:   "not found",
-			id:     primitive.NewObjectID().Hex(),
-			expErr: errors.New("mongo: no documents in result"),
+			name: "not found",
+			id:   primitive.NewObjectID().Hex(),
 		},
 		{
 			name: "all ok",
 			isOk: true,
-			id:   primitive.NewObjectID().Hex(),
 			fn: func(data *test) {
-				data.comment.PurchaseID = data.id
+				for i := range data.comments {
+					data.comments[i].PurchaseID = data.id
+				}
+				data.exp = data.comments
 			},
-			comment: model.CommentDTO{
-				UserID: 1,
-				Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
-				Text:   "some",
+			id: primitive.NewObjectID().Hex(),
+			comments: []model.CommentDTO{
+				{
+					UserID: 1,
+					Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:   "some",
+				},
 			},
+			exp: []model.CommentDTO{},
 		},
 	}
 	for _, tc := range tt {
@@ -208,13 +910,20 @@ func TestCommentRepo_DeleteByPurchaseID(t *testing.T) {
 			}
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
+
 			if tc.isOk {
-				_, err = repo.Create(ctx, tc.comment)
+				for _, c := range tc.comments {
+					_, err = repo.Create(ctx, c)
+				}
 			}
-			id, err := repo.DeleteByPurchaseID(ctx, purchaseID)
+			comments, err := repo.FindByPurchaseID(ctx, purchaseID)
 			assert.Equal(tc.expErr, err)
+
 			if tc.isOk {
-				assert.Equal(purchaseID, id)
+				for i := range tc.exp {
+					tc.exp[i].ID = comments[i].ID
+				}
+				assert.Equal(tc.exp, comments)
 			}
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
@@ -222,18 +931,16 @@ func TestCommentRepo_DeleteByPurchaseID(t *testing.T) {
 	}
 }
 
-func TestCommentRepo_FindByID(t *testing.T) {
+func TestCommentRepo_FindAnyByPurchaseID(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2CommentMongo()
 	require.NoError(t, err)
+	purchaseID := primitive.NewObjectID().Hex()
 	type test struct {
-		name    string
-		isOk    bool
-		id      string
-		fn      func(data *test)
-		comment model.CommentDTO
-		exp     *model.CommentDTO
-		expErr  error
+		name     string
+		id       string
+		comments []model.CommentDTO
+		expErr   error
 	}
 	tt := []test{
 		{
@@ -241,49 +948,50 @@ func TestCommentRepo_FindByID(t *testing.T) {
 			expErr: errors.New("the provided hex string is not a valid ObjectID"),
 		},
 		{
-			name:   "not found",
-			id:     primitive.NewObjectID().Hex(),
-			expErr: errors.New("mongo: no documents in result"),
+			name: "not found",
+			id:   primitive.NewObjectID().Hex(),
 		},
 		{
 			name: "all ok",
-			isOk: true,
-			fn: func(data *test) {
-				data.exp = &data.comment
-			},
-			comment: model.CommentDTO{
-				UserID:     1,
-				PurchaseID: primitive.NewObjectID().Hex(),
-				Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
-				Text:       "some",
+			id:   purchaseID,
+			comments: []model.CommentDTO{
+				{
+					UserID:     1,
+					PurchaseID: purchaseID,
+					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:       "some",
+					Rating:     4,
+				},
+				{
+					UserID:     1,
+					PurchaseID: purchaseID,
+					Date:       time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC),
+					Text:       "some hidden",
+					Rating:     5,
+					Status:     model.CommentRejected,
+				},
 			},
-			exp: &model.CommentDTO{},
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			commentID := tc.id
-			if tc.fn != nil {
-				tc.fn(&tc)
-			}
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
-			if tc.isOk {
-				commentID, err = repo.Create(ctx, tc.comment)
+			for i := range tc.comments {
+				tc.comments[i].ID, err = repo.Create(ctx, tc.comments[i])
+				assert.NoError(err)
 			}
-			comment, err := repo.FindByID(ctx, commentID)
+
+			comments, err := repo.FindAnyByPurchaseID(ctx, tc.id)
 			assert.Equal(tc.expErr, err)
-			if tc.isOk {
-				tc.exp.ID = comment.ID
-				assert.Equal(tc.exp, comment)
-			}
+			assert.ElementsMatch(tc.comments, comments)
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
 		})
 	}
 }
 
-func TestCommentRepo_FindByPurchaseID(t *testing.T) {
+func TestCommentRepo_FindByPurchaseIDs(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2CommentMongo()
 	require.NoError(t, err)
@@ -312,10 +1020,15 @@ func TestCommentRepo_FindByPurchaseID(t *testing.T) {
 				for i := range data.comments {
 					data.comments[i].PurchaseID = data.id
 				}
-				data.exp = data.comments
+				data.exp = []model.CommentDTO{data.comments[1], data.comments[0]}
 			},
 			id: primitive.NewObjectID().Hex(),
 			comments: []model.CommentDTO{
+				{
+					UserID: 1,
+					Date:   time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC),
+					Text:   "some later",
+				},
 				{
 					UserID: 1,
 					Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
@@ -339,7 +1052,7 @@ func TestCommentRepo_FindByPurchaseID(t *testing.T) {
 					_, err = repo.Create(ctx, c)
 				}
 			}
-			comments, err := repo.FindByPurchaseID(ctx, purchaseID)
+			comments, err := repo.FindByPurchaseIDs(ctx, []string{purchaseID})
 			assert.Equal(tc.expErr, err)
 
 			if tc.isOk {
@@ -379,7 +1092,7 @@ func TestCommentRepo_FindAllByUserID(t *testing.T) {
 				for i := range data.comments {
 					data.comments[i].UserID = data.id
 				}
-				data.exp = data.comments
+				data.exp = data.comments[:1]
 			},
 			id: 1,
 			comments: []model.CommentDTO{
@@ -389,6 +1102,13 @@ func TestCommentRepo_FindAllByUserID(t *testing.T) {
 					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
 					Text:       "some",
 				},
+				{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC),
+					Text:       "some hidden",
+					Status:     model.CommentPending,
+				},
 			},
 			exp: []model.CommentDTO{},
 		},
@@ -454,7 +1174,7 @@ func TestCommentRepo_FindAllByUserIDAndPurchaseID(t *testing.T) {
 					data.comments[i].UserID = data.userID
 					data.comments[i].PurchaseID = data.purchaseID
 				}
-				data.exp = data.comments
+				data.exp = data.comments[:1]
 			},
 			userID:     1,
 			purchaseID: primitive.NewObjectID().Hex(),
@@ -463,6 +1183,11 @@ func TestCommentRepo_FindAllByUserIDAndPurchaseID(t *testing.T) {
 					Date: time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
 					Text: "some",
 				},
+				{
+					Date:   time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC),
+					Text:   "some hidden",
+					Status: model.CommentPending,
+				},
 			},
 			exp: []model.CommentDTO{},
 		},
@@ -518,7 +1243,7 @@ func TestCommentRepo_FindAll(t *testing.T) {
 			name: "all ok",
 			isOk: true,
 			fn: func(data *test) {
-				data.exp = data.comments
+				data.exp = data.comments[:1]
 			},
 			comments: []model.CommentDTO{
 				{
@@ -527,6 +1252,13 @@ func TestCommentRepo_FindAll(t *testing.T) {
 					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
 					Text:       "some",
 				},
+				{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC),
+					Text:       "some hidden",
+					Status:     model.CommentPending,
+				},
 			},
 			exp: []model.CommentDTO{},
 		},
@@ -608,12 +1340,13 @@ func TestCommentRepo_FindByText(t *testing.T) {
 					_, err = repo.Create(ctx, c)
 				}
 			}
-			comments, err := repo.FindByText(ctx, tc.text)
+			comments, err := repo.FindByText(ctx, tc.text, "")
 			assert.Equal(tc.expErr, err)
 
 			if tc.isOk {
 				for i := range tc.exp {
 					tc.exp[i].ID = comments[i].ID
+					tc.exp[i].Score = comments[i].Score
 				}
 				assert.Equal(tc.exp, comments)
 			}
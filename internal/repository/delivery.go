@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// DeliveryRepo is a repository of event deliveries to webhooks.
+type DeliveryRepo struct {
+	collection *mongo.Collection
+}
+
+// NewDeliveryRepo is a DeliveryRepo constructor.
+// An event is delivered to a webhook once, so repeated publishing of the event makes no new delivery.
+func NewDeliveryRepo(db *mongo.Database, log *logger.Logger) *DeliveryRepo {
+	c := db.Collection("webhook_delivery")
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "webhookID", Value: bsonx.Int64(1)},
+				{Key: "eventID", Value: bsonx.Int64(1)},
+			},
+			Options: options.Index().SetName("event").SetUnique(true),
+		},
+		{
+			Keys: bson.D{
+				{Key: "status", Value: bsonx.Int64(1)},
+				{Key: "nextAttemptAt", Value: bsonx.Int64(1)},
+			},
+			Options: options.Index().SetName("pending"),
+		},
+	}
+	_, err := c.Indexes().CreateMany(context.Background(), indexes)
+	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
+		return nil
+	}
+
+	return &DeliveryRepo{collection: c}
+}
+
+// Create creates new delivery and returns id. A delivery of the event to the webhook which already exists is kept
+// and its id is returned.
+func (d DeliveryRepo) Create(ctx context.Context, delivery model.DeliveryDTO) (string, error) {
+	deliveryEntity, err := delivery.Entity()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := d.collection.InsertOne(ctx, deliveryEntity)
+	if mongo.IsDuplicateKeyError(err) {
+		var existing model.Delivery
+		query := bson.M{"webhookID": deliveryEntity.WebhookID, "eventID": delivery.EventID}
+		err = d.collection.FindOne(ctx, query, options.FindOne().SetProjection(bson.M{"_id": 1})).Decode(&existing)
+		if err != nil {
+			return "", err
+		}
+		return existing.ID.Hex(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// Update updates state of the delivery after an attempt and returns id.
+func (d DeliveryRepo) Update(ctx context.Context, id string, delivery model.DeliveryDTO) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"status":         delivery.Status,
+			"attempts":       delivery.Attempts,
+			"nextAttemptAt":  delivery.NextAttemptAt,
+			"responseStatus": delivery.ResponseStatus,
+			"lastError":      delivery.LastError,
+			"updated":        delivery.Updated,
+		},
+	}
+	res, err := d.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return "", err
+	}
+	if res.MatchedCount == 0 {
+		return "", mongo.ErrNoDocuments
+	}
+
+	return id, nil
+}
+
+// Claim claims the oldest pending delivery due at now for the lease and counts the attempt.
+func (d DeliveryRepo) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.DeliveryDTO, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).
+		SetReturnDocument(options.After)
+	query := bson.M{
+		"status":        model.DeliveryPending,
+		"nextAttemptAt": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"nextAttemptAt": now.Add(lease), "updated": now},
+		"$inc": bson.M{"attempts": 1},
+	}
+	var delivery model.Delivery
+	err := d.collection.FindOneAndUpdate(ctx, query, update, opts).Decode(&delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery.DTO(), nil
+}
+
+// FindByID finds delivery by id.
+func (d DeliveryRepo) FindByID(ctx context.Context, id string) (*model.DeliveryDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var delivery model.Delivery
+	err = d.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&delivery)
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery.DTO(), nil
+}
+
+// FindByWebhookID finds deliveries of the webhook with the status sorted from the latest. Empty status matches all.
+func (d DeliveryRepo) FindByWebhookID(ctx context.Context, webhookID string, status string) ([]model.DeliveryDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{"webhookID": objID}
+	if status != "" {
+		query["status"] = status
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "created", Value: -1}})
+	var deliveries model.Deliveries
+	cursor, err := d.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &deliveries)
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries.DTO(), nil
+}
+
+// DeleteByWebhookID deletes all deliveries of the webhook and returns webhook id.
+func (d DeliveryRepo) DeleteByWebhookID(ctx context.Context, webhookID string) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = d.collection.DeleteMany(ctx, bson.M{"webhookID": objID})
+	if err != nil {
+		return "", err
+	}
+
+	return webhookID, nil
+}
@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// DownloadRepo is a download repository.
+type DownloadRepo struct {
+	collection *mongo.Collection
+}
+
+// NewDownloadRepo is a DownloadRepo constructor.
+func NewDownloadRepo(db *mongo.Database, log *logger.Logger) *DownloadRepo {
+	c := db.Collection("download")
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "fileID", Value: bsonx.Int64(1)},
+				{Key: "date", Value: bsonx.Int64(-1)},
+			},
+			Options: options.Index().SetName("fileID"),
+		},
+	}
+	_, err := c.Indexes().CreateMany(context.Background(), indexes)
+	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
+		return nil
+	}
+
+	return &DownloadRepo{collection: c}
+}
+
+// Create creates new download record and returns id.
+func (d DownloadRepo) Create(context context.Context, download model.DownloadDTO) (string, error) {
+	downloadEntity, err := download.Entity()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := d.collection.InsertOne(context, downloadEntity)
+	if err != nil {
+		return "", err
+	}
+
+	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// FindByFileID finds downloads of the file sorted from the latest.
+func (d DownloadRepo) FindByFileID(context context.Context, id string) ([]model.DownloadDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"date": -1})
+	query := bson.M{
+		"fileID": objID,
+	}
+	var downloads model.Downloads
+	cursor, err := d.collection.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &downloads)
+	if err != nil {
+		return nil, err
+	}
+
+	return downloads.DTO(), nil
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	assertTest "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func Connect2DownloadMongo() (context.Context, *DownloadRepo, error) {
+	ctx := context.Background()
+	cfg, err := config.Init()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := mongo.NewMongo(ctx, cfg.Mongo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, NewDownloadRepo(db, logger.Nop()), nil
+}
+
+func TestDownloadRepo_Create(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2DownloadMongo()
+	require.NoError(t, err)
+	type test struct {
+		name     string
+		download model.DownloadDTO
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:   "not correct file id",
+			expErr: errors.New("invalid file id: the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "all ok",
+			download: model.DownloadDTO{
+				FileID: primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			id, err := repo.Create(ctx, tc.download)
+			if tc.expErr != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			} else {
+				assert.NoError(err)
+				assert.True(primitive.IsValidObjectID(id))
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestDownloadRepo_FindByFileID(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2DownloadMongo()
+	require.NoError(t, err)
+	fileID := primitive.NewObjectID().Hex()
+	type test struct {
+		name      string
+		id        string
+		downloads []model.DownloadDTO
+		expErr    error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "all ok",
+			id:   fileID,
+			downloads: []model.DownloadDTO{
+				{
+					FileID: fileID,
+					UserID: 2,
+					Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				},
+				{
+					FileID: fileID,
+					UserID: 1,
+					Date:   time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for i, download := range tc.downloads {
+				tc.downloads[i].ID, err = repo.Create(ctx, download)
+				assert.NoError(err)
+			}
+			_, err = repo.Create(ctx, model.DownloadDTO{
+				FileID: primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Date:   time.Date(2020, time.October, 10, 23, 10, 34, 0, time.UTC),
+			})
+			assert.NoError(err)
+			downloads, err := repo.FindByFileID(ctx, tc.id)
+			assert.Equal(tc.expErr, err)
+			assert.Equal(tc.downloads, downloads)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
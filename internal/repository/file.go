@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -18,19 +20,33 @@ type FileRepo struct {
 }
 
 // NewFileRepo is a FileRepo constructor.
-func NewFileRepo(db *mongo.Database) *FileRepo {
-	c := db.Collection("purchase")
+func NewFileRepo(db *mongo.Database, log *logger.Logger) *FileRepo {
+	c := db.Collection("file")
 	indexes := []mongo.IndexModel{
 		{
-			Keys: bson.D{{
-				Key:   "_id",
-				Value: bsonx.Int64(1),
-			}},
-			Options: options.Index().SetName("userID"),
+			Keys: bson.D{
+				{Key: "name", Value: bsonx.String("text")},
+				{Key: "description", Value: bsonx.String("text")},
+			},
+			Options: options.Index().
+				SetName("text").
+				SetDefaultLanguage(defaultSearchLanguage).
+				SetWeights(bson.D{
+					{Key: "name", Value: 10},
+					{Key: "description", Value: 1},
+				}),
+		},
+		{
+			Keys: bson.D{
+				{Key: "rating", Value: bsonx.Int64(-1)},
+				{Key: "ratingCount", Value: bsonx.Int64(-1)},
+			},
+			Options: options.Index().SetName("rating"),
 		},
 	}
 	_, err := c.Indexes().CreateMany(context.Background(), indexes)
 	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
 		return nil
 	}
 
@@ -52,14 +68,84 @@ func (f FileRepo) Create(context context.Context, file model.FileDTO) (string, e
 	return res.InsertedID.(primitive.ObjectID).Hex(), nil
 }
 
-// Update updates purchase and returns userID.
+// Update updates file metadata of the file revision keeping stored content fields, add date and author and returns id.
+// Update of a changed file returns ErrModified.
 func (f FileRepo) Update(context context.Context, id string, file model.FileDTO) (string, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return "", err
 	}
 
-	fileEntity, err := file.Entity()
+	query := revisionQuery(objID, file.Revision)
+	update := bson.M{
+		"$set": bson.M{
+			"name":        file.Name,
+			"description": file.Description,
+			"updateDate":  file.UpdateDate,
+			"actual":      file.Actual,
+		},
+		"$inc": bson.M{"revision": 1},
+	}
+	var updateFile model.File
+	err = f.collection.FindOneAndUpdate(context, query, update).Decode(&updateFile)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", notMatched(context, f.collection, objID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return updateFile.ID.Hex(), nil
+}
+
+// Patch sets only provided fields of the file revision and returns id.
+// Patch of a changed file returns ErrModified.
+func (f FileRepo) Patch(context context.Context, id string, patch model.FilePatch) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	set := bson.M{}
+	if patch.Name != nil {
+		set["name"] = *patch.Name
+	}
+	if patch.Description != nil {
+		set["description"] = *patch.Description
+	}
+	if patch.Actual != nil {
+		set["actual"] = *patch.Actual
+	}
+	if patch.UpdateDate != nil {
+		set["updateDate"] = *patch.UpdateDate
+	}
+
+	query := revisionQuery(objID, patch.Revision)
+	update := bson.M{
+		"$inc": bson.M{"revision": 1},
+	}
+	if len(set) != 0 {
+		update["$set"] = set
+	}
+	var patchFile model.File
+	err = f.collection.FindOneAndUpdate(context, query, update).Decode(&patchFile)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", notMatched(context, f.collection, objID)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return patchFile.ID.Hex(), nil
+}
+
+// SetVersion points the file at its current version copying version fields to the file and returns id.
+func (f FileRepo) SetVersion(context context.Context, id string, version model.FileVersionDTO) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+	versionID, err := primitive.ObjectIDFromHex(version.ID)
 	if err != nil {
 		return "", err
 	}
@@ -68,7 +154,17 @@ func (f FileRepo) Update(context context.Context, id string, file model.FileDTO)
 		"_id": objID,
 	}
 	update := bson.M{
-		"$set": fileEntity,
+		"$set": bson.M{
+			"versionID":   versionID,
+			"version":     version.Number,
+			"name":        version.Name,
+			"description": version.Description,
+			"size":        version.Size,
+			"path":        version.Path,
+			"checksum":    version.Checksum,
+			"contentType": version.ContentType,
+		},
+		"$inc": bson.M{"revision": 1},
 	}
 	var updateFile model.File
 	err = f.collection.FindOneAndUpdate(context, query, update).Decode(&updateFile)
@@ -79,9 +175,8 @@ func (f FileRepo) Update(context context.Context, id string, file model.FileDTO)
 	return updateFile.ID.Hex(), nil
 }
 
-// Delete deletes purchase and returns deleted userID.
-func (f FileRepo) Delete(context context.Context, id string) (string, error) {
-	opts := options.FindOneAndDelete().SetProjection(bson.D{{"_id", 1}})
+// UpdateRating adds sum and count to the file rating aggregate, recalculates average rating and returns id.
+func (f FileRepo) UpdateRating(context context.Context, id string, sum, count int) (string, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return "", err
@@ -90,8 +185,44 @@ func (f FileRepo) Delete(context context.Context, id string) (string, error) {
 	query := bson.M{
 		"_id": objID,
 	}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"ratingSum":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$ratingSum", 0}}, sum}},
+			"ratingCount": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$ratingCount", 0}}, count}},
+			"revision":    bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$revision", 0}}, 1}},
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"rating": bson.M{"$cond": bson.A{
+				bson.M{"$gt": bson.A{"$ratingCount", 0}},
+				bson.M{"$divide": bson.A{"$ratingSum", "$ratingCount"}},
+				0,
+			}},
+		}}},
+	}
+	var updateFile model.File
+	err = f.collection.FindOneAndUpdate(context, query, update).Decode(&updateFile)
+	if err != nil {
+		return "", err
+	}
+
+	return updateFile.ID.Hex(), nil
+}
+
+// Delete deletes the file revision and returns deleted id.
+// Deletion of a changed file returns ErrModified.
+func (f FileRepo) Delete(context context.Context, id string, revision int) (string, error) {
+	opts := options.FindOneAndDelete().SetProjection(bson.D{{"_id", 1}})
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	query := revisionQuery(objID, revision)
 	var delFile model.File
 	err = f.collection.FindOneAndDelete(context, query, opts).Decode(&delFile)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", notMatched(context, f.collection, objID)
+	}
 	if err != nil {
 		return "", err
 	}
@@ -153,6 +284,47 @@ func (f FileRepo) FindByName(context context.Context, name string) ([]model.File
 	return files.DTO(), nil
 }
 
+// FindByText finds files by name and description ranked by relevance score.
+// If full-text search finds nothing it falls back to a case-insensitive name prefix search.
+func (f FileRepo) FindByText(context context.Context, text, language string) ([]model.FileDTO, error) {
+	opts := options.Find().
+		SetProjection(scoreProjection).
+		SetSort(scoreProjection)
+	query := bson.M{
+		"$text": textSearch(text, language),
+	}
+	var scored scoredFiles
+	cursor, err := f.collection.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &scored)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(scored) != 0 {
+		return scored.DTO(), nil
+	}
+
+	query = bson.M{
+		"name": prefixRegex(text),
+	}
+	var files model.Files
+	cursor, err = f.collection.Find(context, query)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &files)
+	if err != nil {
+		return nil, err
+	}
+
+	return files.DTO(), nil
+}
+
 // FindAll finds purchases.
 func (f FileRepo) FindAll(context context.Context) ([]model.FileDTO, error) {
 	query := bson.M{}
@@ -170,6 +342,27 @@ func (f FileRepo) FindAll(context context.Context) ([]model.FileDTO, error) {
 	return files.DTO(), nil
 }
 
+// FindByRating finds files sorted by average rating and number of ratings.
+func (f FileRepo) FindByRating(context context.Context) ([]model.FileDTO, error) {
+	opts := options.Find().SetSort(bson.D{
+		{Key: "rating", Value: -1},
+		{Key: "ratingCount", Value: -1},
+	})
+	query := bson.M{}
+	var files model.Files
+	cursor, err := f.collection.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &files)
+	if err != nil {
+		return nil, err
+	}
+
+	return files.DTO(), nil
+}
+
 // FindByAuthorID finds purchases by author userID.
 func (f FileRepo) FindByAuthorID(context context.Context, id int) ([]model.FileDTO, error) {
 	query := bson.M{
@@ -9,6 +9,7 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	assertTest "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,7 +28,7 @@ func Connect2FileMongo() (context.Context, *FileRepo, error) {
 		return nil, nil, err
 	}
 
-	return ctx, NewFileRepo(db), nil
+	return ctx, NewFileRepo(db, logger.Nop()), nil
 }
 
 func TestFileRepo_Create(t *testing.T) {
@@ -72,11 +73,12 @@ func TestFileRepo_Update(t *testing.T) {
 	ctx, repo, err := Connect2FileMongo()
 	require.NoError(t, err)
 	type test struct {
-		name   string
-		isOk   bool
-		id     string
-		file   model.FileDTO
-		expErr error
+		name     string
+		isOk     bool
+		id       string
+		file     model.FileDTO
+		revision int
+		expErr   error
 	}
 	tt := []test{
 		{
@@ -88,6 +90,20 @@ func TestFileRepo_Update(t *testing.T) {
 			id:     primitive.NewObjectID().Hex(),
 			expErr: errors.New("mongo: no documents in result"),
 		},
+		{
+			name:     "modified",
+			revision: 1,
+			file: model.FileDTO{
+				Name:        "some",
+				Description: "some",
+				Size:        1,
+				Path:        "some",
+				AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+				AuthorID:    1,
+			},
+			expErr: ErrModified,
+		},
 		{
 			name: "all ok",
 			isOk: true,
@@ -103,6 +119,159 @@ func TestFileRepo_Update(t *testing.T) {
 			},
 		},
 	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			fileID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk || tc.revision != 0 {
+				fileID, err = repo.Create(ctx, tc.file)
+			}
+			id, err := repo.Update(ctx, fileID, model.FileDTO{Name: "updated", Revision: tc.revision})
+			assert.Equal(tc.expErr, err)
+			if tc.isOk {
+				assert.Equal(fileID, id)
+				file, err := repo.FindByID(ctx, fileID)
+				assert.NoError(err)
+				assert.Equal("updated", file.Name)
+				assert.Equal(tc.file.AuthorID, file.AuthorID)
+				assert.Equal(1, file.Revision)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestFileRepo_Patch(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileMongo()
+	require.NoError(t, err)
+	actual := false
+	updateDate := time.Date(2020, time.December, 11, 23, 10, 34, 0, time.UTC)
+	type test struct {
+		name    string
+		isOk    bool
+		id      string
+		patch   model.FilePatch
+		expFile model.FileDTO
+		expErr  error
+	}
+	file := model.FileDTO{
+		Name:        "some",
+		Description: "some",
+		Size:        1,
+		Path:        "some",
+		AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+		UpdateDate:  time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+		Actual:      true,
+		AuthorID:    1,
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not found",
+			id:     primitive.NewObjectID().Hex(),
+			patch:  model.FilePatch{Actual: &actual},
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			patch: model.FilePatch{
+				Actual:     &actual,
+				UpdateDate: &updateDate,
+			},
+			expFile: model.FileDTO{
+				Name:        file.Name,
+				Description: file.Description,
+				Size:        file.Size,
+				Path:        file.Path,
+				AddDate:     file.AddDate,
+				UpdateDate:  updateDate,
+				Actual:      actual,
+				AuthorID:    file.AuthorID,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			fileID := tc.id
+			if tc.isOk {
+				fileID, err = repo.Create(ctx, file)
+				assert.NoError(err)
+			}
+			id, err := repo.Patch(ctx, fileID, tc.patch)
+			assert.Equal(tc.expErr, err)
+			if tc.isOk {
+				assert.Equal(fileID, id)
+				f, err := repo.FindByID(ctx, fileID)
+				assert.NoError(err)
+				tc.expFile.ID = fileID
+				assert.Equal(&tc.expFile, f)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestFileRepo_SetVersion(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileMongo()
+	require.NoError(t, err)
+	type test struct {
+		name    string
+		isOk    bool
+		id      string
+		file    model.FileDTO
+		version model.FileVersionDTO
+		expErr  error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not correct version id",
+			id:     primitive.NewObjectID().Hex(),
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "not found",
+			id:   primitive.NewObjectID().Hex(),
+			version: model.FileVersionDTO{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			file: model.FileDTO{
+				Name:        "some",
+				Description: "some",
+				AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+				Actual:      true,
+				AuthorID:    1,
+			},
+			version: model.FileVersionDTO{
+				ID:          primitive.NewObjectID().Hex(),
+				Number:      2,
+				Name:        "other",
+				Description: "other",
+				Size:        12,
+				Path:        "some",
+				Checksum:    "some",
+				ContentType: "text/plain",
+			},
+		},
+	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			fileID := tc.id
@@ -110,11 +279,95 @@ func TestFileRepo_Update(t *testing.T) {
 			assert.NoError(err)
 			if tc.isOk {
 				fileID, err = repo.Create(ctx, tc.file)
+				assert.NoError(err)
 			}
-			id, err := repo.Update(ctx, fileID, tc.file)
+			id, err := repo.SetVersion(ctx, fileID, tc.version)
 			assert.Equal(tc.expErr, err)
 			if tc.isOk {
 				assert.Equal(fileID, id)
+				file, err := repo.FindByID(ctx, fileID)
+				assert.NoError(err)
+				assert.Equal(tc.version.ID, file.VersionID)
+				assert.Equal(tc.version.Number, file.Version)
+				assert.Equal(tc.version.Name, file.Name)
+				assert.Equal(tc.version.Description, file.Description)
+				assert.Equal(tc.version.Size, file.Size)
+				assert.Equal(tc.version.Path, file.Path)
+				assert.Equal(tc.version.Checksum, file.Checksum)
+				assert.Equal(tc.version.ContentType, file.ContentType)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestFileRepo_UpdateRating(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileMongo()
+	require.NoError(t, err)
+	type test struct {
+		name      string
+		isOk      bool
+		id        string
+		ratings   [][2]int
+		expRating float64
+		expCount  int
+		expErr    error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:    "not found",
+			id:      primitive.NewObjectID().Hex(),
+			ratings: [][2]int{{5, 1}},
+			expErr:  errors.New("mongo: no documents in result"),
+		},
+		{
+			name:      "all ok",
+			isOk:      true,
+			ratings:   [][2]int{{5, 1}, {2, 1}, {-1, 0}},
+			expRating: 3,
+			expCount:  2,
+		},
+		{
+			name:    "all removed",
+			isOk:    true,
+			ratings: [][2]int{{5, 1}, {-5, -1}},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			fileID := tc.id
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			if tc.isOk {
+				fileID, err = repo.Create(ctx, model.FileDTO{
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+					AuthorID:    1,
+				})
+				assert.NoError(err)
+			}
+			if len(tc.ratings) == 0 {
+				tc.ratings = [][2]int{{0, 0}}
+			}
+			for _, r := range tc.ratings {
+				_, err = repo.UpdateRating(ctx, fileID, r[0], r[1])
+				assert.Equal(tc.expErr, err)
+			}
+			if tc.isOk {
+				file, err := repo.FindByID(ctx, fileID)
+				assert.NoError(err)
+				assert.Equal(tc.expRating, file.Rating)
+				assert.Equal(tc.expCount, file.RatingCount)
 			}
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
@@ -127,11 +380,12 @@ func TestFileRepo_Delete(t *testing.T) {
 	ctx, repo, err := Connect2FileMongo()
 	require.NoError(t, err)
 	type test struct {
-		name   string
-		isOk   bool
-		id     string
-		file   model.FileDTO
-		expErr error
+		name     string
+		isOk     bool
+		id       string
+		file     model.FileDTO
+		revision int
+		expErr   error
 	}
 	tt := []test{
 		{
@@ -143,6 +397,20 @@ func TestFileRepo_Delete(t *testing.T) {
 			id:     primitive.NewObjectID().Hex(),
 			expErr: errors.New("mongo: no documents in result"),
 		},
+		{
+			name:     "modified",
+			revision: 1,
+			file: model.FileDTO{
+				Name:        "some",
+				Description: "some",
+				Size:        1,
+				Path:        "some",
+				AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+				AuthorID:    1,
+			},
+			expErr: ErrModified,
+		},
 		{
 			name: "all ok",
 			isOk: true,
@@ -157,16 +425,30 @@ func TestFileRepo_Delete(t *testing.T) {
 				AuthorID:    1,
 			},
 		},
+		{
+			name:     "any revision",
+			isOk:     true,
+			revision: model.AnyRevision,
+			file: model.FileDTO{
+				Name:        "some",
+				Description: "some",
+				Size:        1,
+				Path:        "some",
+				AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+				AuthorID:    1,
+			},
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			fileID := tc.id
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
-			if tc.isOk {
+			if tc.isOk || tc.revision != 0 {
 				fileID, err = repo.Create(ctx, tc.file)
 			}
-			id, err := repo.Delete(ctx, fileID)
+			id, err := repo.Delete(ctx, fileID, tc.revision)
 			assert.Equal(tc.expErr, err)
 			if tc.isOk {
 				assert.Equal(fileID, id)
@@ -369,6 +651,97 @@ func TestFileRepo_FindByName(t *testing.T) {
 	}
 }
 
+func TestFileRepo_FindByText(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileMongo()
+	require.NoError(t, err)
+	type test struct {
+		name     string
+		isOk     bool
+		textName string
+		fn       func(data *test)
+		files    []model.FileDTO
+		exp      []model.FileDTO
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:     "not found",
+			textName: "some",
+		},
+		{
+			name: "prefix fallback",
+			isOk: true,
+			fn: func(data *test) {
+				data.exp = data.files
+			},
+			textName: "som",
+			files: []model.FileDTO{
+				{
+					Name:        "Something",
+					Description: "other",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+					AuthorID:    1,
+					Actual:      false,
+				},
+			},
+			exp: []model.FileDTO{},
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			fn: func(data *test) {
+				data.exp = data.files
+			},
+			textName: "some",
+			files: []model.FileDTO{
+				{
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+					AuthorID:    1,
+					Actual:      false,
+				},
+			},
+			exp: []model.FileDTO{},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			text := tc.textName
+			if tc.fn != nil {
+				tc.fn(&tc)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+
+			if tc.isOk {
+				for _, c := range tc.files {
+					_, err = repo.Create(ctx, c)
+				}
+			}
+			files, err := repo.FindByText(ctx, text, "")
+			assert.Equal(tc.expErr, err)
+
+			if tc.isOk {
+				for i := range tc.exp {
+					tc.exp[i].ID = files[i].ID
+					tc.exp[i].Score = files[i].Score
+				}
+				assert.Equal(tc.exp, files)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
 func TestFileRepo_FindAll(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2FileMongo()
@@ -570,6 +943,85 @@ func TestFileRepo_FindActual(t *testing.T) {
 	}
 }
 
+func TestFileRepo_FindByRating(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileMongo()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		isOk   bool
+		fn     func(data *test)
+		files  []model.FileDTO
+		exp    []model.FileDTO
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "not found",
+		},
+		{
+			name: "all ok",
+			isOk: true,
+			fn: func(data *test) {
+				data.exp = data.files
+			},
+			files: []model.FileDTO{
+				{
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+					AuthorID:    1,
+					Actual:      true,
+					Rating:      5,
+					RatingCount: 1,
+				},
+				{
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					UpdateDate:  time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+					AuthorID:    1,
+					Actual:      true,
+					Rating:      2,
+					RatingCount: 1,
+				},
+			},
+			exp: []model.FileDTO{},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.fn != nil {
+				tc.fn(&tc)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+
+			if tc.isOk {
+				for _, c := range tc.files {
+					_, err = repo.Create(ctx, c)
+				}
+			}
+			files, err := repo.FindByRating(ctx)
+			assert.Equal(tc.expErr, err)
+
+			if tc.isOk {
+				for i := range tc.exp {
+					tc.exp[i].ID = files[i].ID
+				}
+				assert.Equal(tc.exp, files)
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
 func TestFileRepo_FindNotActual(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2FileMongo()
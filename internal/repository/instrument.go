@@ -0,0 +1,801 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Observer observes latency and errors of repository operations.
+type Observer interface {
+	ObserveOperation(repository, method string, duration time.Duration, err error)
+}
+
+// Instrument returns repositories which report every operation to the observer.
+func Instrument(repos *Repositories, observer Observer) *Repositories {
+	return &Repositories{
+		Purchase:    observedPurchase{repos.Purchase, observer},
+		Comment:     observedComment{repos.Comment, observer},
+		File:        observedFile{repos.File, observer},
+		FileVersion: observedFileVersion{repos.FileVersion, observer},
+		Download:    observedDownload{repos.Download, observer},
+		Link:        observedLink{repos.Link, observer},
+		Outbox:      observedOutbox{repos.Outbox, observer},
+		Webhook:     observedWebhook{repos.Webhook, observer},
+		Delivery:    observedDelivery{repos.Delivery, observer},
+		ResumeToken: observedResumeToken{repos.ResumeToken, observer},
+	}
+}
+
+// observe reports operation of the repository method started at start. Not found documents are not errors.
+func observe(observer Observer, repository, method string, start time.Time, err error) {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		err = nil
+	}
+	observer.ObserveOperation(repository, method, time.Since(start), err)
+}
+
+type observedPurchase struct {
+	Purchase
+	observer Observer
+}
+
+// Create observes Create of the purchase repository.
+func (p observedPurchase) Create(ctx context.Context, purchase model.PurchaseDTO) (string, error) {
+	begin := time.Now()
+	res, err := p.Purchase.Create(ctx, purchase)
+	observe(p.observer, "purchase", "Create", begin, err)
+
+	return res, err
+}
+
+// Delete observes Delete of the purchase repository.
+func (p observedPurchase) Delete(ctx context.Context, id string) (string, error) {
+	begin := time.Now()
+	res, err := p.Purchase.Delete(ctx, id)
+	observe(p.observer, "purchase", "Delete", begin, err)
+
+	return res, err
+}
+
+// DeleteByFileID observes DeleteByFileID of the purchase repository.
+func (p observedPurchase) DeleteByFileID(ctx context.Context, id string) (int, error) {
+	begin := time.Now()
+	res, err := p.Purchase.DeleteByFileID(ctx, id)
+	observe(p.observer, "purchase", "DeleteByFileID", begin, err)
+
+	return res, err
+}
+
+// FindByID observes FindByID of the purchase repository.
+func (p observedPurchase) FindByID(ctx context.Context, id string) (*model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindByID(ctx, id)
+	observe(p.observer, "purchase", "FindByID", begin, err)
+
+	return res, err
+}
+
+// FindLastByUserID observes FindLastByUserID of the purchase repository.
+func (p observedPurchase) FindLastByUserID(ctx context.Context, id int) (*model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindLastByUserID(ctx, id)
+	observe(p.observer, "purchase", "FindLastByUserID", begin, err)
+
+	return res, err
+}
+
+// FindAllByUserID observes FindAllByUserID of the purchase repository.
+func (p observedPurchase) FindAllByUserID(ctx context.Context, id int) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindAllByUserID(ctx, id)
+	observe(p.observer, "purchase", "FindAllByUserID", begin, err)
+
+	return res, err
+}
+
+// FindByUserIDAndPeriod observes FindByUserIDAndPeriod of the purchase repository.
+func (p observedPurchase) FindByUserIDAndPeriod(ctx context.Context, id int, start, end time.Time) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindByUserIDAndPeriod(ctx, id, start, end)
+	observe(p.observer, "purchase", "FindByUserIDAndPeriod", begin, err)
+
+	return res, err
+}
+
+// FindByUserIDAfterDate observes FindByUserIDAfterDate of the purchase repository.
+func (p observedPurchase) FindByUserIDAfterDate(ctx context.Context, id int, start time.Time) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindByUserIDAfterDate(ctx, id, start)
+	observe(p.observer, "purchase", "FindByUserIDAfterDate", begin, err)
+
+	return res, err
+}
+
+// FindByUserIDBeforeDate observes FindByUserIDBeforeDate of the purchase repository.
+func (p observedPurchase) FindByUserIDBeforeDate(ctx context.Context, id int, end time.Time) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindByUserIDBeforeDate(ctx, id, end)
+	observe(p.observer, "purchase", "FindByUserIDBeforeDate", begin, err)
+
+	return res, err
+}
+
+// FindByUserIDAndFileID observes FindByUserIDAndFileID of the purchase repository.
+func (p observedPurchase) FindByUserIDAndFileID(ctx context.Context, userID int, fileID string) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindByUserIDAndFileID(ctx, userID, fileID)
+	observe(p.observer, "purchase", "FindByUserIDAndFileID", begin, err)
+
+	return res, err
+}
+
+// FindLast observes FindLast of the purchase repository.
+func (p observedPurchase) FindLast(ctx context.Context) (*model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindLast(ctx)
+	observe(p.observer, "purchase", "FindLast", begin, err)
+
+	return res, err
+}
+
+// FindAll observes FindAll of the purchase repository.
+func (p observedPurchase) FindAll(ctx context.Context) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindAll(ctx)
+	observe(p.observer, "purchase", "FindAll", begin, err)
+
+	return res, err
+}
+
+// FindByPeriod observes FindByPeriod of the purchase repository.
+func (p observedPurchase) FindByPeriod(ctx context.Context, start, end time.Time) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindByPeriod(ctx, start, end)
+	observe(p.observer, "purchase", "FindByPeriod", begin, err)
+
+	return res, err
+}
+
+// FindAfterDate observes FindAfterDate of the purchase repository.
+func (p observedPurchase) FindAfterDate(ctx context.Context, start time.Time) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindAfterDate(ctx, start)
+	observe(p.observer, "purchase", "FindAfterDate", begin, err)
+
+	return res, err
+}
+
+// FindBeforeDate observes FindBeforeDate of the purchase repository.
+func (p observedPurchase) FindBeforeDate(ctx context.Context, end time.Time) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindBeforeDate(ctx, end)
+	observe(p.observer, "purchase", "FindBeforeDate", begin, err)
+
+	return res, err
+}
+
+// FindByFileID observes FindByFileID of the purchase repository.
+func (p observedPurchase) FindByFileID(ctx context.Context, id string) ([]model.PurchaseDTO, error) {
+	begin := time.Now()
+	res, err := p.Purchase.FindByFileID(ctx, id)
+	observe(p.observer, "purchase", "FindByFileID", begin, err)
+
+	return res, err
+}
+
+type observedComment struct {
+	Comment
+	observer Observer
+}
+
+// Create observes Create of the comment repository.
+func (c observedComment) Create(ctx context.Context, comment model.CommentDTO) (string, error) {
+	begin := time.Now()
+	res, err := c.Comment.Create(ctx, comment)
+	observe(c.observer, "comment", "Create", begin, err)
+
+	return res, err
+}
+
+// Update observes Update of the comment repository.
+func (c observedComment) Update(ctx context.Context, id string, comment model.CommentDTO) (string, error) {
+	begin := time.Now()
+	res, err := c.Comment.Update(ctx, id, comment)
+	observe(c.observer, "comment", "Update", begin, err)
+
+	return res, err
+}
+
+// Delete observes Delete of the comment repository.
+func (c observedComment) Delete(ctx context.Context, id string, revision int, at time.Time) (string, error) {
+	begin := time.Now()
+	res, err := c.Comment.Delete(ctx, id, revision, at)
+	observe(c.observer, "comment", "Delete", begin, err)
+
+	return res, err
+}
+
+// Tombstone observes Tombstone of the comment repository.
+func (c observedComment) Tombstone(ctx context.Context, id string, revision int, at time.Time) (string, error) {
+	begin := time.Now()
+	res, err := c.Comment.Tombstone(ctx, id, revision, at)
+	observe(c.observer, "comment", "Tombstone", begin, err)
+
+	return res, err
+}
+
+// Flag observes Flag of the comment repository.
+func (c observedComment) Flag(ctx context.Context, id string, userID int) (string, error) {
+	begin := time.Now()
+	res, err := c.Comment.Flag(ctx, id, userID)
+	observe(c.observer, "comment", "Flag", begin, err)
+
+	return res, err
+}
+
+// UpdateStatus observes UpdateStatus of the comment repository.
+func (c observedComment) UpdateStatus(ctx context.Context, id, status string) (string, error) {
+	begin := time.Now()
+	res, err := c.Comment.UpdateStatus(ctx, id, status)
+	observe(c.observer, "comment", "UpdateStatus", begin, err)
+
+	return res, err
+}
+
+// DeleteByPurchaseID observes DeleteByPurchaseID of the comment repository.
+func (c observedComment) DeleteByPurchaseID(ctx context.Context, id string) (int, error) {
+	begin := time.Now()
+	res, err := c.Comment.DeleteByPurchaseID(ctx, id)
+	observe(c.observer, "comment", "DeleteByPurchaseID", begin, err)
+
+	return res, err
+}
+
+// FindByID observes FindByID of the comment repository.
+func (c observedComment) FindByID(ctx context.Context, id string) (*model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindByID(ctx, id)
+	observe(c.observer, "comment", "FindByID", begin, err)
+
+	return res, err
+}
+
+// FindVisibleByID observes FindVisibleByID of the comment repository.
+func (c observedComment) FindVisibleByID(ctx context.Context, id string, userID int) (*model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindVisibleByID(ctx, id, userID)
+	observe(c.observer, "comment", "FindVisibleByID", begin, err)
+
+	return res, err
+}
+
+// FindAnyByID observes FindAnyByID of the comment repository.
+func (c observedComment) FindAnyByID(ctx context.Context, id string) (*model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindAnyByID(ctx, id)
+	observe(c.observer, "comment", "FindAnyByID", begin, err)
+
+	return res, err
+}
+
+// FindAllByUserID observes FindAllByUserID of the comment repository.
+func (c observedComment) FindAllByUserID(ctx context.Context, id int) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindAllByUserID(ctx, id)
+	observe(c.observer, "comment", "FindAllByUserID", begin, err)
+
+	return res, err
+}
+
+// FindByPurchaseID observes FindByPurchaseID of the comment repository.
+func (c observedComment) FindByPurchaseID(ctx context.Context, id string) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindByPurchaseID(ctx, id)
+	observe(c.observer, "comment", "FindByPurchaseID", begin, err)
+
+	return res, err
+}
+
+// FindAnyByPurchaseID observes FindAnyByPurchaseID of the comment repository.
+func (c observedComment) FindAnyByPurchaseID(ctx context.Context, id string) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindAnyByPurchaseID(ctx, id)
+	observe(c.observer, "comment", "FindAnyByPurchaseID", begin, err)
+
+	return res, err
+}
+
+// FindByPurchaseIDs observes FindByPurchaseIDs of the comment repository.
+func (c observedComment) FindByPurchaseIDs(ctx context.Context, ids []string) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindByPurchaseIDs(ctx, ids)
+	observe(c.observer, "comment", "FindByPurchaseIDs", begin, err)
+
+	return res, err
+}
+
+// CountReplies observes CountReplies of the comment repository.
+func (c observedComment) CountReplies(ctx context.Context, id string) (int64, error) {
+	begin := time.Now()
+	res, err := c.Comment.CountReplies(ctx, id)
+	observe(c.observer, "comment", "CountReplies", begin, err)
+
+	return res, err
+}
+
+// FindRevisions observes FindRevisions of the comment repository.
+func (c observedComment) FindRevisions(ctx context.Context, id string) ([]model.CommentRevisionDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindRevisions(ctx, id)
+	observe(c.observer, "comment", "FindRevisions", begin, err)
+
+	return res, err
+}
+
+// FindByUserIDAndPurchaseID observes FindByUserIDAndPurchaseID of the comment repository.
+func (c observedComment) FindByUserIDAndPurchaseID(ctx context.Context, userID int, purchaseID string) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindByUserIDAndPurchaseID(ctx, userID, purchaseID)
+	observe(c.observer, "comment", "FindByUserIDAndPurchaseID", begin, err)
+
+	return res, err
+}
+
+// FindAll observes FindAll of the comment repository.
+func (c observedComment) FindAll(ctx context.Context) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindAll(ctx)
+	observe(c.observer, "comment", "FindAll", begin, err)
+
+	return res, err
+}
+
+// FindByStatus observes FindByStatus of the comment repository.
+func (c observedComment) FindByStatus(ctx context.Context, statuses []string) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindByStatus(ctx, statuses)
+	observe(c.observer, "comment", "FindByStatus", begin, err)
+
+	return res, err
+}
+
+// FindByText observes FindByText of the comment repository.
+func (c observedComment) FindByText(ctx context.Context, text, language string) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindByText(ctx, text, language)
+	observe(c.observer, "comment", "FindByText", begin, err)
+
+	return res, err
+}
+
+// FindByPeriod observes FindByPeriod of the comment repository.
+func (c observedComment) FindByPeriod(ctx context.Context, start, end time.Time) ([]model.CommentDTO, error) {
+	begin := time.Now()
+	res, err := c.Comment.FindByPeriod(ctx, start, end)
+	observe(c.observer, "comment", "FindByPeriod", begin, err)
+
+	return res, err
+}
+
+type observedFile struct {
+	File
+	observer Observer
+}
+
+// Create observes Create of the file repository.
+func (f observedFile) Create(ctx context.Context, file model.FileDTO) (string, error) {
+	begin := time.Now()
+	res, err := f.File.Create(ctx, file)
+	observe(f.observer, "file", "Create", begin, err)
+
+	return res, err
+}
+
+// Update observes Update of the file repository.
+func (f observedFile) Update(ctx context.Context, id string, file model.FileDTO) (string, error) {
+	begin := time.Now()
+	res, err := f.File.Update(ctx, id, file)
+	observe(f.observer, "file", "Update", begin, err)
+
+	return res, err
+}
+
+// Patch observes Patch of the file repository.
+func (f observedFile) Patch(ctx context.Context, id string, patch model.FilePatch) (string, error) {
+	begin := time.Now()
+	res, err := f.File.Patch(ctx, id, patch)
+	observe(f.observer, "file", "Patch", begin, err)
+
+	return res, err
+}
+
+// SetVersion observes SetVersion of the file repository.
+func (f observedFile) SetVersion(ctx context.Context, id string, version model.FileVersionDTO) (string, error) {
+	begin := time.Now()
+	res, err := f.File.SetVersion(ctx, id, version)
+	observe(f.observer, "file", "SetVersion", begin, err)
+
+	return res, err
+}
+
+// UpdateRating observes UpdateRating of the file repository.
+func (f observedFile) UpdateRating(ctx context.Context, id string, sum, count int) (string, error) {
+	begin := time.Now()
+	res, err := f.File.UpdateRating(ctx, id, sum, count)
+	observe(f.observer, "file", "UpdateRating", begin, err)
+
+	return res, err
+}
+
+// Delete observes Delete of the file repository.
+func (f observedFile) Delete(ctx context.Context, id string, revision int) (string, error) {
+	begin := time.Now()
+	res, err := f.File.Delete(ctx, id, revision)
+	observe(f.observer, "file", "Delete", begin, err)
+
+	return res, err
+}
+
+// DeleteByAuthorID observes DeleteByAuthorID of the file repository.
+func (f observedFile) DeleteByAuthorID(ctx context.Context, id int) (int, error) {
+	begin := time.Now()
+	res, err := f.File.DeleteByAuthorID(ctx, id)
+	observe(f.observer, "file", "DeleteByAuthorID", begin, err)
+
+	return res, err
+}
+
+// FindByID observes FindByID of the file repository.
+func (f observedFile) FindByID(ctx context.Context, id string) (*model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindByID(ctx, id)
+	observe(f.observer, "file", "FindByID", begin, err)
+
+	return res, err
+}
+
+// FindByName observes FindByName of the file repository.
+func (f observedFile) FindByName(ctx context.Context, name string) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindByName(ctx, name)
+	observe(f.observer, "file", "FindByName", begin, err)
+
+	return res, err
+}
+
+// FindByText observes FindByText of the file repository.
+func (f observedFile) FindByText(ctx context.Context, text, language string) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindByText(ctx, text, language)
+	observe(f.observer, "file", "FindByText", begin, err)
+
+	return res, err
+}
+
+// FindAll observes FindAll of the file repository.
+func (f observedFile) FindAll(ctx context.Context) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindAll(ctx)
+	observe(f.observer, "file", "FindAll", begin, err)
+
+	return res, err
+}
+
+// FindByRating observes FindByRating of the file repository.
+func (f observedFile) FindByRating(ctx context.Context) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindByRating(ctx)
+	observe(f.observer, "file", "FindByRating", begin, err)
+
+	return res, err
+}
+
+// FindByAuthorID observes FindByAuthorID of the file repository.
+func (f observedFile) FindByAuthorID(ctx context.Context, id int) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindByAuthorID(ctx, id)
+	observe(f.observer, "file", "FindByAuthorID", begin, err)
+
+	return res, err
+}
+
+// FindNotActual observes FindNotActual of the file repository.
+func (f observedFile) FindNotActual(ctx context.Context) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindNotActual(ctx)
+	observe(f.observer, "file", "FindNotActual", begin, err)
+
+	return res, err
+}
+
+// FindActual observes FindActual of the file repository.
+func (f observedFile) FindActual(ctx context.Context) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindActual(ctx)
+	observe(f.observer, "file", "FindActual", begin, err)
+
+	return res, err
+}
+
+// FindAddedByPeriod observes FindAddedByPeriod of the file repository.
+func (f observedFile) FindAddedByPeriod(ctx context.Context, start, end time.Time) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindAddedByPeriod(ctx, start, end)
+	observe(f.observer, "file", "FindAddedByPeriod", begin, err)
+
+	return res, err
+}
+
+// FindUpdatedByPeriod observes FindUpdatedByPeriod of the file repository.
+func (f observedFile) FindUpdatedByPeriod(ctx context.Context, start, end time.Time) ([]model.FileDTO, error) {
+	begin := time.Now()
+	res, err := f.File.FindUpdatedByPeriod(ctx, start, end)
+	observe(f.observer, "file", "FindUpdatedByPeriod", begin, err)
+
+	return res, err
+}
+
+type observedFileVersion struct {
+	FileVersion
+	observer Observer
+}
+
+// Create observes Create of the file version repository.
+func (f observedFileVersion) Create(ctx context.Context, version model.FileVersionDTO) (string, error) {
+	begin := time.Now()
+	res, err := f.FileVersion.Create(ctx, version)
+	observe(f.observer, "file_version", "Create", begin, err)
+
+	return res, err
+}
+
+// DeleteByFileID observes DeleteByFileID of the file version repository.
+func (f observedFileVersion) DeleteByFileID(ctx context.Context, id string) (int, error) {
+	begin := time.Now()
+	res, err := f.FileVersion.DeleteByFileID(ctx, id)
+	observe(f.observer, "file_version", "DeleteByFileID", begin, err)
+
+	return res, err
+}
+
+// FindByFileID observes FindByFileID of the file version repository.
+func (f observedFileVersion) FindByFileID(ctx context.Context, id string) ([]model.FileVersionDTO, error) {
+	begin := time.Now()
+	res, err := f.FileVersion.FindByFileID(ctx, id)
+	observe(f.observer, "file_version", "FindByFileID", begin, err)
+
+	return res, err
+}
+
+// FindByFileIDAndNumber observes FindByFileIDAndNumber of the file version repository.
+func (f observedFileVersion) FindByFileIDAndNumber(ctx context.Context, id string, number int) (*model.FileVersionDTO, error) {
+	begin := time.Now()
+	res, err := f.FileVersion.FindByFileIDAndNumber(ctx, id, number)
+	observe(f.observer, "file_version", "FindByFileIDAndNumber", begin, err)
+
+	return res, err
+}
+
+type observedDownload struct {
+	Download
+	observer Observer
+}
+
+// Create observes Create of the download repository.
+func (d observedDownload) Create(ctx context.Context, download model.DownloadDTO) (string, error) {
+	begin := time.Now()
+	res, err := d.Download.Create(ctx, download)
+	observe(d.observer, "download", "Create", begin, err)
+
+	return res, err
+}
+
+// FindByFileID observes FindByFileID of the download repository.
+func (d observedDownload) FindByFileID(ctx context.Context, id string) ([]model.DownloadDTO, error) {
+	begin := time.Now()
+	res, err := d.Download.FindByFileID(ctx, id)
+	observe(d.observer, "download", "FindByFileID", begin, err)
+
+	return res, err
+}
+
+type observedLink struct {
+	Link
+	observer Observer
+}
+
+// Use observes Use of the link repository.
+func (l observedLink) Use(ctx context.Context, nonce string, expires time.Time) (bool, error) {
+	begin := time.Now()
+	res, err := l.Link.Use(ctx, nonce, expires)
+	observe(l.observer, "link", "Use", begin, err)
+
+	return res, err
+}
+
+type observedOutbox struct {
+	Outbox
+	observer Observer
+}
+
+// Add observes Add of the outbox repository.
+func (o observedOutbox) Add(ctx context.Context, event model.EventDTO) (string, error) {
+	begin := time.Now()
+	res, err := o.Outbox.Add(ctx, event)
+	observe(o.observer, "outbox", "Add", begin, err)
+
+	return res, err
+}
+
+// Claim observes Claim of the outbox repository.
+func (o observedOutbox) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.EventDTO, error) {
+	begin := time.Now()
+	res, err := o.Outbox.Claim(ctx, now, lease)
+	observe(o.observer, "outbox", "Claim", begin, err)
+
+	return res, err
+}
+
+// MarkDispatched observes MarkDispatched of the outbox repository.
+func (o observedOutbox) MarkDispatched(ctx context.Context, id string, at time.Time) (string, error) {
+	begin := time.Now()
+	res, err := o.Outbox.MarkDispatched(ctx, id, at)
+	observe(o.observer, "outbox", "MarkDispatched", begin, err)
+
+	return res, err
+}
+
+// Retry observes Retry of the outbox repository.
+func (o observedOutbox) Retry(ctx context.Context, id string, next time.Time, reason string) (string, error) {
+	begin := time.Now()
+	res, err := o.Outbox.Retry(ctx, id, next, reason)
+	observe(o.observer, "outbox", "Retry", begin, err)
+
+	return res, err
+}
+
+type observedWebhook struct {
+	Webhook
+	observer Observer
+}
+
+// Create observes Create of the webhook repository.
+func (w observedWebhook) Create(ctx context.Context, webhook model.WebhookDTO) (string, error) {
+	begin := time.Now()
+	res, err := w.Webhook.Create(ctx, webhook)
+	observe(w.observer, "webhook", "Create", begin, err)
+
+	return res, err
+}
+
+// Update observes Update of the webhook repository.
+func (w observedWebhook) Update(ctx context.Context, id string, webhook model.WebhookDTO) (string, error) {
+	begin := time.Now()
+	res, err := w.Webhook.Update(ctx, id, webhook)
+	observe(w.observer, "webhook", "Update", begin, err)
+
+	return res, err
+}
+
+// Delete observes Delete of the webhook repository.
+func (w observedWebhook) Delete(ctx context.Context, id string) (string, error) {
+	begin := time.Now()
+	res, err := w.Webhook.Delete(ctx, id)
+	observe(w.observer, "webhook", "Delete", begin, err)
+
+	return res, err
+}
+
+// FindByID observes FindByID of the webhook repository.
+func (w observedWebhook) FindByID(ctx context.Context, id string) (*model.WebhookDTO, error) {
+	begin := time.Now()
+	res, err := w.Webhook.FindByID(ctx, id)
+	observe(w.observer, "webhook", "FindByID", begin, err)
+
+	return res, err
+}
+
+// FindByUserID observes FindByUserID of the webhook repository.
+func (w observedWebhook) FindByUserID(ctx context.Context, userID int) ([]model.WebhookDTO, error) {
+	begin := time.Now()
+	res, err := w.Webhook.FindByUserID(ctx, userID)
+	observe(w.observer, "webhook", "FindByUserID", begin, err)
+
+	return res, err
+}
+
+// FindSubscribed observes FindSubscribed of the webhook repository.
+func (w observedWebhook) FindSubscribed(ctx context.Context, eventType string, userID int) ([]model.WebhookDTO, error) {
+	begin := time.Now()
+	res, err := w.Webhook.FindSubscribed(ctx, eventType, userID)
+	observe(w.observer, "webhook", "FindSubscribed", begin, err)
+
+	return res, err
+}
+
+type observedDelivery struct {
+	Delivery
+	observer Observer
+}
+
+// Create observes Create of the delivery repository.
+func (d observedDelivery) Create(ctx context.Context, delivery model.DeliveryDTO) (string, error) {
+	begin := time.Now()
+	res, err := d.Delivery.Create(ctx, delivery)
+	observe(d.observer, "delivery", "Create", begin, err)
+
+	return res, err
+}
+
+// Update observes Update of the delivery repository.
+func (d observedDelivery) Update(ctx context.Context, id string, delivery model.DeliveryDTO) (string, error) {
+	begin := time.Now()
+	res, err := d.Delivery.Update(ctx, id, delivery)
+	observe(d.observer, "delivery", "Update", begin, err)
+
+	return res, err
+}
+
+// Claim observes Claim of the delivery repository.
+func (d observedDelivery) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.DeliveryDTO, error) {
+	begin := time.Now()
+	res, err := d.Delivery.Claim(ctx, now, lease)
+	observe(d.observer, "delivery", "Claim", begin, err)
+
+	return res, err
+}
+
+// FindByID observes FindByID of the delivery repository.
+func (d observedDelivery) FindByID(ctx context.Context, id string) (*model.DeliveryDTO, error) {
+	begin := time.Now()
+	res, err := d.Delivery.FindByID(ctx, id)
+	observe(d.observer, "delivery", "FindByID", begin, err)
+
+	return res, err
+}
+
+// FindByWebhookID observes FindByWebhookID of the delivery repository.
+func (d observedDelivery) FindByWebhookID(ctx context.Context, webhookID string, status string) ([]model.DeliveryDTO, error) {
+	begin := time.Now()
+	res, err := d.Delivery.FindByWebhookID(ctx, webhookID, status)
+	observe(d.observer, "delivery", "FindByWebhookID", begin, err)
+
+	return res, err
+}
+
+// DeleteByWebhookID observes DeleteByWebhookID of the delivery repository.
+func (d observedDelivery) DeleteByWebhookID(ctx context.Context, webhookID string) (string, error) {
+	begin := time.Now()
+	res, err := d.Delivery.DeleteByWebhookID(ctx, webhookID)
+	observe(d.observer, "delivery", "DeleteByWebhookID", begin, err)
+
+	return res, err
+}
+
+type observedResumeToken struct {
+	ResumeToken
+	observer Observer
+}
+
+// Find observes Find of the resume token repository.
+func (r observedResumeToken) Find(ctx context.Context, name string) (bson.Raw, error) {
+	begin := time.Now()
+	res, err := r.ResumeToken.Find(ctx, name)
+	observe(r.observer, "resume_token", "Find", begin, err)
+
+	return res, err
+}
+
+// Save observes Save of the resume token repository.
+func (r observedResumeToken) Save(ctx context.Context, name string, token bson.Raw, at time.Time) (string, error) {
+	begin := time.Now()
+	res, err := r.ResumeToken.Save(ctx, name, token, at)
+	observe(r.observer, "resume_token", "Save", begin, err)
+
+	return res, err
+}
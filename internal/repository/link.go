@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// LinkRepo is a repository of used single-use download links.
+type LinkRepo struct {
+	collection *mongo.Collection
+}
+
+// NewLinkRepo is a LinkRepo constructor.
+// Used links are removed by mongo once they expire.
+func NewLinkRepo(db *mongo.Database, log *logger.Logger) *LinkRepo {
+	c := db.Collection("download_link")
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{{
+				Key:   "expires",
+				Value: bsonx.Int64(1),
+			}},
+			Options: options.Index().
+				SetName("expires").
+				SetExpireAfterSeconds(0),
+		},
+	}
+	_, err := c.Indexes().CreateMany(context.Background(), indexes)
+	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
+		return nil
+	}
+
+	return &LinkRepo{collection: c}
+}
+
+// Use marks the link nonce as used and reports whether it wasn't used before.
+func (l LinkRepo) Use(context context.Context, nonce string, expires time.Time) (bool, error) {
+	_, err := l.collection.InsertOne(context, bson.M{
+		"_id":     nonce,
+		"expires": expires,
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
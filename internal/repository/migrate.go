@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// legacyCollections maps collections of comments and files to filters of their documents in the purchase collection,
+// where all of them were kept before they got their own collections.
+var legacyCollections = []struct {
+	name   string
+	filter bson.M
+}{
+	{name: "comment", filter: bson.M{"purchaseID": bson.M{"$exists": true}}},
+	{name: "file", filter: bson.M{"path": bson.M{"$exists": true}}},
+}
+
+// SplitPurchaseCollection moves comments and files left in the purchase collection by earlier versions
+// to their own collections and returns number of moved documents.
+// Documents keep their ids and are copied before they are deleted, so an interrupted move is completed on rerun.
+func SplitPurchaseCollection(ctx context.Context, db *mongo.Database, log *logger.Logger) (int, error) {
+	purchases := db.Collection("purchase")
+
+	var moved int
+	for _, legacy := range legacyCollections {
+		target := db.Collection(legacy.name)
+		cursor, err := purchases.Find(ctx, legacy.filter)
+		if err != nil {
+			return moved, errors.Wrapf(err, "couldn't find legacy %s documents", legacy.name)
+		}
+
+		for cursor.Next(ctx) {
+			id := cursor.Current.Lookup("_id")
+			_, err := target.ReplaceOne(ctx, bson.M{"_id": id}, cursor.Current, options.Replace().SetUpsert(true))
+			if err != nil {
+				_ = cursor.Close(ctx)
+				return moved, errors.Wrapf(err, "couldn't copy legacy %s document", legacy.name)
+			}
+			if _, err := purchases.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+				_ = cursor.Close(ctx)
+				return moved, errors.Wrapf(err, "couldn't delete legacy %s document", legacy.name)
+			}
+			moved++
+		}
+		if err := cursor.Err(); err != nil {
+			_ = cursor.Close(ctx)
+			return moved, errors.Wrapf(err, "couldn't read legacy %s documents", legacy.name)
+		}
+		if err := cursor.Close(ctx); err != nil {
+			log.Warn("couldn't close cursor", "collection", purchases.Name(), "error", err)
+		}
+	}
+
+	return moved, nil
+}
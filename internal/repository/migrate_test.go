@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	assertTest "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSplitPurchaseCollection(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx := context.Background()
+	cfg, err := config.Init()
+	require.NoError(t, err)
+	db, err := mongo.NewMongo(ctx, cfg.Mongo)
+	require.NoError(t, err)
+
+	for _, name := range []string{"purchase", "comment", "file"} {
+		_, err = db.Collection(name).DeleteMany(ctx, bson.M{})
+		require.NoError(t, err)
+	}
+
+	date := time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC)
+	purchaseID, commentID, fileID := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	_, err = db.Collection("purchase").InsertMany(ctx, []interface{}{
+		bson.M{"_id": purchaseID, "userID": 1, "date": date, "fileID": fileID},
+		bson.M{"_id": commentID, "userID": 1, "purchaseID": purchaseID, "date": date, "text": "some"},
+		bson.M{"_id": fileID, "name": "some", "path": "some", "addDate": date, "authorID": 2},
+	})
+	require.NoError(t, err)
+	// A comment copied by an interrupted move is kept once.
+	_, err = db.Collection("comment").InsertOne(ctx, bson.M{"_id": commentID, "userID": 1, "purchaseID": purchaseID, "date": date, "text": "some"})
+	require.NoError(t, err)
+
+	moved, err := SplitPurchaseCollection(ctx, db, logger.Nop())
+	require.NoError(t, err)
+	assert.Equal(2, moved)
+
+	for name, exp := range map[string]primitive.ObjectID{"purchase": purchaseID, "comment": commentID, "file": fileID} {
+		var ids []primitive.ObjectID
+		cursor, err := db.Collection(name).Find(ctx, bson.M{})
+		require.NoError(t, err)
+		for cursor.Next(ctx) {
+			ids = append(ids, cursor.Current.Lookup("_id").ObjectID())
+		}
+		assert.Equal([]primitive.ObjectID{exp}, ids, name)
+	}
+
+	moved, err = SplitPurchaseCollection(ctx, db, logger.Nop())
+	require.NoError(t, err)
+	assert.Equal(0, moved)
+}
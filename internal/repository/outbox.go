@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// OutboxRepo is an outbox repository of domain events.
+type OutboxRepo struct {
+	collection *mongo.Collection
+}
+
+// NewOutboxRepo is an OutboxRepo constructor.
+func NewOutboxRepo(db *mongo.Database, log *logger.Logger) *OutboxRepo {
+	c := db.Collection("outbox")
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "dispatchedAt", Value: bsonx.Int64(1)},
+				{Key: "nextAttemptAt", Value: bsonx.Int64(1)},
+				{Key: "occurredAt", Value: bsonx.Int64(1)},
+			},
+			Options: options.Index().SetName("pending"),
+		},
+	}
+	_, err := c.Indexes().CreateMany(context.Background(), indexes)
+	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
+		return nil
+	}
+
+	return &OutboxRepo{collection: c}
+}
+
+// Add adds event to the outbox and returns id.
+// It takes part in the unit of work of the context, so the event is written along with its entity.
+func (o OutboxRepo) Add(ctx context.Context, event model.EventDTO) (string, error) {
+	eventEntity, err := event.Entity()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := o.collection.InsertOne(ctx, eventEntity)
+	if err != nil {
+		return "", err
+	}
+
+	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// Claim claims the oldest event due to be dispatched at now for the lease and counts the attempt.
+// Other dispatchers don't get the event until the lease expires.
+func (o OutboxRepo) Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.EventDTO, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "occurredAt", Value: 1}}).
+		SetReturnDocument(options.After)
+	query := bson.M{
+		"dispatchedAt":  nil,
+		"nextAttemptAt": bson.M{"$lte": now},
+	}
+	update := bson.M{
+		"$set": bson.M{"nextAttemptAt": now.Add(lease)},
+		"$inc": bson.M{"attempts": 1},
+	}
+	var event model.Event
+	err := o.collection.FindOneAndUpdate(ctx, query, update, opts).Decode(&event)
+	if err != nil {
+		return nil, err
+	}
+
+	return event.DTO(), nil
+}
+
+// MarkDispatched marks the event as dispatched and returns id.
+func (o OutboxRepo) MarkDispatched(ctx context.Context, id string, at time.Time) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	update := bson.M{
+		"$set":   bson.M{"dispatchedAt": at},
+		"$unset": bson.M{"lastError": ""},
+	}
+	res, err := o.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return "", err
+	}
+	if res.MatchedCount == 0 {
+		return "", mongo.ErrNoDocuments
+	}
+
+	return id, nil
+}
+
+// Retry schedules the next attempt to dispatch the event after failure and returns id.
+func (o OutboxRepo) Retry(ctx context.Context, id string, next time.Time, reason string) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	update := bson.M{
+		"$set": bson.M{"nextAttemptAt": next, "lastError": reason},
+	}
+	res, err := o.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return "", err
+	}
+	if res.MatchedCount == 0 {
+		return "", mongo.ErrNoDocuments
+	}
+
+	return id, nil
+}
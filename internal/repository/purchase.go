@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -18,7 +19,7 @@ type PurchaseRepo struct {
 }
 
 // NewPurchaseRepo is a PurchaseRepo constructor.
-func NewPurchaseRepo(db *mongo.Database) *PurchaseRepo {
+func NewPurchaseRepo(db *mongo.Database, log *logger.Logger) *PurchaseRepo {
 	c := db.Collection("purchase")
 	indexes := []mongo.IndexModel{
 		{
@@ -31,6 +32,7 @@ func NewPurchaseRepo(db *mongo.Database) *PurchaseRepo {
 	}
 	_, err := c.Indexes().CreateMany(context.Background(), indexes)
 	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
 		return nil
 	}
 
@@ -72,24 +74,22 @@ func (p PurchaseRepo) Delete(ctx context.Context, id string) (string, error) {
 	return purchase.ID.Hex(), nil
 }
 
-// DeleteByFileID deletes purchase by purchase userID and returns purchase userID.
-func (p PurchaseRepo) DeleteByFileID(ctx context.Context, id string) (string, error) {
-	opts := options.FindOneAndDelete().SetProjection(bson.D{{"fileID", 1}})
+// DeleteByFileID deletes all purchases of the file and returns count of deleted purchases.
+func (p PurchaseRepo) DeleteByFileID(ctx context.Context, id string) (int, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
 	query := bson.M{
 		"fileID": objID,
 	}
-	var purchase model.Purchase
-	err = p.collection.FindOneAndDelete(ctx, query, opts).Decode(&purchase)
+	res, err := p.collection.DeleteMany(ctx, query)
 	if err != nil {
-		return "", err
+		return 0, err
 	}
 
-	return purchase.FileID.Hex(), nil
+	return int(res.DeletedCount), nil
 }
 
 // FindByID finds purchase by userID.
@@ -9,6 +9,7 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	assertTest "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson"
@@ -27,7 +28,7 @@ func Connect2PurchaseMongo() (context.Context, *PurchaseRepo, error) {
 		return nil, nil, err
 	}
 
-	return ctx, NewPurchaseRepo(db), nil
+	return ctx, NewPurchaseRepo(db, logger.Nop()), nil
 }
 
 func TestPurchaseRepo_Create(t *testing.T) {
@@ -116,13 +117,12 @@ func TestPurchaseRepo_DeleteByFileID(t *testing.T) {
 	assert := assertTest.New(t)
 	ctx, repo, err := Connect2PurchaseMongo()
 	require.NoError(t, err)
+	fileID := primitive.NewObjectID().Hex()
 	type test struct {
-		name     string
-		isOk     bool
-		id       string
-		fn       func(data *test)
-		purchase model.PurchaseDTO
-		expErr   error
+		name      string
+		id        string
+		purchases int
+		expErr    error
 	}
 	tt := []test{
 		{
@@ -130,39 +130,37 @@ func TestPurchaseRepo_DeleteByFileID(t *testing.T) {
 			expErr: errors.New("the provided hex string is not a valid ObjectID"),
 		},
 		{
-			name:   "not found",
-			id:     primitive.NewObjectID().Hex(),
-			expErr: errors.New("mongo: no documents in result"),
+			name: "not found",
+			id:   primitive.NewObjectID().Hex(),
 		},
 		{
-			name: "all ok",
-			isOk: true,
-			id:   primitive.NewObjectID().Hex(),
-			fn: func(data *test) {
-				data.purchase.FileID = data.id
-			},
-			purchase: model.PurchaseDTO{
-				UserID: 1,
-				Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
-			},
+			name:      "all ok",
+			id:        fileID,
+			purchases: 2,
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			fileID := tc.id
-			if tc.fn != nil {
-				tc.fn(&tc)
+			for i := 1; i <= tc.purchases; i++ {
+				_, err = repo.Create(ctx, model.PurchaseDTO{
+					UserID: i,
+					FileID: fileID,
+					Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				})
+				assert.NoError(err)
 			}
-			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			other, err := repo.Create(ctx, model.PurchaseDTO{
+				UserID: 1,
+				FileID: primitive.NewObjectID().Hex(),
+				Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+			})
 			assert.NoError(err)
-			if tc.isOk {
-				_, err = repo.Create(ctx, tc.purchase)
-			}
-			id, err := repo.DeleteByFileID(ctx, fileID)
+
+			count, err := repo.DeleteByFileID(ctx, tc.id)
 			assert.Equal(tc.expErr, err)
-			if tc.isOk {
-				assert.Equal(fileID, id)
-			}
+			assert.Equal(tc.purchases, count)
+			_, err = repo.FindByID(ctx, other)
+			assert.NoError(err)
 			_, err = repo.collection.DeleteMany(ctx, bson.M{})
 			assert.NoError(err)
 		})
@@ -5,14 +5,21 @@ import (
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 )
 
+// ErrModified is returned when a document was changed since the revision expected by the caller.
+var ErrModified = errors.New("document was modified")
+
 // Purchase is an interface for PurchaseRepo methods.
 type Purchase interface {
 	Create(ctx context.Context, purchase model.PurchaseDTO) (string, error)
 	Delete(ctx context.Context, id string) (string, error)
-	DeleteByFileID(ctx context.Context, id string) (string, error)
+	DeleteByFileID(ctx context.Context, id string) (int, error)
 	FindByID(ctx context.Context, id string) (*model.PurchaseDTO, error)
 	FindLastByUserID(ctx context.Context, id int) (*model.PurchaseDTO, error)
 	FindAllByUserID(ctx context.Context, id int) ([]model.PurchaseDTO, error)
@@ -32,14 +39,24 @@ type Purchase interface {
 type Comment interface {
 	Create(ctx context.Context, comment model.CommentDTO) (string, error)
 	Update(ctx context.Context, id string, comment model.CommentDTO) (string, error)
-	Delete(ctx context.Context, id string) (string, error)
-	DeleteByPurchaseID(ctx context.Context, id string) (string, error)
+	Delete(ctx context.Context, id string, revision int, at time.Time) (string, error)
+	Tombstone(ctx context.Context, id string, revision int, at time.Time) (string, error)
+	Flag(ctx context.Context, id string, userID int) (string, error)
+	UpdateStatus(ctx context.Context, id, status string) (string, error)
+	DeleteByPurchaseID(ctx context.Context, id string) (int, error)
 	FindByID(ctx context.Context, id string) (*model.CommentDTO, error)
+	FindVisibleByID(ctx context.Context, id string, userID int) (*model.CommentDTO, error)
+	FindAnyByID(ctx context.Context, id string) (*model.CommentDTO, error)
 	FindAllByUserID(ctx context.Context, id int) ([]model.CommentDTO, error)
 	FindByPurchaseID(ctx context.Context, id string) ([]model.CommentDTO, error)
+	FindAnyByPurchaseID(ctx context.Context, id string) ([]model.CommentDTO, error)
+	FindByPurchaseIDs(ctx context.Context, ids []string) ([]model.CommentDTO, error)
+	CountReplies(ctx context.Context, id string) (int64, error)
+	FindRevisions(ctx context.Context, id string) ([]model.CommentRevisionDTO, error)
 	FindByUserIDAndPurchaseID(ctx context.Context, userID int, purchaseID string) ([]model.CommentDTO, error)
 	FindAll(ctx context.Context) ([]model.CommentDTO, error)
-	FindByText(ctx context.Context, text string) ([]model.CommentDTO, error)
+	FindByStatus(ctx context.Context, statuses []string) ([]model.CommentDTO, error)
+	FindByText(ctx context.Context, text, language string) ([]model.CommentDTO, error)
 	FindByPeriod(ctx context.Context, start, end time.Time) ([]model.CommentDTO, error)
 }
 
@@ -47,11 +64,16 @@ type Comment interface {
 type File interface {
 	Create(ctx context.Context, file model.FileDTO) (string, error)
 	Update(ctx context.Context, id string, file model.FileDTO) (string, error)
-	Delete(ctx context.Context, id string) (string, error)
+	Patch(ctx context.Context, id string, patch model.FilePatch) (string, error)
+	SetVersion(ctx context.Context, id string, version model.FileVersionDTO) (string, error)
+	UpdateRating(ctx context.Context, id string, sum, count int) (string, error)
+	Delete(ctx context.Context, id string, revision int) (string, error)
 	DeleteByAuthorID(ctx context.Context, id int) (int, error)
 	FindByID(ctx context.Context, id string) (*model.FileDTO, error)
 	FindByName(ctx context.Context, name string) ([]model.FileDTO, error)
+	FindByText(ctx context.Context, text, language string) ([]model.FileDTO, error)
 	FindAll(ctx context.Context) ([]model.FileDTO, error)
+	FindByRating(ctx context.Context) ([]model.FileDTO, error)
 	FindByAuthorID(ctx context.Context, id int) ([]model.FileDTO, error)
 	FindNotActual(ctx context.Context) ([]model.FileDTO, error)
 	FindActual(ctx context.Context) ([]model.FileDTO, error)
@@ -59,18 +81,115 @@ type File interface {
 	FindUpdatedByPeriod(ctx context.Context, start, end time.Time) ([]model.FileDTO, error)
 }
 
+// FileVersion is an interface for FileVersionRepo methods.
+type FileVersion interface {
+	Create(ctx context.Context, version model.FileVersionDTO) (string, error)
+	DeleteByFileID(ctx context.Context, id string) (int, error)
+	FindByFileID(ctx context.Context, id string) ([]model.FileVersionDTO, error)
+	FindByFileIDAndNumber(ctx context.Context, id string, number int) (*model.FileVersionDTO, error)
+}
+
+// Download is an interface for DownloadRepo methods.
+type Download interface {
+	Create(ctx context.Context, download model.DownloadDTO) (string, error)
+	FindByFileID(ctx context.Context, id string) ([]model.DownloadDTO, error)
+}
+
+// Link is an interface for LinkRepo methods.
+type Link interface {
+	Use(ctx context.Context, nonce string, expires time.Time) (bool, error)
+}
+
+// Outbox is an interface for OutboxRepo methods.
+type Outbox interface {
+	Add(ctx context.Context, event model.EventDTO) (string, error)
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.EventDTO, error)
+	MarkDispatched(ctx context.Context, id string, at time.Time) (string, error)
+	Retry(ctx context.Context, id string, next time.Time, reason string) (string, error)
+}
+
+// Webhook is an interface for WebhookRepo methods.
+type Webhook interface {
+	Create(ctx context.Context, webhook model.WebhookDTO) (string, error)
+	Update(ctx context.Context, id string, webhook model.WebhookDTO) (string, error)
+	Delete(ctx context.Context, id string) (string, error)
+	FindByID(ctx context.Context, id string) (*model.WebhookDTO, error)
+	FindByUserID(ctx context.Context, userID int) ([]model.WebhookDTO, error)
+	FindSubscribed(ctx context.Context, eventType string, userID int) ([]model.WebhookDTO, error)
+}
+
+// Delivery is an interface for DeliveryRepo methods.
+type Delivery interface {
+	Create(ctx context.Context, delivery model.DeliveryDTO) (string, error)
+	Update(ctx context.Context, id string, delivery model.DeliveryDTO) (string, error)
+	Claim(ctx context.Context, now time.Time, lease time.Duration) (*model.DeliveryDTO, error)
+	FindByID(ctx context.Context, id string) (*model.DeliveryDTO, error)
+	FindByWebhookID(ctx context.Context, webhookID string, status string) ([]model.DeliveryDTO, error)
+	DeleteByWebhookID(ctx context.Context, webhookID string) (string, error)
+}
+
+// ResumeToken is an interface for ResumeTokenRepo methods.
+type ResumeToken interface {
+	Find(ctx context.Context, name string) (bson.Raw, error)
+	Save(ctx context.Context, name string, token bson.Raw, at time.Time) (string, error)
+}
+
 // Repositories collects all repository interfaces.
 type Repositories struct {
-	Purchase Purchase
-	Comment  Comment
-	File     File
+	Purchase    Purchase
+	Comment     Comment
+	File        File
+	FileVersion FileVersion
+	Download    Download
+	Link        Link
+	Outbox      Outbox
+	Webhook     Webhook
+	Delivery    Delivery
+	ResumeToken ResumeToken
 }
 
 // NewRepositories is a Repositories constructor.
-func NewRepositories(db *mongo.Database) *Repositories {
+func NewRepositories(db *mongo.Database, log *logger.Logger) *Repositories {
 	return &Repositories{
-		Purchase: NewPurchaseRepo(db),
-		Comment:  NewCommentRepo(db),
-		File:     NewFileRepo(db),
+		Purchase:    NewPurchaseRepo(db, log),
+		Comment:     NewCommentRepo(db, log),
+		File:        NewFileRepo(db, log),
+		FileVersion: NewFileVersionRepo(db, log),
+		Download:    NewDownloadRepo(db, log),
+		Link:        NewLinkRepo(db, log),
+		Outbox:      NewOutboxRepo(db, log),
+		Webhook:     NewWebhookRepo(db, log),
+		Delivery:    NewDeliveryRepo(db, log),
+		ResumeToken: NewResumeTokenRepo(db),
+	}
+}
+
+// revisionQuery matches the document with the id of the revision.
+// Documents created before revisions were counted have no revision and match revision 0,
+// AnyRevision matches every revision.
+func revisionQuery(id primitive.ObjectID, revision int) bson.M {
+	query := bson.M{"_id": id}
+	switch revision {
+	case model.AnyRevision:
+	case 0:
+		query["revision"] = bson.M{"$in": bson.A{0, nil}}
+	default:
+		query["revision"] = revision
 	}
+
+	return query
+}
+
+// notMatched explains why a document with the id wasn't matched by a revision filter.
+// It returns ErrModified if the document exists and mongo.ErrNoDocuments otherwise.
+func notMatched(context context.Context, collection *mongo.Collection, id primitive.ObjectID) error {
+	count, err := collection.CountDocuments(context, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count != 0 {
+		return ErrModified
+	}
+
+	return mongo.ErrNoDocuments
 }
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ResumeTokenRepo is a repository of change stream resume tokens, which are kept by watcher name.
+type ResumeTokenRepo struct {
+	collection *mongo.Collection
+}
+
+// NewResumeTokenRepo is a ResumeTokenRepo constructor.
+func NewResumeTokenRepo(db *mongo.Database) *ResumeTokenRepo {
+	return &ResumeTokenRepo{collection: db.Collection("resume_token")}
+}
+
+// Find finds the last saved resume token of the watcher.
+func (r ResumeTokenRepo) Find(ctx context.Context, name string) (bson.Raw, error) {
+	var token model.ResumeToken
+	err := r.collection.FindOne(ctx, bson.M{"_id": name}).Decode(&token)
+	if err != nil {
+		return nil, err
+	}
+
+	return token.Token, nil
+}
+
+// Save saves the resume token of the watcher and returns its name. A nil token forgets the saved one.
+func (r ResumeTokenRepo) Save(ctx context.Context, name string, token bson.Raw, at time.Time) (string, error) {
+	update := bson.M{
+		"$set": bson.M{"token": token, "updated": at},
+	}
+	if token == nil {
+		update = bson.M{
+			"$set":   bson.M{"updated": at},
+			"$unset": bson.M{"token": ""},
+		}
+	}
+	_, err := r.collection.UpdateOne(ctx, bson.M{"_id": name}, update, options.Update().SetUpsert(true))
+	if err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
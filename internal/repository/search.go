@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"regexp"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultSearchLanguage is a language used by text indexes when the request doesn't specify one.
+const defaultSearchLanguage = "english"
+
+// scoreProjection projects relevance score of the text search. The score isn't stored with documents.
+var scoreProjection = bson.M{"score": bson.M{"$meta": "textScore"}}
+
+// scoredComment represents a comment found by text search with its relevance score.
+type scoredComment struct {
+	model.Comment `bson:",inline"`
+	Score         float64 `bson:"score"`
+}
+
+// scoredComments represents a slice of scoredComment.
+type scoredComments []scoredComment
+
+// DTO converts scoredComments to a slice of CommentDTO with scores.
+func (c scoredComments) DTO() []model.CommentDTO {
+	var comments []model.CommentDTO
+	for _, scored := range c {
+		comment := scored.Comment.DTO()
+		comment.Score = scored.Score
+		comments = append(comments, *comment)
+	}
+	return comments
+}
+
+// scoredFile represents a file found by text search with its relevance score.
+type scoredFile struct {
+	model.File `bson:",inline"`
+	Score      float64 `bson:"score"`
+}
+
+// scoredFiles represents a slice of scoredFile.
+type scoredFiles []scoredFile
+
+// DTO converts scoredFiles to a slice of FileDTO with scores.
+func (f scoredFiles) DTO() []model.FileDTO {
+	var files []model.FileDTO
+	for _, scored := range f {
+		file := scored.File.DTO()
+		file.Score = scored.Score
+		files = append(files, *file)
+	}
+	return files
+}
+
+// textSearch builds a case and diacritic insensitive $text operator.
+func textSearch(text, language string) bson.M {
+	search := bson.M{
+		"$search":             text,
+		"$caseSensitive":      false,
+		"$diacriticSensitive": false,
+	}
+	if language != "" {
+		search["$language"] = language
+	}
+
+	return search
+}
+
+// prefixRegex builds a case-insensitive regex matching words which start with escaped text.
+func prefixRegex(text string) primitive.Regex {
+	return primitive.Regex{
+		Pattern: `\b` + regexp.QuoteMeta(text),
+		Options: "i",
+	}
+}
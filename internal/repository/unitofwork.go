@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// TransactionsAuto uses transactions if the deployment is a replica set or a sharded cluster.
+	TransactionsAuto = "auto"
+	// TransactionsOn requires transactions.
+	TransactionsOn = "on"
+	// TransactionsOff runs units of work as sequential writes.
+	TransactionsOff = "off"
+)
+
+// UnitOfWork runs several repository operations as a whole.
+type UnitOfWork interface {
+	// Do runs fn as a unit of work. Repository operations take part in it when they are called with the context passed to fn.
+	Do(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// NewUnitOfWork creates UnitOfWork of the transactions mode. Auto mode falls back to sequential writes on a standalone server.
+func NewUnitOfWork(ctx context.Context, db *mongo.Database, mode string, log *logger.Logger) (UnitOfWork, error) {
+	switch mode {
+	case TransactionsOff:
+		return SequentialUnitOfWork{}, nil
+	case TransactionsOn, TransactionsAuto:
+	default:
+		return nil, errors.Errorf("unknown transactions mode %q", mode)
+	}
+
+	supported, err := supportsTransactions(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if !supported {
+		if mode == TransactionsOn {
+			return nil, errors.New("transactions require a replica set or a sharded cluster")
+		}
+		log.Warn("transactions are not supported by the deployment, units of work run as sequential writes")
+		return SequentialUnitOfWork{}, nil
+	}
+
+	return SessionUnitOfWork{db.Client()}, nil
+}
+
+// supportsTransactions checks whether the server is a replica set member or a mongos.
+func supportsTransactions(ctx context.Context, db *mongo.Database) (bool, error) {
+	var res struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := db.RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&res); err != nil {
+		return false, errors.Wrap(err, "couldn't check deployment")
+	}
+
+	return res.SetName != "" || res.Msg == "isdbgrid", nil
+}
+
+// SessionUnitOfWork runs units of work in session transactions, which are retried on transient errors.
+type SessionUnitOfWork struct {
+	client *mongo.Client
+}
+
+// Do runs fn in a transaction. A unit of work started inside another one joins its transaction.
+func (u SessionUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	if mongo.SessionFromContext(ctx) != nil {
+		return fn(ctx)
+	}
+
+	session, err := u.client.StartSession()
+	if err != nil {
+		return errors.Wrap(err, "couldn't start session")
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessCtx)
+	})
+
+	return err
+}
+
+// SequentialUnitOfWork runs units of work as sequential writes without atomicity.
+type SequentialUnitOfWork struct{}
+
+// Do runs fn.
+func (SequentialUnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	assertTest "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Connect2UnitOfWorkMongo connects to a single-node replica set, transactions are required.
+func Connect2UnitOfWorkMongo() (context.Context, UnitOfWork, *PurchaseRepo, *CommentRepo, error) {
+	ctx := context.Background()
+	cfg, err := config.Init()
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	db, err := mongo.NewMongo(ctx, cfg.Mongo)
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	tx, err := NewUnitOfWork(ctx, db, TransactionsOn, logger.Nop())
+	if err != nil {
+		return nil, nil, nil, nil, err
+	}
+
+	return ctx, tx, NewPurchaseRepo(db, logger.Nop()), NewCommentRepo(db, logger.Nop()), nil
+}
+
+func TestUnitOfWork_Do(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, tx, purchaseRepo, commentRepo, err := Connect2UnitOfWorkMongo()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		tx     UnitOfWork
+		nested bool
+		fnErr  error
+		expErr error
+		expDoc int64
+	}
+	tt := []test{
+		{
+			name:   "committed",
+			tx:     tx,
+			expDoc: 1,
+		},
+		{
+			name:   "nested committed",
+			tx:     tx,
+			nested: true,
+			expDoc: 1,
+		},
+		{
+			name:   "aborted",
+			tx:     tx,
+			fnErr:  errors.New("failed"),
+			expErr: errors.New("failed"),
+		},
+		{
+			name:   "sequential not rolled back",
+			tx:     SequentialUnitOfWork{},
+			fnErr:  errors.New("failed"),
+			expErr: errors.New("failed"),
+			expDoc: 1,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			fn := func(ctx context.Context) error {
+				purchaseID, err := purchaseRepo.Create(ctx, model.PurchaseDTO{
+					UserID: 1,
+					Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					FileID: primitive.NewObjectID().Hex(),
+				})
+				if err != nil {
+					return err
+				}
+				_, err = commentRepo.Create(ctx, model.CommentDTO{
+					UserID:     1,
+					PurchaseID: purchaseID,
+					Date:       time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+					Text:       "text",
+				})
+				if err != nil {
+					return err
+				}
+
+				return tc.fnErr
+			}
+
+			err := tc.tx.Do(ctx, func(ctx context.Context) error {
+				if tc.nested {
+					return tc.tx.Do(ctx, fn)
+				}
+				return fn(ctx)
+			})
+			if tc.expErr != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			} else {
+				assert.NoError(err)
+			}
+
+			purchases, err := purchaseRepo.collection.CountDocuments(ctx, bson.M{})
+			assert.NoError(err)
+			assert.Equal(tc.expDoc, purchases)
+			comments, err := commentRepo.collection.CountDocuments(ctx, bson.M{})
+			assert.NoError(err)
+			assert.Equal(tc.expDoc, comments)
+
+			_, err = purchaseRepo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+			_, err = commentRepo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// FileVersionRepo is a file version repository.
+type FileVersionRepo struct {
+	collection *mongo.Collection
+}
+
+// NewFileVersionRepo is a FileVersionRepo constructor.
+func NewFileVersionRepo(db *mongo.Database, log *logger.Logger) *FileVersionRepo {
+	c := db.Collection("file_version")
+	indexes := []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "fileID", Value: bsonx.Int64(1)},
+				{Key: "number", Value: bsonx.Int64(-1)},
+			},
+			Options: options.Index().
+				SetName("fileID").
+				SetUnique(true),
+		},
+	}
+	_, err := c.Indexes().CreateMany(context.Background(), indexes)
+	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
+		return nil
+	}
+
+	return &FileVersionRepo{collection: c}
+}
+
+// Create creates new file version and returns id.
+// Creating a version with a number already taken by the file returns duplicate key error.
+func (f FileVersionRepo) Create(context context.Context, version model.FileVersionDTO) (string, error) {
+	versionEntity, err := version.Entity()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := f.collection.InsertOne(context, versionEntity)
+	if err != nil {
+		return "", err
+	}
+
+	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// DeleteByFileID deletes all versions of the file and returns count of deleted versions.
+func (f FileVersionRepo) DeleteByFileID(context context.Context, id string) (int, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return 0, err
+	}
+
+	query := bson.M{
+		"fileID": objID,
+	}
+	res, err := f.collection.DeleteMany(context, query)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.DeletedCount), nil
+}
+
+// FindByFileID finds versions of the file sorted from the latest.
+func (f FileVersionRepo) FindByFileID(context context.Context, id string) ([]model.FileVersionDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := options.Find().SetSort(bson.M{"number": -1})
+	query := bson.M{
+		"fileID": objID,
+	}
+	var versions model.FileVersions
+	cursor, err := f.collection.Find(context, query, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(context, &versions)
+	if err != nil {
+		return nil, err
+	}
+
+	return versions.DTO(), nil
+}
+
+// FindByFileIDAndNumber finds version of the file by version number.
+func (f FileVersionRepo) FindByFileIDAndNumber(context context.Context, id string, number int) (*model.FileVersionDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	query := bson.M{
+		"fileID": objID,
+		"number": number,
+	}
+	var version model.FileVersion
+	err = f.collection.FindOne(context, query).Decode(&version)
+	if err != nil {
+		return nil, err
+	}
+
+	return version.DTO(), nil
+}
@@ -0,0 +1,238 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	assertTest "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	driver "go.mongodb.org/mongo-driver/mongo"
+)
+
+func Connect2FileVersionMongo() (context.Context, *FileVersionRepo, error) {
+	ctx := context.Background()
+	cfg, err := config.Init()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := mongo.NewMongo(ctx, cfg.Mongo)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, NewFileVersionRepo(db, logger.Nop()), nil
+}
+
+func TestFileVersionRepo_Create(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileVersionMongo()
+	require.NoError(t, err)
+	fileID := primitive.NewObjectID().Hex()
+	type test struct {
+		name    string
+		version model.FileVersionDTO
+		isDup   bool
+		expErr  error
+	}
+	tt := []test{
+		{
+			name:   "not correct file id",
+			expErr: errors.New("invalid file id: the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "all ok",
+			version: model.FileVersionDTO{
+				FileID: fileID,
+				Number: 1,
+				Name:   "some",
+				Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+			},
+		},
+		{
+			name:  "duplicate number",
+			isDup: true,
+			version: model.FileVersionDTO{
+				FileID: fileID,
+				Number: 1,
+				Name:   "some",
+				Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.isDup {
+				_, err = repo.Create(ctx, tc.version)
+				assert.NoError(err)
+			}
+			id, err := repo.Create(ctx, tc.version)
+			switch {
+			case tc.expErr != nil:
+				assert.Equal(tc.expErr.Error(), err.Error())
+			case tc.isDup:
+				assert.True(driver.IsDuplicateKeyError(err))
+			default:
+				assert.NoError(err)
+				assert.True(primitive.IsValidObjectID(id))
+			}
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestFileVersionRepo_FindByFileID(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileVersionMongo()
+	require.NoError(t, err)
+	fileID := primitive.NewObjectID().Hex()
+	type test struct {
+		name     string
+		id       string
+		versions []model.FileVersionDTO
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name: "all ok",
+			id:   fileID,
+			versions: []model.FileVersionDTO{
+				{
+					FileID: fileID,
+					Number: 2,
+					Name:   "other",
+					Date:   time.Date(2020, time.December, 10, 23, 10, 34, 0, time.UTC),
+				},
+				{
+					FileID: fileID,
+					Number: 1,
+					Name:   "some",
+					Date:   time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := len(tc.versions) - 1; i >= 0; i-- {
+				tc.versions[i].ID, err = repo.Create(ctx, tc.versions[i])
+				assert.NoError(err)
+			}
+			_, err = repo.Create(ctx, model.FileVersionDTO{
+				FileID: primitive.NewObjectID().Hex(),
+				Number: 1,
+				Date:   time.Date(2020, time.October, 10, 23, 10, 34, 0, time.UTC),
+			})
+			assert.NoError(err)
+			versions, err := repo.FindByFileID(ctx, tc.id)
+			assert.Equal(tc.expErr, err)
+			assert.Equal(tc.versions, versions)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestFileVersionRepo_FindByFileIDAndNumber(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileVersionMongo()
+	require.NoError(t, err)
+	fileID := primitive.NewObjectID().Hex()
+	type test struct {
+		name    string
+		id      string
+		number  int
+		version *model.FileVersionDTO
+		expErr  error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:   "not found",
+			id:     fileID,
+			number: 3,
+			expErr: errors.New("mongo: no documents in result"),
+		},
+		{
+			name:   "all ok",
+			id:     fileID,
+			number: 1,
+			version: &model.FileVersionDTO{
+				FileID: fileID,
+				Number: 1,
+				Name:   "some",
+				Date:   time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.version != nil {
+				tc.version.ID, err = repo.Create(ctx, *tc.version)
+				assert.NoError(err)
+			}
+			version, err := repo.FindByFileIDAndNumber(ctx, tc.id, tc.number)
+			assert.Equal(tc.expErr, err)
+			assert.Equal(tc.version, version)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestFileVersionRepo_DeleteByFileID(t *testing.T) {
+	assert := assertTest.New(t)
+	ctx, repo, err := Connect2FileVersionMongo()
+	require.NoError(t, err)
+	fileID := primitive.NewObjectID().Hex()
+	type test struct {
+		name     string
+		id       string
+		versions int
+		expErr   error
+	}
+	tt := []test{
+		{
+			name:   "not correct id",
+			expErr: errors.New("the provided hex string is not a valid ObjectID"),
+		},
+		{
+			name:     "all ok",
+			id:       fileID,
+			versions: 2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			for i := 1; i <= tc.versions; i++ {
+				_, err = repo.Create(ctx, model.FileVersionDTO{
+					FileID: fileID,
+					Number: i,
+					Date:   time.Date(2020, time.November, 10, 23, 10, 34, 0, time.UTC),
+				})
+				assert.NoError(err)
+			}
+			count, err := repo.DeleteByFileID(ctx, tc.id)
+			assert.Equal(tc.expErr, err)
+			assert.Equal(tc.versions, count)
+			_, err = repo.collection.DeleteMany(ctx, bson.M{})
+			assert.NoError(err)
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/x/bsonx"
+)
+
+// WebhookRepo is a webhook subscription repository.
+type WebhookRepo struct {
+	collection *mongo.Collection
+}
+
+// NewWebhookRepo is a WebhookRepo constructor.
+func NewWebhookRepo(db *mongo.Database, log *logger.Logger) *WebhookRepo {
+	c := db.Collection("webhook")
+	indexes := []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "userID", Value: bsonx.Int64(1)}},
+			Options: options.Index().SetName("userID"),
+		},
+		{
+			Keys: bson.D{
+				{Key: "events", Value: bsonx.Int64(1)},
+				{Key: "active", Value: bsonx.Int64(1)},
+			},
+			Options: options.Index().SetName("events"),
+		},
+	}
+	_, err := c.Indexes().CreateMany(context.Background(), indexes)
+	if err != nil {
+		log.Error("couldn't create indexes", "collection", c.Name(), "error", err)
+		return nil
+	}
+
+	return &WebhookRepo{collection: c}
+}
+
+// Create creates new webhook and returns id.
+func (w WebhookRepo) Create(ctx context.Context, webhook model.WebhookDTO) (string, error) {
+	webhookEntity, err := webhook.Entity()
+	if err != nil {
+		return "", err
+	}
+
+	res, err := w.collection.InsertOne(ctx, webhookEntity)
+	if err != nil {
+		return "", err
+	}
+
+	return res.InsertedID.(primitive.ObjectID).Hex(), nil
+}
+
+// Update updates url, events, secret and activity of the webhook and returns id.
+func (w WebhookRepo) Update(ctx context.Context, id string, webhook model.WebhookDTO) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"url":     webhook.URL,
+			"events":  webhook.Events,
+			"secret":  webhook.Secret,
+			"active":  webhook.Active,
+			"updated": webhook.Updated,
+		},
+	}
+	res, err := w.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
+	if err != nil {
+		return "", err
+	}
+	if res.MatchedCount == 0 {
+		return "", mongo.ErrNoDocuments
+	}
+
+	return id, nil
+}
+
+// Delete deletes webhook and returns deleted id.
+func (w WebhookRepo) Delete(ctx context.Context, id string) (string, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := w.collection.DeleteOne(ctx, bson.M{"_id": objID})
+	if err != nil {
+		return "", err
+	}
+	if res.DeletedCount == 0 {
+		return "", mongo.ErrNoDocuments
+	}
+
+	return id, nil
+}
+
+// FindByID finds webhook by id.
+func (w WebhookRepo) FindByID(ctx context.Context, id string) (*model.WebhookDTO, error) {
+	objID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var webhook model.Webhook
+	err = w.collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&webhook)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhook.DTO(), nil
+}
+
+// FindByUserID finds webhooks of the user.
+func (w WebhookRepo) FindByUserID(ctx context.Context, userID int) ([]model.WebhookDTO, error) {
+	var webhooks model.Webhooks
+	cursor, err := w.collection.Find(ctx, bson.M{"userID": userID})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks.DTO(), nil
+}
+
+// FindSubscribed finds active webhooks subscribed to the event type which are either of the user or global.
+func (w WebhookRepo) FindSubscribed(ctx context.Context, eventType string, userID int) ([]model.WebhookDTO, error) {
+	query := bson.M{
+		"events": eventType,
+		"active": true,
+		"$or": bson.A{
+			bson.M{"userID": userID},
+			bson.M{"global": true},
+		},
+	}
+	var webhooks model.Webhooks
+	cursor, err := w.collection.Find(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	err = cursor.All(ctx, &webhooks)
+	if err != nil {
+		return nil, err
+	}
+
+	return webhooks.DTO(), nil
+}
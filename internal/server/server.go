@@ -3,14 +3,17 @@ package server
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/pkg/errors"
 )
 
 // Server represents a http server structure.
 type Server struct {
 	httpServer *http.Server
+	listener   net.Listener
 }
 
 // NewServer is a Server constructor.
@@ -26,12 +29,43 @@ func NewServer(cfg *config.Config, handler http.Handler) *Server {
 	}
 }
 
-// Run runs a http server.
+// Listen listens on the address of the server, so errors of the address are returned before serving.
+func (s *Server) Listen(ctx context.Context) error {
+	var lc net.ListenConfig
+	listener, err := lc.Listen(ctx, "tcp", s.httpServer.Addr)
+	if err != nil {
+		return errors.Wrap(err, "couldn't listen")
+	}
+	s.listener = listener
+
+	return nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	if s.listener == nil {
+		return s.httpServer.Addr
+	}
+
+	return s.listener.Addr().String()
+}
+
+// Run serves requests until the server is stopped. It listens first if Listen wasn't called.
 func (s *Server) Run() error {
-	return s.httpServer.ListenAndServe()
+	if s.listener == nil {
+		if err := s.Listen(context.Background()); err != nil {
+			return err
+		}
+	}
+
+	if err := s.httpServer.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
 }
 
-// Stop stops a http server.
+// Stop stops a http server. It waits for in-flight requests until the context is done.
 func (s *Server) Stop(ctx context.Context) error {
 	return s.httpServer.Shutdown(ctx)
 }
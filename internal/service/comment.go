@@ -2,26 +2,63 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/JesusG2000/hexsatisfaction/pkg/grpc/api"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/filter"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrRatingNotAllowed is returned when a file is rated by a user who doesn't own the purchase.
+	ErrRatingNotAllowed = errors.New("only purchase owner can rate the file")
+	// ErrParentDeleted is returned when a reply is added to a deleted comment.
+	ErrParentDeleted = errors.New("couldn't reply to deleted comment")
+	// ErrCommentRejected is returned when a comment text is rejected by the content filter.
+	ErrCommentRejected = errors.New("comment text isn't allowed")
+	// ErrNotCommentOwner is returned when a comment is changed by a user who doesn't own it.
+	ErrNotCommentOwner = errors.New("only comment owner can change the comment")
+	// ErrReplyRating is returned when a reply is rated.
+	ErrReplyRating = errors.New("reply couldn't have rating")
+	// ErrAlreadyRated is returned when a purchase rated by another comment is rated again.
+	ErrAlreadyRated = errors.New("purchase is already rated")
 )
 
 // CommentService is a purchase service.
 type CommentService struct {
 	repository.Comment
-	client api.ExistanceClient
+	purchase repository.Purchase
+	file     repository.File
+	outbox   repository.Outbox
+	tx       repository.UnitOfWork
+	filter   filter.Filter
+	clock    clock.Clock
+	client   api.ExistanceClient
+	log      *logger.Logger
 }
 
 // NewCommentService is a CommentService service constructor.
-func NewCommentService(comment repository.Comment, client api.ExistanceClient) *CommentService {
-	return &CommentService{comment, client}
+func NewCommentService(comment repository.Comment, purchase repository.Purchase, file repository.File, outbox repository.Outbox, tx repository.UnitOfWork, filter filter.Filter, clock clock.Clock, client api.ExistanceClient, log *logger.Logger) *CommentService {
+	return &CommentService{comment, purchase, file, outbox, tx, filter, clock, client, log}
 }
 
-// Create creates comments and returns id.
+// Create creates comment dated now and returns id. CommentPosted event is recorded with the comment.
 func (c CommentService) Create(ctx context.Context, request model.CreateCommentRequest) (string, error) {
+	return c.create(ctx, request, c.clock.Now(), true)
+}
+
+// Import creates comment with the requested date and returns id.
+// No event is recorded for imported comments as they were posted before.
+func (c CommentService) Import(ctx context.Context, request model.ImportCommentRequest) (string, error) {
+	return c.create(ctx, request.CreateCommentRequest, request.Date, false)
+}
+
+func (c CommentService) create(ctx context.Context, request model.CreateCommentRequest, date time.Time, posted bool) (string, error) {
 	var id string
 	res, err := c.client.User(ctx, &api.IsUserExistRequest{Id: int32(request.UserID)})
 	if err != nil {
@@ -29,22 +66,74 @@ func (c CommentService) Create(ctx context.Context, request model.CreateCommentR
 	}
 
 	if res.Exist {
+		status, err := c.moderate(ctx, request.Text)
+		if err != nil {
+			return "", err
+		}
+
+		purchaseID := request.PurchaseID
+		if request.ParentID != "" {
+			parent, err := c.Comment.FindByID(ctx, request.ParentID)
+			if err != nil {
+				return "", errors.Wrap(err, "couldn't find parent comment")
+			}
+			if parent.Deleted {
+				return "", ErrParentDeleted
+			}
+			purchaseID = parent.PurchaseID
+		}
+
+		var fileID string
+		if request.Rating != 0 {
+			fileID, err = c.ratedFileID(ctx, request.UserID, purchaseID)
+			if err != nil {
+				return "", err
+			}
+			err = c.checkRated(ctx, purchaseID, "")
+			if err != nil {
+				return "", err
+			}
+		}
+
 		comment := model.CommentDTO{
 			UserID:     request.UserID,
-			PurchaseID: request.PurchaseID,
-			Date:       request.Date,
+			PurchaseID: purchaseID,
+			ParentID:   request.ParentID,
+			Date:       date,
 			Text:       request.Text,
+			Rating:     request.Rating,
+			Status:     status,
 		}
-		id, err = c.Comment.Create(ctx, comment)
+		err = c.tx.Do(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = c.Comment.Create(ctx, comment)
+			if err != nil {
+				return errors.Wrap(err, "couldn't create comment")
+			}
+
+			if request.Rating != 0 {
+				_, err = c.file.UpdateRating(ctx, fileID, request.Rating, 1)
+				if err != nil {
+					return errors.Wrap(err, "couldn't update file rating")
+				}
+			}
+			if !posted {
+				return nil
+			}
+
+			comment.ID = id
+			return record(ctx, c.outbox, model.EventCommentPosted, id, comment, date)
+		})
 		if err != nil {
-			return "", errors.Wrap(err, "couldn't create comment")
+			return "", err
 		}
 	}
 
 	return id, nil
 }
 
-// Update updates comments and returns id.
+// Update updates text and rating of the comment and returns id.
+// Owner, purchase and creation date of the comment couldn't be changed.
 func (c CommentService) Update(ctx context.Context, request model.UpdateCommentRequest) (string, error) {
 	var id string
 	res, err := c.client.User(ctx, &api.IsUserExistRequest{Id: int32(request.UserID)})
@@ -53,33 +142,341 @@ func (c CommentService) Update(ctx context.Context, request model.UpdateCommentR
 	}
 
 	if res.Exist {
+		old, err := c.Comment.FindAnyByID(ctx, request.ID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't find comment")
+		}
+		if old.Deleted {
+			return "", nil
+		}
+		if old.UserID != request.UserID {
+			return "", ErrNotCommentOwner
+		}
+		if request.Rating != 0 && old.ParentID != "" {
+			return "", ErrReplyRating
+		}
+		request.Revision, err = matchRevision(old.Revision, request.Revision)
+		if err != nil {
+			return "", err
+		}
+
+		status, err := c.moderate(ctx, request.Text)
+		if err != nil {
+			return "", err
+		}
+		// Comments under review keep their status until an admin reviews them,
+		// edits of rejected comments are reviewed again.
+		switch old.Status {
+		case model.CommentPending, model.CommentFlagged:
+			status = old.Status
+		case model.CommentRejected:
+			status = model.CommentPending
+		}
+
+		var fileID string
+		if request.Rating != 0 {
+			fileID, err = c.ratedFileID(ctx, request.UserID, old.PurchaseID)
+			if err != nil {
+				return "", err
+			}
+			err = c.checkRated(ctx, old.PurchaseID, request.ID)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		editedAt := c.clock.Now()
 		comment := model.CommentDTO{
-			UserID:     request.UserID,
-			PurchaseID: request.PurchaseID,
-			Date:       request.Date,
-			Text:       request.Text,
+			Text:     request.Text,
+			Rating:   request.Rating,
+			Status:   status,
+			EditedAt: &editedAt,
+			Revision: request.Revision,
 		}
-		id, err = c.Comment.Update(ctx, request.ID, comment)
+		err = c.tx.Do(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = c.Comment.Update(ctx, request.ID, comment)
+			if err != nil {
+				return errors.Wrap(err, "couldn't update comment")
+			}
+
+			oldRating := countedRating(*old)
+			if oldRating == request.Rating {
+				return nil
+			}
+			if fileID == "" {
+				fileID, err = c.fileID(ctx, old.PurchaseID)
+				if err != nil {
+					return err
+				}
+			}
+
+			var count int
+			switch {
+			case oldRating == 0:
+				count = 1
+			case request.Rating == 0:
+				count = -1
+			}
+			_, err = c.file.UpdateRating(ctx, fileID, request.Rating-oldRating, count)
+			if err != nil {
+				return errors.Wrap(err, "couldn't update file rating")
+			}
+
+			return nil
+		})
 		if err != nil {
-			return "", errors.Wrap(err, "couldn't update comment")
+			return "", err
 		}
 	}
 	return id, nil
 }
 
-// Delete deletes comments and returns id.
+// Patch updates only provided text and rating of the comment and returns id.
+// Missing fields are taken from the current comment, so the patch is moderated,
+// kept in the revision history and counted in the file rating as a full update.
+func (c CommentService) Patch(ctx context.Context, request model.PatchCommentRequest) (string, error) {
+	old, err := c.Comment.FindAnyByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find comment")
+	}
+
+	update := model.UpdateCommentRequest{
+		ID:       request.ID,
+		UserID:   request.UserID,
+		Revision: request.Revision,
+		Text:     old.Text,
+		Rating:   old.Rating,
+	}
+	if request.Text != nil {
+		update.Text = *request.Text
+	}
+	if request.Rating != nil {
+		update.Rating = *request.Rating
+	}
+
+	return c.Update(ctx, update)
+}
+
+// Delete deletes comment of the owner and returns id. Admins could delete any comment.
+// Comments with replies are tombstoned to keep the thread intact.
 func (c CommentService) Delete(ctx context.Context, request model.DeleteCommentRequest) (string, error) {
-	id, err := c.Comment.Delete(ctx, request.ID)
+	old, err := c.Comment.FindAnyByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find comment")
+	}
+	if old.Deleted {
+		return "", nil
+	}
+	if old.UserID != request.UserID && !request.Admin {
+		return "", ErrNotCommentOwner
+	}
+	request.Revision, err = matchRevision(old.Revision, request.Revision)
+	if err != nil {
+		return "", err
+	}
+
+	replies, err := c.Comment.CountReplies(ctx, request.ID)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't count replies")
+	}
+
+	var id string
+	deletedAt := c.clock.Now()
+	err = c.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		if replies != 0 {
+			id, err = c.Comment.Tombstone(ctx, request.ID, request.Revision, deletedAt)
+		} else {
+			id, err = c.Comment.Delete(ctx, request.ID, request.Revision, deletedAt)
+		}
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete comment")
+		}
+
+		rating := countedRating(*old)
+		if rating == 0 {
+			return nil
+		}
+		fileID, err := c.fileID(ctx, old.PurchaseID)
+		if err != nil {
+			return err
+		}
+		_, err = c.file.UpdateRating(ctx, fileID, -rating, -1)
+		if err != nil {
+			return errors.Wrap(err, "couldn't update file rating")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Flag flags published comment for moderator review and returns id.
+func (c CommentService) Flag(ctx context.Context, request model.FlagCommentRequest) (string, error) {
+	id, err := c.Comment.Flag(ctx, request.ID, request.UserID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't flag comment")
+	}
+
+	return id, nil
+}
+
+// Review sets moderation state of the comment and returns id.
+// Ratings of rejected comments are excluded from the file rating.
+func (c CommentService) Review(ctx context.Context, request model.ReviewCommentRequest) (string, error) {
+	old, err := c.Comment.FindAnyByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find comment")
+	}
+	if old.Deleted {
+		return "", nil
+	}
+
+	var id string
+	err = c.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = c.Comment.UpdateStatus(ctx, request.ID, request.Status)
+		if err != nil {
+			return errors.Wrap(err, "couldn't update comment status")
+		}
+
+		reviewed := *old
+		reviewed.Status = request.Status
+		oldRating, newRating := countedRating(*old), countedRating(reviewed)
+		if oldRating == newRating {
+			return nil
+		}
+		fileID, err := c.fileID(ctx, old.PurchaseID)
+		if err != nil {
+			return err
+		}
+		count := 1
+		if newRating == 0 {
+			count = -1
+		}
+		_, err = c.file.UpdateRating(ctx, fileID, newRating-oldRating, count)
+		if err != nil {
+			return errors.Wrap(err, "couldn't update file rating")
+		}
+
+		return nil
+	})
 	if err != nil {
-		return "", errors.Wrap(err, "couldn't delete comment")
+		return "", err
 	}
 
 	return id, nil
 }
 
-// FindByID finds comments by id.
+// FindForReview finds pending and flagged comments.
+func (c CommentService) FindForReview(ctx context.Context) ([]model.CommentDTO, error) {
+	comments, err := c.Comment.FindByStatus(ctx, []string{model.CommentPending, model.CommentFlagged})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find comments")
+	}
+
+	return comments, nil
+}
+
+// moderate checks comment text with the content filter and returns moderation state of the comment.
+func (c CommentService) moderate(ctx context.Context, text string) (string, error) {
+	verdict, err := c.filter.Check(ctx, text)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't check comment text")
+	}
+
+	switch verdict {
+	case filter.Reject:
+		c.log.WithContext(ctx).Info("comment rejected by content filter")
+		return "", ErrCommentRejected
+	case filter.Review:
+		c.log.WithContext(ctx).Info("comment held for review")
+		return model.CommentPending, nil
+	default:
+		return model.CommentApproved, nil
+	}
+}
+
+// countedRating returns rating of the comment included in the file rating.
+func countedRating(comment model.CommentDTO) int {
+	if comment.Status == model.CommentRejected {
+		return 0
+	}
+
+	return comment.Rating
+}
+
+// ratedFileID checks that user owns the purchase and returns id of the purchased file.
+func (c CommentService) ratedFileID(ctx context.Context, userID int, purchaseID string) (string, error) {
+	purchase, err := c.purchase.FindByID(ctx, purchaseID)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find purchase")
+	}
+
+	if purchase.UserID != userID {
+		return "", ErrRatingNotAllowed
+	}
+
+	return purchase.FileID, nil
+}
+
+// checkRated returns ErrAlreadyRated when a comment of the purchase other than the given one has a rating.
+// Only one comment rates the purchase, so its owner couldn't shift the file rating by posting more ratings.
+func (c CommentService) checkRated(ctx context.Context, purchaseID, commentID string) error {
+	comments, err := c.Comment.FindAnyByPurchaseID(ctx, purchaseID)
+	if err != nil {
+		return errors.Wrap(err, "couldn't find purchase comments")
+	}
+
+	for _, comment := range comments {
+		if comment.Rating != 0 && comment.ID != commentID {
+			return ErrAlreadyRated
+		}
+	}
+
+	return nil
+}
+
+// fileID returns id of the file purchased by purchase id.
+func (c CommentService) fileID(ctx context.Context, purchaseID string) (string, error) {
+	purchase, err := c.purchase.FindByID(ctx, purchaseID)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find purchase")
+	}
+
+	return purchase.FileID, nil
+}
+
+// FindByID finds comment by id.
+// Pending and rejected comments are found only for their author and admins.
 func (c CommentService) FindByID(ctx context.Context, request model.IDCommentRequest) (*model.CommentDTO, error) {
-	comment, err := c.Comment.FindByID(ctx, request.ID)
+	var comment *model.CommentDTO
+	var err error
+	if request.Admin {
+		comment, err = c.Comment.FindAnyByID(ctx, request.ID)
+	} else {
+		comment, err = c.Comment.FindVisibleByID(ctx, request.ID, request.UserID)
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't find comment")
 	}
@@ -87,6 +484,16 @@ func (c CommentService) FindByID(ctx context.Context, request model.IDCommentReq
 	return comment, nil
 }
 
+// FindRevisions finds previous versions of the comment.
+func (c CommentService) FindRevisions(ctx context.Context, request model.IDCommentRequest) ([]model.CommentRevisionDTO, error) {
+	revisions, err := c.Comment.FindRevisions(ctx, request.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find comment revisions")
+	}
+
+	return revisions, nil
+}
+
 // FindAllByUserID finds comments by user id.
 func (c CommentService) FindAllByUserID(ctx context.Context, request model.UserIDCommentRequest) ([]model.CommentDTO, error) {
 	var comments []model.CommentDTO
@@ -115,6 +522,40 @@ func (c CommentService) FindByPurchaseID(ctx context.Context, request model.Purc
 	return comments, nil
 }
 
+// FindTreeByPurchaseID finds comment threads by purchase id.
+func (c CommentService) FindTreeByPurchaseID(ctx context.Context, request model.PurchaseIDCommentRequest) ([]model.CommentNode, error) {
+	comments, err := c.Comment.FindByPurchaseIDs(ctx, []string{request.ID})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find comments")
+	}
+
+	return model.CommentsDTO(comments).Tree(), nil
+}
+
+// FindTreeByFileID finds comment threads of all file purchases.
+func (c CommentService) FindTreeByFileID(ctx context.Context, request model.FileIDCommentRequest) ([]model.CommentNode, error) {
+	purchases, err := c.purchase.FindByFileID(ctx, request.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find purchases")
+	}
+
+	if len(purchases) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]string, 0, len(purchases))
+	for _, purchase := range purchases {
+		ids = append(ids, purchase.ID)
+	}
+
+	comments, err := c.Comment.FindByPurchaseIDs(ctx, ids)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find comments")
+	}
+
+	return model.CommentsDTO(comments).Tree(), nil
+}
+
 // FindByUserIDAndPurchaseID finds comments by purchase and user id.
 func (c CommentService) FindByUserIDAndPurchaseID(ctx context.Context, request model.UserPurchaseIDCommentRequest) ([]model.CommentDTO, error) {
 	var comments []model.CommentDTO
@@ -144,7 +585,7 @@ func (c CommentService) FindAll(ctx context.Context) ([]model.CommentDTO, error)
 
 // FindByText finds comments by text.
 func (c CommentService) FindByText(ctx context.Context, request model.TextCommentRequest) ([]model.CommentDTO, error) {
-	comments, err := c.Comment.FindByText(ctx, request.Text)
+	comments, err := c.Comment.FindByText(ctx, request.Text, request.Language)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't find comments")
 	}
@@ -7,22 +7,28 @@ import (
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/service/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/filter"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/pkg/errors"
 	testAssert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+var testFilter = filter.NewBannedWords([]string{"banned"}, []string{"suspicious"})
+
 func TestCommentService_Create(t *testing.T) {
-	primitive.NewObjectID().Hex()
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
 	require.NoError(t, err)
+	purchaseID := primitive.NewObjectID().Hex()
+	fileID := primitive.NewObjectID().Hex()
 	type test struct {
 		name   string
 		req    model.CreateCommentRequest
-		fn     func(comment *m.Comment, data test)
+		fn     func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test)
 		expID  string
 		expErr error
 	}
@@ -31,28 +37,260 @@ func TestCommentService_Create(t *testing.T) {
 			name: "Create errors",
 			req: model.CreateCommentRequest{
 				UserID:     1,
-				PurchaseID: primitive.NewObjectID().Hex(),
-				Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+				PurchaseID: purchaseID,
 				Text:       "some text",
 			},
-			fn: func(comment *m.Comment, data test) {
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
 				comment.On("Create", mock.Anything, model.CommentDTO{
 					UserID:     data.req.UserID,
 					PurchaseID: data.req.PurchaseID,
-					Date:       data.req.Date,
+					Date:       time.Time(testClock),
 					Text:       data.req.Text,
+					Status:     model.CommentApproved,
 				}).
 					Return(data.expID, errors.New(""))
 			},
 			expErr: errors.Wrap(errors.New(""), "couldn't create comment"),
 		},
+		{
+			name: "Text rejected",
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Text:       "some banned text",
+			},
+			expErr: ErrCommentRejected,
+		},
+		{
+			name: "Text sent to review",
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Text:       "some suspicious text",
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("Create", mock.Anything, model.CommentDTO{
+					UserID:     data.req.UserID,
+					PurchaseID: data.req.PurchaseID,
+					Date:       time.Time(testClock),
+					Text:       data.req.Text,
+					Status:     model.CommentPending,
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "Rating not allowed",
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Text:       "some text",
+				Rating:     5,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				purchase.On("FindByID", mock.Anything, data.req.PurchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: 2, FileID: fileID}, nil)
+			},
+			expErr: ErrRatingNotAllowed,
+		},
+		{
+			name: "Find purchase comments errors",
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Text:       "some text",
+				Rating:     5,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				purchase.On("FindByID", mock.Anything, data.req.PurchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, data.req.PurchaseID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find purchase comments"),
+		},
+		{
+			name: "Already rated",
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Text:       "some text",
+				Rating:     5,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				purchase.On("FindByID", mock.Anything, data.req.PurchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, data.req.PurchaseID).
+					Return([]model.CommentDTO{{ID: primitive.NewObjectID().Hex(), Rating: 3}}, nil)
+			},
+			expErr: ErrAlreadyRated,
+		},
+		{
+			name: "Update rating errors",
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Text:       "some text",
+				Rating:     5,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				purchase.On("FindByID", mock.Anything, data.req.PurchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, data.req.PurchaseID).
+					Return([]model.CommentDTO{{ID: primitive.NewObjectID().Hex()}}, nil)
+				comment.On("Create", mock.Anything, model.CommentDTO{
+					UserID:     data.req.UserID,
+					PurchaseID: data.req.PurchaseID,
+					Date:       time.Time(testClock),
+					Text:       data.req.Text,
+					Rating:     data.req.Rating,
+					Status:     model.CommentApproved,
+				}).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("UpdateRating", mock.Anything, fileID, data.req.Rating, 1).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't update file rating"),
+		},
 		{
 			name: "All ok",
 			req: model.CreateCommentRequest{
 				UserID:     1,
-				PurchaseID: primitive.NewObjectID().Hex(),
-				Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+				PurchaseID: purchaseID,
+				Text:       "some text",
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("Create", mock.Anything, model.CommentDTO{
+					UserID:     data.req.UserID,
+					PurchaseID: data.req.PurchaseID,
+					Date:       time.Time(testClock),
+					Text:       data.req.Text,
+					Status:     model.CommentApproved,
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "Parent deleted",
+			req: model.CreateCommentRequest{
+				UserID:   1,
+				ParentID: primitive.NewObjectID().Hex(),
+				Text:     "some text",
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindByID", mock.Anything, data.req.ParentID).
+					Return(&model.CommentDTO{ID: data.req.ParentID, PurchaseID: purchaseID, Deleted: true}, nil)
+			},
+			expErr: ErrParentDeleted,
+		},
+		{
+			name: "All ok with parent",
+			req: model.CreateCommentRequest{
+				UserID:   1,
+				ParentID: primitive.NewObjectID().Hex(),
+				Text:     "some text",
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindByID", mock.Anything, data.req.ParentID).
+					Return(&model.CommentDTO{ID: data.req.ParentID, PurchaseID: purchaseID}, nil)
+				comment.On("Create", mock.Anything, model.CommentDTO{
+					UserID:     data.req.UserID,
+					PurchaseID: purchaseID,
+					ParentID:   data.req.ParentID,
+					Date:       time.Time(testClock),
+					Text:       data.req.Text,
+					Status:     model.CommentApproved,
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "All ok with rating",
+			req: model.CreateCommentRequest{
+				UserID:     1,
+				PurchaseID: purchaseID,
 				Text:       "some text",
+				Rating:     4,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				purchase.On("FindByID", mock.Anything, data.req.PurchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, data.req.PurchaseID).
+					Return([]model.CommentDTO{{ID: primitive.NewObjectID().Hex()}}, nil)
+				comment.On("Create", mock.Anything, model.CommentDTO{
+					UserID:     data.req.UserID,
+					PurchaseID: data.req.PurchaseID,
+					Date:       time.Time(testClock),
+					Text:       data.req.Text,
+					Rating:     data.req.Rating,
+					Status:     model.CommentApproved,
+				}).
+					Return(data.expID, nil)
+				file.On("UpdateRating", mock.Anything, fileID, data.req.Rating, 1).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			purchase := new(m.Purchase)
+			file := new(m.File)
+			ctx := context.Background()
+			service := NewCommentService(comment, purchase, file, newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, purchase, file, tc)
+			}
+			id, err := service.Create(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestCommentService_Import(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		req    model.ImportCommentRequest
+		fn     func(comment *m.Comment, data test)
+		expID  string
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Create errors",
+			req: model.ImportCommentRequest{
+				CreateCommentRequest: model.CreateCommentRequest{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Text:       "some text",
+				},
+				Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("Create", mock.Anything, mock.Anything).
+					Return(data.expID, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't create comment"),
+		},
+		{
+			name: "All ok",
+			req: model.ImportCommentRequest{
+				CreateCommentRequest: model.CreateCommentRequest{
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Text:       "some text",
+				},
+				Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
 			},
 			fn: func(comment *m.Comment, data test) {
 				comment.On("Create", mock.Anything, model.CommentDTO{
@@ -60,6 +298,7 @@ func TestCommentService_Create(t *testing.T) {
 					PurchaseID: data.req.PurchaseID,
 					Date:       data.req.Date,
 					Text:       data.req.Text,
+					Status:     model.CommentApproved,
 				}).
 					Return(data.expID, nil)
 			},
@@ -70,11 +309,11 @@ func TestCommentService_Create(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
-			id, err := service.Create(ctx, tc.req)
+			id, err := service.Import(ctx, tc.req)
 			if err != nil {
 				assert.Equal(tc.expErr.Error(), err.Error())
 			}
@@ -87,29 +326,117 @@ func TestCommentService_Update(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
 	require.NoError(t, err)
+	editedAt := time.Time(testClock)
+	purchaseID := primitive.NewObjectID().Hex()
+	fileID := primitive.NewObjectID().Hex()
 	type test struct {
 		name   string
 		req    model.UpdateCommentRequest
-		fn     func(comment *m.Comment, data test)
+		old    model.CommentDTO
+		fn     func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test)
 		expID  string
 		expErr error
 	}
 	tt := []test{
 		{
-			name: "Update errors",
+			name: "Reply rated",
 			req: model.UpdateCommentRequest{
-				ID:         primitive.NewObjectID().Hex(),
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+				Rating: 4,
+			},
+			old: model.CommentDTO{
 				UserID:     1,
-				PurchaseID: primitive.NewObjectID().Hex(),
-				Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+				PurchaseID: purchaseID,
+				ParentID:   primitive.NewObjectID().Hex(),
 				Text:       "some text",
 			},
-			fn: func(comment *m.Comment, data test) {
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+			expErr: ErrReplyRating,
+		},
+		{
+			name: "Not found",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Find errors",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find comment"),
+		},
+		{
+			name: "Not owner",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			old: model.CommentDTO{
+				UserID:     2,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+			expErr: ErrNotCommentOwner,
+		},
+		{
+			name: "Modified",
+			req: model.UpdateCommentRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				UserID:   1,
+				Text:     "some text",
+				Revision: 1,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Revision:   2,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "Update errors",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
 				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
-					UserID:     data.req.UserID,
-					PurchaseID: data.req.PurchaseID,
-					Date:       data.req.Date,
-					Text:       data.req.Text,
+					Text:     data.req.Text,
+					Status:   model.CommentApproved,
+					EditedAt: &editedAt,
 				}).
 					Return(data.expID, errors.New(""))
 			},
@@ -118,18 +445,319 @@ func TestCommentService_Update(t *testing.T) {
 		{
 			name: "All ok",
 			req: model.UpdateCommentRequest{
-				ID:         primitive.NewObjectID().Hex(),
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
+					Text:     data.req.Text,
+					Status:   model.CommentApproved,
+					EditedAt: &editedAt,
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "All ok flagged",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Status:     model.CommentFlagged,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
+					Text:     data.req.Text,
+					Status:   model.CommentFlagged,
+					EditedAt: &editedAt,
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "All ok pending",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Status:     model.CommentPending,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
+					Text:     data.req.Text,
+					Status:   model.CommentPending,
+					EditedAt: &editedAt,
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "All ok rejected",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+				Rating: 4,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Rating:     4,
+				Status:     model.CommentRejected,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: 1, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, purchaseID).
+					Return([]model.CommentDTO{{ID: data.req.ID, Rating: data.old.Rating}}, nil)
+				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
+					Text:     data.req.Text,
+					Rating:   data.req.Rating,
+					Status:   model.CommentPending,
+					EditedAt: &editedAt,
+				}).
+					Return(data.expID, nil)
+				file.On("UpdateRating", mock.Anything, fileID, 4, 1).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "Already rated",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+				Rating: 4,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, purchaseID).
+					Return([]model.CommentDTO{{ID: data.req.ID}, {ID: primitive.NewObjectID().Hex(), Rating: 5}}, nil)
+			},
+			expErr: ErrAlreadyRated,
+		},
+		{
+			name: "All ok with changed rating",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+				Rating: 2,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Rating:     5,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, purchaseID).
+					Return([]model.CommentDTO{{ID: data.req.ID, Rating: data.old.Rating}}, nil)
+				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
+					Text:     data.req.Text,
+					Rating:   data.req.Rating,
+					Status:   model.CommentApproved,
+					EditedAt: &editedAt,
+				}).
+					Return(data.expID, nil)
+				file.On("UpdateRating", mock.Anything, fileID, -3, 0).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "All ok with added rating",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+				Rating: 4,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("FindAnyByPurchaseID", mock.Anything, purchaseID).
+					Return([]model.CommentDTO{{ID: data.req.ID, Rating: data.old.Rating}}, nil)
+				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
+					Text:     data.req.Text,
+					Rating:   data.req.Rating,
+					Status:   model.CommentApproved,
+					EditedAt: &editedAt,
+				}).
+					Return(data.expID, nil)
+				file.On("UpdateRating", mock.Anything, fileID, 4, 1).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "All ok with removed rating",
+			req: model.UpdateCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   "some text",
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Rating:     5,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: data.req.UserID, FileID: fileID}, nil)
+				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
+					Text:     data.req.Text,
+					Status:   model.CommentApproved,
+					EditedAt: &editedAt,
+				}).
+					Return(data.expID, nil)
+				file.On("UpdateRating", mock.Anything, fileID, -5, -1).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			purchase := new(m.Purchase)
+			file := new(m.File)
+			ctx := context.Background()
+			service := NewCommentService(comment, purchase, file, newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, purchase, file, tc)
+			}
+			id, err := service.Update(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestCommentService_Patch(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	editedAt := time.Time(testClock)
+	text := "new text"
+	rating := 4
+	type test struct {
+		name   string
+		req    model.PatchCommentRequest
+		old    model.CommentDTO
+		fn     func(comment *m.Comment, data test)
+		expID  string
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.PatchCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   &text,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find comment"),
+		},
+		{
+			name: "Not found",
+			req: model.PatchCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   &text,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Reply rated",
+			req: model.PatchCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Rating: &rating,
+			},
+			old: model.CommentDTO{
+				UserID:   1,
+				ParentID: primitive.NewObjectID().Hex(),
+				Text:     "some text",
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+			expErr: ErrReplyRating,
+		},
+		{
+			name: "Text changed",
+			req: model.PatchCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+				Text:   &text,
+			},
+			old: model.CommentDTO{
 				UserID:     1,
 				PurchaseID: primitive.NewObjectID().Hex(),
-				Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+				ParentID:   primitive.NewObjectID().Hex(),
 				Text:       "some text",
 			},
 			fn: func(comment *m.Comment, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
 				comment.On("Update", mock.Anything, data.req.ID, model.CommentDTO{
-					UserID:     data.req.UserID,
-					PurchaseID: data.req.PurchaseID,
-					Date:       data.req.Date,
-					Text:       data.req.Text,
+					Text:     text,
+					Status:   model.CommentApproved,
+					EditedAt: &editedAt,
 				}).
 					Return(data.expID, nil)
 			},
@@ -140,11 +768,11 @@ func TestCommentService_Update(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
-			id, err := service.Update(ctx, tc.req)
+			id, err := service.Patch(ctx, tc.req)
 			if err != nil {
 				assert.Equal(tc.expErr.Error(), err.Error())
 			}
@@ -153,25 +781,109 @@ func TestCommentService_Update(t *testing.T) {
 	}
 }
 
+func TestCommentService_FindRevisions(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	commentID := primitive.NewObjectID().Hex()
+	type test struct {
+		name   string
+		req    model.IDCommentRequest
+		fn     func(comment *m.Comment, data test)
+		exp    []model.CommentRevisionDTO
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.IDCommentRequest{
+				ID: commentID,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindRevisions", mock.Anything, data.req.ID).
+					Return(data.exp, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find comment revisions"),
+		},
+		{
+			name: "All ok",
+			req: model.IDCommentRequest{
+				ID: commentID,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindRevisions", mock.Anything, data.req.ID).
+					Return(data.exp, nil)
+			},
+			exp: []model.CommentRevisionDTO{
+				{
+					ID:         primitive.NewObjectID().Hex(),
+					CommentID:  commentID,
+					Text:       "some text",
+					Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					ReplacedAt: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			ctx := context.Background()
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+			c, err := service.FindRevisions(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.exp, c)
+		})
+	}
+}
+
 func TestCommentService_Delete(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
 	require.NoError(t, err)
+	purchaseID := primitive.NewObjectID().Hex()
+	fileID := primitive.NewObjectID().Hex()
 	type test struct {
 		name   string
 		req    model.DeleteCommentRequest
-		fn     func(comment *m.Comment, data test)
+		old    model.CommentDTO
+		fn     func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test)
 		expID  string
 		expErr error
 	}
 	tt := []test{
+		{
+			name: "Not found",
+			req: model.DeleteCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
 		{
 			name: "Delete errors",
 			req: model.DeleteCommentRequest{
-				ID: primitive.NewObjectID().Hex(),
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
 			},
-			fn: func(comment *m.Comment, data test) {
-				comment.On("Delete", mock.Anything, data.req.ID).
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("CountReplies", mock.Anything, data.req.ID).
+					Return(int64(0), nil)
+				comment.On("Delete", mock.Anything, data.req.ID, data.req.Revision, time.Time(testClock)).
 					Return(data.expID, errors.New(""))
 			},
 			expErr: errors.Wrap(errors.New(""), "couldn't delete comment"),
@@ -179,22 +891,148 @@ func TestCommentService_Delete(t *testing.T) {
 		{
 			name: "All ok",
 			req: model.DeleteCommentRequest{
-				ID: primitive.NewObjectID().Hex(),
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
 			},
-			fn: func(comment *m.Comment, data test) {
-				comment.On("Delete", mock.Anything, data.req.ID).
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("CountReplies", mock.Anything, data.req.ID).
+					Return(int64(0), nil)
+				comment.On("Delete", mock.Anything, data.req.ID, data.req.Revision, time.Time(testClock)).
 					Return(data.expID, nil)
 			},
 			expID: primitive.NewObjectID().Hex(),
 		},
+		{
+			name: "Not owner",
+			req: model.DeleteCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 2,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+			expErr: ErrNotCommentOwner,
+		},
+		{
+			name: "All ok by admin",
+			req: model.DeleteCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 2,
+				Admin:  true,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("CountReplies", mock.Anything, data.req.ID).
+					Return(int64(0), nil)
+				comment.On("Delete", mock.Anything, data.req.ID, data.req.Revision, time.Time(testClock)).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "Modified",
+			req: model.DeleteCommentRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				UserID:   1,
+				Revision: 1,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "Deleted",
+			req: model.DeleteCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Deleted:    true,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+		},
+		{
+			name: "All ok with replies",
+			req: model.DeleteCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("CountReplies", mock.Anything, data.req.ID).
+					Return(int64(2), nil)
+				comment.On("Tombstone", mock.Anything, data.req.ID, data.req.Revision, time.Time(testClock)).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "All ok with rating",
+			req: model.DeleteCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			old: model.CommentDTO{
+				UserID:     1,
+				PurchaseID: purchaseID,
+				Rating:     3,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("CountReplies", mock.Anything, data.req.ID).
+					Return(int64(0), nil)
+				comment.On("Delete", mock.Anything, data.req.ID, data.req.Revision, time.Time(testClock)).
+					Return(data.expID, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: 1, FileID: fileID}, nil)
+				file.On("UpdateRating", mock.Anything, fileID, -3, -1).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
+			purchase := new(m.Purchase)
+			file := new(m.File)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, purchase, file, newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
-				tc.fn(comment, tc)
+				tc.fn(comment, purchase, file, tc)
 			}
 			id, err := service.Delete(ctx, tc.req)
 			if err != nil {
@@ -220,21 +1058,43 @@ func TestCommentService_FindByID(t *testing.T) {
 		{
 			name: "Find errors",
 			req: model.IDCommentRequest{
-				ID: primitive.NewObjectID().Hex(),
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
 			},
 			fn: func(comment *m.Comment, data test) {
-				comment.On("FindByID", mock.Anything, data.req.ID).
+				comment.On("FindVisibleByID", mock.Anything, data.req.ID, data.req.UserID).
 					Return(data.exp, errors.New(""))
 			},
-			expErr: errors.Wrap(errors.New(""), "couldn't find comment"),
+			expErr: errors.Wrap(errors.New(""), "couldn't find comment"),
+		},
+		{
+			name: "Admin finds any",
+			req: model.IDCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 2,
+				Admin:  true,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(data.exp, nil)
+			},
+			exp: &model.CommentDTO{
+				ID:         primitive.NewObjectID().Hex(),
+				UserID:     1,
+				PurchaseID: primitive.NewObjectID().Hex(),
+				Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+				Text:       "some text",
+				Status:     model.CommentPending,
+			},
 		},
 		{
 			name: "All ok",
 			req: model.IDCommentRequest{
-				ID: primitive.NewObjectID().Hex(),
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
 			},
 			fn: func(comment *m.Comment, data test) {
-				comment.On("FindByID", mock.Anything, data.req.ID).
+				comment.On("FindVisibleByID", mock.Anything, data.req.ID, data.req.UserID).
 					Return(data.exp, nil)
 			},
 			exp: &model.CommentDTO{
@@ -250,7 +1110,7 @@ func TestCommentService_FindByID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -259,6 +1119,7 @@ func TestCommentService_FindByID(t *testing.T) {
 				assert.Equal(tc.expErr.Error(), err.Error())
 			}
 			assert.Equal(tc.exp, c)
+			comment.AssertExpectations(t)
 		})
 	}
 }
@@ -318,7 +1179,7 @@ func TestCommentService_FindAllByUserID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, &tc)
 			}
@@ -386,7 +1247,7 @@ func TestCommentService_FindAllByPurchaseID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, &tc)
 			}
@@ -399,6 +1260,179 @@ func TestCommentService_FindAllByPurchaseID(t *testing.T) {
 	}
 }
 
+func TestCommentService_FindTreeByPurchaseID(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	purchaseID := primitive.NewObjectID().Hex()
+	parentID := primitive.NewObjectID().Hex()
+	replyID := primitive.NewObjectID().Hex()
+	type test struct {
+		name     string
+		req      model.PurchaseIDCommentRequest
+		fn       func(comment *m.Comment, data test)
+		comments []model.CommentDTO
+		exp      []model.CommentNode
+		expErr   error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.PurchaseIDCommentRequest{
+				ID: purchaseID,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindByPurchaseIDs", mock.Anything, []string{data.req.ID}).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find comments"),
+		},
+		{
+			name: "All ok",
+			req: model.PurchaseIDCommentRequest{
+				ID: purchaseID,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindByPurchaseIDs", mock.Anything, []string{data.req.ID}).
+					Return(data.comments, nil)
+			},
+			comments: []model.CommentDTO{
+				{
+					ID:         parentID,
+					UserID:     1,
+					PurchaseID: purchaseID,
+					Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Deleted:    true,
+				},
+				{
+					ID:         replyID,
+					UserID:     2,
+					PurchaseID: purchaseID,
+					ParentID:   parentID,
+					Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+					Text:       "some reply",
+				},
+			},
+			exp: []model.CommentNode{
+				{
+					CommentDTO: model.CommentDTO{
+						ID:         parentID,
+						UserID:     1,
+						PurchaseID: purchaseID,
+						Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+						Deleted:    true,
+					},
+					Replies: []model.CommentNode{
+						{
+							CommentDTO: model.CommentDTO{
+								ID:         replyID,
+								UserID:     2,
+								PurchaseID: purchaseID,
+								ParentID:   parentID,
+								Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+								Text:       "some reply",
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			ctx := context.Background()
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+			c, err := service.FindTreeByPurchaseID(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.exp, c)
+		})
+	}
+}
+
+func TestCommentService_FindTreeByFileID(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	purchaseID := primitive.NewObjectID().Hex()
+	type test struct {
+		name     string
+		req      model.FileIDCommentRequest
+		fn       func(comment *m.Comment, purchase *m.Purchase, data test)
+		comments []model.CommentDTO
+		exp      []model.CommentNode
+		expErr   error
+	}
+	tt := []test{
+		{
+			name: "Find purchases errors",
+			req: model.FileIDCommentRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, data test) {
+				purchase.On("FindByFileID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find purchases"),
+		},
+		{
+			name: "No purchases",
+			req: model.FileIDCommentRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, data test) {
+				purchase.On("FindByFileID", mock.Anything, data.req.ID).
+					Return(nil, nil)
+			},
+		},
+		{
+			name: "All ok",
+			req: model.FileIDCommentRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, data test) {
+				purchase.On("FindByFileID", mock.Anything, data.req.ID).
+					Return([]model.PurchaseDTO{{ID: purchaseID, UserID: 1, FileID: data.req.ID}}, nil)
+				comment.On("FindByPurchaseIDs", mock.Anything, []string{purchaseID}).
+					Return(data.comments, nil)
+			},
+			comments: []model.CommentDTO{
+				{
+					ID:         primitive.NewObjectID().Hex(),
+					UserID:     1,
+					PurchaseID: purchaseID,
+					Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Text:       "some text",
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			purchase := new(m.Purchase)
+			ctx := context.Background()
+			service := NewCommentService(comment, purchase, new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, purchase, tc)
+			}
+			if tc.comments != nil {
+				tc.exp = model.CommentsDTO(tc.comments).Tree()
+			}
+			c, err := service.FindTreeByFileID(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.exp, c)
+		})
+	}
+}
+
 func TestCommentService_FindByUserIDAndPurchaseID(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
@@ -455,7 +1489,7 @@ func TestCommentService_FindByUserIDAndPurchaseID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, &tc)
 			}
@@ -516,7 +1550,7 @@ func TestCommentService_FindAll(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -547,7 +1581,7 @@ func TestCommentService_FindByText(t *testing.T) {
 				Text: "some",
 			},
 			fn: func(comment *m.Comment, data test) {
-				comment.On("FindByText", mock.Anything, data.req.Text).
+				comment.On("FindByText", mock.Anything, data.req.Text, data.req.Language).
 					Return(data.exp, errors.New(""))
 			},
 			expErr: errors.Wrap(errors.New(""), "couldn't find comments"),
@@ -558,7 +1592,7 @@ func TestCommentService_FindByText(t *testing.T) {
 				Text: "some",
 			},
 			fn: func(comment *m.Comment, data test) {
-				comment.On("FindByText", mock.Anything, data.req.Text).
+				comment.On("FindByText", mock.Anything, data.req.Text, data.req.Language).
 					Return(data.exp, nil)
 			},
 			exp: []model.CommentDTO{
@@ -583,7 +1617,7 @@ func TestCommentService_FindByText(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -652,7 +1686,7 @@ func TestCommentService_FindByPeriod(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			comment := new(m.Comment)
 			ctx := context.Background()
-			service := NewCommentService(comment, testApi.GRPCClient)
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(comment, tc)
 			}
@@ -664,3 +1698,276 @@ func TestCommentService_FindByPeriod(t *testing.T) {
 		})
 	}
 }
+
+func TestCommentService_Flag(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		req    model.FlagCommentRequest
+		fn     func(comment *m.Comment, data test)
+		expID  string
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Flag errors",
+			req: model.FlagCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("Flag", mock.Anything, data.req.ID, data.req.UserID).
+					Return(data.expID, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't flag comment"),
+		},
+		{
+			name: "Not published",
+			req: model.FlagCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("Flag", mock.Anything, data.req.ID, data.req.UserID).
+					Return(data.expID, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "All ok",
+			req: model.FlagCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(comment *m.Comment, data test) {
+				comment.On("Flag", mock.Anything, data.req.ID, data.req.UserID).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			ctx := context.Background()
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+			id, err := service.Flag(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestCommentService_Review(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	purchaseID := primitive.NewObjectID().Hex()
+	fileID := primitive.NewObjectID().Hex()
+	type test struct {
+		name   string
+		req    model.ReviewCommentRequest
+		old    model.CommentDTO
+		fn     func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test)
+		expID  string
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Not found",
+			req: model.ReviewCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Status: model.CommentApproved,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Update status errors",
+			req: model.ReviewCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Status: model.CommentApproved,
+			},
+			old: model.CommentDTO{
+				PurchaseID: purchaseID,
+				Status:     model.CommentPending,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("UpdateStatus", mock.Anything, data.req.ID, data.req.Status).
+					Return(data.expID, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't update comment status"),
+		},
+		{
+			name: "Deleted",
+			req: model.ReviewCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Status: model.CommentApproved,
+			},
+			old: model.CommentDTO{
+				PurchaseID: purchaseID,
+				Deleted:    true,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+			},
+		},
+		{
+			name: "All ok",
+			req: model.ReviewCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Status: model.CommentApproved,
+			},
+			old: model.CommentDTO{
+				PurchaseID: purchaseID,
+				Status:     model.CommentFlagged,
+				Rating:     4,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("UpdateStatus", mock.Anything, data.req.ID, data.req.Status).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "Rejected with rating",
+			req: model.ReviewCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Status: model.CommentRejected,
+			},
+			old: model.CommentDTO{
+				PurchaseID: purchaseID,
+				Status:     model.CommentFlagged,
+				Rating:     4,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("UpdateStatus", mock.Anything, data.req.ID, data.req.Status).
+					Return(data.expID, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: 1, FileID: fileID}, nil)
+				file.On("UpdateRating", mock.Anything, fileID, -4, -1).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+		{
+			name: "Approved after rejection with rating",
+			req: model.ReviewCommentRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Status: model.CommentApproved,
+			},
+			old: model.CommentDTO{
+				PurchaseID: purchaseID,
+				Status:     model.CommentRejected,
+				Rating:     4,
+			},
+			fn: func(comment *m.Comment, purchase *m.Purchase, file *m.File, data test) {
+				comment.On("FindAnyByID", mock.Anything, data.req.ID).
+					Return(&data.old, nil)
+				comment.On("UpdateStatus", mock.Anything, data.req.ID, data.req.Status).
+					Return(data.expID, nil)
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, UserID: 1, FileID: fileID}, nil)
+				file.On("UpdateRating", mock.Anything, fileID, 4, 1).
+					Return(fileID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			purchase := new(m.Purchase)
+			file := new(m.File)
+			ctx := context.Background()
+			service := NewCommentService(comment, purchase, file, newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, purchase, file, tc)
+			}
+			id, err := service.Review(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestCommentService_FindForReview(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	statuses := []string{model.CommentPending, model.CommentFlagged}
+	type test struct {
+		name   string
+		fn     func(comment *m.Comment, data test)
+		exp    []model.CommentDTO
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindByStatus", mock.Anything, statuses).
+					Return(data.exp, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find comments"),
+		},
+		{
+			name: "All ok",
+			fn: func(comment *m.Comment, data test) {
+				comment.On("FindByStatus", mock.Anything, statuses).
+					Return(data.exp, nil)
+			},
+			exp: []model.CommentDTO{
+				{
+					ID:         primitive.NewObjectID().Hex(),
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Text:       "some text1",
+					Status:     model.CommentPending,
+				},
+				{
+					ID:         primitive.NewObjectID().Hex(),
+					UserID:     1,
+					PurchaseID: primitive.NewObjectID().Hex(),
+					Date:       time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
+					Text:       "some text2",
+					Status:     model.CommentFlagged,
+					FlaggedBy:  []int{2},
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			comment := new(m.Comment)
+			ctx := context.Background()
+			service := NewCommentService(comment, new(m.Purchase), new(m.File), newTestOutbox(), testTx, testFilter, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(comment, tc)
+			}
+			c, err := service.FindForReview(ctx)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.exp, c)
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/pkg/errors"
+)
+
+// record adds domain event about the aggregate to the outbox.
+// It is called in the unit of work of the entity, so the event is written only along with it.
+func record(ctx context.Context, outbox repository.Outbox, eventType, aggregateID string, payload interface{}, at time.Time) error {
+	event, err := model.NewEvent(eventType, aggregateID, payload, at)
+	if err != nil {
+		return err
+	}
+
+	_, err = outbox.Add(ctx, event)
+	if err != nil {
+		return errors.Wrap(err, "couldn't add event to outbox")
+	}
+
+	return nil
+}
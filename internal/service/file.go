@@ -2,26 +2,67 @@ package service
 
 import (
 	"context"
+	"strconv"
+	"time"
 
 	"github.com/JesusG2000/hexsatisfaction/pkg/grpc/api"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/storage"
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrNotFileAuthor is returned when file or its content is changed by a user who isn't the file author.
+	ErrNotFileAuthor = errors.New("only file author can change file")
+	// ErrEmptyContent is returned when uploaded file content is empty.
+	ErrEmptyContent = errors.New("file content is empty")
+	// ErrNotEntitled is returned when file is downloaded by a user who neither bought nor authored it.
+	ErrNotEntitled = errors.New("file isn't purchased by user")
+	// ErrNoContent is returned when downloaded file has no uploaded content.
+	ErrNoContent = errors.New("file has no content")
+	// ErrLinkUsed is returned when a single-use download link is used again.
+	ErrLinkUsed = errors.New("download link already used")
 )
 
 // FileService is a file service.
 type FileService struct {
 	repository.File
-	client api.ExistanceClient
+	version  repository.FileVersion
+	purchase repository.Purchase
+	comment  repository.Comment
+	download repository.Download
+	link     repository.Link
+	outbox   repository.Outbox
+	tx       repository.UnitOfWork
+	signer   auth.LinkManager
+	storage  storage.Backend
+	clock    clock.Clock
+	client   api.ExistanceClient
+	log      *logger.Logger
 }
 
 // NewFileService is a FileService service constructor.
-func NewFileService(file repository.File, client api.ExistanceClient) *FileService {
-	return &FileService{file, client}
+func NewFileService(file repository.File, version repository.FileVersion, purchase repository.Purchase, comment repository.Comment, download repository.Download, link repository.Link, outbox repository.Outbox, tx repository.UnitOfWork, signer auth.LinkManager, storage storage.Backend, clock clock.Clock, client api.ExistanceClient, log *logger.Logger) *FileService {
+	return &FileService{file, version, purchase, comment, download, link, outbox, tx, signer, storage, clock, client, log}
 }
 
-// Create creates new file and returns id.
+// Create creates new file added now with its first version and returns id.
 func (f FileService) Create(ctx context.Context, request model.CreateFileRequest) (string, error) {
+	now := f.clock.Now()
+	return f.create(ctx, request, now, now)
+}
+
+// Import creates new file with the requested dates and its first version and returns id.
+func (f FileService) Import(ctx context.Context, request model.ImportFileRequest) (string, error) {
+	return f.create(ctx, request.CreateFileRequest, request.AddDate, request.UpdateDate)
+}
+
+func (f FileService) create(ctx context.Context, request model.CreateFileRequest, addDate, updateDate time.Time) (string, error) {
 	var id string
 	res, err := f.client.Author(ctx, &api.IsAuthorExistRequest{Id: int32(request.AuthorID)})
 	if err != nil {
@@ -32,23 +73,35 @@ func (f FileService) Create(ctx context.Context, request model.CreateFileRequest
 		file := model.FileDTO{
 			Name:        request.Name,
 			Description: request.Description,
-			Size:        request.Size,
-			Path:        request.Path,
-			AddDate:     request.AddDate,
-			UpdateDate:  request.UpdateDate,
+			AddDate:     addDate,
+			UpdateDate:  updateDate,
 			Actual:      request.Actual,
 			AuthorID:    request.AuthorID,
 		}
-		id, err = f.File.Create(ctx, file)
+		err = f.tx.Do(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = f.File.Create(ctx, file)
+			if err != nil {
+				return errors.Wrap(err, "couldn't create file")
+			}
+
+			_, err = f.newVersion(ctx, id, model.FileVersionDTO{
+				Number:      1,
+				Name:        request.Name,
+				Description: request.Description,
+			})
+			return err
+		})
 		if err != nil {
-			return "", errors.Wrap(err, "couldn't create file")
+			return "", err
 		}
 	}
 
 	return id, nil
 }
 
-// Update updates file and returns id.
+// Update updates file of the author creating a new version with current content and returns id.
+// Add date and author of the file couldn't be changed.
 func (f FileService) Update(ctx context.Context, request model.UpdateFileRequest) (string, error) {
 	var id string
 	res, err := f.client.Author(ctx, &api.IsAuthorExistRequest{Id: int32(request.AuthorID)})
@@ -57,30 +110,445 @@ func (f FileService) Update(ctx context.Context, request model.UpdateFileRequest
 	}
 
 	if res.Exist {
+		current, err := f.File.FindByID(ctx, request.ID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't find file")
+		}
+		if current.AuthorID != request.AuthorID {
+			return "", ErrNotFileAuthor
+		}
+		request.Revision, err = matchRevision(current.Revision, request.Revision)
+		if err != nil {
+			return "", err
+		}
+
 		file := model.FileDTO{
 			Name:        request.Name,
 			Description: request.Description,
-			Size:        request.Size,
-			Path:        request.Path,
-			AddDate:     request.AddDate,
-			UpdateDate:  request.UpdateDate,
+			UpdateDate:  f.clock.Now(),
 			Actual:      request.Actual,
-			AuthorID:    request.AuthorID,
+			Revision:    request.Revision,
 		}
-		id, err = f.File.Update(ctx, request.ID, file)
+		err = f.tx.Do(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = f.File.Update(ctx, request.ID, file)
+			if err != nil {
+				return errors.Wrap(err, "couldn't update file")
+			}
+
+			return f.update(ctx, request.ID, model.FileVersionDTO{
+				Number:      current.Version + 1,
+				Name:        request.Name,
+				Description: request.Description,
+				Size:        current.Size,
+				Path:        current.Path,
+				Checksum:    current.Checksum,
+				ContentType: current.ContentType,
+			})
+		})
 		if err != nil {
-			return "", errors.Wrap(err, "couldn't update file")
+			return "", err
 		}
 	}
 
 	return id, nil
 }
 
-// Delete deletes file and returns deleted id.
+// Patch updates only provided fields of the file of the author and returns id.
+// A new version with current content is created when name or description is changed.
+func (f FileService) Patch(ctx context.Context, request model.PatchFileRequest) (string, error) {
+	current, err := f.File.FindByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find file")
+	}
+	if current.AuthorID != request.AuthorID {
+		return "", ErrNotFileAuthor
+	}
+	request.Revision, err = matchRevision(current.Revision, request.Revision)
+	if err != nil {
+		return "", err
+	}
+
+	name, description := current.Name, current.Description
+	if request.Name != nil {
+		name = *request.Name
+	}
+	if request.Description != nil {
+		description = *request.Description
+	}
+
+	now := f.clock.Now()
+	var id string
+	err = f.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = f.File.Patch(ctx, request.ID, model.FilePatch{
+			Name:        request.Name,
+			Description: request.Description,
+			Actual:      request.Actual,
+			UpdateDate:  &now,
+			Revision:    request.Revision,
+		})
+		if err != nil {
+			return errors.Wrap(err, "couldn't patch file")
+		}
+
+		if name == current.Name && description == current.Description {
+			return nil
+		}
+
+		return f.update(ctx, request.ID, model.FileVersionDTO{
+			Number:      current.Version + 1,
+			Name:        name,
+			Description: description,
+			Size:        current.Size,
+			Path:        current.Path,
+			Checksum:    current.Checksum,
+			ContentType: current.ContentType,
+		})
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Upload stores file content as a new version of the file revision and returns id.
+// Content of previous versions is kept for their buyers.
+func (f FileService) Upload(ctx context.Context, request model.UploadFileRequest) (string, error) {
+	file, err := f.File.FindByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find file")
+	}
+
+	if file.AuthorID != request.AuthorID {
+		return "", ErrNotFileAuthor
+	}
+	_, err = matchRevision(file.Revision, request.Revision)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := storage.Store(ctx, f.storage, request.Name, request.Content)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't store file content")
+	}
+
+	if info.Size == 0 {
+		err = f.storage.Delete(ctx, info.Path)
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't delete file content")
+		}
+		return "", ErrEmptyContent
+	}
+
+	err = f.update(ctx, request.ID, model.FileVersionDTO{
+		Number:      file.Version + 1,
+		Name:        file.Name,
+		Description: file.Description,
+		Size:        int(info.Size),
+		Path:        info.Path,
+		Checksum:    info.Checksum,
+		ContentType: request.ContentType,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return request.ID, nil
+}
+
+// newVersion creates a version of the file and makes it current in a unit of work.
+func (f FileService) newVersion(ctx context.Context, fileID string, version model.FileVersionDTO) (*model.FileVersionDTO, error) {
+	version.FileID = fileID
+	version.Date = f.clock.Now()
+
+	err := f.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		version.ID, err = f.version.Create(ctx, version)
+		if err != nil {
+			return errors.Wrap(err, "couldn't create file version")
+		}
+
+		_, err = f.File.SetVersion(ctx, fileID, version)
+		if err != nil {
+			return errors.Wrap(err, "couldn't set file version")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// update creates a new version of the existing file with FileUpdated event in a unit of work.
+func (f FileService) update(ctx context.Context, fileID string, version model.FileVersionDTO) error {
+	return f.tx.Do(ctx, func(ctx context.Context) error {
+		current, err := f.newVersion(ctx, fileID, version)
+		if err != nil {
+			return err
+		}
+
+		return record(ctx, f.outbox, model.EventFileUpdated, fileID, current, current.Date)
+	})
+}
+
+// FindVersions finds versions of the file sorted from the latest.
+func (f FileService) FindVersions(ctx context.Context, request model.IDFileRequest) ([]model.FileVersionDTO, error) {
+	versions, err := f.version.FindByFileID(ctx, request.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find file versions")
+	}
+
+	return versions, nil
+}
+
+// Download checks that user bought or authored the current file version and opens file content.
+// The caller must close the returned content.
+func (f FileService) Download(ctx context.Context, request model.DownloadFileRequest) (*model.FileContent, error) {
+	file, err := f.File.FindByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find file")
+	}
+
+	err = f.entitled(ctx, file, request.UserID, file.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	return f.open(ctx, *file, request.UserID)
+}
+
+// DownloadVersion checks that user bought or authored the file version and opens version content.
+// The caller must close the returned content.
+func (f FileService) DownloadVersion(ctx context.Context, request model.DownloadFileVersionRequest) (*model.FileContent, error) {
+	file, err := f.File.FindByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find file")
+	}
+
+	version, err := f.version.FindByFileIDAndNumber(ctx, request.ID, request.Number)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find file version")
+	}
+
+	err = f.entitled(ctx, file, request.UserID, version.Number)
+	if err != nil {
+		return nil, err
+	}
+
+	versioned := *file
+	versioned.VersionID = version.ID
+	versioned.Version = version.Number
+	versioned.Name = version.Name
+	versioned.Description = version.Description
+	versioned.Size = version.Size
+	versioned.Path = version.Path
+	versioned.Checksum = version.Checksum
+	versioned.ContentType = version.ContentType
+
+	return f.open(ctx, versioned, request.UserID)
+}
+
+// SignDownload checks that user bought or authored the current file version and creates an expiring signed download link.
+func (f FileService) SignDownload(ctx context.Context, request model.SignDownloadFileRequest) (*auth.Link, error) {
+	file, err := f.File.FindByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find file")
+	}
+
+	err = f.entitled(ctx, file, request.UserID, file.Version)
+	if err != nil {
+		return nil, err
+	}
+
+	link, err := f.signer.Sign(request.ID, strconv.Itoa(request.UserID), request.SingleUse)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't sign download link")
+	}
+
+	return &link, nil
+}
+
+// DownloadByLink verifies signature and expiry of the link and downloads file for the link user.
+// A single-use link is spent before the content is opened.
+func (f FileService) DownloadByLink(ctx context.Context, link auth.Link) (*model.FileContent, error) {
+	err := f.signer.Verify(link)
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(link.UserID)
+	if err != nil {
+		return nil, auth.ErrInvalidLink
+	}
+
+	if link.SingleUse {
+		unused, err := f.link.Use(ctx, link.Nonce, link.Expires)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't use download link")
+		}
+		if !unused {
+			return nil, ErrLinkUsed
+		}
+	}
+
+	return f.Download(ctx, model.DownloadFileRequest{ID: link.FileID, UserID: userID})
+}
+
+// entitled checks that user is the author of the file or has bought the file version.
+// A purchase entitles to the bought version and all later versions.
+func (f FileService) entitled(ctx context.Context, file *model.FileDTO, userID, version int) error {
+	if file.AuthorID == userID {
+		return nil
+	}
+
+	purchases, err := f.purchase.FindByUserIDAndFileID(ctx, userID, file.ID)
+	if err != nil {
+		return errors.Wrap(err, "couldn't find purchases")
+	}
+	for _, purchase := range purchases {
+		if purchase.Version <= version {
+			return nil
+		}
+	}
+
+	return ErrNotEntitled
+}
+
+// open opens content of the file version for the user.
+func (f FileService) open(ctx context.Context, file model.FileDTO, userID int) (*model.FileContent, error) {
+	if file.Checksum == "" {
+		return nil, ErrNoContent
+	}
+
+	content, err := f.storage.Open(ctx, file.Path)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, ErrNoContent
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open file content")
+	}
+
+	return &model.FileContent{File: file, UserID: userID, Content: content}, nil
+}
+
+// RecordDownload records the download of the file version by the content user.
+// It is called once the whole content has been served, partial and not modified responses aren't downloads.
+func (f FileService) RecordDownload(ctx context.Context, content *model.FileContent) error {
+	_, err := f.download.Create(ctx, model.DownloadDTO{
+		FileID:  content.File.ID,
+		UserID:  content.UserID,
+		Version: content.File.Version,
+		Date:    f.clock.Now(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "couldn't log file download")
+	}
+
+	return nil
+}
+
+// Delete deletes file of the author with its versions, purchases and their comments in a unit of work and returns deleted id.
+// PurchaseRefunded event is recorded for every deleted purchase.
+// Stored content of the file versions is deleted once the unit of work succeeds.
 func (f FileService) Delete(ctx context.Context, request model.DeleteFileRequest) (string, error) {
-	id, err := f.File.Delete(ctx, request.ID)
+	current, err := f.File.FindByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find file")
+	}
+	if current.AuthorID != request.AuthorID {
+		return "", ErrNotFileAuthor
+	}
+	request.Revision, err = matchRevision(current.Revision, request.Revision)
 	if err != nil {
-		return "", errors.Wrap(err, "couldn't delete file")
+		return "", err
+	}
+
+	var id string
+	var versions []model.FileVersionDTO
+	now := f.clock.Now()
+	err = f.tx.Do(ctx, func(ctx context.Context) error {
+		purchases, err := f.purchase.FindByFileID(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't find file purchases")
+		}
+		versions, err = f.version.FindByFileID(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't find file versions")
+		}
+
+		id, err = f.File.Delete(ctx, request.ID, request.Revision)
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete file")
+		}
+
+		_, err = f.version.DeleteByFileID(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete file versions")
+		}
+
+		for _, purchase := range purchases {
+			_, err = f.comment.DeleteByPurchaseID(ctx, purchase.ID)
+			if err != nil {
+				return errors.Wrap(err, "couldn't delete purchase comments")
+			}
+
+			err = record(ctx, f.outbox, model.EventPurchaseRefunded, purchase.ID, purchase, now)
+			if err != nil {
+				return err
+			}
+		}
+
+		_, err = f.purchase.DeleteByFileID(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete file purchases")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	deleted := make(map[string]bool, len(versions))
+	for _, version := range versions {
+		if version.Path == "" || deleted[version.Path] {
+			continue
+		}
+		deleted[version.Path] = true
+
+		err = f.storage.Delete(ctx, version.Path)
+		if err != nil && !errors.Is(err, storage.ErrNotFound) {
+			f.log.WithContext(ctx).Error("couldn't delete file content", "id", id, "path", version.Path, "error", err)
+		}
 	}
 
 	return id, nil
@@ -106,6 +574,16 @@ func (f FileService) FindByName(ctx context.Context, request model.NameFileReque
 	return files, nil
 }
 
+// FindByText finds files by name and description text.
+func (f FileService) FindByText(ctx context.Context, request model.TextFileRequest) ([]model.FileDTO, error) {
+	files, err := f.File.FindByText(ctx, request.Text, request.Language)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find files")
+	}
+
+	return files, nil
+}
+
 // FindAll finds files.
 func (f FileService) FindAll(ctx context.Context) ([]model.FileDTO, error) {
 	files, err := f.File.FindAll(ctx)
@@ -116,6 +594,16 @@ func (f FileService) FindAll(ctx context.Context) ([]model.FileDTO, error) {
 	return files, nil
 }
 
+// FindByRating finds files sorted by rating.
+func (f FileService) FindByRating(ctx context.Context) ([]model.FileDTO, error) {
+	files, err := f.File.FindByRating(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find files")
+	}
+
+	return files, nil
+}
+
 // FindByAuthorID finds files by author id.
 func (f FileService) FindByAuthorID(ctx context.Context, request model.AuthorIDFileRequest) ([]model.FileDTO, error) {
 	var files []model.FileDTO
@@ -2,16 +2,24 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/service/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/storage"
 	"github.com/pkg/errors"
 	testAssert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func TestFileService_Create(t *testing.T) {
@@ -21,31 +29,34 @@ func TestFileService_Create(t *testing.T) {
 	type test struct {
 		name   string
 		req    model.CreateFileRequest
-		fn     func(file *m.File, data test)
+		fn     func(file *m.File, version *m.FileVersion, data test)
 		expID  string
 		expErr error
 	}
+	first := func(data test) interface{} {
+		return mock.MatchedBy(func(version model.FileVersionDTO) bool {
+			return version.FileID == data.expID &&
+				version.Number == 1 &&
+				version.Name == data.req.Name &&
+				version.Description == data.req.Description &&
+				version.Date.Equal(time.Time(testClock))
+		})
+	}
 	tt := []test{
 		{
 			name: "Create errors",
 			req: model.CreateFileRequest{
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
-			fn: func(file *m.File, data test) {
+			fn: func(file *m.File, version *m.FileVersion, data test) {
 				file.On("Create", mock.Anything, model.FileDTO{
 					Name:        data.req.Name,
 					Description: data.req.Description,
-					Size:        data.req.Size,
-					Path:        data.req.Path,
-					AddDate:     data.req.AddDate,
-					UpdateDate:  data.req.UpdateDate,
+					AddDate:     time.Time(testClock),
+					UpdateDate:  time.Time(testClock),
 					Actual:      data.req.Actual,
 					AuthorID:    data.req.AuthorID,
 				}).
@@ -53,186 +64,1611 @@ func TestFileService_Create(t *testing.T) {
 			},
 			expErr: errors.Wrap(errors.New(""), "couldn't create file"),
 		},
+		{
+			name: "Create version errors",
+			req: model.CreateFileRequest{
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data test) {
+				id := primitive.NewObjectID().Hex()
+				file.On("Create", mock.Anything, mock.Anything).
+					Return(id, nil)
+				version.On("Create", mock.Anything, mock.Anything).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't create file version"),
+		},
 		{
 			name: "All ok",
 			req: model.CreateFileRequest{
 				Name:        "some",
 				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
 				Actual:      true,
 				AuthorID:    1,
 			},
-			fn: func(file *m.File, data test) {
+			fn: func(file *m.File, version *m.FileVersion, data test) {
+				versionID := primitive.NewObjectID().Hex()
+				file.On("Create", mock.Anything, model.FileDTO{
+					Name:        data.req.Name,
+					Description: data.req.Description,
+					AddDate:     time.Time(testClock),
+					UpdateDate:  time.Time(testClock),
+					Actual:      data.req.Actual,
+					AuthorID:    data.req.AuthorID,
+				}).
+					Return(data.expID, nil)
+				version.On("Create", mock.Anything, first(data)).
+					Return(versionID, nil)
+				file.On("SetVersion", mock.Anything, data.expID, mock.MatchedBy(func(version model.FileVersionDTO) bool {
+					return version.ID == versionID && version.Number == 1
+				})).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			version := new(m.FileVersion)
+			ctx := context.Background()
+			service := NewFileService(file, version, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, version, tc)
+			}
+			id, err := service.Create(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestFileService_Import(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		req    model.ImportFileRequest
+		fn     func(file *m.File, version *m.FileVersion, data test)
+		expID  string
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Create errors",
+			req: model.ImportFileRequest{
+				CreateFileRequest: model.CreateFileRequest{
+					Name:        "some",
+					Description: "some",
+					AuthorID:    1,
+				},
+				AddDate:    time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+				UpdateDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data test) {
+				file.On("Create", mock.Anything, mock.Anything).
+					Return(data.expID, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't create file"),
+		},
+		{
+			name: "All ok",
+			req: model.ImportFileRequest{
+				CreateFileRequest: model.CreateFileRequest{
+					Name:        "some",
+					Description: "some",
+					AuthorID:    1,
+				},
+				AddDate:    time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+				UpdateDate: time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data test) {
 				file.On("Create", mock.Anything, model.FileDTO{
 					Name:        data.req.Name,
 					Description: data.req.Description,
-					Size:        data.req.Size,
-					Path:        data.req.Path,
 					AddDate:     data.req.AddDate,
 					UpdateDate:  data.req.UpdateDate,
-					Actual:      data.req.Actual,
 					AuthorID:    data.req.AuthorID,
 				}).
 					Return(data.expID, nil)
+				version.On("Create", mock.Anything, mock.Anything).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.expID, mock.Anything).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			version := new(m.FileVersion)
+			ctx := context.Background()
+			service := NewFileService(file, version, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, version, tc)
+			}
+			id, err := service.Import(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestFileService_Update(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		req    model.UpdateFileRequest
+		fn     func(file *m.File, version *m.FileVersion, data *test)
+		expID  string
+		expErr error
+	}
+	current := func(data *test) *model.FileDTO {
+		return &model.FileDTO{
+			ID:          data.req.ID,
+			Name:        "old",
+			Description: "old",
+			Size:        12,
+			Path:        "some",
+			Checksum:    "some",
+			ContentType: "text/plain",
+			Version:     2,
+			AuthorID:    data.req.AuthorID,
+		}
+	}
+	next := func(data *test) interface{} {
+		return mock.MatchedBy(func(version model.FileVersionDTO) bool {
+			return version.FileID == data.req.ID &&
+				version.Number == 3 &&
+				version.Name == data.req.Name &&
+				version.Description == data.req.Description &&
+				version.Size == 12 &&
+				version.Path == "some" &&
+				version.Checksum == "some" &&
+				version.ContentType == "text/plain" &&
+				version.Date.Equal(time.Time(testClock))
+		})
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "Not found",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Not author",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    2,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 1}, nil)
+			},
+			expErr: ErrNotFileAuthor,
+		},
+		{
+			name: "Modified",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+				Revision:    1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "Update errors",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+				file.On("Update", mock.Anything, data.req.ID, model.FileDTO{
+					Name:        data.req.Name,
+					Description: data.req.Description,
+					UpdateDate:  time.Time(testClock),
+					Actual:      data.req.Actual,
+				}).
+					Return(data.expID, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't update file"),
+		},
+		{
+			name: "Set version errors",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+				file.On("Update", mock.Anything, data.req.ID, mock.Anything).
+					Return(data.req.ID, nil)
+				version.On("Create", mock.Anything, next(data)).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.req.ID, mock.Anything).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't set file version"),
+		},
+		{
+			name: "All ok",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				data.expID = data.req.ID
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+				file.On("Update", mock.Anything, data.req.ID, model.FileDTO{
+					Name:        data.req.Name,
+					Description: data.req.Description,
+					UpdateDate:  time.Time(testClock),
+					Actual:      data.req.Actual,
+				}).
+					Return(data.expID, nil)
+				version.On("Create", mock.Anything, next(data)).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.req.ID, next(data)).
+					Return(data.expID, nil)
+			},
+		},
+		{
+			name: "All ok any revision",
+			req: model.UpdateFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				Name:        "some",
+				Description: "some",
+				Actual:      true,
+				AuthorID:    1,
+				Revision:    model.AnyRevision,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				data.expID = data.req.ID
+				current := current(data)
+				current.Revision = 3
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current, nil)
+				file.On("Update", mock.Anything, data.req.ID, model.FileDTO{
+					Name:        data.req.Name,
+					Description: data.req.Description,
+					UpdateDate:  time.Time(testClock),
+					Actual:      data.req.Actual,
+					Revision:    3,
+				}).
+					Return(data.expID, nil)
+				version.On("Create", mock.Anything, next(data)).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.req.ID, next(data)).
+					Return(data.expID, nil)
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			version := new(m.FileVersion)
+			ctx := context.Background()
+			service := NewFileService(file, version, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, version, &tc)
+			}
+			id, err := service.Update(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestFileService_Patch(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	name := "new"
+	actual := false
+	updateDate := time.Time(testClock)
+	type test struct {
+		name   string
+		req    model.PatchFileRequest
+		fn     func(file *m.File, version *m.FileVersion, data *test)
+		expID  string
+		expErr error
+	}
+	current := func(data *test) *model.FileDTO {
+		return &model.FileDTO{
+			ID:          data.req.ID,
+			Name:        "old",
+			Description: "old",
+			Size:        12,
+			Path:        "some",
+			Checksum:    "some",
+			ContentType: "text/plain",
+			Version:     2,
+			Actual:      true,
+			AuthorID:    1,
+		}
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.PatchFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Actual:   &actual,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "Not found",
+			req: model.PatchFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Actual:   &actual,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Not author",
+			req: model.PatchFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 2,
+				Actual:   &actual,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+			},
+			expErr: ErrNotFileAuthor,
+		},
+		{
+			name: "Modified",
+			req: model.PatchFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Actual:   &actual,
+				Revision: 1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "Patch errors",
+			req: model.PatchFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Actual:   &actual,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+				file.On("Patch", mock.Anything, data.req.ID, mock.Anything).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't patch file"),
+		},
+		{
+			name: "Actual changed",
+			req: model.PatchFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Actual:   &actual,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				data.expID = data.req.ID
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+				file.On("Patch", mock.Anything, data.req.ID, model.FilePatch{
+					Actual:     &actual,
+					UpdateDate: &updateDate,
+				}).
+					Return(data.expID, nil)
+			},
+		},
+		{
+			name: "Name changed",
+			req: model.PatchFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Name:     &name,
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				data.expID = data.req.ID
+				next := mock.MatchedBy(func(version model.FileVersionDTO) bool {
+					return version.FileID == data.req.ID &&
+						version.Number == 3 &&
+						version.Name == name &&
+						version.Description == "old" &&
+						version.Checksum == "some" &&
+						version.Date.Equal(time.Time(testClock))
+				})
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data), nil)
+				file.On("Patch", mock.Anything, data.req.ID, model.FilePatch{
+					Name:       &name,
+					UpdateDate: &updateDate,
+				}).
+					Return(data.expID, nil)
+				version.On("Create", mock.Anything, next).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.req.ID, next).
+					Return(data.expID, nil)
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			version := new(m.FileVersion)
+			ctx := context.Background()
+			service := NewFileService(file, version, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, version, &tc)
+			}
+			id, err := service.Patch(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+			file.AssertExpectations(t)
+			version.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFileService_Upload(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	backend, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	oldPath, err := backend.Save(context.Background(), "old.txt", strings.NewReader("old content"))
+	require.NoError(t, err)
+	content := "some content"
+	sum := sha256.Sum256([]byte(content))
+	checksum := hex.EncodeToString(sum[:])
+	stored := func(number int) interface{} {
+		return mock.MatchedBy(func(version model.FileVersionDTO) bool {
+			return version.Number == number &&
+				version.Name == "some" &&
+				version.Size == len(content) &&
+				version.Checksum == checksum &&
+				version.ContentType == "text/plain" &&
+				version.Path != ""
+		})
+	}
+	type test struct {
+		name   string
+		req    model.UploadFileRequest
+		fn     func(file *m.File, version *m.FileVersion, data *test)
+		expID  string
+		expErr error
+		kept   string
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.UploadFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Name:     "some.txt",
+				Content:  strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "Not found",
+			req: model.UploadFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Name:     "some.txt",
+				Content:  strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Not author",
+			req: model.UploadFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Name:     "some.txt",
+				Content:  strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 2}, nil)
+			},
+			expErr: ErrNotFileAuthor,
+		},
+		{
+			name: "Modified",
+			req: model.UploadFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Revision: 1,
+				Name:     "some.txt",
+				Content:  strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 1, Revision: 2}, nil)
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "Empty content",
+			req: model.UploadFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Name:     "some.txt",
+				Content:  strings.NewReader(""),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 1}, nil)
+			},
+			expErr: ErrEmptyContent,
+		},
+		{
+			name: "Create version errors",
+			req: model.UploadFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				AuthorID:    1,
+				Name:        "some.txt",
+				ContentType: "text/plain",
+				Content:     strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, Name: "some", AuthorID: 1}, nil)
+				version.On("Create", mock.Anything, stored(1)).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't create file version"),
+		},
+		{
+			name: "All ok",
+			req: model.UploadFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				AuthorID:    1,
+				Name:        "some.txt",
+				ContentType: "text/plain",
+				Content:     strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				data.expID = data.req.ID
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, Name: "some", AuthorID: 1}, nil)
+				version.On("Create", mock.Anything, stored(1)).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.req.ID, stored(1)).
+					Return(data.expID, nil)
+			},
+		},
+		{
+			name: "All ok any revision",
+			req: model.UploadFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				AuthorID:    1,
+				Revision:    model.AnyRevision,
+				Name:        "some.txt",
+				ContentType: "text/plain",
+				Content:     strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				data.expID = data.req.ID
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, Name: "some", AuthorID: 1, Revision: 2}, nil)
+				version.On("Create", mock.Anything, stored(1)).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.req.ID, stored(1)).
+					Return(data.expID, nil)
+			},
+		},
+		{
+			name: "All ok keeps previous content",
+			req: model.UploadFileRequest{
+				ID:          primitive.NewObjectID().Hex(),
+				AuthorID:    1,
+				Name:        "some.txt",
+				ContentType: "text/plain",
+				Content:     strings.NewReader(content),
+			},
+			fn: func(file *m.File, version *m.FileVersion, data *test) {
+				data.expID = data.req.ID
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, Name: "some", AuthorID: 1, Path: oldPath, Checksum: "some", Version: 1}, nil)
+				version.On("Create", mock.Anything, stored(2)).
+					Return(primitive.NewObjectID().Hex(), nil)
+				file.On("SetVersion", mock.Anything, data.req.ID, stored(2)).
+					Return(data.expID, nil)
+			},
+			kept: oldPath,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			version := new(m.FileVersion)
+			ctx := context.Background()
+			service := NewFileService(file, version, nil, nil, nil, nil, newTestOutbox(), testTx, nil, backend, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, version, &tc)
+			}
+			id, err := service.Upload(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+			if tc.kept != "" {
+				content, err := backend.Open(ctx, tc.kept)
+				assert.NoError(err)
+				assert.NoError(content.Close())
+			}
+		})
+	}
+}
+
+func TestFileService_Download(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	backend, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	path, err := backend.Save(context.Background(), "some.txt", strings.NewReader("some content"))
+	require.NoError(t, err)
+	type test struct {
+		name       string
+		req        model.DownloadFileRequest
+		fn         func(file *m.File, purchase *m.Purchase, data test)
+		expFile    *model.FileDTO
+		expContent string
+		expErr     error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "Not found",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Purchases errors",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 2, Path: path, Checksum: "some"}, nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find purchases"),
+		},
+		{
+			name: "Not entitled",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 2, Path: path, Checksum: "some"}, nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return(nil, nil)
+			},
+			expErr: ErrNotEntitled,
+		},
+		{
+			name: "Bought later version",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 2, Path: path, Checksum: "some", Version: 1}, nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return([]model.PurchaseDTO{{UserID: data.req.UserID, FileID: data.req.ID, Version: 2}}, nil)
+			},
+			expErr: ErrNotEntitled,
+		},
+		{
+			name: "No content",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 1}, nil)
+			},
+			expErr: ErrNoContent,
+		},
+		{
+			name: "Missing content",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 1, Path: "missing", Checksum: "some"}, nil)
+			},
+			expErr: ErrNoContent,
+		},
+		{
+			name: "All ok for author",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				data.expFile.ID = data.req.ID
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(data.expFile, nil)
+			},
+			expFile:    &model.FileDTO{AuthorID: 1, Path: path, Checksum: "some"},
+			expContent: "some content",
+		},
+		{
+			name: "All ok for buyer",
+			req: model.DownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				data.expFile.ID = data.req.ID
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(data.expFile, nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return([]model.PurchaseDTO{{UserID: data.req.UserID, FileID: data.req.ID}}, nil)
+			},
+			expFile:    &model.FileDTO{AuthorID: 2, Path: path, Checksum: "some"},
+			expContent: "some content",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			purchase := new(m.Purchase)
+			ctx := context.Background()
+			service := NewFileService(file, nil, purchase, nil, nil, nil, newTestOutbox(), testTx, nil, backend, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, purchase, tc)
+			}
+			content, err := service.Download(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			if tc.expFile == nil {
+				assert.Nil(content)
+				return
+			}
+			defer content.Content.Close()
+			assert.Equal(*tc.expFile, content.File)
+			assert.Equal(tc.req.UserID, content.UserID)
+			data, err := io.ReadAll(content.Content)
+			assert.NoError(err)
+			assert.Equal(tc.expContent, string(data))
+		})
+	}
+}
+
+func TestFileService_DownloadVersion(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	backend, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	oldPath, err := backend.Save(context.Background(), "old.txt", strings.NewReader("old content"))
+	require.NoError(t, err)
+	path, err := backend.Save(context.Background(), "some.txt", strings.NewReader("some content"))
+	require.NoError(t, err)
+	type test struct {
+		name       string
+		req        model.DownloadFileVersionRequest
+		fn         func(file *m.File, version *m.FileVersion, purchase *m.Purchase, data test)
+		expName    string
+		expContent string
+		expErr     error
+	}
+	current := func(data test, authorID int) *model.FileDTO {
+		return &model.FileDTO{ID: data.req.ID, Name: "new", AuthorID: authorID, Path: path, Checksum: "new", Version: 2}
+	}
+	first := func(data test) *model.FileVersionDTO {
+		return &model.FileVersionDTO{
+			ID:       primitive.NewObjectID().Hex(),
+			FileID:   data.req.ID,
+			Number:   1,
+			Name:     "old",
+			Path:     oldPath,
+			Checksum: "old",
+		}
+	}
+	tt := []test{
+		{
+			name: "Find file errors",
+			req: model.DownloadFileVersionRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Number: 1,
+				UserID: 1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "Version not found",
+			req: model.DownloadFileVersionRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Number: 3,
+				UserID: 1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data, 1), nil)
+				version.On("FindByFileIDAndNumber", mock.Anything, data.req.ID, data.req.Number).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Find version errors",
+			req: model.DownloadFileVersionRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Number: 1,
+				UserID: 1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data, 1), nil)
+				version.On("FindByFileIDAndNumber", mock.Anything, data.req.ID, data.req.Number).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file version"),
+		},
+		{
+			name: "Bought later version",
+			req: model.DownloadFileVersionRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Number: 1,
+				UserID: 1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data, 2), nil)
+				version.On("FindByFileIDAndNumber", mock.Anything, data.req.ID, data.req.Number).
+					Return(first(data), nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return([]model.PurchaseDTO{{UserID: data.req.UserID, FileID: data.req.ID, Version: 2}}, nil)
+			},
+			expErr: ErrNotEntitled,
+		},
+		{
+			name: "All ok for buyer",
+			req: model.DownloadFileVersionRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				Number: 1,
+				UserID: 1,
+			},
+			fn: func(file *m.File, version *m.FileVersion, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(current(data, 2), nil)
+				version.On("FindByFileIDAndNumber", mock.Anything, data.req.ID, data.req.Number).
+					Return(first(data), nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return([]model.PurchaseDTO{{UserID: data.req.UserID, FileID: data.req.ID, Version: 1}}, nil)
+			},
+			expName:    "old",
+			expContent: "old content",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			version := new(m.FileVersion)
+			purchase := new(m.Purchase)
+			ctx := context.Background()
+			service := NewFileService(file, version, purchase, nil, nil, nil, newTestOutbox(), testTx, nil, backend, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, version, purchase, tc)
+			}
+			content, err := service.DownloadVersion(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			if tc.expContent == "" {
+				assert.Nil(content)
+				return
+			}
+			defer content.Content.Close()
+			assert.Equal(tc.expName, content.File.Name)
+			assert.Equal(tc.req.Number, content.File.Version)
+			data, err := io.ReadAll(content.Content)
+			assert.NoError(err)
+			assert.Equal(tc.expContent, string(data))
+		})
+	}
+}
+
+func TestFileService_SignDownload(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	signer, err := auth.NewLinkSigner("key", time.Minute, testClock)
+	require.NoError(t, err)
+	type test struct {
+		name    string
+		req     model.SignDownloadFileRequest
+		fn      func(file *m.File, purchase *m.Purchase, data test)
+		expLink bool
+		expErr  error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.SignDownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "Not found",
+			req: model.SignDownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Not entitled",
+			req: model.SignDownloadFileRequest{
+				ID:     primitive.NewObjectID().Hex(),
+				UserID: 1,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 2}, nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return(nil, nil)
+			},
+			expErr: ErrNotEntitled,
+		},
+		{
+			name: "All ok",
+			req: model.SignDownloadFileRequest{
+				ID:        primitive.NewObjectID().Hex(),
+				UserID:    1,
+				SingleUse: true,
+			},
+			fn: func(file *m.File, purchase *m.Purchase, data test) {
+				file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 2}, nil)
+				purchase.On("FindByUserIDAndFileID", mock.Anything, data.req.UserID, data.req.ID).
+					Return([]model.PurchaseDTO{{UserID: data.req.UserID, FileID: data.req.ID}}, nil)
+			},
+			expLink: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			purchase := new(m.Purchase)
+			ctx := context.Background()
+			service := NewFileService(file, nil, purchase, nil, nil, nil, newTestOutbox(), testTx, signer, nil, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, purchase, tc)
+			}
+			link, err := service.SignDownload(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			if !tc.expLink {
+				assert.Nil(link)
+				return
+			}
+			assert.Equal(tc.req.ID, link.FileID)
+			assert.Equal("1", link.UserID)
+			assert.Equal(tc.req.SingleUse, link.SingleUse)
+			assert.NoError(signer.Verify(*link))
+		})
+	}
+}
+
+func TestFileService_DownloadByLink(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	backend, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	path, err := backend.Save(context.Background(), "some.txt", strings.NewReader("some content"))
+	require.NoError(t, err)
+	signer, err := auth.NewLinkSigner("key", time.Minute, testClock)
+	require.NoError(t, err)
+	// Links of this signer expire as soon as they are signed.
+	expiredSigner, err := auth.NewLinkSigner("key", time.Nanosecond, testClock)
+	require.NoError(t, err)
+	sign := func(signer *auth.LinkSigner, userID string, singleUse bool) auth.Link {
+		link, err := signer.Sign(primitive.NewObjectID().Hex(), userID, singleUse)
+		require.NoError(t, err)
+		return link
+	}
+	tampered := sign(signer, "2", false)
+	tampered.UserID = "1"
+	type test struct {
+		name       string
+		link       auth.Link
+		fn         func(file *m.File, link *m.Link, data test)
+		expContent string
+		expErr     error
+	}
+	tt := []test{
+		{
+			name:   "Invalid signature",
+			link:   tampered,
+			expErr: auth.ErrInvalidLink,
+		},
+		{
+			name:   "Expired",
+			link:   sign(expiredSigner, "1", false),
+			expErr: auth.ErrLinkExpired,
+		},
+		{
+			name: "Use errors",
+			link: sign(signer, "1", true),
+			fn: func(file *m.File, link *m.Link, data test) {
+				link.On("Use", mock.Anything, data.link.Nonce, data.link.Expires).
+					Return(false, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't use download link"),
+		},
+		{
+			name: "Used",
+			link: sign(signer, "1", true),
+			fn: func(file *m.File, link *m.Link, data test) {
+				link.On("Use", mock.Anything, data.link.Nonce, data.link.Expires).
+					Return(false, nil)
+			},
+			expErr: ErrLinkUsed,
+		},
+		{
+			name: "All ok",
+			link: sign(signer, "1", false),
+			fn: func(file *m.File, link *m.Link, data test) {
+				file.On("FindByID", mock.Anything, data.link.FileID).
+					Return(&model.FileDTO{ID: data.link.FileID, AuthorID: 1, Path: path, Checksum: "some"}, nil)
+			},
+			expContent: "some content",
+		},
+		{
+			name: "All ok with single-use link",
+			link: sign(signer, "1", true),
+			fn: func(file *m.File, link *m.Link, data test) {
+				link.On("Use", mock.Anything, data.link.Nonce, data.link.Expires).
+					Return(true, nil)
+				file.On("FindByID", mock.Anything, data.link.FileID).
+					Return(&model.FileDTO{ID: data.link.FileID, AuthorID: 1, Path: path, Checksum: "some"}, nil)
+			},
+			expContent: "some content",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			link := new(m.Link)
+			ctx := context.Background()
+			service := NewFileService(file, nil, nil, nil, nil, link, newTestOutbox(), testTx, signer, backend, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, link, tc)
+			}
+			content, err := service.DownloadByLink(ctx, tc.link)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			if tc.expContent == "" {
+				assert.Nil(content)
+				return
+			}
+			defer content.Content.Close()
+			data, err := io.ReadAll(content.Content)
+			assert.NoError(err)
+			assert.Equal(tc.expContent, string(data))
+		})
+	}
+}
+
+func TestFileService_RecordDownload(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	type test struct {
+		name    string
+		content *model.FileContent
+		fn      func(download *m.Download, data test)
+		expErr  error
+	}
+	logged := func(data test) interface{} {
+		return mock.MatchedBy(func(download model.DownloadDTO) bool {
+			return download.FileID == data.content.File.ID &&
+				download.UserID == data.content.UserID &&
+				download.Version == data.content.File.Version &&
+				download.Date.Equal(time.Time(testClock))
+		})
+	}
+	tt := []test{
+		{
+			name:    "Create errors",
+			content: &model.FileContent{File: model.FileDTO{ID: primitive.NewObjectID().Hex(), Version: 2}, UserID: 1},
+			fn: func(download *m.Download, data test) {
+				download.On("Create", mock.Anything, logged(data)).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't log file download"),
+		},
+		{
+			name:    "All ok",
+			content: &model.FileContent{File: model.FileDTO{ID: primitive.NewObjectID().Hex(), Version: 2}, UserID: 1},
+			fn: func(download *m.Download, data test) {
+				download.On("Create", mock.Anything, logged(data)).
+					Return(primitive.NewObjectID().Hex(), nil)
 			},
-			expID: primitive.NewObjectID().Hex(),
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			file := new(m.File)
+			download := new(m.Download)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(nil, nil, nil, nil, download, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
-				tc.fn(file, tc)
+				tc.fn(download, tc)
 			}
-			id, err := service.Create(ctx, tc.req)
-			if err != nil {
+			err := service.RecordDownload(ctx, tc.content)
+			if tc.expErr != nil {
 				assert.Equal(tc.expErr.Error(), err.Error())
+			} else {
+				assert.NoError(err)
 			}
-			assert.Equal(tc.expID, id)
+			download.AssertExpectations(t)
 		})
 	}
 }
 
-func TestFileService_Update(t *testing.T) {
+func TestFileService_Delete(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
 	require.NoError(t, err)
+	backend, err := storage.NewLocal(t.TempDir())
+	require.NoError(t, err)
+	purchases := []model.PurchaseDTO{
+		{ID: primitive.NewObjectID().Hex(), UserID: 1},
+		{ID: primitive.NewObjectID().Hex(), UserID: 2},
+	}
+	type mocks struct {
+		file     *m.File
+		version  *m.FileVersion
+		purchase *m.Purchase
+		comment  *m.Comment
+		outbox   *m.Outbox
+		tx       *rollbackTx
+	}
 	type test struct {
-		name   string
-		req    model.UpdateFileRequest
-		fn     func(file *m.File, data *test)
-		expID  string
-		expErr error
+		name        string
+		req         model.DeleteFileRequest
+		path        string
+		fn          func(mocks mocks, data *test)
+		expID       string
+		expRollback bool
+		expDeleted  bool
+		expErr      error
+	}
+	existing := func(mocks mocks, data *test) {
+		mocks.file.On("FindByID", mock.Anything, data.req.ID).
+			Return(&model.FileDTO{ID: data.req.ID, AuthorID: 1, Revision: data.req.Revision}, nil)
+	}
+	found := func(mocks mocks, data *test) {
+		existing(mocks, data)
+		mocks.purchase.On("FindByFileID", mocks.tx.ctx(), data.req.ID).
+			Return(purchases, nil)
+		mocks.version.On("FindByFileID", mocks.tx.ctx(), data.req.ID).
+			Return([]model.FileVersionDTO{{Number: 1}, {Number: 2, Path: data.path}, {Number: 3, Path: data.path}}, nil)
 	}
 	tt := []test{
 		{
-			name: "Update errors",
-			req: model.UpdateFileRequest{
-				ID:          primitive.NewObjectID().Hex(),
-				Name:        "some",
-				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				Actual:      true,
-				AuthorID:    1,
+			name: "Find file errors",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
 			},
-			fn: func(file *m.File, data *test) {
-				file.On("Update", mock.Anything, data.req.ID, model.FileDTO{
-					Name:        data.req.Name,
-					Description: data.req.Description,
-					Size:        data.req.Size,
-					Path:        data.req.Path,
-					AddDate:     data.req.AddDate,
-					UpdateDate:  data.req.UpdateDate,
-					Actual:      data.req.Actual,
-					AuthorID:    data.req.AuthorID,
-				}).
+			fn: func(mocks mocks, data *test) {
+				mocks.file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "File not found",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				mocks.file.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Not author",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 2,
+			},
+			fn: func(mocks mocks, data *test) {
+				existing(mocks, data)
+			},
+			expErr: ErrNotFileAuthor,
+		},
+		{
+			name: "Find purchases errors",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				existing(mocks, data)
+				mocks.purchase.On("FindByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't find file purchases"),
+		},
+		{
+			name: "Find versions errors",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				existing(mocks, data)
+				mocks.purchase.On("FindByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(purchases, nil)
+				mocks.version.On("FindByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't find file versions"),
+		},
+		{
+			name: "Delete file errors",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				found(mocks, data)
+				mocks.file.On("Delete", mocks.tx.ctx(), data.req.ID, data.req.Revision).
 					Return(data.expID, errors.New(""))
 			},
-			expErr: errors.Wrap(errors.New(""), "couldn't update file"),
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't delete file"),
+		},
+		{
+			name: "Modified",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Revision: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				mocks.file.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.FileDTO{ID: data.req.ID, AuthorID: 1, Revision: 2}, nil)
+			},
+			expErr: ErrModified,
+		},
+		{
+			name: "Modified concurrently",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+				Revision: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				found(mocks, data)
+				mocks.file.On("Delete", mocks.tx.ctx(), data.req.ID, data.req.Revision).
+					Return("", ErrModified)
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(ErrModified, "couldn't delete file"),
+		},
+		{
+			name: "Delete versions errors",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				found(mocks, data)
+				mocks.file.On("Delete", mocks.tx.ctx(), data.req.ID, data.req.Revision).
+					Return(data.req.ID, nil)
+				mocks.version.On("DeleteByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(0, errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't delete file versions"),
+		},
+		{
+			name: "Delete comments errors",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				found(mocks, data)
+				mocks.file.On("Delete", mocks.tx.ctx(), data.req.ID, data.req.Revision).
+					Return(data.req.ID, nil)
+				mocks.version.On("DeleteByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(3, nil)
+				mocks.comment.On("DeleteByPurchaseID", mocks.tx.ctx(), purchases[0].ID).
+					Return(0, errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't delete purchase comments"),
+		},
+		{
+			name: "Delete purchases errors",
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
+			},
+			fn: func(mocks mocks, data *test) {
+				found(mocks, data)
+				mocks.file.On("Delete", mocks.tx.ctx(), data.req.ID, data.req.Revision).
+					Return(data.req.ID, nil)
+				mocks.version.On("DeleteByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(3, nil)
+				for _, purchase := range purchases {
+					mocks.comment.On("DeleteByPurchaseID", mocks.tx.ctx(), purchase.ID).
+						Return(1, nil)
+				}
+				mocks.outbox.On("Add", mocks.tx.ctx(), mock.Anything).
+					Return(primitive.NewObjectID().Hex(), nil)
+				mocks.purchase.On("DeleteByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(0, errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't delete file purchases"),
 		},
 		{
 			name: "All ok",
-			req: model.UpdateFileRequest{
-				ID:          primitive.NewObjectID().Hex(),
-				Name:        "some",
-				Description: "some",
-				Size:        1,
-				Path:        "some",
-				AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
-				Actual:      true,
-				AuthorID:    1,
+			req: model.DeleteFileRequest{
+				ID:       primitive.NewObjectID().Hex(),
+				AuthorID: 1,
 			},
-			fn: func(file *m.File, data *test) {
+			fn: func(mocks mocks, data *test) {
 				data.expID = data.req.ID
-				file.On("Update", mock.Anything, data.req.ID, model.FileDTO{
-					Name:        data.req.Name,
-					Description: data.req.Description,
-					Size:        data.req.Size,
-					Path:        data.req.Path,
-					AddDate:     data.req.AddDate,
-					UpdateDate:  data.req.UpdateDate,
-					Actual:      data.req.Actual,
-					AuthorID:    data.req.AuthorID,
-				}).
+				found(mocks, data)
+				mocks.file.On("Delete", mocks.tx.ctx(), data.req.ID, data.req.Revision).
 					Return(data.expID, nil)
+				mocks.version.On("DeleteByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(3, nil)
+				for _, purchase := range purchases {
+					purchase := purchase
+					mocks.comment.On("DeleteByPurchaseID", mocks.tx.ctx(), purchase.ID).
+						Return(1, nil)
+					mocks.outbox.On("Add", mocks.tx.ctx(), mock.MatchedBy(func(event model.EventDTO) bool {
+						return event.Type == model.EventPurchaseRefunded && event.AggregateID == purchase.ID
+					})).
+						Return(primitive.NewObjectID().Hex(), nil).Once()
+				}
+				mocks.purchase.On("DeleteByFileID", mocks.tx.ctx(), data.req.ID).
+					Return(len(purchases), nil)
 			},
+			expDeleted: true,
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			file := new(m.File)
+			mocks := mocks{
+				file:     new(m.File),
+				version:  new(m.FileVersion),
+				purchase: new(m.Purchase),
+				comment:  new(m.Comment),
+				outbox:   new(m.Outbox),
+				tx:       new(rollbackTx),
+			}
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			tc.path, err = backend.Save(ctx, "some.txt", strings.NewReader("some content"))
+			require.NoError(t, err)
+			service := NewFileService(mocks.file, mocks.version, mocks.purchase, mocks.comment, nil, nil, mocks.outbox, mocks.tx, nil, backend, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
-				tc.fn(file, &tc)
+				tc.fn(mocks, &tc)
 			}
-			id, err := service.Update(ctx, tc.req)
+			id, err := service.Delete(ctx, tc.req)
 			if err != nil {
 				assert.Equal(tc.expErr.Error(), err.Error())
 			}
 			assert.Equal(tc.expID, id)
+			assert.Equal(tc.expRollback, mocks.tx.rolledBack)
+			mocks.file.AssertExpectations(t)
+			mocks.version.AssertExpectations(t)
+			mocks.purchase.AssertExpectations(t)
+			mocks.comment.AssertExpectations(t)
+			mocks.outbox.AssertExpectations(t)
+
+			content, err := backend.Open(ctx, tc.path)
+			if tc.expDeleted {
+				assert.Equal(storage.ErrNotFound, err)
+				return
+			}
+			assert.NoError(err)
+			assert.NoError(content.Close())
 		})
 	}
 }
 
-func TestFileService_Delete(t *testing.T) {
+func TestFileService_FindVersions(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
 	require.NoError(t, err)
 	type test struct {
-		name   string
-		req    model.DeleteFileRequest
-		fn     func(file *m.File, data *test)
-		expID  string
-		expErr error
+		name        string
+		req         model.IDFileRequest
+		fn          func(version *m.FileVersion, data *test)
+		expVersions []model.FileVersionDTO
+		expErr      error
 	}
 	tt := []test{
 		{
-			name: "Delete file errors",
-
-			req: model.DeleteFileRequest{
+			name: "Find errors",
+			req: model.IDFileRequest{
 				ID: primitive.NewObjectID().Hex(),
 			},
-			fn: func(file *m.File, data *test) {
-				file.On("Delete", mock.Anything, data.req.ID).
-					Return(data.expID, errors.New(""))
+			fn: func(version *m.FileVersion, data *test) {
+				version.On("FindByFileID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
 			},
-			expErr: errors.Wrap(errors.New(""), "couldn't delete file"),
+			expErr: errors.Wrap(errors.New(""), "couldn't find file versions"),
 		},
 		{
 			name: "All ok",
-			req: model.DeleteFileRequest{
+			req: model.IDFileRequest{
 				ID: primitive.NewObjectID().Hex(),
 			},
-			fn: func(file *m.File, data *test) {
-				data.expID = data.req.ID
-				file.On("Delete", mock.Anything, data.req.ID).
-					Return(data.expID, nil)
+			fn: func(version *m.FileVersion, data *test) {
+				data.expVersions = []model.FileVersionDTO{
+					{
+						ID:     primitive.NewObjectID().Hex(),
+						FileID: data.req.ID,
+						Number: 2,
+						Name:   "some",
+					},
+					{
+						ID:     primitive.NewObjectID().Hex(),
+						FileID: data.req.ID,
+						Number: 1,
+						Name:   "some",
+					},
+				}
+				version.On("FindByFileID", mock.Anything, data.req.ID).
+					Return(data.expVersions, nil)
 			},
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			file := new(m.File)
+			version := new(m.FileVersion)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(nil, version, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
-				tc.fn(file, &tc)
+				tc.fn(version, &tc)
 			}
-			id, err := service.Delete(ctx, tc.req)
+			versions, err := service.FindVersions(ctx, tc.req)
 			if err != nil {
 				assert.Equal(tc.expErr.Error(), err.Error())
 			}
-			assert.Equal(tc.expID, id)
+			assert.Equal(tc.expVersions, versions)
 		})
 	}
 }
@@ -286,7 +1722,7 @@ func TestFileService_FindByID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, &tc)
 			}
@@ -350,7 +1786,7 @@ func TestFileService_FindByName(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -363,6 +1799,72 @@ func TestFileService_FindByName(t *testing.T) {
 	}
 }
 
+func TestFileService_FindByText(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		req    model.TextFileRequest
+		fn     func(file *m.File, data test)
+		exp    []model.FileDTO
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+			req: model.TextFileRequest{
+				Text:     "some",
+				Language: "english",
+			},
+			fn: func(file *m.File, data test) {
+				file.On("FindByText", mock.Anything, data.req.Text, data.req.Language).
+					Return(data.exp, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find files"),
+		},
+		{
+			name: "All ok",
+			req: model.TextFileRequest{
+				Text:     "some",
+				Language: "english",
+			},
+			fn: func(file *m.File, data test) {
+				file.On("FindByText", mock.Anything, data.req.Text, data.req.Language).
+					Return(data.exp, nil)
+			},
+			exp: []model.FileDTO{
+				{
+					ID:          primitive.NewObjectID().Hex(),
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Actual:      true,
+					AuthorID:    1,
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			ctx := context.Background()
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+			f, err := service.FindByText(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.exp, f)
+		})
+	}
+}
+
 func TestFileService_FindAll(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
@@ -409,7 +1911,7 @@ func TestFileService_FindAll(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -475,7 +1977,7 @@ func TestFileService_FindByAuthorID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, &tc)
 			}
@@ -534,7 +2036,7 @@ func TestFileService_FindNotActual(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -593,7 +2095,7 @@ func TestFileService_FindActual(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -606,6 +2108,65 @@ func TestFileService_FindActual(t *testing.T) {
 	}
 }
 
+func TestFileService_FindByRating(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	type test struct {
+		name   string
+		fn     func(file *m.File, data test)
+		exp    []model.FileDTO
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Find errors",
+
+			fn: func(file *m.File, data test) {
+				file.On("FindByRating", mock.Anything).
+					Return(data.exp, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find files"),
+		},
+		{
+			name: "All ok",
+
+			fn: func(file *m.File, data test) {
+				file.On("FindByRating", mock.Anything).
+					Return(data.exp, nil)
+			},
+			exp: []model.FileDTO{
+				{
+					ID:          primitive.NewObjectID().Hex(),
+					Name:        "some",
+					Description: "some",
+					Size:        1,
+					Path:        "some",
+					AddDate:     time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					UpdateDate:  time.Date(2009, time.November, 10, 23, 0, 0, 0, time.Local),
+					Actual:      true,
+					AuthorID:    1,
+				},
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file := new(m.File)
+			ctx := context.Background()
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(file, tc)
+			}
+			f, err := service.FindByRating(ctx)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.exp, f)
+		})
+	}
+}
+
 func TestFileService_FindAddedByPeriod(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
@@ -659,7 +2220,7 @@ func TestFileService_FindAddedByPeriod(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
@@ -725,7 +2286,7 @@ func TestFileService_FindUpdatedByPeriod(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			file := new(m.File)
 			ctx := context.Background()
-			service := NewFileService(file, testApi.GRPCClient)
+			service := NewFileService(file, nil, nil, nil, nil, nil, newTestOutbox(), testTx, nil, nil, testClock, testApi.GRPCClient, logger.Nop())
 			if tc.fn != nil {
 				tc.fn(file, tc)
 			}
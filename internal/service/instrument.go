@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+)
+
+// Recorder records business events of the services.
+type Recorder interface {
+	PurchaseCreated()
+	CommentPosted()
+}
+
+// Instrument returns services which record created purchases and posted comments.
+// Imported purchases and comments are not recorded as they were made before.
+func Instrument(services *Services, recorder Recorder) *Services {
+	return &Services{
+		Purchase: recordedPurchase{services.Purchase, recorder},
+		Comment:  recordedComment{services.Comment, recorder},
+		File:     services.File,
+		Webhook:  services.Webhook,
+	}
+}
+
+type recordedPurchase struct {
+	Purchase
+	recorder Recorder
+}
+
+// Create creates purchase and records it.
+func (p recordedPurchase) Create(ctx context.Context, request model.CreatePurchaseRequest) (string, error) {
+	id, err := p.Purchase.Create(ctx, request)
+	if err == nil && id != "" {
+		p.recorder.PurchaseCreated()
+	}
+
+	return id, err
+}
+
+type recordedComment struct {
+	Comment
+	recorder Recorder
+}
+
+// Create creates comment and records it.
+func (c recordedComment) Create(ctx context.Context, request model.CreateCommentRequest) (string, error) {
+	id, err := c.Comment.Create(ctx, request)
+	if err == nil && id != "" {
+		c.recorder.CommentPosted()
+	}
+
+	return id, err
+}
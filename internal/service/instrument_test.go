@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	hm "github.com/JesusG2000/hexsatisfaction_purchase/internal/handler/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/pkg/errors"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testRecorder struct {
+	purchases int
+	comments  int
+}
+
+func (r *testRecorder) PurchaseCreated() {
+	r.purchases++
+}
+
+func (r *testRecorder) CommentPosted() {
+	r.comments++
+}
+
+func TestInstrument(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name         string
+		id           string
+		err          error
+		expPurchases int
+		expComments  int
+	}
+
+	tt := []test{
+		{
+			name:         "created",
+			id:           "id",
+			expPurchases: 1,
+			expComments:  1,
+		},
+		{
+			name: "not found",
+		},
+		{
+			name: "create err",
+			err:  errors.New(""),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			purchase := new(hm.Purchase)
+			purchase.On("Create", mock.Anything, mock.Anything).Return(tc.id, tc.err)
+			purchase.On("Import", mock.Anything, mock.Anything).Return(tc.id, tc.err)
+			comment := new(hm.Comment)
+			comment.On("Create", mock.Anything, mock.Anything).Return(tc.id, tc.err)
+			recorder := new(testRecorder)
+			services := Instrument(&Services{Purchase: purchase, Comment: comment}, recorder)
+
+			id, err := services.Purchase.Create(context.Background(), model.CreatePurchaseRequest{})
+			assert.Equal(tc.id, id)
+			assert.Equal(tc.err, err)
+			_, _ = services.Purchase.Import(context.Background(), model.ImportPurchaseRequest{})
+			id, err = services.Comment.Create(context.Background(), model.CreateCommentRequest{})
+			assert.Equal(tc.id, id)
+			assert.Equal(tc.err, err)
+
+			assert.Equal(tc.expPurchases, recorder.purchases)
+			assert.Equal(tc.expComments, recorder.comments)
+		})
+	}
+}
@@ -15,6 +15,27 @@ type Comment struct {
 	mock.Mock
 }
 
+// CountReplies provides a mock function with given fields: ctx, id
+func (_m *Comment) CountReplies(ctx context.Context, id string) (int64, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 int64
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Create provides a mock function with given fields: ctx, comment
 func (_m *Comment) Create(ctx context.Context, comment model.CommentDTO) (string, error) {
 	ret := _m.Called(ctx, comment)
@@ -36,20 +57,20 @@ func (_m *Comment) Create(ctx context.Context, comment model.CommentDTO) (string
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *Comment) Delete(ctx context.Context, id string) (string, error) {
-	ret := _m.Called(ctx, id)
+// Delete provides a mock function with given fields: ctx, id, revision, at
+func (_m *Comment) Delete(ctx context.Context, id string, revision int, at time.Time) (string, error) {
+	ret := _m.Called(ctx, id, revision, at)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, time.Time) string); ok {
+		r0 = rf(ctx, id, revision, at)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, time.Time) error); ok {
+		r1 = rf(ctx, id, revision, at)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -58,14 +79,14 @@ func (_m *Comment) Delete(ctx context.Context, id string) (string, error) {
 }
 
 // DeleteByPurchaseID provides a mock function with given fields: ctx, id
-func (_m *Comment) DeleteByPurchaseID(ctx context.Context, id string) (string, error) {
+func (_m *Comment) DeleteByPurchaseID(ctx context.Context, id string) (int, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
 		r0 = rf(ctx, id)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
@@ -124,6 +145,52 @@ func (_m *Comment) FindAllByUserID(ctx context.Context, id int) ([]model.Comment
 	return r0, r1
 }
 
+// FindAnyByID provides a mock function with given fields: ctx, id
+func (_m *Comment) FindAnyByID(ctx context.Context, id string) (*model.CommentDTO, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *model.CommentDTO
+	if rf, ok := ret.Get(0).(func(context.Context, string) *model.CommentDTO); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CommentDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindAnyByPurchaseID provides a mock function with given fields: ctx, id
+func (_m *Comment) FindAnyByPurchaseID(ctx context.Context, id string) ([]model.CommentDTO, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []model.CommentDTO
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.CommentDTO); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindByID provides a mock function with given fields: ctx, id
 func (_m *Comment) FindByID(ctx context.Context, id string) (*model.CommentDTO, error) {
 	ret := _m.Called(ctx, id)
@@ -193,13 +260,13 @@ func (_m *Comment) FindByPurchaseID(ctx context.Context, id string) ([]model.Com
 	return r0, r1
 }
 
-// FindByText provides a mock function with given fields: ctx, text
-func (_m *Comment) FindByText(ctx context.Context, text string) ([]model.CommentDTO, error) {
-	ret := _m.Called(ctx, text)
+// FindByPurchaseIDs provides a mock function with given fields: ctx, ids
+func (_m *Comment) FindByPurchaseIDs(ctx context.Context, ids []string) ([]model.CommentDTO, error) {
+	ret := _m.Called(ctx, ids)
 
 	var r0 []model.CommentDTO
-	if rf, ok := ret.Get(0).(func(context.Context, string) []model.CommentDTO); ok {
-		r0 = rf(ctx, text)
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []model.CommentDTO); ok {
+		r0 = rf(ctx, ids)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]model.CommentDTO)
@@ -207,8 +274,54 @@ func (_m *Comment) FindByText(ctx context.Context, text string) ([]model.Comment
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, text)
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, ids)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByStatus provides a mock function with given fields: ctx, statuses
+func (_m *Comment) FindByStatus(ctx context.Context, statuses []string) ([]model.CommentDTO, error) {
+	ret := _m.Called(ctx, statuses)
+
+	var r0 []model.CommentDTO
+	if rf, ok := ret.Get(0).(func(context.Context, []string) []model.CommentDTO); ok {
+		r0 = rf(ctx, statuses)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []string) error); ok {
+		r1 = rf(ctx, statuses)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByText provides a mock function with given fields: ctx, text, language
+func (_m *Comment) FindByText(ctx context.Context, text string, language string) ([]model.CommentDTO, error) {
+	ret := _m.Called(ctx, text, language)
+
+	var r0 []model.CommentDTO
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.CommentDTO); ok {
+		r0 = rf(ctx, text, language)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, text, language)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -239,6 +352,94 @@ func (_m *Comment) FindByUserIDAndPurchaseID(ctx context.Context, userID int, pu
 	return r0, r1
 }
 
+// FindRevisions provides a mock function with given fields: ctx, id
+func (_m *Comment) FindRevisions(ctx context.Context, id string) ([]model.CommentRevisionDTO, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []model.CommentRevisionDTO
+	if rf, ok := ret.Get(0).(func(context.Context, string) []model.CommentRevisionDTO); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.CommentRevisionDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindVisibleByID provides a mock function with given fields: ctx, id, userID
+func (_m *Comment) FindVisibleByID(ctx context.Context, id string, userID int) (*model.CommentDTO, error) {
+	ret := _m.Called(ctx, id, userID)
+
+	var r0 *model.CommentDTO
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) *model.CommentDTO); ok {
+		r0 = rf(ctx, id, userID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*model.CommentDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, id, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Flag provides a mock function with given fields: ctx, id, userID
+func (_m *Comment) Flag(ctx context.Context, id string, userID int) (string, error) {
+	ret := _m.Called(ctx, id, userID)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) string); ok {
+		r0 = rf(ctx, id, userID)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, id, userID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Tombstone provides a mock function with given fields: ctx, id, revision, at
+func (_m *Comment) Tombstone(ctx context.Context, id string, revision int, at time.Time) (string, error) {
+	ret := _m.Called(ctx, id, revision, at)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, time.Time) string); ok {
+		r0 = rf(ctx, id, revision, at)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, time.Time) error); ok {
+		r1 = rf(ctx, id, revision, at)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, id, comment
 func (_m *Comment) Update(ctx context.Context, id string, comment model.CommentDTO) (string, error) {
 	ret := _m.Called(ctx, id, comment)
@@ -259,3 +460,24 @@ func (_m *Comment) Update(ctx context.Context, id string, comment model.CommentD
 
 	return r0, r1
 }
+
+// UpdateStatus provides a mock function with given fields: ctx, id, status
+func (_m *Comment) UpdateStatus(ctx context.Context, id string, status string) (string, error) {
+	ret := _m.Called(ctx, id, status)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, id, status)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, id, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -36,20 +36,20 @@ func (_m *File) Create(ctx context.Context, file model.FileDTO) (string, error)
 	return r0, r1
 }
 
-// Delete provides a mock function with given fields: ctx, id
-func (_m *File) Delete(ctx context.Context, id string) (string, error) {
-	ret := _m.Called(ctx, id)
+// Delete provides a mock function with given fields: ctx, id, revision
+func (_m *File) Delete(ctx context.Context, id string, revision int) (string, error) {
+	ret := _m.Called(ctx, id, revision)
 
 	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
-		r0 = rf(ctx, id)
+	if rf, ok := ret.Get(0).(func(context.Context, string, int) string); ok {
+		r0 = rf(ctx, id, revision)
 	} else {
 		r0 = ret.Get(0).(string)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, string, int) error); ok {
+		r1 = rf(ctx, id, revision)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -216,6 +216,52 @@ func (_m *File) FindByName(ctx context.Context, name string) ([]model.FileDTO, e
 	return r0, r1
 }
 
+// FindByRating provides a mock function with given fields: ctx
+func (_m *File) FindByRating(ctx context.Context) ([]model.FileDTO, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []model.FileDTO
+	if rf, ok := ret.Get(0).(func(context.Context) []model.FileDTO); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.FileDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// FindByText provides a mock function with given fields: ctx, text, language
+func (_m *File) FindByText(ctx context.Context, text string, language string) ([]model.FileDTO, error) {
+	ret := _m.Called(ctx, text, language)
+
+	var r0 []model.FileDTO
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []model.FileDTO); ok {
+		r0 = rf(ctx, text, language)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]model.FileDTO)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, text, language)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // FindNotActual provides a mock function with given fields: ctx
 func (_m *File) FindNotActual(ctx context.Context) ([]model.FileDTO, error) {
 	ret := _m.Called(ctx)
@@ -262,6 +308,48 @@ func (_m *File) FindUpdatedByPeriod(ctx context.Context, start time.Time, end ti
 	return r0, r1
 }
 
+// Patch provides a mock function with given fields: ctx, id, patch
+func (_m *File) Patch(ctx context.Context, id string, patch model.FilePatch) (string, error) {
+	ret := _m.Called(ctx, id, patch)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.FilePatch) string); ok {
+		r0 = rf(ctx, id, patch)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.FilePatch) error); ok {
+		r1 = rf(ctx, id, patch)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetVersion provides a mock function with given fields: ctx, id, version
+func (_m *File) SetVersion(ctx context.Context, id string, version model.FileVersionDTO) (string, error) {
+	ret := _m.Called(ctx, id, version)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, model.FileVersionDTO) string); ok {
+		r0 = rf(ctx, id, version)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, model.FileVersionDTO) error); ok {
+		r1 = rf(ctx, id, version)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Update provides a mock function with given fields: ctx, id, file
 func (_m *File) Update(ctx context.Context, id string, file model.FileDTO) (string, error) {
 	ret := _m.Called(ctx, id, file)
@@ -282,3 +370,24 @@ func (_m *File) Update(ctx context.Context, id string, file model.FileDTO) (stri
 
 	return r0, r1
 }
+
+// UpdateRating provides a mock function with given fields: ctx, id, sum, count
+func (_m *File) UpdateRating(ctx context.Context, id string, sum int, count int) (string, error) {
+	ret := _m.Called(ctx, id, sum, count)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, int) string); ok {
+		r0 = rf(ctx, id, sum, count)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, int) error); ok {
+		r1 = rf(ctx, id, sum, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -58,14 +58,14 @@ func (_m *Purchase) Delete(ctx context.Context, id string) (string, error) {
 }
 
 // DeleteByFileID provides a mock function with given fields: ctx, id
-func (_m *Purchase) DeleteByFileID(ctx context.Context, id string) (string, error) {
+func (_m *Purchase) DeleteByFileID(ctx context.Context, id string) (int, error) {
 	ret := _m.Called(ctx, id)
 
-	var r0 string
-	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
 		r0 = rf(ctx, id)
 	} else {
-		r0 = ret.Get(0).(string)
+		r0 = ret.Get(0).(int)
 	}
 
 	var r1 error
@@ -2,26 +2,47 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/JesusG2000/hexsatisfaction/pkg/grpc/api"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 // PurchaseService is a purchase service.
 type PurchaseService struct {
 	repository.Purchase
-	client api.ExistanceClient
+	file    repository.File
+	comment repository.Comment
+	outbox  repository.Outbox
+	tx      repository.UnitOfWork
+	clock   clock.Clock
+	client  api.ExistanceClient
+	log     *logger.Logger
 }
 
 // NewPurchaseService is a PurchaseService service constructor.
-func NewPurchaseService(purchase repository.Purchase, client api.ExistanceClient) *PurchaseService {
-	return &PurchaseService{purchase, client}
+func NewPurchaseService(purchase repository.Purchase, file repository.File, comment repository.Comment, outbox repository.Outbox, tx repository.UnitOfWork, clock clock.Clock, client api.ExistanceClient, log *logger.Logger) *PurchaseService {
+	return &PurchaseService{purchase, file, comment, outbox, tx, clock, client, log}
 }
 
-// Create creates new purchase and returns id.
+// Create creates new purchase of the current file version dated now and returns id.
+// PurchaseCreated event is recorded with the purchase.
 func (p PurchaseService) Create(ctx context.Context, request model.CreatePurchaseRequest) (string, error) {
+	return p.create(ctx, request, p.clock.Now(), true)
+}
+
+// Import creates new purchase of the current file version with the requested date and returns id.
+// No event is recorded for imported purchases as they were made before.
+func (p PurchaseService) Import(ctx context.Context, request model.ImportPurchaseRequest) (string, error) {
+	return p.create(ctx, request.CreatePurchaseRequest, request.Date, false)
+}
+
+func (p PurchaseService) create(ctx context.Context, request model.CreatePurchaseRequest, date time.Time, created bool) (string, error) {
 	var id string
 	res, err := p.client.User(ctx, &api.IsUserExistRequest{Id: int32(request.UserID)})
 	if err != nil {
@@ -29,25 +50,89 @@ func (p PurchaseService) Create(ctx context.Context, request model.CreatePurchas
 	}
 
 	if res.Exist {
+		file, err := p.file.FindByID(ctx, request.FileID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return "", nil
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "couldn't find file")
+		}
+
 		purchase := model.PurchaseDTO{
-			UserID: request.UserID,
-			Date:   request.Date,
-			FileID: request.FileID,
+			UserID:    request.UserID,
+			Date:      date,
+			FileID:    request.FileID,
+			VersionID: file.VersionID,
+			Version:   file.Version,
 		}
-		id, err = p.Purchase.Create(ctx, purchase)
+		err = p.tx.Do(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = p.Purchase.Create(ctx, purchase)
+			if err != nil {
+				return errors.Wrap(err, "couldn't create purchase")
+			}
+			if !created {
+				return nil
+			}
+
+			purchase.ID = id
+			return record(ctx, p.outbox, model.EventPurchaseCreated, id, purchase, date)
+		})
 		if err != nil {
-			return "", errors.Wrap(err, "couldn't create purchase")
+			return "", err
 		}
+		p.log.WithContext(ctx).Info("purchase created", "id", id, "user_id", request.UserID, "file_id", request.FileID, "version", file.Version)
 	}
 
 	return id, nil
 }
 
-// Delete deletes purchase and returns deleted id.
+// Delete deletes purchase and its comments with PurchaseRefunded event and returns deleted id.
+// Ratings of the deleted comments are excluded from the file rating.
 func (p PurchaseService) Delete(ctx context.Context, request model.DeletePurchaseRequest) (string, error) {
-	id, err := p.Purchase.Delete(ctx, request.ID)
+	purchase, err := p.Purchase.FindByID(ctx, request.ID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find purchase")
+	}
+
+	var id string
+	err = p.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = p.Purchase.Delete(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete purchase")
+		}
+
+		comments, err := p.comment.FindAnyByPurchaseID(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't find purchase comments")
+		}
+		_, err = p.comment.DeleteByPurchaseID(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete purchase comments")
+		}
+
+		var sum, count int
+		for _, comment := range comments {
+			if rating := countedRating(comment); rating != 0 {
+				sum += rating
+				count++
+			}
+		}
+		if count != 0 {
+			_, err = p.file.UpdateRating(ctx, purchase.FileID, -sum, -count)
+			if err != nil {
+				return errors.Wrap(err, "couldn't update file rating")
+			}
+		}
+
+		return record(ctx, p.outbox, model.EventPurchaseRefunded, id, purchase, p.clock.Now())
+	})
 	if err != nil {
-		return "", errors.Wrap(err, "couldn't delete purchase")
+		return "", err
 	}
 
 	return id, nil
@@ -6,14 +6,54 @@ import (
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
 	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/service/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
 	"github.com/pkg/errors"
 	testAssert "github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
+var (
+	testClock = clock.Fixed(time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC))
+	testTx    = repository.SequentialUnitOfWork{}
+)
+
+// newTestOutbox returns an outbox which accepts any event.
+func newTestOutbox() *m.Outbox {
+	outbox := new(m.Outbox)
+	outbox.On("Add", mock.Anything, mock.Anything).
+		Return(primitive.NewObjectID().Hex(), nil)
+
+	return outbox
+}
+
+type rollbackTxKey struct{}
+
+// rollbackTx is a unit of work which remembers whether its writes were rolled back.
+type rollbackTx struct {
+	rolledBack bool
+}
+
+// Do runs fn and rolls back when fn fails.
+func (tx *rollbackTx) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := fn(context.WithValue(ctx, rollbackTxKey{}, tx))
+	tx.rolledBack = err != nil
+
+	return err
+}
+
+// ctx matches contexts of writes taking part in the unit of work.
+func (tx *rollbackTx) ctx() interface{} {
+	return mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Value(rollbackTxKey{}) == tx
+	})
+}
+
 func TestPurchaseService_Create(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
@@ -22,42 +62,94 @@ func TestPurchaseService_Create(t *testing.T) {
 	type test struct {
 		name   string
 		req    model.CreatePurchaseRequest
-		fn     func(purchase *m.Purchase, data test)
+		fn     func(purchase *m.Purchase, file *m.File, outbox *m.Outbox, data test)
 		expID  string
 		expErr error
 	}
+	versionID := primitive.NewObjectID().Hex()
 	tt := []test{
+		{
+			name: "Find file errors",
+			req: model.CreatePurchaseRequest{
+				UserID: 1,
+				FileID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, file *m.File, outbox *m.Outbox, data test) {
+				file.On("FindByID", mock.Anything, data.req.FileID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find file"),
+		},
+		{
+			name: "File not found",
+			req: model.CreatePurchaseRequest{
+				UserID: 1,
+				FileID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, file *m.File, outbox *m.Outbox, data test) {
+				file.On("FindByID", mock.Anything, data.req.FileID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
 		{
 			name: "Create errors",
 			req: model.CreatePurchaseRequest{
 				UserID: 1,
-				Date:   time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
 				FileID: primitive.NewObjectID().Hex(),
 			},
-			fn: func(purchase *m.Purchase, data test) {
+			fn: func(purchase *m.Purchase, file *m.File, outbox *m.Outbox, data test) {
+				file.On("FindByID", mock.Anything, data.req.FileID).
+					Return(&model.FileDTO{ID: data.req.FileID, VersionID: versionID, Version: 2}, nil)
 				purchase.On("Create", mock.Anything, model.PurchaseDTO{
-					UserID: data.req.UserID,
-					Date:   data.req.Date,
-					FileID: data.req.FileID,
+					UserID:    data.req.UserID,
+					Date:      time.Time(testClock),
+					FileID:    data.req.FileID,
+					VersionID: versionID,
+					Version:   2,
 				}).
 					Return(data.expID, errors.New(""))
 			},
 			expErr: errors.Wrap(errors.New(""), "couldn't create purchase"),
 		},
+		{
+			name: "Add event errors",
+			req: model.CreatePurchaseRequest{
+				UserID: 1,
+				FileID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, file *m.File, outbox *m.Outbox, data test) {
+				file.On("FindByID", mock.Anything, data.req.FileID).
+					Return(&model.FileDTO{ID: data.req.FileID, VersionID: versionID, Version: 2}, nil)
+				purchase.On("Create", mock.Anything, mock.Anything).
+					Return(primitive.NewObjectID().Hex(), nil)
+				outbox.On("Add", mock.Anything, mock.Anything).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't add event to outbox"),
+		},
 		{
 			name: "All ok",
 			req: model.CreatePurchaseRequest{
 				UserID: 1,
-				Date:   time.Date(2009, time.December, 10, 23, 0, 0, 0, time.Local),
 				FileID: primitive.NewObjectID().Hex(),
 			},
-			fn: func(purchase *m.Purchase, data test) {
+			fn: func(purchase *m.Purchase, file *m.File, outbox *m.Outbox, data test) {
+				file.On("FindByID", mock.Anything, data.req.FileID).
+					Return(&model.FileDTO{ID: data.req.FileID, VersionID: versionID, Version: 2}, nil)
 				purchase.On("Create", mock.Anything, model.PurchaseDTO{
-					UserID: data.req.UserID,
-					Date:   data.req.Date,
-					FileID: data.req.FileID,
+					UserID:    data.req.UserID,
+					Date:      time.Time(testClock),
+					FileID:    data.req.FileID,
+					VersionID: versionID,
+					Version:   2,
 				}).
 					Return(data.expID, nil)
+				outbox.On("Add", mock.Anything, mock.MatchedBy(func(event model.EventDTO) bool {
+					return event.Type == model.EventPurchaseCreated &&
+						event.AggregateID == data.expID &&
+						event.OccurredAt.Equal(time.Time(testClock))
+				})).
+					Return(primitive.NewObjectID().Hex(), nil)
 			},
 			expID: primitive.NewObjectID().Hex(),
 		},
@@ -65,71 +157,270 @@ func TestPurchaseService_Create(t *testing.T) {
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
+			file := new(m.File)
+			outbox := new(m.Outbox)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, file, nil, outbox, testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
-				tc.fn(purchase, tc)
+				tc.fn(purchase, file, outbox, tc)
 			}
 			id, err := service.Create(ctx, tc.req)
 			if err != nil {
 				assert.Equal(tc.expErr.Error(), err.Error())
 			}
 			assert.Equal(tc.expID, id)
+			outbox.AssertExpectations(t)
 		})
 	}
 }
 
-func TestPurchaseService_Delete(t *testing.T) {
+func TestPurchaseService_Import(t *testing.T) {
 	assert := testAssert.New(t)
 	testApi, err := InitTest4Mock()
 	require.NoError(t, err)
+
 	type test struct {
 		name   string
-		req    model.DeletePurchaseRequest
-		fn     func(purchase *m.Purchase, data *test)
+		req    model.ImportPurchaseRequest
+		fn     func(purchase *m.Purchase, file *m.File, data test)
 		expID  string
 		expErr error
 	}
 	tt := []test{
+		{
+			name: "Create errors",
+			req: model.ImportPurchaseRequest{
+				CreatePurchaseRequest: model.CreatePurchaseRequest{
+					UserID: 1,
+					FileID: primitive.NewObjectID().Hex(),
+				},
+				Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+			},
+			fn: func(purchase *m.Purchase, file *m.File, data test) {
+				file.On("FindByID", mock.Anything, data.req.FileID).
+					Return(&model.FileDTO{ID: data.req.FileID}, nil)
+				purchase.On("Create", mock.Anything, mock.Anything).
+					Return(data.expID, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't create purchase"),
+		},
+		{
+			name: "All ok",
+			req: model.ImportPurchaseRequest{
+				CreatePurchaseRequest: model.CreatePurchaseRequest{
+					UserID: 1,
+					FileID: primitive.NewObjectID().Hex(),
+				},
+				Date: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC),
+			},
+			fn: func(purchase *m.Purchase, file *m.File, data test) {
+				file.On("FindByID", mock.Anything, data.req.FileID).
+					Return(&model.FileDTO{ID: data.req.FileID}, nil)
+				purchase.On("Create", mock.Anything, model.PurchaseDTO{
+					UserID: data.req.UserID,
+					Date:   data.req.Date,
+					FileID: data.req.FileID,
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			purchase := new(m.Purchase)
+			file := new(m.File)
+			ctx := context.Background()
+			service := NewPurchaseService(purchase, file, nil, new(m.Outbox), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
+			if tc.fn != nil {
+				tc.fn(purchase, file, tc)
+			}
+			id, err := service.Import(ctx, tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestPurchaseService_Delete(t *testing.T) {
+	assert := testAssert.New(t)
+	testApi, err := InitTest4Mock()
+	require.NoError(t, err)
+	fileID := primitive.NewObjectID().Hex()
+	type test struct {
+		name        string
+		req         model.DeletePurchaseRequest
+		fn          func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test)
+		expID       string
+		expRollback bool
+		expErr      error
+	}
+	tt := []test{
+		{
+			name: "FindByID errors",
+			req: model.DeletePurchaseRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't find purchase"),
+		},
+		{
+			name: "Purchase not found",
+			req: model.DeletePurchaseRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
 		{
 			name: "Delete errors",
 			req: model.DeletePurchaseRequest{
 				ID: primitive.NewObjectID().Hex(),
 			},
-			fn: func(purchase *m.Purchase, data *test) {
-				purchase.On("Delete", mock.Anything, data.req.ID).
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.PurchaseDTO{ID: data.req.ID, FileID: fileID}, nil)
+				purchase.On("Delete", tx.ctx(), data.req.ID).
 					Return(data.expID, errors.New(""))
 			},
-			expErr: errors.Wrap(errors.New(""), "couldn't delete purchase"),
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't delete purchase"),
+		},
+		{
+			name: "Find comments errors",
+			req: model.DeletePurchaseRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.PurchaseDTO{ID: data.req.ID, FileID: fileID}, nil)
+				purchase.On("Delete", tx.ctx(), data.req.ID).
+					Return(data.req.ID, nil)
+				comment.On("FindAnyByPurchaseID", tx.ctx(), data.req.ID).
+					Return(nil, errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't find purchase comments"),
+		},
+		{
+			name: "Delete comments errors",
+			req: model.DeletePurchaseRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.PurchaseDTO{ID: data.req.ID, FileID: fileID}, nil)
+				purchase.On("Delete", tx.ctx(), data.req.ID).
+					Return(data.req.ID, nil)
+				comment.On("FindAnyByPurchaseID", tx.ctx(), data.req.ID).
+					Return([]model.CommentDTO{}, nil)
+				comment.On("DeleteByPurchaseID", tx.ctx(), data.req.ID).
+					Return(0, errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't delete purchase comments"),
+		},
+		{
+			name: "Update rating errors",
+			req: model.DeletePurchaseRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.PurchaseDTO{ID: data.req.ID, FileID: fileID}, nil)
+				purchase.On("Delete", tx.ctx(), data.req.ID).
+					Return(data.req.ID, nil)
+				comment.On("FindAnyByPurchaseID", tx.ctx(), data.req.ID).
+					Return([]model.CommentDTO{{Rating: 4}}, nil)
+				comment.On("DeleteByPurchaseID", tx.ctx(), data.req.ID).
+					Return(1, nil)
+				file.On("UpdateRating", tx.ctx(), fileID, -4, -1).
+					Return("", errors.New(""))
+			},
+			expRollback: true,
+			expErr:      errors.Wrap(errors.New(""), "couldn't update file rating"),
 		},
 		{
 			name: "All ok",
 			req: model.DeletePurchaseRequest{
 				ID: primitive.NewObjectID().Hex(),
 			},
-			fn: func(purchase *m.Purchase, data *test) {
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
+				data.expID = data.req.ID
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.PurchaseDTO{ID: data.req.ID, FileID: fileID}, nil)
+				purchase.On("Delete", tx.ctx(), data.req.ID).
+					Return(data.expID, nil)
+				comment.On("FindAnyByPurchaseID", tx.ctx(), data.req.ID).
+					Return([]model.CommentDTO{
+						{Rating: 4, Status: model.CommentPending},
+						{Rating: 3, Status: model.CommentApproved},
+						{Rating: 5, Status: model.CommentRejected},
+						{Deleted: true},
+						{},
+					}, nil)
+				comment.On("DeleteByPurchaseID", tx.ctx(), data.req.ID).
+					Return(5, nil)
+				file.On("UpdateRating", tx.ctx(), fileID, -7, -2).
+					Return(fileID, nil)
+				outbox.On("Add", tx.ctx(), mock.MatchedBy(func(event model.EventDTO) bool {
+					return event.Type == model.EventPurchaseRefunded && event.AggregateID == data.req.ID
+				})).
+					Return(primitive.NewObjectID().Hex(), nil)
+			},
+		},
+		{
+			name: "All ok without ratings",
+			req: model.DeletePurchaseRequest{
+				ID: primitive.NewObjectID().Hex(),
+			},
+			fn: func(purchase *m.Purchase, comment *m.Comment, file *m.File, outbox *m.Outbox, tx *rollbackTx, data *test) {
 				data.expID = data.req.ID
-				purchase.On("Delete", mock.Anything, data.req.ID).
+				purchase.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.PurchaseDTO{ID: data.req.ID, FileID: fileID}, nil)
+				purchase.On("Delete", tx.ctx(), data.req.ID).
 					Return(data.expID, nil)
+				comment.On("FindAnyByPurchaseID", tx.ctx(), data.req.ID).
+					Return([]model.CommentDTO{{Text: "some"}}, nil)
+				comment.On("DeleteByPurchaseID", tx.ctx(), data.req.ID).
+					Return(1, nil)
+				outbox.On("Add", tx.ctx(), mock.Anything).
+					Return(primitive.NewObjectID().Hex(), nil)
 			},
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
+			comment := new(m.Comment)
+			file := new(m.File)
+			outbox := new(m.Outbox)
+			tx := new(rollbackTx)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, file, comment, outbox, tx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
-				tc.fn(purchase, &tc)
+				tc.fn(purchase, comment, file, outbox, tx, &tc)
 			}
 			id, err := service.Delete(ctx, tc.req)
 			if err != nil {
 				assert.Equal(tc.expErr.Error(), err.Error())
 			}
 			assert.Equal(tc.expID, id)
+			assert.Equal(tc.expRollback, tx.rolledBack)
+			purchase.AssertExpectations(t)
+			comment.AssertExpectations(t)
+			file.AssertExpectations(t)
+			outbox.AssertExpectations(t)
 		})
 	}
 }
@@ -178,7 +469,7 @@ func TestPurchaseService_FindById(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, &tc)
@@ -236,7 +527,7 @@ func TestPurchaseService_FindLastByUserId(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, &tc)
@@ -303,7 +594,7 @@ func TestPurchaseService_FindAllByUserId(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, &tc)
@@ -374,7 +665,7 @@ func TestPurchaseService_FindByUserIdAndPeriod(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, &tc)
@@ -443,7 +734,7 @@ func TestPurchaseService_FindByUserIdAfterDate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, &tc)
@@ -512,7 +803,7 @@ func TestPurchaseService_FindByUserIdBeforeDate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, &tc)
@@ -580,7 +871,7 @@ func TestPurchaseService_FindByUserIdAndFileID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, &tc)
@@ -631,7 +922,7 @@ func TestPurchaseService_FindLast(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, tc)
@@ -690,7 +981,7 @@ func TestPurchaseService_FindAll(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, tc)
@@ -758,7 +1049,7 @@ func TestPurchaseService_FindByPeriod(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, tc)
@@ -824,7 +1115,7 @@ func TestPurchaseService_FindAfterDate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, tc)
@@ -887,7 +1178,7 @@ func TestPurchaseService_FindBeforeDate(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, tc)
@@ -954,7 +1245,7 @@ func TestPurchaseService_FindByFileID(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			purchase := new(m.Purchase)
 			ctx := context.Background()
-			service := NewPurchaseService(purchase, testApi.GRPCClient)
+			service := NewPurchaseService(purchase, nil, nil, newTestOutbox(), testTx, testClock, testApi.GRPCClient, logger.Nop())
 
 			if tc.fn != nil {
 				tc.fn(purchase, tc)
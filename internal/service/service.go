@@ -7,11 +7,16 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/filter"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/storage"
 )
 
 // Purchase is an interface for PurchaseService repository methods.
 type Purchase interface {
 	Create(ctx context.Context, request model.CreatePurchaseRequest) (string, error)
+	Import(ctx context.Context, request model.ImportPurchaseRequest) (string, error)
 	Delete(ctx context.Context, request model.DeletePurchaseRequest) (string, error)
 	FindByID(ctx context.Context, request model.IDPurchaseRequest) (*model.PurchaseDTO, error)
 	FindLastByUserID(ctx context.Context, request model.UserIDPurchaseRequest) (*model.PurchaseDTO, error)
@@ -31,25 +36,44 @@ type Purchase interface {
 // Comment is an interface for CommentService repository methods.
 type Comment interface {
 	Create(ctx context.Context, request model.CreateCommentRequest) (string, error)
+	Import(ctx context.Context, request model.ImportCommentRequest) (string, error)
 	Update(ctx context.Context, request model.UpdateCommentRequest) (string, error)
+	Patch(ctx context.Context, request model.PatchCommentRequest) (string, error)
 	Delete(ctx context.Context, request model.DeleteCommentRequest) (string, error)
 	FindByID(ctx context.Context, request model.IDCommentRequest) (*model.CommentDTO, error)
+	FindRevisions(ctx context.Context, request model.IDCommentRequest) ([]model.CommentRevisionDTO, error)
 	FindAllByUserID(ctx context.Context, request model.UserIDCommentRequest) ([]model.CommentDTO, error)
 	FindByPurchaseID(ctx context.Context, request model.PurchaseIDCommentRequest) ([]model.CommentDTO, error)
+	FindTreeByPurchaseID(ctx context.Context, request model.PurchaseIDCommentRequest) ([]model.CommentNode, error)
+	FindTreeByFileID(ctx context.Context, request model.FileIDCommentRequest) ([]model.CommentNode, error)
 	FindByUserIDAndPurchaseID(ctx context.Context, request model.UserPurchaseIDCommentRequest) ([]model.CommentDTO, error)
 	FindAll(ctx context.Context) ([]model.CommentDTO, error)
 	FindByText(ctx context.Context, request model.TextCommentRequest) ([]model.CommentDTO, error)
 	FindByPeriod(ctx context.Context, request model.PeriodCommentRequest) ([]model.CommentDTO, error)
+	Flag(ctx context.Context, request model.FlagCommentRequest) (string, error)
+	Review(ctx context.Context, request model.ReviewCommentRequest) (string, error)
+	FindForReview(ctx context.Context) ([]model.CommentDTO, error)
 }
 
 // File is an interface for FileService repository methods.
 type File interface {
 	Create(ctx context.Context, request model.CreateFileRequest) (string, error)
+	Import(ctx context.Context, request model.ImportFileRequest) (string, error)
 	Update(ctx context.Context, request model.UpdateFileRequest) (string, error)
+	Patch(ctx context.Context, request model.PatchFileRequest) (string, error)
+	Upload(ctx context.Context, request model.UploadFileRequest) (string, error)
+	Download(ctx context.Context, request model.DownloadFileRequest) (*model.FileContent, error)
+	DownloadVersion(ctx context.Context, request model.DownloadFileVersionRequest) (*model.FileContent, error)
+	FindVersions(ctx context.Context, request model.IDFileRequest) ([]model.FileVersionDTO, error)
+	SignDownload(ctx context.Context, request model.SignDownloadFileRequest) (*auth.Link, error)
+	DownloadByLink(ctx context.Context, link auth.Link) (*model.FileContent, error)
+	RecordDownload(ctx context.Context, content *model.FileContent) error
 	Delete(ctx context.Context, request model.DeleteFileRequest) (string, error)
 	FindByID(ctx context.Context, request model.IDFileRequest) (*model.FileDTO, error)
 	FindByName(ctx context.Context, request model.NameFileRequest) ([]model.FileDTO, error)
+	FindByText(ctx context.Context, request model.TextFileRequest) ([]model.FileDTO, error)
 	FindAll(ctx context.Context) ([]model.FileDTO, error)
+	FindByRating(ctx context.Context) ([]model.FileDTO, error)
 	FindByAuthorID(ctx context.Context, request model.AuthorIDFileRequest) ([]model.FileDTO, error)
 	FindNotActual(ctx context.Context) ([]model.FileDTO, error)
 	FindActual(ctx context.Context) ([]model.FileDTO, error)
@@ -57,25 +81,61 @@ type File interface {
 	FindUpdatedByPeriod(ctx context.Context, request model.UpdatedPeriodFileRequest) ([]model.FileDTO, error)
 }
 
+// Webhook is an interface for WebhookService repository methods.
+type Webhook interface {
+	Create(ctx context.Context, request model.CreateWebhookRequest) (string, error)
+	Update(ctx context.Context, request model.UpdateWebhookRequest) (string, error)
+	Delete(ctx context.Context, request model.IDWebhookRequest) (string, error)
+	FindByID(ctx context.Context, request model.IDWebhookRequest) (*model.WebhookDTO, error)
+	FindByUserID(ctx context.Context, request model.UserIDWebhookRequest) ([]model.WebhookDTO, error)
+	FindDeliveries(ctx context.Context, request model.DeliveriesWebhookRequest) ([]model.DeliveryDTO, error)
+	Redeliver(ctx context.Context, request model.RedeliverWebhookRequest) (string, error)
+	Enqueue(ctx context.Context, event model.EventDTO) error
+}
+
+// ErrModified is returned when a file or comment was changed since the revision expected by the client.
+var ErrModified = repository.ErrModified
+
+// matchRevision checks the revision expected by the client against the current revision and returns the revision to write.
+// AnyRevision matches the current revision.
+func matchRevision(current, expected int) (int, error) {
+	if expected == model.AnyRevision {
+		return current, nil
+	}
+	if expected != current {
+		return 0, ErrModified
+	}
+
+	return expected, nil
+}
+
 // Services collects all service interfaces.
 type Services struct {
 	Purchase Purchase
 	Comment  Comment
 	File     File
+	Webhook  Webhook
 }
 
 // Deps represents dependencies for services.
 type Deps struct {
 	Repos        *repository.Repositories
+	UnitOfWork   repository.UnitOfWork
 	TokenManager auth.TokenManager
+	Links        auth.LinkManager
 	GRPCClient   api.ExistanceClient
+	Filter       filter.Filter
+	Storage      storage.Backend
+	Clock        clock.Clock
+	Log          *logger.Logger
 }
 
 // NewServices is a Services constructor.
 func NewServices(deps Deps) *Services {
 	return &Services{
-		Purchase: NewPurchaseService(deps.Repos.Purchase, deps.GRPCClient),
-		Comment:  NewCommentService(deps.Repos.Comment, deps.GRPCClient),
-		File:     NewFileService(deps.Repos.File, deps.GRPCClient),
+		Purchase: NewPurchaseService(deps.Repos.Purchase, deps.Repos.File, deps.Repos.Comment, deps.Repos.Outbox, deps.UnitOfWork, deps.Clock, deps.GRPCClient, deps.Log),
+		Comment:  NewCommentService(deps.Repos.Comment, deps.Repos.Purchase, deps.Repos.File, deps.Repos.Outbox, deps.UnitOfWork, deps.Filter, deps.Clock, deps.GRPCClient, deps.Log),
+		File:     NewFileService(deps.Repos.File, deps.Repos.FileVersion, deps.Repos.Purchase, deps.Repos.Comment, deps.Repos.Download, deps.Repos.Link, deps.Repos.Outbox, deps.UnitOfWork, deps.Links, deps.Storage, deps.Clock, deps.GRPCClient, deps.Log),
+		Webhook:  NewWebhookService(deps.Repos.Webhook, deps.Repos.Delivery, deps.Repos.Purchase, deps.Repos.File, deps.UnitOfWork, deps.Clock, deps.Log),
 	}
 }
@@ -9,7 +9,11 @@ import (
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/grpc"
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/auth"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
 	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/database/mongo"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/filter"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/storage"
 	"github.com/pkg/errors"
 )
 
@@ -40,11 +44,26 @@ func initServices4Test() (*TestAPI, error) {
 		return nil, errors.Wrap(err, "couldn't init mongo")
 	}
 
-	tokenManager, err := auth.NewManager(cfg.Auth.SigningKey)
+	tokenManager, err := auth.NewManager(cfg.Auth.SigningKey, cfg.Auth.AdminIDs...)
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't init token manager")
 	}
 
+	linkSigner, err := auth.NewLinkSigner(cfg.Auth.SigningKey, cfg.Auth.LinkTTL, clock.System{})
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't init link signer")
+	}
+
+	backend, err := storage.New(cfg.Storage, db)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't init storage")
+	}
+
+	tx, err := repository.NewUnitOfWork(context.Background(), db, cfg.Mongo.Transactions, logger.Nop())
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't init unit of work")
+	}
+
 	addr := net.JoinHostPort(cfg.GRPC.Host, cfg.GRPC.Port)
 	grpcClient, err := grpc.NewGRPCClient(addr)
 	if err != nil {
@@ -53,9 +72,15 @@ func initServices4Test() (*TestAPI, error) {
 
 	return &TestAPI{
 		Services: NewServices(Deps{
-			Repos:        repository.NewRepositories(db),
+			Repos:        repository.NewRepositories(db, logger.Nop()),
+			UnitOfWork:   tx,
 			TokenManager: tokenManager,
+			Links:        linkSigner,
 			GRPCClient:   grpcClient,
+			Filter:       filter.NewBannedWords(cfg.Filter.BannedWords, cfg.Filter.ReviewWords),
+			Storage:      backend,
+			Clock:        clock.System{},
+			Log:          logger.Nop(),
 		}),
 		TokenManager: tokenManager,
 		GRPCClient:   grpcClient,
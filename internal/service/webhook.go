@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	// ErrNotWebhookOwner is returned when webhook is accessed by a user who didn't create it.
+	ErrNotWebhookOwner = errors.New("only webhook owner can access webhook")
+	// ErrInvalidEvents is returned when webhook is subscribed to no events or to unknown ones.
+	ErrInvalidEvents = errors.New("events must be a non-empty list of known event types")
+)
+
+// WebhookService is a webhook subscription service.
+type WebhookService struct {
+	repository.Webhook
+	delivery repository.Delivery
+	purchase repository.Purchase
+	file     repository.File
+	tx       repository.UnitOfWork
+	clock    clock.Clock
+	log      *logger.Logger
+}
+
+// NewWebhookService is a WebhookService constructor.
+func NewWebhookService(webhook repository.Webhook, delivery repository.Delivery, purchase repository.Purchase, file repository.File, tx repository.UnitOfWork, clock clock.Clock, log *logger.Logger) *WebhookService {
+	return &WebhookService{webhook, delivery, purchase, file, tx, clock, log}
+}
+
+// Create creates active webhook and returns id.
+func (w WebhookService) Create(ctx context.Context, request model.CreateWebhookRequest) (string, error) {
+	if !validEvents(request.Events) {
+		return "", ErrInvalidEvents
+	}
+
+	now := w.clock.Now()
+	id, err := w.Webhook.Create(ctx, model.WebhookDTO{
+		UserID:  request.UserID,
+		URL:     request.URL,
+		Events:  request.Events,
+		Secret:  request.Secret,
+		Global:  request.Global,
+		Active:  true,
+		Created: now,
+		Updated: now,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't create webhook")
+	}
+
+	return id, nil
+}
+
+// Update updates webhook of the user and returns id. Secret is kept if the request has none.
+func (w WebhookService) Update(ctx context.Context, request model.UpdateWebhookRequest) (string, error) {
+	if !validEvents(request.Events) {
+		return "", ErrInvalidEvents
+	}
+
+	webhook, err := w.owned(ctx, request.ID, request.UserID)
+	if err != nil || webhook == nil {
+		return "", err
+	}
+
+	webhook.URL = request.URL
+	webhook.Events = request.Events
+	webhook.Active = request.Active
+	webhook.Updated = w.clock.Now()
+	if request.Secret != "" {
+		webhook.Secret = request.Secret
+	}
+	id, err := w.Webhook.Update(ctx, request.ID, *webhook)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't update webhook")
+	}
+
+	return id, nil
+}
+
+// Delete deletes webhook of the user with its deliveries in a unit of work and returns deleted id.
+func (w WebhookService) Delete(ctx context.Context, request model.IDWebhookRequest) (string, error) {
+	webhook, err := w.owned(ctx, request.ID, request.UserID)
+	if err != nil || webhook == nil {
+		return "", err
+	}
+
+	var id string
+	err = w.tx.Do(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = w.Webhook.Delete(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete webhook")
+		}
+
+		_, err = w.delivery.DeleteByWebhookID(ctx, request.ID)
+		if err != nil {
+			return errors.Wrap(err, "couldn't delete webhook deliveries")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// FindByID finds webhook of the user by id.
+func (w WebhookService) FindByID(ctx context.Context, request model.IDWebhookRequest) (*model.WebhookDTO, error) {
+	return w.owned(ctx, request.ID, request.UserID)
+}
+
+// FindByUserID finds webhooks of the user.
+func (w WebhookService) FindByUserID(ctx context.Context, request model.UserIDWebhookRequest) ([]model.WebhookDTO, error) {
+	webhooks, err := w.Webhook.FindByUserID(ctx, request.UserID)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find webhooks")
+	}
+
+	return webhooks, nil
+}
+
+// FindDeliveries finds deliveries of webhook of the user sorted from the latest, optionally by status.
+// Dead deliveries make the dead-letter list of the webhook.
+func (w WebhookService) FindDeliveries(ctx context.Context, request model.DeliveriesWebhookRequest) ([]model.DeliveryDTO, error) {
+	webhook, err := w.owned(ctx, request.ID, request.UserID)
+	if err != nil || webhook == nil {
+		return nil, err
+	}
+
+	deliveries, err := w.delivery.FindByWebhookID(ctx, request.ID, request.Status)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find deliveries")
+	}
+
+	return deliveries, nil
+}
+
+// Redeliver schedules delivery of webhook of the user to be attempted again at once with reset attempts
+// and returns delivery id.
+func (w WebhookService) Redeliver(ctx context.Context, request model.RedeliverWebhookRequest) (string, error) {
+	webhook, err := w.owned(ctx, request.ID, request.UserID)
+	if err != nil || webhook == nil {
+		return "", err
+	}
+
+	delivery, err := w.delivery.FindByID(ctx, request.DeliveryID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't find delivery")
+	}
+	if delivery.WebhookID != webhook.ID {
+		return "", nil
+	}
+
+	now := w.clock.Now()
+	delivery.Status = model.DeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+	delivery.ResponseStatus = 0
+	delivery.LastError = ""
+	delivery.Updated = now
+	id, err := w.delivery.Update(ctx, request.DeliveryID, *delivery)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't update delivery")
+	}
+
+	return id, nil
+}
+
+// Enqueue makes deliveries of the event to subscribed webhooks of the author of the file the event is about
+// and to subscribed global webhooks. It is idempotent, so the event may be enqueued again.
+func (w WebhookService) Enqueue(ctx context.Context, event model.EventDTO) error {
+	authorID, err := w.authorID(ctx, event)
+	if err != nil {
+		return err
+	}
+
+	webhooks, err := w.Webhook.FindSubscribed(ctx, event.Type, authorID)
+	if err != nil {
+		return errors.Wrap(err, "couldn't find subscribed webhooks")
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return errors.Wrap(err, "couldn't encode event")
+	}
+	now := w.clock.Now()
+	for _, webhook := range webhooks {
+		_, err := w.delivery.Create(ctx, model.DeliveryDTO{
+			WebhookID:     webhook.ID,
+			EventID:       event.ID,
+			EventType:     event.Type,
+			Body:          body,
+			Status:        model.DeliveryPending,
+			NextAttemptAt: now,
+			Created:       now,
+			Updated:       now,
+		})
+		if err != nil {
+			return errors.Wrap(err, "couldn't create delivery")
+		}
+	}
+
+	return nil
+}
+
+// authorID finds author of the file the event is about. Payloads of comment events have purchase id
+// instead of file id. It is zero if the file doesn't exist anymore, so only global webhooks get the event.
+func (w WebhookService) authorID(ctx context.Context, event model.EventDTO) (int, error) {
+	var payload struct {
+		FileID     string `json:"fileID"`
+		PurchaseID string `json:"purchaseID"`
+	}
+	if err := json.Unmarshal(event.Payload, &payload); err != nil {
+		return 0, errors.Wrap(err, "couldn't decode event payload")
+	}
+	if payload.FileID == "" && payload.PurchaseID != "" {
+		purchase, err := w.purchase.FindByID(ctx, payload.PurchaseID)
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return 0, nil
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "couldn't find purchase")
+		}
+		payload.FileID = purchase.FileID
+	}
+	if payload.FileID == "" {
+		return 0, nil
+	}
+
+	file, err := w.file.FindByID(ctx, payload.FileID)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't find file")
+	}
+
+	return file.AuthorID, nil
+}
+
+// owned finds webhook by id and checks that it belongs to the user. It is nil if the webhook doesn't exist.
+func (w WebhookService) owned(ctx context.Context, id string, userID int) (*model.WebhookDTO, error) {
+	webhook, err := w.Webhook.FindByID(ctx, id)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't find webhook")
+	}
+	if webhook.UserID != userID {
+		return nil, ErrNotWebhookOwner
+	}
+
+	return webhook, nil
+}
+
+func validEvents(events []string) bool {
+	if len(events) == 0 {
+		return false
+	}
+	for _, event := range events {
+		known := false
+		for _, eventType := range model.EventTypes {
+			if event == eventType {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/service/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWebhookService_Create(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name   string
+		req    model.CreateWebhookRequest
+		fn     func(webhook *m.Webhook, data test)
+		expID  string
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Unknown event",
+			req: model.CreateWebhookRequest{
+				UserID: 1,
+				URL:    "https://example.com/hook",
+				Events: []string{model.EventPurchaseCreated, "file.deleted"},
+				Secret: "secret",
+			},
+			expErr: ErrInvalidEvents,
+		},
+		{
+			name: "Create errors",
+			req: model.CreateWebhookRequest{
+				UserID: 1,
+				URL:    "https://example.com/hook",
+				Events: []string{model.EventPurchaseCreated},
+				Secret: "secret",
+			},
+			fn: func(webhook *m.Webhook, data test) {
+				webhook.On("Create", mock.Anything, mock.Anything).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't create webhook"),
+		},
+		{
+			name: "All ok",
+			req: model.CreateWebhookRequest{
+				UserID: 1,
+				URL:    "https://example.com/hook",
+				Events: []string{model.EventPurchaseCreated, model.EventCommentPosted},
+				Secret: "secret",
+			},
+			fn: func(webhook *m.Webhook, data test) {
+				webhook.On("Create", mock.Anything, model.WebhookDTO{
+					UserID:  data.req.UserID,
+					URL:     data.req.URL,
+					Events:  data.req.Events,
+					Secret:  data.req.Secret,
+					Active:  true,
+					Created: time.Time(testClock),
+					Updated: time.Time(testClock),
+				}).
+					Return(data.expID, nil)
+			},
+			expID: primitive.NewObjectID().Hex(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			webhook := new(m.Webhook)
+			service := NewWebhookService(webhook, nil, nil, nil, testTx, testClock, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(webhook, tc)
+			}
+
+			id, err := service.Create(context.Background(), tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestWebhookService_Redeliver(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name   string
+		req    model.RedeliverWebhookRequest
+		fn     func(webhook *m.Webhook, delivery *m.Delivery, data *test)
+		expID  string
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Webhook not found",
+			req: model.RedeliverWebhookRequest{
+				ID:         primitive.NewObjectID().Hex(),
+				DeliveryID: primitive.NewObjectID().Hex(),
+				UserID:     1,
+			},
+			fn: func(webhook *m.Webhook, delivery *m.Delivery, data *test) {
+				webhook.On("FindByID", mock.Anything, data.req.ID).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+		},
+		{
+			name: "Not owner",
+			req: model.RedeliverWebhookRequest{
+				ID:         primitive.NewObjectID().Hex(),
+				DeliveryID: primitive.NewObjectID().Hex(),
+				UserID:     1,
+			},
+			fn: func(webhook *m.Webhook, delivery *m.Delivery, data *test) {
+				webhook.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.WebhookDTO{ID: data.req.ID, UserID: 2}, nil)
+			},
+			expErr: ErrNotWebhookOwner,
+		},
+		{
+			name: "Delivery of other webhook",
+			req: model.RedeliverWebhookRequest{
+				ID:         primitive.NewObjectID().Hex(),
+				DeliveryID: primitive.NewObjectID().Hex(),
+				UserID:     1,
+			},
+			fn: func(webhook *m.Webhook, delivery *m.Delivery, data *test) {
+				webhook.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.WebhookDTO{ID: data.req.ID, UserID: 1}, nil)
+				delivery.On("FindByID", mock.Anything, data.req.DeliveryID).
+					Return(&model.DeliveryDTO{ID: data.req.DeliveryID, WebhookID: primitive.NewObjectID().Hex()}, nil)
+			},
+		},
+		{
+			name: "All ok",
+			req: model.RedeliverWebhookRequest{
+				ID:         primitive.NewObjectID().Hex(),
+				DeliveryID: primitive.NewObjectID().Hex(),
+				UserID:     1,
+			},
+			fn: func(webhook *m.Webhook, delivery *m.Delivery, data *test) {
+				data.expID = data.req.DeliveryID
+				webhook.On("FindByID", mock.Anything, data.req.ID).
+					Return(&model.WebhookDTO{ID: data.req.ID, UserID: 1}, nil)
+				delivery.On("FindByID", mock.Anything, data.req.DeliveryID).
+					Return(&model.DeliveryDTO{
+						ID:        data.req.DeliveryID,
+						WebhookID: data.req.ID,
+						Status:    model.DeliveryDead,
+						Attempts:  10,
+						LastError: "webhook responded with 500",
+					}, nil)
+				delivery.On("Update", mock.Anything, data.req.DeliveryID, model.DeliveryDTO{
+					ID:            data.req.DeliveryID,
+					WebhookID:     data.req.ID,
+					Status:        model.DeliveryPending,
+					NextAttemptAt: time.Time(testClock),
+					Updated:       time.Time(testClock),
+				}).
+					Return(data.req.DeliveryID, nil)
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			webhook := new(m.Webhook)
+			delivery := new(m.Delivery)
+			service := NewWebhookService(webhook, delivery, nil, nil, testTx, testClock, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(webhook, delivery, &tc)
+			}
+
+			id, err := service.Redeliver(context.Background(), tc.req)
+			if err != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			}
+			assert.Equal(tc.expID, id)
+		})
+	}
+}
+
+func TestWebhookService_Enqueue(t *testing.T) {
+	assert := testAssert.New(t)
+
+	fileID := primitive.NewObjectID().Hex()
+	purchaseID := primitive.NewObjectID().Hex()
+	payload, err := json.Marshal(model.CommentDTO{PurchaseID: purchaseID, Text: "text"})
+	assert.NoError(err)
+	event := model.EventDTO{
+		ID:          primitive.NewObjectID().Hex(),
+		Type:        model.EventCommentPosted,
+		AggregateID: primitive.NewObjectID().Hex(),
+		Payload:     payload,
+		OccurredAt:  time.Time(testClock),
+	}
+	body, err := json.Marshal(event)
+	assert.NoError(err)
+
+	type test struct {
+		name   string
+		fn     func(webhook *m.Webhook, delivery *m.Delivery, purchase *m.Purchase, file *m.File)
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "Purchase deleted",
+			fn: func(webhook *m.Webhook, delivery *m.Delivery, purchase *m.Purchase, file *m.File) {
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(nil, mongo.ErrNoDocuments)
+				webhook.On("FindSubscribed", mock.Anything, event.Type, 0).
+					Return(nil, nil)
+			},
+		},
+		{
+			name: "Create delivery errors",
+			fn: func(webhook *m.Webhook, delivery *m.Delivery, purchase *m.Purchase, file *m.File) {
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, FileID: fileID}, nil)
+				file.On("FindByID", mock.Anything, fileID).
+					Return(&model.FileDTO{ID: fileID, AuthorID: 7}, nil)
+				webhook.On("FindSubscribed", mock.Anything, event.Type, 7).
+					Return([]model.WebhookDTO{{ID: primitive.NewObjectID().Hex()}}, nil)
+				delivery.On("Create", mock.Anything, mock.Anything).
+					Return("", errors.New(""))
+			},
+			expErr: errors.Wrap(errors.New(""), "couldn't create delivery"),
+		},
+		{
+			name: "All ok",
+			fn: func(webhook *m.Webhook, delivery *m.Delivery, purchase *m.Purchase, file *m.File) {
+				purchase.On("FindByID", mock.Anything, purchaseID).
+					Return(&model.PurchaseDTO{ID: purchaseID, FileID: fileID}, nil)
+				file.On("FindByID", mock.Anything, fileID).
+					Return(&model.FileDTO{ID: fileID, AuthorID: 7}, nil)
+				webhooks := []model.WebhookDTO{
+					{ID: primitive.NewObjectID().Hex(), UserID: 7},
+					{ID: primitive.NewObjectID().Hex(), UserID: 1, Global: true},
+				}
+				webhook.On("FindSubscribed", mock.Anything, event.Type, 7).
+					Return(webhooks, nil)
+				for _, w := range webhooks {
+					delivery.On("Create", mock.Anything, model.DeliveryDTO{
+						WebhookID:     w.ID,
+						EventID:       event.ID,
+						EventType:     event.Type,
+						Body:          body,
+						Status:        model.DeliveryPending,
+						NextAttemptAt: time.Time(testClock),
+						Created:       time.Time(testClock),
+						Updated:       time.Time(testClock),
+					}).
+						Return(primitive.NewObjectID().Hex(), nil).Once()
+				}
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			webhook := new(m.Webhook)
+			delivery := new(m.Delivery)
+			purchase := new(m.Purchase)
+			file := new(m.File)
+			service := NewWebhookService(webhook, delivery, purchase, file, testTx, testClock, logger.Nop())
+			if tc.fn != nil {
+				tc.fn(webhook, delivery, purchase, file)
+			}
+
+			err := service.Enqueue(context.Background(), event)
+			if tc.expErr != nil {
+				assert.Equal(tc.expErr.Error(), err.Error())
+			} else {
+				assert.NoError(err)
+			}
+			delivery.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package watcher
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Operations of changes.
+const (
+	OperationInsert  = "insert"
+	OperationUpdate  = "update"
+	OperationReplace = "replace"
+	OperationDelete  = "delete"
+	// OperationReset means that changes of the collection may be missed, e.g. the collection was dropped
+	// or the resume token fell off the oplog. Consumers drop whatever they derived from the collection.
+	OperationReset = "reset"
+)
+
+// Change represents a change of a document of the watched collection.
+type Change struct {
+	Collection string
+	Operation  string
+	// ID is a hex id of the changed document. It is empty for resets.
+	ID string
+	// Token identifies the change in the stream, so the change passed again after restart has the same token.
+	// It is empty for resets.
+	Token string
+	// Document is the document after the change. It is empty for deletes and resets,
+	// and for updates of documents deleted before they were looked up.
+	Document bson.Raw
+}
+
+// Consumer consumes changes of a watched collection.
+type Consumer func(ctx context.Context, change Change) error
+
+// changeEvent represents a change stream event.
+type changeEvent struct {
+	ID struct {
+		Data string `bson:"_data"`
+	} `bson:"_id"`
+	OperationType string   `bson:"operationType"`
+	FullDocument  bson.Raw `bson:"fullDocument"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+}
+
+// change converts the event of the collection to Change.
+// Drops, renames and invalidations of the collection are resets.
+func (e changeEvent) change(collection string) Change {
+	change := Change{
+		Collection: collection,
+		Operation:  e.OperationType,
+		Document:   e.FullDocument,
+	}
+	switch e.OperationType {
+	case OperationInsert, OperationUpdate, OperationReplace, OperationDelete:
+		change.ID = e.DocumentKey.ID.Hex()
+		change.Token = e.ID.Data
+	default:
+		change.Operation = OperationReset
+		change.Document = nil
+	}
+
+	return change
+}
@@ -0,0 +1,87 @@
+package watcher
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/cache"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// changedSuffix is a suffix of the type of change events, e.g. file.changed.
+const changedSuffix = ".changed"
+
+// Invalidate returns a Consumer which deletes changed documents from the cache of documents by id.
+// The cache is purged on reset.
+func Invalidate(c *cache.Cache) Consumer {
+	return func(ctx context.Context, change Change) error {
+		if change.Operation == OperationReset {
+			c.Purge()
+			return nil
+		}
+		c.Delete(change.ID)
+
+		return nil
+	}
+}
+
+// Observer observes watched changes.
+type Observer interface {
+	ObserveChange(collection, operation string)
+}
+
+// Observe returns a Consumer which reports every change to the observer.
+func Observe(observer Observer) Consumer {
+	return func(ctx context.Context, change Change) error {
+		observer.ObserveChange(change.Collection, change.Operation)
+		return nil
+	}
+}
+
+// Publisher publishes events.
+type Publisher interface {
+	Publish(ctx context.Context, event model.EventDTO) error
+}
+
+// changePayload represents a payload of a change event.
+type changePayload struct {
+	Operation string `json:"operation"`
+	ID        string `json:"id,omitempty"`
+	// Document is the document after the change in relaxed extended JSON.
+	Document json.RawMessage `json:"document,omitempty"`
+}
+
+// Publish returns a Consumer which publishes every change as an event of type <collection>.changed,
+// so subscribers of the publisher get a live feed of the collection including writes of other instances.
+// The event id is the change token, so a change passed again after restart is deduplicated by its id.
+func Publish(publisher Publisher, clock clock.Clock) Consumer {
+	return func(ctx context.Context, change Change) error {
+		payload := changePayload{Operation: change.Operation, ID: change.ID}
+		if change.Document != nil {
+			document, err := bson.MarshalExtJSON(change.Document, false, false)
+			if err != nil {
+				return errors.Wrap(err, "couldn't encode changed document")
+			}
+			payload.Document = document
+		}
+
+		event, err := model.NewEvent(change.Collection+changedSuffix, change.ID, payload, clock.Now())
+		if err != nil {
+			return err
+		}
+		event.ID = change.Token
+		if event.ID == "" {
+			event.ID = primitive.NewObjectID().Hex()
+		}
+
+		if err := publisher.Publish(ctx, event); err != nil {
+			return errors.Wrap(err, "couldn't publish change")
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,61 @@
+package watcher
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Stream is a stream of change events. *mongo.ChangeStream is a Stream.
+type Stream interface {
+	Next(ctx context.Context) bool
+	Decode(val interface{}) error
+	ResumeToken() bson.Raw
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// Source opens a stream of changes after the resume token, or from now if the token is nil.
+type Source func(ctx context.Context, token bson.Raw) (Stream, error)
+
+// Collection returns a Source of changes of the collection. Updated documents are looked up in full.
+func Collection(collection *mongo.Collection) Source {
+	return func(ctx context.Context, token bson.Raw) (Stream, error) {
+		opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+		if token != nil {
+			opts.SetResumeAfter(token)
+		}
+
+		stream, err := collection.Watch(ctx, mongo.Pipeline{}, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		return stream, nil
+	}
+}
+
+// historyLostCodes are codes of server errors after which the stream can't be resumed by the token.
+var historyLostCodes = []int{
+	260, // InvalidResumeToken
+	280, // ChangeStreamFatalError
+	286, // ChangeStreamHistoryLost
+}
+
+// isHistoryLost reports whether the error means that the stream can't be resumed by the token.
+func isHistoryLost(err error) bool {
+	var serverErr mongo.ServerError
+	if !errors.As(err, &serverErr) {
+		return false
+	}
+	for _, code := range historyLostCodes {
+		if serverErr.HasErrorCode(code) {
+			return true
+		}
+	}
+
+	return false
+}
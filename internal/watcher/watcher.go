@@ -0,0 +1,193 @@
+package watcher
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// errInvalidated is returned when the stream is closed by the server after the collection was dropped or renamed.
+var errInvalidated = errors.New("change stream invalidated")
+
+// Watcher passes changes of a collection to in-process consumers and saves the resume token after every change,
+// so it continues from the last handled change after restart.
+// Delivery is at least once: a change is passed again if the watcher stops before its token is saved.
+type Watcher struct {
+	collection string
+	source     Source
+	tokens     repository.ResumeToken
+	clock      clock.Clock
+	cfg        config.WatchConfig
+	log        *logger.Logger
+
+	mu        sync.RWMutex
+	consumers []Consumer
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	once    sync.Once
+	running int32
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewWatcher is a Watcher constructor.
+func NewWatcher(collection string, source Source, tokens repository.ResumeToken, clock clock.Clock, cfg config.WatchConfig, log *logger.Logger) *Watcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Watcher{
+		collection: collection,
+		source:     source,
+		tokens:     tokens,
+		clock:      clock,
+		cfg:        cfg,
+		log:        log,
+		ctx:        ctx,
+		cancel:     cancel,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+}
+
+// Subscribe adds a consumer of all changes.
+func (w *Watcher) Subscribe(consumer Consumer) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.consumers = append(w.consumers, consumer)
+}
+
+// Run watches changes until the watcher is stopped. A failed stream is reopened after the retry interval.
+func (w *Watcher) Run() error {
+	atomic.StoreInt32(&w.running, 1)
+	defer close(w.done)
+
+	for {
+		err := w.Watch(w.ctx)
+		select {
+		case <-w.stop:
+			return nil
+		default:
+		}
+		w.log.Error("couldn't watch changes", "collection", w.collection, "retry", w.cfg.Retry, "error", err)
+
+		timer := time.NewTimer(w.cfg.Retry)
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+	}
+}
+
+// Stop stops waiting for changes at once and waits until the change in flight is abandoned.
+func (w *Watcher) Stop(ctx context.Context) error {
+	w.once.Do(func() { close(w.stop) })
+	w.cancel()
+	if atomic.LoadInt32(&w.running) == 0 {
+		return nil
+	}
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Watch opens a stream after the saved resume token and passes changes to consumers
+// until the stream fails or the context is done. It always returns an error.
+// When the stream can't be resumed, consumers get a reset and the stream is opened from now.
+func (w *Watcher) Watch(ctx context.Context) error {
+	token, err := w.tokens.Find(ctx, w.tokenName())
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		return errors.Wrap(err, "couldn't find resume token")
+	}
+
+	stream, err := w.source(ctx, token)
+	if token != nil && isHistoryLost(err) {
+		w.log.Warn("couldn't resume changes, watching from now", "collection", w.collection, "error", err)
+		if err := w.reset(ctx); err != nil {
+			return err
+		}
+		stream, err = w.source(ctx, nil)
+	}
+	if err != nil {
+		return errors.Wrap(err, "couldn't open change stream")
+	}
+	defer func() {
+		if err := stream.Close(context.Background()); err != nil {
+			w.log.Warn("couldn't close change stream", "collection", w.collection, "error", err)
+		}
+	}()
+
+	for stream.Next(ctx) {
+		var event changeEvent
+		if err := stream.Decode(&event); err != nil {
+			return errors.Wrap(err, "couldn't decode change")
+		}
+
+		change := event.change(w.collection)
+		if change.Operation == OperationReset {
+			if err := w.reset(ctx); err != nil {
+				return err
+			}
+			return errInvalidated
+		}
+
+		w.publish(ctx, change)
+		if _, err := w.tokens.Save(ctx, w.tokenName(), stream.ResumeToken(), w.clock.Now()); err != nil {
+			return errors.Wrap(err, "couldn't save resume token")
+		}
+	}
+
+	err = stream.Err()
+	if err == nil {
+		err = ctx.Err()
+	}
+	if isHistoryLost(err) {
+		if err := w.reset(ctx); err != nil {
+			return err
+		}
+	}
+
+	return errors.Wrap(err, "change stream failed")
+}
+
+// reset passes a reset to consumers and forgets the resume token, so the next stream is opened from now.
+func (w *Watcher) reset(ctx context.Context) error {
+	w.publish(ctx, Change{Collection: w.collection, Operation: OperationReset})
+	if _, err := w.tokens.Save(ctx, w.tokenName(), nil, w.clock.Now()); err != nil {
+		return errors.Wrap(err, "couldn't reset resume token")
+	}
+
+	return nil
+}
+
+// publish passes the change to consumers in order of subscription.
+// Errors of consumers are logged and don't stop the stream, so a consumer can't hold back the others.
+func (w *Watcher) publish(ctx context.Context, change Change) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	for _, consumer := range w.consumers {
+		if err := consumer(ctx, change); err != nil {
+			w.log.Error("couldn't consume change", "collection", change.Collection,
+				"operation", change.Operation, "id", change.ID, "error", err)
+		}
+	}
+}
+
+// tokenName returns name of the resume token of the watcher. Instances of the same name share the token.
+func (w *Watcher) tokenName() string {
+	return w.cfg.Name + "." + w.collection
+}
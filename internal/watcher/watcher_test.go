@@ -0,0 +1,396 @@
+package watcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/service/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/cache"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	testCollection = "file"
+	testTokenName  = "test.file"
+)
+
+var (
+	testClock = clock.Fixed(time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC))
+	testCfg   = config.WatchConfig{
+		Enabled: true,
+		Name:    "test",
+		Retry:   time.Millisecond,
+	}
+)
+
+// testStream is a Stream of the events. When events are over, it fails with err
+// or waits for the context to be done if block is set.
+type testStream struct {
+	events []bson.M
+	err    error
+	block  bool
+	next   int
+	closed bool
+}
+
+func (s *testStream) Next(ctx context.Context) bool {
+	if s.next < len(s.events) {
+		s.next++
+		return true
+	}
+	if s.block {
+		<-ctx.Done()
+	}
+
+	return false
+}
+
+func (s *testStream) Decode(val interface{}) error {
+	raw, err := bson.Marshal(s.events[s.next-1])
+	if err != nil {
+		return err
+	}
+
+	return bson.Unmarshal(raw, val)
+}
+
+func (s *testStream) ResumeToken() bson.Raw {
+	return testToken(s.next)
+}
+
+func (s *testStream) Err() error {
+	return s.err
+}
+
+func (s *testStream) Close(ctx context.Context) error {
+	s.closed = true
+	return nil
+}
+
+func testToken(n int) bson.Raw {
+	token, _ := bson.Marshal(bson.M{"_data": n})
+	return token
+}
+
+func TestWatcher_Watch(t *testing.T) {
+	assert := testAssert.New(t)
+
+	id := primitive.NewObjectID()
+	doc := bson.D{{Key: "_id", Value: id}, {Key: "name", Value: "file"}}
+	rawDoc, err := bson.Marshal(doc)
+	require.NoError(t, err)
+	now := time.Time(testClock)
+	streamErr := errors.New("connection reset")
+	historyLost := mongo.CommandError{Code: 286, Message: "resume point may no longer be in the oplog"}
+
+	type test struct {
+		name       string
+		streams    []*testStream
+		fn         func(tokens *m.ResumeToken)
+		expSources []bson.Raw
+		expChanges []Change
+		expErr     string
+	}
+	tt := []test{
+		{
+			name: "Find token errors",
+			fn: func(tokens *m.ResumeToken) {
+				tokens.On("Find", mock.Anything, testTokenName).
+					Return(bson.Raw(nil), errors.New("timeout"))
+			},
+			expErr: "couldn't find resume token: timeout",
+		},
+		{
+			name: "Changes from now",
+			streams: []*testStream{{
+				events: []bson.M{
+					{"_id": bson.M{"_data": "1"}, "operationType": "insert", "documentKey": bson.M{"_id": id}, "fullDocument": doc},
+					{"_id": bson.M{"_data": "2"}, "operationType": "delete", "documentKey": bson.M{"_id": id}},
+				},
+				err: streamErr,
+			}},
+			fn: func(tokens *m.ResumeToken) {
+				tokens.On("Find", mock.Anything, testTokenName).
+					Return(bson.Raw(nil), mongo.ErrNoDocuments)
+				tokens.On("Save", mock.Anything, testTokenName, testToken(1), now).
+					Return(testTokenName, nil)
+				tokens.On("Save", mock.Anything, testTokenName, testToken(2), now).
+					Return(testTokenName, nil)
+			},
+			expSources: []bson.Raw{nil},
+			expChanges: []Change{
+				{Collection: testCollection, Operation: OperationInsert, ID: id.Hex(), Token: "1", Document: rawDoc},
+				{Collection: testCollection, Operation: OperationDelete, ID: id.Hex(), Token: "2"},
+			},
+			expErr: "change stream failed: connection reset",
+		},
+		{
+			name: "Changes after token",
+			streams: []*testStream{{
+				events: []bson.M{
+					{"operationType": "update", "documentKey": bson.M{"_id": id}, "fullDocument": doc},
+				},
+				err: streamErr,
+			}},
+			fn: func(tokens *m.ResumeToken) {
+				tokens.On("Find", mock.Anything, testTokenName).
+					Return(testToken(7), nil)
+				tokens.On("Save", mock.Anything, testTokenName, testToken(1), now).
+					Return(testTokenName, nil)
+			},
+			expSources: []bson.Raw{testToken(7)},
+			expChanges: []Change{
+				{Collection: testCollection, Operation: OperationUpdate, ID: id.Hex(), Document: rawDoc},
+			},
+			expErr: "change stream failed: connection reset",
+		},
+		{
+			name: "Save token errors",
+			streams: []*testStream{{
+				events: []bson.M{
+					{"operationType": "delete", "documentKey": bson.M{"_id": id}},
+					{"operationType": "delete", "documentKey": bson.M{"_id": id}},
+				},
+			}},
+			fn: func(tokens *m.ResumeToken) {
+				tokens.On("Find", mock.Anything, testTokenName).
+					Return(bson.Raw(nil), mongo.ErrNoDocuments)
+				tokens.On("Save", mock.Anything, testTokenName, testToken(1), now).
+					Return("", errors.New("timeout"))
+			},
+			expSources: []bson.Raw{nil},
+			expChanges: []Change{
+				{Collection: testCollection, Operation: OperationDelete, ID: id.Hex()},
+			},
+			expErr: "couldn't save resume token: timeout",
+		},
+		{
+			name: "History lost",
+			streams: []*testStream{nil, {
+				events: []bson.M{
+					{"operationType": "delete", "documentKey": bson.M{"_id": id}},
+				},
+				err: streamErr,
+			}},
+			fn: func(tokens *m.ResumeToken) {
+				tokens.On("Find", mock.Anything, testTokenName).
+					Return(testToken(7), nil)
+				tokens.On("Save", mock.Anything, testTokenName, bson.Raw(nil), now).
+					Return(testTokenName, nil)
+				tokens.On("Save", mock.Anything, testTokenName, testToken(1), now).
+					Return(testTokenName, nil)
+			},
+			expSources: []bson.Raw{testToken(7), nil},
+			expChanges: []Change{
+				{Collection: testCollection, Operation: OperationReset},
+				{Collection: testCollection, Operation: OperationDelete, ID: id.Hex()},
+			},
+			expErr: "change stream failed: connection reset",
+		},
+		{
+			name: "Collection dropped",
+			streams: []*testStream{{
+				events: []bson.M{
+					{"operationType": "drop"},
+					{"operationType": "invalidate"},
+				},
+			}},
+			fn: func(tokens *m.ResumeToken) {
+				tokens.On("Find", mock.Anything, testTokenName).
+					Return(testToken(7), nil)
+				tokens.On("Save", mock.Anything, testTokenName, bson.Raw(nil), now).
+					Return(testTokenName, nil)
+			},
+			expSources: []bson.Raw{testToken(7)},
+			expChanges: []Change{
+				{Collection: testCollection, Operation: OperationReset},
+			},
+			expErr: errInvalidated.Error(),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			tokens := new(m.ResumeToken)
+			var sources []bson.Raw
+			if tc.fn != nil {
+				tc.fn(tokens)
+			}
+			source := func(ctx context.Context, token bson.Raw) (Stream, error) {
+				sources = append(sources, token)
+				stream := tc.streams[len(sources)-1]
+				if stream == nil {
+					return nil, historyLost
+				}
+				return stream, nil
+			}
+
+			var changes []Change
+			w := NewWatcher(testCollection, source, tokens, testClock, testCfg, logger.Nop())
+			w.Subscribe(func(ctx context.Context, change Change) error {
+				changes = append(changes, change)
+				return nil
+			})
+			w.Subscribe(func(ctx context.Context, change Change) error {
+				return errors.New("consumer failed")
+			})
+
+			err := w.Watch(context.Background())
+			assert.EqualError(err, tc.expErr)
+			assert.Equal(tc.expSources, sources)
+			assert.Equal(tc.expChanges, changes)
+			for _, stream := range tc.streams {
+				if stream != nil {
+					assert.True(stream.closed)
+				}
+			}
+			tokens.AssertExpectations(t)
+		})
+	}
+}
+
+func TestWatcher_Stop(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID()
+
+	tokens := new(m.ResumeToken)
+	tokens.On("Find", mock.Anything, testTokenName).
+		Return(bson.Raw(nil), mongo.ErrNoDocuments)
+	tokens.On("Save", mock.Anything, testTokenName, testToken(1), time.Time(testClock)).
+		Return(testTokenName, nil)
+	stream := &testStream{
+		events: []bson.M{{"operationType": "delete", "documentKey": bson.M{"_id": id}}},
+		block:  true,
+	}
+	source := func(ctx context.Context, token bson.Raw) (Stream, error) {
+		return stream, nil
+	}
+
+	consumed := make(chan Change, 1)
+	w := NewWatcher(testCollection, source, tokens, testClock, testCfg, logger.Nop())
+	w.Subscribe(func(ctx context.Context, change Change) error {
+		consumed <- change
+		return nil
+	})
+
+	done := make(chan error)
+	go func() { done <- w.Run() }()
+	assert.Equal(Change{Collection: testCollection, Operation: OperationDelete, ID: id.Hex()}, <-consumed)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(w.Stop(ctx))
+	assert.NoError(<-done)
+	assert.True(stream.closed)
+}
+
+func TestInvalidate(t *testing.T) {
+	assert := testAssert.New(t)
+
+	c := cache.New(10, time.Minute, testClock)
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Set("c", 3)
+	consume := Invalidate(c)
+
+	assert.NoError(consume(context.Background(), Change{Collection: testCollection, Operation: OperationUpdate, ID: "a"}))
+	assert.Equal(2, c.Len())
+	assert.NoError(consume(context.Background(), Change{Collection: testCollection, Operation: OperationReset}))
+	assert.Equal(0, c.Len())
+}
+
+// testPublisher is a Publisher which keeps published events or fails with err.
+type testPublisher struct {
+	events []model.EventDTO
+	err    error
+}
+
+func (p *testPublisher) Publish(ctx context.Context, event model.EventDTO) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.events = append(p.events, event)
+
+	return nil
+}
+
+func TestPublish(t *testing.T) {
+	assert := testAssert.New(t)
+
+	id := primitive.NewObjectID()
+	rawDoc, err := bson.Marshal(bson.D{{Key: "_id", Value: id}, {Key: "name", Value: "file"}})
+	require.NoError(t, err)
+	now := time.Time(testClock)
+
+	type test struct {
+		name       string
+		change     Change
+		err        error
+		expType    string
+		expID      string
+		expPayload string
+		expErr     string
+	}
+	tt := []test{
+		{
+			name:   "Publish errors",
+			change: Change{Collection: testCollection, Operation: OperationDelete, ID: id.Hex(), Token: "1"},
+			err:    errors.New("timeout"),
+			expErr: "couldn't publish change: timeout",
+		},
+		{
+			name:       "Insert",
+			change:     Change{Collection: testCollection, Operation: OperationInsert, ID: id.Hex(), Token: "1", Document: rawDoc},
+			expType:    "file.changed",
+			expID:      "1",
+			expPayload: `{"operation":"insert","id":"` + id.Hex() + `","document":{"_id":{"$oid":"` + id.Hex() + `"},"name":"file"}}`,
+		},
+		{
+			name:       "Delete",
+			change:     Change{Collection: testCollection, Operation: OperationDelete, ID: id.Hex(), Token: "2"},
+			expType:    "file.changed",
+			expID:      "2",
+			expPayload: `{"operation":"delete","id":"` + id.Hex() + `"}`,
+		},
+		{
+			name:       "Reset",
+			change:     Change{Collection: testCollection, Operation: OperationReset},
+			expType:    "file.changed",
+			expPayload: `{"operation":"reset"}`,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			publisher := &testPublisher{err: tc.err}
+			err := Publish(publisher, testClock)(context.Background(), tc.change)
+			if tc.expErr != "" {
+				assert.EqualError(err, tc.expErr)
+				assert.Empty(publisher.events)
+				return
+			}
+			assert.NoError(err)
+			require.Len(t, publisher.events, 1)
+
+			event := publisher.events[0]
+			assert.Equal(tc.expType, event.Type)
+			assert.Equal(tc.change.ID, event.AggregateID)
+			assert.Equal(now, event.OccurredAt)
+			assert.JSONEq(tc.expPayload, string(event.Payload))
+			if tc.expID != "" {
+				assert.Equal(tc.expID, event.ID)
+			} else {
+				assert.True(primitive.IsValidObjectID(event.ID))
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/outbox"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/repository"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// DeliveryIDHeader is a header of a delivery request with the delivery id, which is the same for all its attempts.
+const DeliveryIDHeader = "X-Delivery-ID"
+
+// Deliverer posts pending deliveries to their webhooks. Failed deliveries are retried with exponential backoff
+// and become dead once they run out of attempts.
+type Deliverer struct {
+	webhook  repository.Webhook
+	delivery repository.Delivery
+	client   *http.Client
+	clock    clock.Clock
+	cfg      config.WebhookConfig
+	log      *logger.Logger
+
+	ctx     context.Context
+	cancel  context.CancelFunc
+	once    sync.Once
+	running int32
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// NewDeliverer is a Deliverer constructor.
+func NewDeliverer(webhook repository.Webhook, delivery repository.Delivery, clock clock.Clock, cfg config.WebhookConfig, log *logger.Logger) *Deliverer {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Deliverer{
+		webhook:  webhook,
+		delivery: delivery,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		clock:    clock,
+		cfg:      cfg,
+		log:      log,
+		ctx:      ctx,
+		cancel:   cancel,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Run delivers pending deliveries every poll interval until the deliverer is stopped.
+func (d *Deliverer) Run() error {
+	atomic.StoreInt32(&d.running, 1)
+	defer close(d.done)
+
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		d.deliverPending()
+
+		select {
+		case <-d.stop:
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops the deliverer after the delivery in flight is attempted.
+// The attempt is abandoned if it isn't finished before the context is done.
+func (d *Deliverer) Stop(ctx context.Context) error {
+	d.once.Do(func() { close(d.stop) })
+	defer d.cancel()
+	if atomic.LoadInt32(&d.running) == 0 {
+		return nil
+	}
+
+	select {
+	case <-d.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliverPending delivers until no delivery is due or the deliverer is stopped.
+func (d *Deliverer) deliverPending() {
+	for {
+		select {
+		case <-d.stop:
+			return
+		default:
+		}
+
+		ok, err := d.Deliver(d.ctx)
+		if err != nil {
+			d.log.Error("couldn't deliver webhook", "error", err)
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+}
+
+// Deliver claims the oldest due delivery and attempts it. It reports whether any delivery was due.
+// A failed attempt isn't an error of Deliver, its result is saved in the delivery.
+func (d *Deliverer) Deliver(ctx context.Context) (bool, error) {
+	delivery, err := d.delivery.Claim(ctx, d.clock.Now(), d.cfg.Lease)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "couldn't claim delivery")
+	}
+
+	webhook, err := d.webhook.FindByID(ctx, delivery.WebhookID)
+	switch {
+	case errors.Is(err, mongo.ErrNoDocuments):
+		err = errors.New("webhook is deleted")
+		delivery.Attempts = d.cfg.MaxAttempts
+	case err != nil:
+		return true, errors.Wrap(err, "couldn't find webhook")
+	case !webhook.Active:
+		err = errors.New("webhook is inactive")
+		delivery.Attempts = d.cfg.MaxAttempts
+	default:
+		delivery.ResponseStatus, err = d.post(ctx, webhook, delivery)
+	}
+
+	now := d.clock.Now()
+	delivery.Updated = now
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryDelivered
+		delivery.LastError = ""
+	case delivery.Attempts >= d.cfg.MaxAttempts:
+		delivery.Status = model.DeliveryDead
+		delivery.LastError = err.Error()
+		d.log.Warn("webhook delivery is dead", "id", delivery.ID, "webhook", delivery.WebhookID,
+			"attempts", delivery.Attempts, "error", err)
+	default:
+		delivery.NextAttemptAt = now.Add(outbox.Backoff(d.cfg.Backoff, d.cfg.MaxBackoff, delivery.Attempts))
+		delivery.LastError = err.Error()
+	}
+
+	if _, err := d.delivery.Update(ctx, delivery.ID, *delivery); err != nil {
+		return true, errors.Wrap(err, "couldn't update delivery")
+	}
+
+	return true, nil
+}
+
+// post posts the signed delivery body to the webhook and returns the response status. Any response but 2xx is an error.
+func (d *Deliverer) post(ctx context.Context, webhook *model.WebhookDTO, delivery *model.DeliveryDTO) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(delivery.Body))
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't create request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(outbox.EventTypeHeader, delivery.EventType)
+	req.Header.Set(outbox.EventIDHeader, delivery.EventID)
+	req.Header.Set(DeliveryIDHeader, delivery.ID)
+	req.Header.Set(SignatureHeader, Sign(webhook.Secret, d.clock.Now(), delivery.Body))
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't post delivery")
+	}
+	defer res.Body.Close()
+	// The body is drained, so the connection is reused.
+	_, _ = io.Copy(ioutil.Discard, res.Body)
+
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res.StatusCode, errors.Errorf("webhook responded with %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,195 @@
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/model"
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/outbox"
+	m "github.com/JesusG2000/hexsatisfaction_purchase/internal/service/mock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	testClock = clock.Fixed(time.Date(2009, time.December, 10, 23, 0, 0, 0, time.UTC))
+	testCfg   = config.WebhookConfig{
+		Timeout:      time.Second,
+		PollInterval: time.Millisecond,
+		Lease:        time.Minute,
+		Backoff:      10 * time.Second,
+		MaxBackoff:   time.Hour,
+		MaxAttempts:  3,
+	}
+)
+
+func TestDeliverer_Deliver(t *testing.T) {
+	assert := testAssert.New(t)
+	now := time.Time(testClock)
+	const secret = "secret"
+
+	type test struct {
+		name     string
+		status   int
+		attempts int
+		fn       func(webhook *m.Webhook, url string, data test)
+		expSent  bool
+		expState model.DeliveryDTO
+	}
+	tt := []test{
+		{
+			name:     "Webhook deleted",
+			attempts: 1,
+			fn: func(webhook *m.Webhook, url string, data test) {
+				webhook.On("FindByID", mock.Anything, mock.Anything).
+					Return(nil, mongo.ErrNoDocuments)
+			},
+			expState: model.DeliveryDTO{Status: model.DeliveryDead, Attempts: 3, LastError: "webhook is deleted"},
+		},
+		{
+			name:     "Receiver fails",
+			status:   http.StatusServiceUnavailable,
+			attempts: 2,
+			fn: func(webhook *m.Webhook, url string, data test) {
+				webhook.On("FindByID", mock.Anything, mock.Anything).
+					Return(&model.WebhookDTO{URL: url, Secret: secret, Active: true}, nil)
+			},
+			expSent: true,
+			expState: model.DeliveryDTO{
+				Status:         model.DeliveryPending,
+				Attempts:       2,
+				NextAttemptAt:  now.Add(20 * time.Second),
+				ResponseStatus: http.StatusServiceUnavailable,
+				LastError:      "webhook responded with 503",
+			},
+		},
+		{
+			name:     "Out of attempts",
+			status:   http.StatusInternalServerError,
+			attempts: 3,
+			fn: func(webhook *m.Webhook, url string, data test) {
+				webhook.On("FindByID", mock.Anything, mock.Anything).
+					Return(&model.WebhookDTO{URL: url, Secret: secret, Active: true}, nil)
+			},
+			expSent: true,
+			expState: model.DeliveryDTO{
+				Status:         model.DeliveryDead,
+				Attempts:       3,
+				ResponseStatus: http.StatusInternalServerError,
+				LastError:      "webhook responded with 500",
+			},
+		},
+		{
+			name:     "All ok",
+			status:   http.StatusOK,
+			attempts: 1,
+			fn: func(webhook *m.Webhook, url string, data test) {
+				webhook.On("FindByID", mock.Anything, mock.Anything).
+					Return(&model.WebhookDTO{URL: url, Secret: secret, Active: true}, nil)
+			},
+			expSent: true,
+			expState: model.DeliveryDTO{
+				Status:         model.DeliveryDelivered,
+				Attempts:       1,
+				ResponseStatus: http.StatusOK,
+			},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			delivery := model.DeliveryDTO{
+				ID:        primitive.NewObjectID().Hex(),
+				WebhookID: primitive.NewObjectID().Hex(),
+				EventID:   primitive.NewObjectID().Hex(),
+				EventType: model.EventPurchaseCreated,
+				Body:      []byte(`{"type":"purchase.created"}`),
+				Status:    model.DeliveryPending,
+				Attempts:  tc.attempts,
+			}
+
+			var sent bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				sent = true
+				body, err := ioutil.ReadAll(r.Body)
+				assert.NoError(err)
+				assert.Equal(string(delivery.Body), string(body))
+				assert.NoError(Verify(secret, r.Header.Get(SignatureHeader), body, now, time.Minute))
+				assert.Equal(delivery.EventType, r.Header.Get(outbox.EventTypeHeader))
+				assert.Equal(delivery.EventID, r.Header.Get(outbox.EventIDHeader))
+				assert.Equal(delivery.ID, r.Header.Get(DeliveryIDHeader))
+				w.WriteHeader(tc.status)
+			}))
+			defer srv.Close()
+
+			webhook := new(m.Webhook)
+			deliveries := new(m.Delivery)
+			deliveries.On("Claim", mock.Anything, now, testCfg.Lease).
+				Return(&delivery, nil)
+			deliveries.On("Update", mock.Anything, delivery.ID, mock.MatchedBy(func(d model.DeliveryDTO) bool {
+				return d.Status == tc.expState.Status &&
+					d.Attempts == tc.expState.Attempts &&
+					d.NextAttemptAt.Equal(tc.expState.NextAttemptAt) &&
+					d.ResponseStatus == tc.expState.ResponseStatus &&
+					d.LastError == tc.expState.LastError
+			})).
+				Return(delivery.ID, nil)
+			if tc.fn != nil {
+				tc.fn(webhook, srv.URL, tc)
+			}
+
+			deliverer := NewDeliverer(webhook, deliveries, testClock, testCfg, logger.Nop())
+			ok, err := deliverer.Deliver(context.Background())
+			assert.NoError(err)
+			assert.True(ok)
+			assert.Equal(tc.expSent, sent)
+			deliveries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDeliverer_Run(t *testing.T) {
+	assert := testAssert.New(t)
+
+	received := make(chan string, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received <- r.Header.Get(DeliveryIDHeader)
+	}))
+	defer srv.Close()
+
+	delivery := model.DeliveryDTO{ID: primitive.NewObjectID().Hex(), WebhookID: primitive.NewObjectID().Hex()}
+	webhook := new(m.Webhook)
+	webhook.On("FindByID", mock.Anything, delivery.WebhookID).
+		Return(&model.WebhookDTO{URL: srv.URL, Active: true}, nil)
+	deliveries := new(m.Delivery)
+	deliveries.On("Claim", mock.Anything, mock.Anything, testCfg.Lease).
+		Return(&delivery, nil).Once()
+	deliveries.On("Claim", mock.Anything, mock.Anything, testCfg.Lease).
+		Return(nil, mongo.ErrNoDocuments)
+	deliveries.On("Update", mock.Anything, delivery.ID, mock.Anything).
+		Return(delivery.ID, nil)
+
+	deliverer := NewDeliverer(webhook, deliveries, testClock, testCfg, logger.Nop())
+	done := make(chan error, 1)
+	go func() { done <- deliverer.Run() }()
+
+	select {
+	case id := <-received:
+		assert.Equal(delivery.ID, id)
+	case <-time.After(time.Second):
+		t.Fatal("delivery isn't received")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.NoError(deliverer.Stop(ctx))
+	assert.NoError(<-done)
+}
@@ -0,0 +1,71 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	testAssert "github.com/stretchr/testify/assert"
+)
+
+func TestVerify(t *testing.T) {
+	assert := testAssert.New(t)
+	body := []byte(`{"id":"1"}`)
+	signedAt := time.Time(testClock)
+	header := Sign("secret", signedAt, body)
+
+	type test struct {
+		name   string
+		secret string
+		header string
+		body   []byte
+		now    time.Time
+		expErr error
+	}
+	tt := []test{
+		{
+			name:   "Malformed header",
+			secret: "secret",
+			header: "v1",
+			body:   body,
+			now:    signedAt,
+			expErr: ErrInvalidSignature,
+		},
+		{
+			name:   "Other secret",
+			secret: "other",
+			header: header,
+			body:   body,
+			now:    signedAt,
+			expErr: ErrInvalidSignature,
+		},
+		{
+			name:   "Changed body",
+			secret: "secret",
+			header: header,
+			body:   []byte(`{"id":"2"}`),
+			now:    signedAt,
+			expErr: ErrInvalidSignature,
+		},
+		{
+			name:   "Expired",
+			secret: "secret",
+			header: header,
+			body:   body,
+			now:    signedAt.Add(6 * time.Minute),
+			expErr: ErrSignatureExpired,
+		},
+		{
+			name:   "All ok",
+			secret: "secret",
+			header: header,
+			body:   body,
+			now:    signedAt.Add(time.Minute),
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Verify(tc.secret, tc.header, tc.body, tc.now, 5*time.Minute)
+			assert.Equal(tc.expErr, err)
+		})
+	}
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+	"github.com/pkg/errors"
+)
+
+const (
+	linkUserParam      = "user"
+	linkExpiresParam   = "expires"
+	linkNonceParam     = "nonce"
+	linkSingleUseParam = "once"
+	linkSignatureParam = "signature"
+)
+
+var (
+	// ErrInvalidLink is returned when a download link is malformed or its signature doesn't match.
+	ErrInvalidLink = errors.New("invalid download link")
+	// ErrLinkExpired is returned when a download link is used after its expiry.
+	ErrLinkExpired = errors.New("download link expired")
+)
+
+// Link represents a signed download link of a file for a user.
+type Link struct {
+	FileID    string
+	UserID    string
+	Expires   time.Time
+	Nonce     string
+	SingleUse bool
+	Signature string
+}
+
+// Query encodes link parameters except the file id into url query.
+func (l Link) Query() string {
+	values := url.Values{}
+	values.Set(linkUserParam, l.UserID)
+	values.Set(linkExpiresParam, strconv.FormatInt(l.Expires.Unix(), 10))
+	values.Set(linkNonceParam, l.Nonce)
+	if l.SingleUse {
+		values.Set(linkSingleUseParam, "1")
+	}
+	values.Set(linkSignatureParam, l.Signature)
+
+	return values.Encode()
+}
+
+// ParseLink decodes link of the file from url query.
+func ParseLink(fileID string, query url.Values) (Link, error) {
+	expires, err := strconv.ParseInt(query.Get(linkExpiresParam), 10, 64)
+	if err != nil {
+		return Link{}, ErrInvalidLink
+	}
+
+	link := Link{
+		FileID:    fileID,
+		UserID:    query.Get(linkUserParam),
+		Expires:   time.Unix(expires, 0).UTC(),
+		Nonce:     query.Get(linkNonceParam),
+		SingleUse: query.Get(linkSingleUseParam) == "1",
+		Signature: query.Get(linkSignatureParam),
+	}
+	if link.UserID == "" || link.Nonce == "" || link.Signature == "" {
+		return Link{}, ErrInvalidLink
+	}
+
+	return link, nil
+}
+
+// LinkManager provides logic for signed download links generation and verification.
+type LinkManager interface {
+	Sign(fileID, userID string, singleUse bool) (Link, error)
+	Verify(link Link) error
+}
+
+// LinkSigner signs download links with HMAC-SHA256.
+type LinkSigner struct {
+	signingKey []byte
+	ttl        time.Duration
+	clock      clock.Clock
+}
+
+// NewLinkSigner is a LinkSigner constructor.
+func NewLinkSigner(signingKey string, ttl time.Duration, clock clock.Clock) (*LinkSigner, error) {
+	if signingKey == "" {
+		return nil, errors.New("empty secret key")
+	}
+	if ttl <= 0 {
+		return nil, errors.New("link ttl must be positive")
+	}
+
+	return &LinkSigner{signingKey: []byte(signingKey), ttl: ttl, clock: clock}, nil
+}
+
+// Sign creates a link of the file for the user valid for the signer ttl.
+func (s *LinkSigner) Sign(fileID, userID string, singleUse bool) (Link, error) {
+	nonce := make([]byte, 16)
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return Link{}, errors.Wrap(err, "couldn't generate nonce")
+	}
+
+	link := Link{
+		FileID:    fileID,
+		UserID:    userID,
+		Expires:   s.clock.Now().Add(s.ttl).Truncate(time.Second).UTC(),
+		Nonce:     hex.EncodeToString(nonce),
+		SingleUse: singleUse,
+	}
+	link.Signature = s.signature(link)
+
+	return link, nil
+}
+
+// Verify checks signature and expiry of the link.
+func (s *LinkSigner) Verify(link Link) error {
+	expected := s.signature(link)
+	if !hmac.Equal([]byte(expected), []byte(link.Signature)) {
+		return ErrInvalidLink
+	}
+	if !s.clock.Now().Before(link.Expires) {
+		return ErrLinkExpired
+	}
+
+	return nil
+}
+
+func (s *LinkSigner) signature(link Link) string {
+	singleUse := "0"
+	if link.SingleUse {
+		singleUse = "1"
+	}
+
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(strings.Join([]string{
+		"download",
+		link.FileID,
+		link.UserID,
+		strconv.FormatInt(link.Expires.Unix(), 10),
+		link.Nonce,
+		singleUse,
+	}, "\n")))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
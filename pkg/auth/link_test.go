@@ -0,0 +1,299 @@
+package auth
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	testAssert "github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+func TestNewLinkSigner(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name   string
+		key    string
+		ttl    time.Duration
+		expErr string
+	}
+	tt := []test{
+		{
+			name:   "empty key",
+			ttl:    time.Minute,
+			expErr: "empty secret key",
+		},
+		{
+			name:   "zero ttl",
+			key:    "key",
+			expErr: "link ttl must be positive",
+		},
+		{
+			name: "all ok",
+			key:  "key",
+			ttl:  time.Minute,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := NewLinkSigner(tc.key, tc.ttl, &manualClock{})
+			if tc.expErr != "" {
+				assert.EqualError(err, tc.expErr)
+				assert.Nil(signer)
+				return
+			}
+			assert.NoError(err)
+			assert.NotNil(signer)
+		})
+	}
+}
+
+func TestLinkSigner_Verify(t *testing.T) {
+	assert := testAssert.New(t)
+	now := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+
+	type test struct {
+		name   string
+		fn     func(link *Link, clock *manualClock)
+		expErr error
+	}
+	tt := []test{
+		{
+			name: "all ok",
+			fn:   func(link *Link, clock *manualClock) {},
+		},
+		{
+			name: "before expiry",
+			fn: func(link *Link, clock *manualClock) {
+				clock.now = link.Expires.Add(-time.Second)
+			},
+		},
+		{
+			name: "at expiry",
+			fn: func(link *Link, clock *manualClock) {
+				clock.now = link.Expires
+			},
+			expErr: ErrLinkExpired,
+		},
+		{
+			name: "expired",
+			fn: func(link *Link, clock *manualClock) {
+				clock.now = link.Expires.Add(time.Hour)
+			},
+			expErr: ErrLinkExpired,
+		},
+		{
+			name: "tampered user",
+			fn: func(link *Link, clock *manualClock) {
+				link.UserID = "2"
+			},
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "tampered file",
+			fn: func(link *Link, clock *manualClock) {
+				link.FileID = "other"
+			},
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "tampered expiry",
+			fn: func(link *Link, clock *manualClock) {
+				link.Expires = link.Expires.Add(time.Hour)
+			},
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "tampered nonce",
+			fn: func(link *Link, clock *manualClock) {
+				link.Nonce = "other"
+			},
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "tampered single use",
+			fn: func(link *Link, clock *manualClock) {
+				link.SingleUse = !link.SingleUse
+			},
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "tampered signature",
+			fn: func(link *Link, clock *manualClock) {
+				link.Signature = link.Signature[1:]
+			},
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "expired with tampered signature",
+			fn: func(link *Link, clock *manualClock) {
+				link.Signature = "other"
+				clock.now = link.Expires.Add(time.Hour)
+			},
+			expErr: ErrInvalidLink,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := &manualClock{now: now}
+			signer, err := NewLinkSigner("key", time.Minute, clock)
+			require.NoError(t, err)
+			link, err := signer.Sign("file", "1", true)
+			require.NoError(t, err)
+			assert.Equal(now.Add(time.Minute), link.Expires)
+
+			tc.fn(&link, clock)
+			assert.Equal(tc.expErr, signer.Verify(link))
+		})
+	}
+}
+
+func TestLinkSigner_Verify_OtherKey(t *testing.T) {
+	clock := &manualClock{now: time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)}
+	signer, err := NewLinkSigner("key", time.Minute, clock)
+	require.NoError(t, err)
+	other, err := NewLinkSigner("other", time.Minute, clock)
+	require.NoError(t, err)
+
+	link, err := other.Sign("file", "1", false)
+	require.NoError(t, err)
+	testAssert.Equal(t, ErrInvalidLink, signer.Verify(link))
+}
+
+func TestParseLink(t *testing.T) {
+	assert := testAssert.New(t)
+	link := Link{
+		FileID:    "file",
+		UserID:    "1",
+		Expires:   time.Date(2009, time.November, 10, 23, 1, 0, 0, time.UTC),
+		Nonce:     "nonce",
+		Signature: "signature",
+	}
+	singleUse := link
+	singleUse.SingleUse = true
+
+	type test struct {
+		name    string
+		query   func() url.Values
+		expLink Link
+		expErr  error
+	}
+	values := func(link Link, fn func(values url.Values)) func() url.Values {
+		return func() url.Values {
+			values, err := url.ParseQuery(link.Query())
+			require.NoError(t, err)
+			fn(values)
+			return values
+		}
+	}
+	tt := []test{
+		{
+			name:    "all ok",
+			query:   values(link, func(values url.Values) {}),
+			expLink: link,
+		},
+		{
+			name:    "single use",
+			query:   values(singleUse, func(values url.Values) {}),
+			expLink: singleUse,
+		},
+		{
+			name: "unknown single use flag",
+			query: values(link, func(values url.Values) {
+				values.Set(linkSingleUseParam, "true")
+			}),
+			expLink: link,
+		},
+		{
+			name:   "empty query",
+			query:  func() url.Values { return url.Values{} },
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "no expiry",
+			query: values(link, func(values url.Values) {
+				values.Del(linkExpiresParam)
+			}),
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "not correct expiry",
+			query: values(link, func(values url.Values) {
+				values.Set(linkExpiresParam, "tomorrow")
+			}),
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "no user",
+			query: values(link, func(values url.Values) {
+				values.Del(linkUserParam)
+			}),
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "no nonce",
+			query: values(link, func(values url.Values) {
+				values.Del(linkNonceParam)
+			}),
+			expErr: ErrInvalidLink,
+		},
+		{
+			name: "no signature",
+			query: values(link, func(values url.Values) {
+				values.Del(linkSignatureParam)
+			}),
+			expErr: ErrInvalidLink,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			parsed, err := ParseLink("file", tc.query())
+			assert.Equal(tc.expErr, err)
+			assert.Equal(tc.expLink, parsed)
+		})
+	}
+}
+
+func TestLink_Query(t *testing.T) {
+	assert := testAssert.New(t)
+	expires := time.Date(2009, time.November, 10, 23, 1, 0, 0, time.UTC)
+
+	type test struct {
+		name     string
+		link     Link
+		expQuery string
+	}
+	tt := []test{
+		{
+			name:     "reusable",
+			link:     Link{FileID: "file", UserID: "1", Expires: expires, Nonce: "nonce", Signature: "a+b/c"},
+			expQuery: "expires=1257894060&nonce=nonce&signature=a%2Bb%2Fc&user=1",
+		},
+		{
+			name:     "single use",
+			link:     Link{FileID: "file", UserID: "1", Expires: expires, Nonce: "nonce", SingleUse: true, Signature: "signature"},
+			expQuery: "expires=1257894060&nonce=nonce&once=1&signature=signature&user=1",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			query := tc.link.Query()
+			assert.Equal(tc.expQuery, query)
+
+			values, err := url.ParseQuery(query)
+			assert.NoError(err)
+			parsed, err := ParseLink(tc.link.FileID, values)
+			assert.NoError(err)
+			assert.Equal(tc.link, parsed)
+		})
+	}
+}
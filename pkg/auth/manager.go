@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"context"
 	"net/http"
 	"strings"
 
@@ -11,25 +12,41 @@ import (
 
 const authorizationHeader = "Authorization"
 
+type contextKey string
+
+const (
+	userIDKey contextKey = "userID"
+	adminKey  contextKey = "admin"
+)
+
 // TokenManager provides logic for a JWT token generation and parsing.
 type TokenManager interface {
 	NewJWT(userID string) (string, error)
 	Parse(accessToken string) (string, error)
 	UserIdentity(next http.Handler) http.Handler
+	AdminIdentity(next http.Handler) http.Handler
 }
 
 // Manager manages a JWT token.
 type Manager struct {
 	signingKey string
+	admins     map[string]struct{}
 }
 
 // NewManager is a Manager constructor.
-func NewManager(signingKey string) (*Manager, error) {
+func NewManager(signingKey string, adminIDs ...string) (*Manager, error) {
 	if signingKey == "" {
 		return nil, errors.New("empty secret key")
 	}
 
-	return &Manager{signingKey: signingKey}, nil
+	admins := make(map[string]struct{}, len(adminIDs))
+	for _, id := range adminIDs {
+		if id != "" {
+			admins[id] = struct{}{}
+		}
+	}
+
+	return &Manager{signingKey: signingKey, admins: admins}, nil
 }
 
 // NewJWT creates a new JWT token.
@@ -74,11 +91,37 @@ func (m *Manager) UserIdentity(next http.Handler) http.Handler {
 			middleware.JSONError(w, errors.New("invalid auth header"), http.StatusUnauthorized)
 			return
 		}
-		_, err := m.Parse(headerParts[1])
+		userID, err := m.Parse(headerParts[1])
 		if err != nil {
 			middleware.JSONError(w, err, http.StatusUnauthorized)
 			return
 		}
-		next.ServeHTTP(w, r)
+		middleware.SetUserID(r.Context(), userID)
+		_, admin := m.admins[userID]
+		ctx := context.WithValue(r.Context(), userIDKey, userID)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(ctx, adminKey, admin)))
 	})
 }
+
+// AdminIdentity checks validation of the token and that its owner is an admin.
+func (m *Manager) AdminIdentity(next http.Handler) http.Handler {
+	return m.UserIdentity(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAdmin(r.Context()) {
+			middleware.JSONError(w, errors.New("admin access required"), http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// UserID returns id of the user authenticated by UserIdentity.
+func UserID(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(userIDKey).(string)
+	return userID, ok
+}
+
+// IsAdmin reports whether the user authenticated by UserIdentity is an admin.
+func IsAdmin(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminKey).(bool)
+	return admin
+}
@@ -0,0 +1,103 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/clock"
+)
+
+// Cache is a concurrency-safe in-memory cache of values which expire after TTL.
+// When the cache is full, expired entries are evicted first and an arbitrary entry otherwise.
+type Cache struct {
+	mu      sync.Mutex
+	entries map[string]entry
+	size    int
+	ttl     time.Duration
+	clock   clock.Clock
+}
+
+type entry struct {
+	value   interface{}
+	expires time.Time
+}
+
+// New is a Cache constructor. It keeps up to size values for ttl.
+func New(size int, ttl time.Duration, clock clock.Clock) *Cache {
+	return &Cache{
+		entries: make(map[string]entry, size),
+		size:    size,
+		ttl:     ttl,
+		clock:   clock,
+	}
+}
+
+// Get returns the value of the key unless it is absent or expired.
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !c.clock.Now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, false
+	}
+
+	return e.value, true
+}
+
+// Set sets the value of the key for TTL.
+func (c *Cache) Set(key string, value interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.size {
+		c.evict(now)
+	}
+	c.entries[key] = entry{value: value, expires: now.Add(c.ttl)}
+}
+
+// Delete deletes the value of the key.
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, key)
+}
+
+// Purge deletes all values.
+func (c *Cache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]entry, c.size)
+}
+
+// Len returns number of values including expired ones which aren't evicted yet.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// evict deletes expired entries or an arbitrary one if none is expired.
+func (c *Cache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+	if len(c.entries) < c.size {
+		return
+	}
+
+	for key := range c.entries {
+		delete(c.entries, key)
+		return
+	}
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	testAssert "github.com/stretchr/testify/assert"
+)
+
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+func TestCache(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name   string
+		fn     func(c *Cache, clock *manualClock)
+		expKey string
+		expOK  bool
+		expLen int
+	}
+	tt := []test{
+		{
+			name:   "absent",
+			fn:     func(c *Cache, clock *manualClock) {},
+			expKey: "a",
+		},
+		{
+			name: "set",
+			fn: func(c *Cache, clock *manualClock) {
+				c.Set("a", 1)
+			},
+			expKey: "a",
+			expOK:  true,
+			expLen: 1,
+		},
+		{
+			name: "expired",
+			fn: func(c *Cache, clock *manualClock) {
+				c.Set("a", 1)
+				clock.now = clock.now.Add(time.Minute)
+			},
+			expKey: "a",
+		},
+		{
+			name: "deleted",
+			fn: func(c *Cache, clock *manualClock) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Delete("a")
+			},
+			expKey: "a",
+			expLen: 1,
+		},
+		{
+			name: "purged",
+			fn: func(c *Cache, clock *manualClock) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Purge()
+			},
+			expKey: "b",
+		},
+		{
+			name: "expired evicted when full",
+			fn: func(c *Cache, clock *manualClock) {
+				c.Set("a", 1)
+				clock.now = clock.now.Add(time.Minute)
+				c.Set("b", 2)
+				c.Set("c", 3)
+			},
+			expKey: "b",
+			expOK:  true,
+			expLen: 2,
+		},
+		{
+			name: "arbitrary evicted when full",
+			fn: func(c *Cache, clock *manualClock) {
+				c.Set("a", 1)
+				c.Set("b", 2)
+				c.Set("c", 3)
+			},
+			expKey: "c",
+			expOK:  true,
+			expLen: 2,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := &manualClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+			c := New(2, time.Minute, clock)
+			tc.fn(c, clock)
+
+			_, ok := c.Get(tc.expKey)
+			assert.Equal(tc.expOK, ok)
+			assert.Equal(tc.expLen, c.Len())
+		})
+	}
+}
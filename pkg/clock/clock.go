@@ -0,0 +1,24 @@
+package clock
+
+import "time"
+
+// Clock provides current time.
+type Clock interface {
+	Now() time.Time
+}
+
+// System is a Clock which returns current system time in UTC.
+type System struct{}
+
+// Now returns current system time in UTC.
+func (System) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// Fixed is a Clock which always returns the same time.
+type Fixed time.Time
+
+// Now returns the fixed time.
+func (f Fixed) Now() time.Time {
+	return time.Time(f)
+}
@@ -11,6 +11,24 @@ type Comment struct {
 	ID         primitive.ObjectID `bson:"_id,omitempty"`
 	UserID     int                `bson:"userID"`
 	PurchaseID primitive.ObjectID `bson:"purchaseID"`
+	ParentID   primitive.ObjectID `bson:"parentID,omitempty"`
 	Date       time.Time          `bson:"date"`
+	EditedAt   *time.Time         `bson:"editedAt,omitempty"`
 	Text       string             `bson:"text"`
+	Rating     int                `bson:"rating"`
+	Deleted    bool               `bson:"deleted,omitempty"`
+	Status     string             `bson:"status,omitempty"`
+	FlaggedBy  []int              `bson:"flaggedBy,omitempty"`
+	Revision   int                `bson:"revision"`
+}
+
+// CommentRevision represents a previous version of a comment.
+type CommentRevision struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	CommentID  primitive.ObjectID `bson:"commentID"`
+	Text       string             `bson:"text"`
+	Rating     int                `bson:"rating"`
+	Status     string             `bson:"status,omitempty"`
+	Date       time.Time          `bson:"date"`
+	ReplacedAt time.Time          `bson:"replacedAt"`
 }
@@ -0,0 +1,16 @@
+package mongo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Download represents a file download model.
+type Download struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	FileID  primitive.ObjectID `bson:"fileID"`
+	UserID  int                `bson:"userID"`
+	Version int                `bson:"version,omitempty"`
+	Date    time.Time          `bson:"date"`
+}
@@ -0,0 +1,20 @@
+package mongo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Event represents a domain event model kept in the outbox until it is dispatched.
+type Event struct {
+	ID            primitive.ObjectID `bson:"_id,omitempty"`
+	Type          string             `bson:"type"`
+	AggregateID   string             `bson:"aggregateID"`
+	Payload       string             `bson:"payload"`
+	OccurredAt    time.Time          `bson:"occurredAt"`
+	Attempts      int                `bson:"attempts"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt"`
+	DispatchedAt  *time.Time         `bson:"dispatchedAt,omitempty"`
+	LastError     string             `bson:"lastError,omitempty"`
+}
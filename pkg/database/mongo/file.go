@@ -13,8 +13,30 @@ type File struct {
 	Description string             `bson:"description"`
 	Size        int                `bson:"size"`
 	Path        string             `bson:"path"`
+	Checksum    string             `bson:"checksum,omitempty"`
+	ContentType string             `bson:"contentType,omitempty"`
+	VersionID   primitive.ObjectID `bson:"versionID,omitempty"`
+	Version     int                `bson:"version,omitempty"`
 	AddDate     time.Time          `bson:"addDate"`
 	UpdateDate  time.Time          `bson:"updateDate"`
 	Actual      bool               `bson:"actual"`
 	AuthorID    int                `bson:"authorID"`
+	Rating      float64            `bson:"rating,omitempty"`
+	RatingSum   int                `bson:"ratingSum,omitempty"`
+	RatingCount int                `bson:"ratingCount,omitempty"`
+	Revision    int                `bson:"revision"`
+}
+
+// FileVersion represents an immutable version of a file model.
+type FileVersion struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	FileID      primitive.ObjectID `bson:"fileID"`
+	Number      int                `bson:"number"`
+	Name        string             `bson:"name"`
+	Description string             `bson:"description"`
+	Size        int                `bson:"size"`
+	Path        string             `bson:"path"`
+	Checksum    string             `bson:"checksum,omitempty"`
+	ContentType string             `bson:"contentType,omitempty"`
+	Date        time.Time          `bson:"date"`
 }
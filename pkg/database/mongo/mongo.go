@@ -2,22 +2,32 @@ package mongo
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"strconv"
 	"time"
 
 	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
 	"github.com/pkg/errors"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 const timeout = 5
 
-// NewMongo creates new connection to mongo database.
-func NewMongo(ctx context.Context, cfg config.MongoConfig) (*mongo.Database, error) {
-	cfg.URI = fmt.Sprintf("%s://%s:%d", cfg.DatabaseDialect, cfg.Host, cfg.Port)
-	c, err := mongo.NewClient(options.Client().ApplyURI(cfg.URI))
+// NewMongo creates new connection to mongo database. Options are applied over the ones from config.
+func NewMongo(ctx context.Context, cfg config.MongoConfig, opts ...*options.ClientOptions) (*mongo.Database, error) {
+	clientOpts, err := ClientOptions(cfg)
+	if err != nil {
+		return nil, err
+	}
+	c, err := mongo.NewClient(options.MergeClientOptions(append([]*options.ClientOptions{clientOpts}, opts...)...))
 	if err != nil {
 		return nil, errors.Wrap(err, "couldn't create mongo client")
 	}
@@ -29,12 +39,165 @@ func NewMongo(ctx context.Context, cfg config.MongoConfig) (*mongo.Database, err
 
 	err = checkConnection(ctx, c)
 	if err != nil {
+		_ = c.Disconnect(ctx)
 		return nil, errors.Wrap(err, "couldn't connect to mongo client")
 	}
 
 	return c.Database(cfg.DatabaseName), nil
 }
 
+// ClientOptions builds client options from the connection string and explicit options of config.
+func ClientOptions(cfg config.MongoConfig) (*options.ClientOptions, error) {
+	uri := cfg.URI
+	if uri == "" {
+		uri = fmt.Sprintf("%s://%s", cfg.DatabaseDialect, net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	}
+	opts := options.Client().ApplyURI(uri)
+
+	for _, apply := range []func(*options.ClientOptions, config.MongoConfig) error{applyAuth, applyConcerns, applyPool} {
+		if err := apply(opts, cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := opts.Validate(); err != nil {
+		return nil, errors.Wrap(err, "invalid mongo options")
+	}
+
+	return opts, nil
+}
+
+func applyAuth(opts *options.ClientOptions, cfg config.MongoConfig) error {
+	if cfg.Username != "" {
+		opts.SetAuth(options.Credential{
+			Username:      cfg.Username,
+			Password:      cfg.Password,
+			PasswordSet:   cfg.Password != "",
+			AuthSource:    cfg.AuthSource,
+			AuthMechanism: cfg.AuthMechanism,
+		})
+	}
+	if cfg.ReplicaSet != "" {
+		opts.SetReplicaSet(cfg.ReplicaSet)
+	}
+	if cfg.TLS {
+		tlsConfig, err := newTLSConfig(cfg)
+		if err != nil {
+			return err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	return nil
+}
+
+func applyConcerns(opts *options.ClientOptions, cfg config.MongoConfig) error {
+	if cfg.ReadConcern != "" {
+		opts.SetReadConcern(readconcern.New(readconcern.Level(cfg.ReadConcern)))
+	}
+	if cfg.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(cfg.ReadPreference)
+		if err != nil {
+			return errors.Wrap(err, "couldn't parse read preference")
+		}
+		rp, err := readpref.New(mode)
+		if err != nil {
+			return errors.Wrap(err, "couldn't create read preference")
+		}
+		opts.SetReadPreference(rp)
+	}
+	if cfg.WriteConcern != "" || cfg.WriteConcernTimeout != 0 || cfg.Journal {
+		opts.SetWriteConcern(newWriteConcern(cfg))
+	}
+
+	return nil
+}
+
+func applyPool(opts *options.ClientOptions, cfg config.MongoConfig) error {
+	if cfg.MinPoolSize != 0 {
+		opts.SetMinPoolSize(cfg.MinPoolSize)
+	}
+	if cfg.MaxPoolSize != 0 {
+		opts.SetMaxPoolSize(cfg.MaxPoolSize)
+	}
+	if cfg.MaxConnIdleTime != 0 {
+		opts.SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	}
+	if cfg.ConnectTimeout != 0 {
+		opts.SetConnectTimeout(cfg.ConnectTimeout)
+	}
+	if cfg.ServerSelectionTimeout != 0 {
+		opts.SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	}
+	if cfg.SocketTimeout != 0 {
+		opts.SetSocketTimeout(cfg.SocketTimeout)
+	}
+
+	return nil
+}
+
+// newTLSConfig loads CA and client certificate files of config.
+// Certificate key file contains both certificate and private key in PEM as mongo tools expect.
+func newTLSConfig(cfg config.MongoConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.TLSInsecure,
+	}
+
+	if cfg.TLSCAFile != "" {
+		ca, err := ioutil.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't read tls ca file")
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("tls ca file contains no certificates")
+		}
+	}
+
+	if cfg.TLSCertificateKeyFile != "" {
+		pem, err := ioutil.ReadFile(cfg.TLSCertificateKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't read tls certificate key file")
+		}
+		cert, err := tls.X509KeyPair(pem, pem)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't load tls certificate key file")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// newWriteConcern creates write concern of majority or number of nodes.
+func newWriteConcern(cfg config.MongoConfig) *writeconcern.WriteConcern {
+	var opts []writeconcern.Option
+	if cfg.WriteConcern == "majority" {
+		opts = append(opts, writeconcern.WMajority())
+	} else if w, err := strconv.Atoi(cfg.WriteConcern); err == nil {
+		opts = append(opts, writeconcern.W(w))
+	}
+	if cfg.WriteConcernTimeout != 0 {
+		opts = append(opts, writeconcern.WTimeout(cfg.WriteConcernTimeout))
+	}
+	if cfg.Journal {
+		opts = append(opts, writeconcern.J(true))
+	}
+
+	return writeconcern.New(opts...)
+}
+
+// Ping checks connection to the primary of the database.
+func Ping(ctx context.Context, db *mongo.Database) error {
+	return db.Client().Ping(ctx, readpref.Primary())
+}
+
+// Close disconnects client of the database.
+func Close(ctx context.Context, db *mongo.Database) error {
+	return db.Client().Disconnect(ctx)
+}
+
 func checkConnection(ctx context.Context, c *mongo.Client) error {
 	ctx, cancel := context.WithTimeout(ctx, timeout*time.Second)
 	defer cancel()
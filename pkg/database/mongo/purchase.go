@@ -8,8 +8,10 @@ import (
 
 // Purchase represents a purchase model.
 type Purchase struct {
-	ID     primitive.ObjectID `bson:"_id,omitempty"`
-	UserID int                `bson:"userID"`
-	Date   time.Time          `bson:"date"`
-	FileID primitive.ObjectID `bson:"fileID"`
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	UserID    int                `bson:"userID"`
+	Date      time.Time          `bson:"date"`
+	FileID    primitive.ObjectID `bson:"fileID"`
+	VersionID primitive.ObjectID `bson:"versionID,omitempty"`
+	Version   int                `bson:"version,omitempty"`
 }
@@ -0,0 +1,14 @@
+package mongo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ResumeToken represents a model of the last change stream position of a watcher.
+type ResumeToken struct {
+	ID      string    `bson:"_id"`
+	Token   bson.Raw  `bson:"token"`
+	Updated time.Time `bson:"updated"`
+}
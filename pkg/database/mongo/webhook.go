@@ -0,0 +1,36 @@
+package mongo
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Webhook represents a webhook subscription model.
+type Webhook struct {
+	ID      primitive.ObjectID `bson:"_id,omitempty"`
+	UserID  int                `bson:"userID"`
+	URL     string             `bson:"url"`
+	Events  []string           `bson:"events"`
+	Secret  string             `bson:"secret"`
+	Global  bool               `bson:"global"`
+	Active  bool               `bson:"active"`
+	Created time.Time          `bson:"created"`
+	Updated time.Time          `bson:"updated"`
+}
+
+// Delivery represents a delivery model of an event to a webhook.
+type Delivery struct {
+	ID             primitive.ObjectID `bson:"_id,omitempty"`
+	WebhookID      primitive.ObjectID `bson:"webhookID"`
+	EventID        string             `bson:"eventID"`
+	EventType      string             `bson:"eventType"`
+	Body           string             `bson:"body"`
+	Status         string             `bson:"status"`
+	Attempts       int                `bson:"attempts"`
+	NextAttemptAt  time.Time          `bson:"nextAttemptAt"`
+	ResponseStatus int                `bson:"responseStatus,omitempty"`
+	LastError      string             `bson:"lastError,omitempty"`
+	Created        time.Time          `bson:"created"`
+	Updated        time.Time          `bson:"updated"`
+}
@@ -0,0 +1,72 @@
+package filter
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// Verdict represents a result of the content check.
+type Verdict int
+
+const (
+	// Allow means that content could be published.
+	Allow Verdict = iota
+	// Review means that content should be reviewed by a moderator before publishing.
+	Review
+	// Reject means that content mustn't be published.
+	Reject
+)
+
+// Filter checks user generated content.
+type Filter interface {
+	Check(ctx context.Context, text string) (Verdict, error)
+}
+
+// BannedWords is a Filter which rejects text with banned words
+// and sends text with suspicious words to review.
+type BannedWords struct {
+	banned     map[string]struct{}
+	suspicious map[string]struct{}
+}
+
+// NewBannedWords is a BannedWords constructor.
+func NewBannedWords(banned, suspicious []string) *BannedWords {
+	return &BannedWords{
+		banned:     wordSet(banned),
+		suspicious: wordSet(suspicious),
+	}
+}
+
+// Check checks text for banned and suspicious words.
+func (b *BannedWords) Check(_ context.Context, text string) (Verdict, error) {
+	verdict := Allow
+	for _, word := range words(text) {
+		if _, ok := b.banned[word]; ok {
+			return Reject, nil
+		}
+		if _, ok := b.suspicious[word]; ok {
+			verdict = Review
+		}
+	}
+
+	return verdict, nil
+}
+
+func wordSet(list []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(list))
+	for _, word := range list {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word != "" {
+			set[word] = struct{}{}
+		}
+	}
+
+	return set
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
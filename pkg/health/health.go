@@ -0,0 +1,127 @@
+package health
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/middleware"
+)
+
+const (
+	// StatusOK reports a healthy service or dependency.
+	StatusOK = "ok"
+	// StatusDown reports a failed dependency or a service which is not ready.
+	StatusDown = "down"
+	// StatusShuttingDown reports a service which is stopping and takes no new requests.
+	StatusShuttingDown = "shutting_down"
+)
+
+// CheckFunc checks availability of a dependency.
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+	name    string
+	timeout time.Duration
+	check   CheckFunc
+}
+
+// Health serves liveness and readiness probes of the service.
+type Health struct {
+	checks   []check
+	stopping int32
+}
+
+// Response represents a probe response.
+type Response struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult represents a result of a dependency check.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// New is a Health constructor.
+func New() *Health {
+	return &Health{}
+}
+
+// Add adds a dependency check for readiness. The check fails if it takes longer than timeout.
+func (h *Health) Add(name string, timeout time.Duration, checkFunc CheckFunc) {
+	h.checks = append(h.checks, check{name: name, timeout: timeout, check: checkFunc})
+}
+
+// Shutdown turns readiness false, so the service gets no new requests while it is stopping.
+func (h *Health) Shutdown() {
+	atomic.StoreInt32(&h.stopping, 1)
+}
+
+// Live reports that the process is alive.
+func (h *Health) Live() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		middleware.JSONReturn(w, http.StatusOK, Response{Status: StatusOK})
+	})
+}
+
+// Ready checks all dependencies concurrently and reports their statuses.
+// It responds with 503 if any of them fails or the service is shutting down.
+func (h *Health) Ready() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&h.stopping) == 1 {
+			middleware.JSONReturn(w, http.StatusServiceUnavailable, Response{Status: StatusShuttingDown})
+			return
+		}
+
+		res := Response{Status: StatusOK, Checks: h.run(r.Context())}
+		for _, result := range res.Checks {
+			if result.Status != StatusOK {
+				res.Status = StatusDown
+			}
+		}
+
+		status := http.StatusOK
+		if res.Status != StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+		middleware.JSONReturn(w, status, res)
+	})
+}
+
+func (h *Health) run(ctx context.Context) map[string]CheckResult {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]CheckResult, len(h.checks))
+	)
+
+	for _, c := range h.checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			result := CheckResult{Status: StatusOK}
+			if err := c.check(ctx); err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+			result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+
+			mu.Lock()
+			results[c.name] = result
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	return results
+}
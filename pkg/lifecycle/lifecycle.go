@@ -0,0 +1,134 @@
+package lifecycle
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+)
+
+// Hook represents a component of the service. All its functions are optional.
+type Hook struct {
+	Name string
+	// Start starts the component. It must not block.
+	Start func(ctx context.Context) error
+	// Run serves the component until it is stopped. Its error stops the service.
+	Run func() error
+	// Stop stops the component. Components are stopped in reverse order.
+	Stop func(ctx context.Context) error
+}
+
+// Errors collects errors of several components.
+type Errors []error
+
+func (e Errors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// Lifecycle starts components in order, runs them until the service is stopped and stops them in reverse order.
+type Lifecycle struct {
+	hooks   []Hook
+	started []bool
+	timeout time.Duration
+	log     *logger.Logger
+}
+
+// New is a Lifecycle constructor. Stopping of all components is limited by timeout.
+func New(timeout time.Duration, log *logger.Logger) *Lifecycle {
+	return &Lifecycle{timeout: timeout, log: log}
+}
+
+// Append appends a component. Components without Start are resources which are already open,
+// so they are stopped even if the service fails before it runs.
+func (l *Lifecycle) Append(hook Hook) {
+	l.hooks = append(l.hooks, hook)
+	l.started = append(l.started, hook.Start == nil)
+}
+
+// Run starts components and runs them until the context is done or any of them fails, then stops them.
+// It returns the error which stopped the service along with errors of stopping.
+func (l *Lifecycle) Run(ctx context.Context) error {
+	for i, hook := range l.hooks {
+		if l.started[i] {
+			continue
+		}
+		l.log.Info("starting", "component", hook.Name)
+		if err := hook.Start(ctx); err != nil {
+			return l.Stop(errors.Wrapf(err, "couldn't start %s", hook.Name))
+		}
+		l.started[i] = true
+	}
+
+	var wg sync.WaitGroup
+	failed := make(chan error, len(l.hooks))
+	for _, hook := range l.hooks {
+		if hook.Run == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(hook Hook) {
+			defer wg.Done()
+			if err := hook.Run(); err != nil {
+				failed <- errors.Wrapf(err, "%s failed", hook.Name)
+			}
+		}(hook)
+	}
+
+	var cause error
+	select {
+	case <-ctx.Done():
+		l.log.Info("stopping")
+	case cause = <-failed:
+		l.log.Error("stopping on failure", "error", cause)
+	}
+
+	err := l.Stop(cause)
+	wg.Wait()
+
+	return err
+}
+
+// Stop stops started components in reverse order. It returns the cause along with errors of stopping.
+func (l *Lifecycle) Stop(cause error) error {
+	ctx, cancel := context.WithTimeout(context.Background(), l.timeout)
+	defer cancel()
+
+	var errs Errors
+	if cause != nil {
+		errs = append(errs, cause)
+	}
+	for i := len(l.hooks) - 1; i >= 0; i-- {
+		hook := l.hooks[i]
+		if !l.started[i] {
+			continue
+		}
+		l.started[i] = false
+		if hook.Stop == nil {
+			continue
+		}
+
+		if err := hook.Stop(ctx); err != nil {
+			l.log.Error("couldn't stop", "component", hook.Name, "error", err)
+			errs = append(errs, errors.Wrapf(err, "couldn't stop %s", hook.Name))
+			continue
+		}
+		l.log.Info("stopped", "component", hook.Name)
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+
+	return errs
+}
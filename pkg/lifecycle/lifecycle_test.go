@@ -0,0 +1,120 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/pkg/errors"
+	testAssert "github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name      string
+		startErr  error
+		runErr    error
+		stopErr   error
+		expCalls  []string
+		expErr    string
+		cancelRun bool
+	}
+
+	tt := []test{
+		{
+			name:      "stopped by context",
+			cancelRun: true,
+			expCalls:  []string{"start server", "stop readiness", "stop server", "stop db"},
+		},
+		{
+			name:     "start err",
+			startErr: errors.New("address in use"),
+			expCalls: []string{"start server", "stop readiness", "stop db"},
+			expErr:   "couldn't start server: address in use",
+		},
+		{
+			name:     "run err",
+			runErr:   errors.New("accept failed"),
+			expCalls: []string{"start server", "stop readiness", "stop server", "stop db"},
+			expErr:   "server failed: accept failed",
+		},
+		{
+			name:      "stop err",
+			cancelRun: true,
+			stopErr:   errors.New("disconnect failed"),
+			expCalls:  []string{"start server", "stop readiness", "stop server", "stop db"},
+			expErr:    "couldn't stop db: disconnect failed",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var calls []string
+			stopped := make(chan struct{})
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			app := New(time.Second, logger.Nop())
+			app.Append(Hook{Name: "db", Stop: func(ctx context.Context) error {
+				calls = append(calls, "stop db")
+				return tc.stopErr
+			}})
+			app.Append(Hook{
+				Name: "server",
+				Start: func(ctx context.Context) error {
+					calls = append(calls, "start server")
+					if tc.cancelRun {
+						cancel()
+					}
+					return tc.startErr
+				},
+				Run: func() error {
+					if tc.runErr != nil {
+						return tc.runErr
+					}
+					<-stopped
+					return nil
+				},
+				Stop: func(ctx context.Context) error {
+					calls = append(calls, "stop server")
+					close(stopped)
+					return nil
+				},
+			})
+			app.Append(Hook{Name: "readiness", Stop: func(ctx context.Context) error {
+				calls = append(calls, "stop readiness")
+				return nil
+			}})
+
+			err := app.Run(ctx)
+			if tc.expErr == "" {
+				assert.NoError(err)
+			} else {
+				assert.EqualError(err, tc.expErr)
+			}
+			assert.Equal(tc.expCalls, calls)
+		})
+	}
+}
+
+func TestStop(t *testing.T) {
+	assert := testAssert.New(t)
+
+	var calls []string
+	app := New(time.Second, logger.Nop())
+	app.Append(Hook{Name: "db", Stop: func(ctx context.Context) error {
+		calls = append(calls, "stop db")
+		return errors.New("disconnect failed")
+	}})
+	app.Append(Hook{Name: "server", Start: func(ctx context.Context) error {
+		calls = append(calls, "start server")
+		return nil
+	}})
+
+	err := app.Stop(errors.New("init failed"))
+	assert.EqualError(err, "init failed; couldn't stop db: disconnect failed")
+	assert.Equal([]string{"stop db"}, calls)
+	assert.NoError(app.Stop(nil))
+}
@@ -0,0 +1,222 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Level is a severity of log entries.
+type Level int
+
+// Levels of log entries from the least to the most severe.
+const (
+	DebugLevel Level = iota
+	InfoLevel
+	WarnLevel
+	ErrorLevel
+)
+
+var levelNames = map[Level]string{
+	DebugLevel: "debug",
+	InfoLevel:  "info",
+	WarnLevel:  "warn",
+	ErrorLevel: "error",
+}
+
+// String returns name of the level.
+func (l Level) String() string {
+	return levelNames[l]
+}
+
+// ParseLevel parses level by its name.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown log level %s", name)
+}
+
+// Decode decodes level from config value.
+func (l *Level) Decode(value string) error {
+	level, err := ParseLevel(value)
+	if err != nil {
+		return err
+	}
+	*l = level
+
+	return nil
+}
+
+// Logger writes leveled log entries as JSON lines with time, level, message and fields of key-value pairs.
+type Logger struct {
+	mu     *sync.Mutex
+	out    io.Writer
+	level  Level
+	fields []interface{}
+	now    func() time.Time
+}
+
+// New is a Logger constructor. Entries less severe than level are skipped.
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{
+		mu:    new(sync.Mutex),
+		out:   out,
+		level: level,
+		now:   func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// Nop returns Logger which skips all entries.
+func Nop() *Logger {
+	return New(io.Discard, ErrorLevel+1)
+}
+
+// WithLevel returns copy of the logger which skips entries less severe than level.
+func (l *Logger) WithLevel(level Level) *Logger {
+	c := *l
+	c.level = level
+
+	return &c
+}
+
+// With returns copy of the logger which adds fields of key-value pairs to every entry.
+func (l *Logger) With(keyvals ...interface{}) *Logger {
+	c := *l
+	c.fields = append(append([]interface{}{}, l.fields...), keyvals...)
+
+	return &c
+}
+
+// WithContext returns copy of the logger which adds request id of the context to every entry.
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	id, ok := RequestID(ctx)
+	if !ok {
+		return l
+	}
+
+	return l.With("request_id", id)
+}
+
+// Debug writes debug entry.
+func (l *Logger) Debug(msg string, keyvals ...interface{}) {
+	l.write(DebugLevel, msg, keyvals)
+}
+
+// Info writes info entry.
+func (l *Logger) Info(msg string, keyvals ...interface{}) {
+	l.write(InfoLevel, msg, keyvals)
+}
+
+// Warn writes warn entry.
+func (l *Logger) Warn(msg string, keyvals ...interface{}) {
+	l.write(WarnLevel, msg, keyvals)
+}
+
+// Error writes error entry.
+func (l *Logger) Error(msg string, keyvals ...interface{}) {
+	l.write(ErrorLevel, msg, keyvals)
+}
+
+// Fatal writes error entry and exits with status 1.
+func (l *Logger) Fatal(msg string, keyvals ...interface{}) {
+	l.write(ErrorLevel, msg, keyvals)
+	os.Exit(1)
+}
+
+func (l *Logger) write(level Level, msg string, keyvals []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	var entry bytes.Buffer
+	entry.WriteByte('{')
+	writeField(&entry, "time", l.now().Format(time.RFC3339Nano))
+	writeField(&entry, "level", level.String())
+	writeField(&entry, "msg", msg)
+	writeFields(&entry, l.fields)
+	writeFields(&entry, keyvals)
+	entry.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.out.Write(entry.Bytes())
+}
+
+func writeFields(entry *bytes.Buffer, keyvals []interface{}) {
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if i+1 == len(keyvals) {
+			writeField(entry, "!BADKEY", key)
+			break
+		}
+		writeField(entry, key, keyvals[i+1])
+	}
+}
+
+func writeField(entry *bytes.Buffer, key string, value interface{}) {
+	if entry.Len() > 1 {
+		entry.WriteByte(',')
+	}
+
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case time.Time:
+	case time.Duration:
+		value = v.String()
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	k, _ := json.Marshal(key)
+	v, err := json.Marshal(value)
+	if err != nil {
+		v, _ = json.Marshal(fmt.Sprint(value))
+	}
+	entry.Write(k)
+	entry.WriteByte(':')
+	entry.Write(v)
+}
+
+type contextKey string
+
+const (
+	requestIDKey contextKey = "requestID"
+	loggerKey    contextKey = "logger"
+)
+
+// ContextWithRequestID returns copy of the context with request id.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID returns request id of the context.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey).(string)
+	return id, ok
+}
+
+// NewContext returns copy of the context with the logger.
+func NewContext(ctx context.Context, l *Logger) context.Context {
+	return context.WithValue(ctx, loggerKey, l)
+}
+
+// FromContext returns logger of the context or a Logger which skips all entries if there is no one.
+func FromContext(ctx context.Context) *Logger {
+	l, ok := ctx.Value(loggerKey).(*Logger)
+	if !ok {
+		return Nop()
+	}
+
+	return l
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const namespace = "hexsatisfaction_purchase"
+
+// Metrics collects metrics of the service in its own registry.
+type Metrics struct {
+	registry      *prometheus.Registry
+	httpRequests  *prometheus.CounterVec
+	httpDuration  *prometheus.HistogramVec
+	mongoDuration *prometheus.HistogramVec
+	mongoErrors   *prometheus.CounterVec
+	grpcDuration  *prometheus.HistogramVec
+	grpcErrors    *prometheus.CounterVec
+	purchases     prometheus.Counter
+	comments      prometheus.Counter
+	changes       *prometheus.CounterVec
+}
+
+// New is a Metrics constructor. Go runtime and process metrics are collected too.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by method, route template and status.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Latency of HTTP requests by method and route template.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		mongoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "mongo",
+			Name:      "operation_duration_seconds",
+			Help:      "Latency of mongo operations by repository and its method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"repository", "method"}),
+		mongoErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "mongo",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed mongo operations by repository and its method.",
+		}, []string{"repository", "method"}),
+		grpcDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "grpc_client",
+			Name:      "call_duration_seconds",
+			Help:      "Latency of gRPC calls by method and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		grpcErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "grpc_client",
+			Name:      "call_errors_total",
+			Help:      "Number of failed gRPC calls by method and status code.",
+		}, []string{"method", "code"}),
+		purchases: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "purchases_created_total",
+			Help:      "Number of created purchases.",
+		}),
+		comments: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "comments_posted_total",
+			Help:      "Number of posted comments and replies.",
+		}),
+		changes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "watcher",
+			Name:      "changes_total",
+			Help:      "Number of watched changes by collection and operation.",
+		}, []string{"collection", "operation"}),
+	}
+
+	m.registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.mongoDuration,
+		m.mongoErrors,
+		m.grpcDuration,
+		m.grpcErrors,
+		m.purchases,
+		m.comments,
+		m.changes,
+	)
+
+	return m
+}
+
+// Handler returns handler which exposes collected metrics.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest observes served HTTP request.
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveOperation observes mongo operation of the repository method.
+func (m *Metrics) ObserveOperation(repository, method string, duration time.Duration, err error) {
+	m.mongoDuration.WithLabelValues(repository, method).Observe(duration.Seconds())
+	if err != nil {
+		m.mongoErrors.WithLabelValues(repository, method).Inc()
+	}
+}
+
+// PurchaseCreated counts created purchase.
+func (m *Metrics) PurchaseCreated() {
+	m.purchases.Inc()
+}
+
+// CommentPosted counts posted comment.
+func (m *Metrics) CommentPosted() {
+	m.comments.Inc()
+}
+
+// ObserveChange counts watched change of the collection.
+func (m *Metrics) ObserveChange(collection, operation string) {
+	m.changes.WithLabelValues(collection, operation).Inc()
+}
+
+// UnaryClientInterceptor observes latency and errors of gRPC calls.
+func (m *Metrics) UnaryClientInterceptor(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	start := time.Now()
+	err := invoker(ctx, method, req, reply, cc, opts...)
+	code := status.Code(err).String()
+
+	m.grpcDuration.WithLabelValues(method, code).Observe(time.Since(start).Seconds())
+	if err != nil {
+		m.grpcErrors.WithLabelValues(method, code).Inc()
+	}
+
+	return err
+}
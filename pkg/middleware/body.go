@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// MIMEApplicationJSON is a media type of JSON request body.
+	MIMEApplicationJSON = "application/json"
+	// MIMEMergePatchJSON is a media type of JSON Merge Patch request body.
+	MIMEMergePatchJSON = "application/merge-patch+json"
+)
+
+var (
+	// ErrBodyTooLarge is returned when request body exceeds its limit.
+	ErrBodyTooLarge = errors.New("request body is too large")
+	// ErrEmptyBody is returned when request body has no JSON value.
+	ErrEmptyBody = errors.New("request body is required")
+)
+
+// MaxBytes limits size of request body by limit bytes.
+// Requests with larger Content-Length are rejected with 413 status,
+// reading of the body beyond the limit returns ErrBodyTooLarge.
+func MaxBytes(limit int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				JSONError(w, ErrBodyTooLarge, http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil {
+				r.Body = &maxBytesReader{ReadCloser: r.Body, remaining: limit}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+type maxBytesReader struct {
+	io.ReadCloser
+	remaining int64
+	err       error
+}
+
+// Read reads up to the remaining bytes of the limit and fails with ErrBodyTooLarge after it.
+func (m *maxBytesReader) Read(p []byte) (int, error) {
+	if m.err != nil {
+		return 0, m.err
+	}
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if int64(len(p)) > m.remaining+1 {
+		p = p[:m.remaining+1]
+	}
+
+	n, err := m.ReadCloser.Read(p)
+	if int64(n) <= m.remaining {
+		m.remaining -= int64(n)
+		m.err = err
+		return n, err
+	}
+
+	n = int(m.remaining)
+	m.remaining = 0
+	m.err = ErrBodyTooLarge
+	return n, m.err
+}
+
+// ContentType rejects requests with a body of not allowed media types with 415 status.
+// Requests without a body are passed as is.
+func ContentType(allowed ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if err != nil || !contains(allowed, mediaType) {
+				err = fmt.Errorf("content type must be %s", strings.Join(allowed, " or "))
+				JSONError(w, err, http.StatusUnsupportedMediaType)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// DecodeJSON decodes the single JSON value of request body into the value pointed to by v.
+// Unknown fields of objects are not allowed.
+func DecodeJSON(r io.Reader, v interface{}) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(v)
+	if err != nil {
+		return decodeError(err)
+	}
+
+	return decodeEnd(decoder)
+}
+
+// decodeEnd checks that nothing but whitespace follows the decoded JSON value.
+func decodeEnd(decoder *json.Decoder) error {
+	var extra json.RawMessage
+	err := decoder.Decode(&extra)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	if errors.Is(err, ErrBodyTooLarge) {
+		return err
+	}
+
+	return fmt.Errorf("request body must contain a single JSON value")
+}
+
+// decodeError converts error of JSON decoder into a clear error for the client.
+func decodeError(err error) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrBodyTooLarge):
+		return err
+	case errors.Is(err, io.EOF):
+		return ErrEmptyBody
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("request body contains incomplete JSON")
+	case errors.As(err, &syntaxErr):
+		return fmt.Errorf("not correct JSON at position %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		path := strings.Split(typeErr.Field, ".")
+		return fmt.Errorf("not correct %s", fieldLabel(path[len(path)-1]))
+	case errors.As(err, &typeErr):
+		return fmt.Errorf("not correct JSON value at position %d", typeErr.Offset)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		name := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return fmt.Errorf("unknown field %s", name)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,192 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/logger"
+	"github.com/gorilla/mux"
+)
+
+// RequestIDHeader is a header of request id.
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
+// RequestID takes request id from X-Request-ID header or generates a new one if it is absent or not correct.
+// The id is put into the request context and returned in X-Request-ID header of the response.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(logger.ContextWithRequestID(r.Context(), id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+func newRequestID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+
+	return hex.EncodeToString(id)
+}
+
+type accessKey struct{}
+
+// access represents an access log entry filled while the request is served.
+type access struct {
+	route  string
+	userID string
+	status int
+	err    error
+}
+
+// accessWriter captures status and error of the response.
+type accessWriter struct {
+	http.ResponseWriter
+	entry *access
+}
+
+// WriteHeader captures status of the response.
+func (w accessWriter) WriteHeader(status int) {
+	if w.entry.status == 0 {
+		w.entry.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write captures implicit status of the response.
+func (w accessWriter) Write(b []byte) (int, error) {
+	if w.entry.status == 0 {
+		w.entry.status = http.StatusOK
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+// track returns access log entry of the request. If the request has no entry yet,
+// it is created with a response writer which captures status and error of the response.
+func track(w http.ResponseWriter, r *http.Request) (*access, http.ResponseWriter, *http.Request) {
+	if entry, ok := r.Context().Value(accessKey{}).(*access); ok {
+		return entry, w, r
+	}
+
+	entry := &access{}
+	if route := mux.CurrentRoute(r); route != nil {
+		entry.route, _ = route.GetPathTemplate()
+	}
+
+	return entry, accessWriter{w, entry}, r.WithContext(context.WithValue(r.Context(), accessKey{}, entry))
+}
+
+// statusCode returns status of the response, which is 200 if nothing was written.
+func (a *access) statusCode() int {
+	if a.status == 0 {
+		return http.StatusOK
+	}
+
+	return a.status
+}
+
+// AccessLog writes an access log entry with method, route template, status, latency and user id of every request.
+// Server errors are logged with error level and client errors with warn level.
+// Request scoped logger is put into the request context.
+func AccessLog(log *logger.Logger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry, w, r := track(w, r)
+			reqLog := log.WithContext(r.Context())
+			next.ServeHTTP(w, r.WithContext(logger.NewContext(r.Context(), reqLog)))
+
+			status := entry.statusCode()
+			keyvals := []interface{}{
+				"method", r.Method,
+				"route", entry.route,
+				"status", status,
+				"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if entry.userID != "" {
+				keyvals = append(keyvals, "user_id", entry.userID)
+			}
+			if entry.err != nil {
+				keyvals = append(keyvals, "error", entry.err)
+			}
+
+			switch {
+			case status >= http.StatusInternalServerError:
+				reqLog.Error("request", keyvals...)
+			case status >= http.StatusBadRequest:
+				reqLog.Warn("request", keyvals...)
+			default:
+				reqLog.Info("request", keyvals...)
+			}
+		})
+	}
+}
+
+// RequestObserver observes served requests.
+type RequestObserver interface {
+	ObserveRequest(method, route string, status int, duration time.Duration)
+}
+
+// Instrument reports method, route template, status and latency of every request to the observer.
+func Instrument(observer RequestObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			entry, w, r := track(w, r)
+			next.ServeHTTP(w, r)
+
+			observer.ObserveRequest(r.Method, entry.route, entry.statusCode(), time.Since(start))
+		})
+	}
+}
+
+// Route records route template of the request into its access log entry.
+// It is used as middleware of routers mounted under a path prefix.
+func Route(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry, ok := r.Context().Value(accessKey{}).(*access)
+		if ok {
+			if route := mux.CurrentRoute(r); route != nil {
+				entry.route, _ = route.GetPathTemplate()
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SetUserID records id of the user who made the request into its access log entry.
+func SetUserID(ctx context.Context, userID string) {
+	if entry, ok := ctx.Value(accessKey{}).(*access); ok {
+		entry.userID = userID
+	}
+}
+
+// recordError records error of the response into its access log entry.
+func recordError(w http.ResponseWriter, err error) {
+	if aw, ok := w.(accessWriter); ok {
+		aw.entry.err = err
+	}
+}
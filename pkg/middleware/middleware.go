@@ -1,13 +1,21 @@
 package middleware
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+
+	"github.com/pkg/errors"
 )
 
 type request interface {
 	Build(*http.Request) error
+}
+
+// validator is implemented by requests with checks which couldn't be expressed by validate tags.
+type validator interface {
 	Validate() error
 }
 
@@ -20,14 +28,60 @@ type SwagError struct {
 type SwagEmptyError struct {
 }
 
-// ParseRequest parses request from http Request, stores it in the value pointed to by s and validates it.
+// ParseRequest parses request from http Request, stores it in the value pointed to by s and validates it
+// by validate tags of its fields and its Validate method if any.
+// All failed fields are returned at once as ValidationErrors.
 // You must close r.Body in the Build method if you used it.
 func ParseRequest(r *http.Request, s request) error {
 	err := s.Build(r)
 	if err != nil {
 		return err
 	}
-	return s.Validate()
+
+	var errs ValidationErrors
+	errors.As(Validate(s), &errs)
+	if v, ok := s.(validator); ok {
+		err = v.Validate()
+		var fields ValidationErrors
+		var field FieldError
+		switch {
+		case err == nil:
+		case errors.As(err, &fields):
+			errs = append(errs, fields...)
+		case errors.As(err, &field):
+			errs = append(errs, field)
+		case len(errs) == 0:
+			return err
+		default:
+			errs = append(errs, FieldError{Message: err.Error()})
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// DecodeMergePatch decodes JSON Merge Patch document into its members by name.
+// The document must be a JSON object.
+func DecodeMergePatch(r io.Reader) (map[string]json.RawMessage, error) {
+	var patch map[string]json.RawMessage
+	decoder := json.NewDecoder(r)
+	err := decoder.Decode(&patch)
+	if err != nil {
+		return nil, decodeError(err)
+	}
+	if patch == nil {
+		return nil, fmt.Errorf("patch must be an object")
+	}
+
+	return patch, decodeEnd(decoder)
+}
+
+// IsNull reports whether a member of JSON Merge Patch document is null, which means removal of the member.
+func IsNull(value json.RawMessage) bool {
+	return bytes.Equal(bytes.TrimSpace(value), []byte("null"))
 }
 
 // JSONReturn returns server response in JSON format.
@@ -36,7 +90,7 @@ func JSONReturn(w http.ResponseWriter, statusCode int, jsonObject interface{}) {
 	w.WriteHeader(statusCode)
 	err := json.NewEncoder(w).Encode(jsonObject)
 	if err != nil {
-		fmt.Printf("could not encode json :%v", err.Error())
+		recordError(w, errors.Wrap(err, "couldn't encode json"))
 	}
 }
 
@@ -47,6 +101,18 @@ func Empty(w http.ResponseWriter, statusCode int) {
 }
 
 // JSONError returns error from server in JSON format.
+// Validation errors are returned with the list of failed fields,
+// ErrBodyTooLarge is always returned with 413 status.
 func JSONError(w http.ResponseWriter, err error, httpStatus int) {
+	if errors.Is(err, ErrBodyTooLarge) {
+		httpStatus = http.StatusRequestEntityTooLarge
+	}
+	recordError(w, err)
+
+	var errs ValidationErrors
+	if errors.As(err, &errs) {
+		JSONReturn(w, httpStatus, validationResponse{Message: errs.Error(), Errors: errs})
+		return
+	}
 	JSONReturn(w, httpStatus, err.Error())
 }
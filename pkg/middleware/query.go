@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// relativeUnits are units of relative time of query parameters.
+var relativeUnits = map[string]time.Duration{
+	"m": time.Minute,
+	"h": time.Hour,
+	"d": 24 * time.Hour,
+	"w": 7 * 24 * time.Hour,
+}
+
+// QueryTime parses time from query parameter key of the request. It returns zero time for an absent parameter.
+// The value is RFC3339 time or a time relative to now: "now", "today" for the start of the current day
+// or "last N" with N of minutes (m), hours (h), days (d) or weeks (w) before now, e.g. "last 7d".
+func QueryTime(r *http.Request, key string, now time.Time) (time.Time, error) {
+	value := strings.TrimSpace(r.URL.Query().Get(key))
+	switch {
+	case value == "":
+		return time.Time{}, nil
+	case value == "now":
+		return now, nil
+	case value == "today":
+		year, month, day := now.Date()
+		return time.Date(year, month, day, 0, 0, 0, 0, now.Location()), nil
+	case strings.HasPrefix(value, "last "):
+		amount := strings.TrimSpace(strings.TrimPrefix(value, "last "))
+		if amount == "" {
+			return time.Time{}, fmt.Errorf("not correct %s", key)
+		}
+		unit, ok := relativeUnits[amount[len(amount)-1:]]
+		n, err := strconv.Atoi(amount[:len(amount)-1])
+		if !ok || err != nil || n < 0 {
+			return time.Time{}, fmt.Errorf("not correct %s", key)
+		}
+
+		return now.Add(-time.Duration(n) * unit), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("not correct %s", key)
+	}
+
+	return t, nil
+}
+
+// Deprecation marks responses of a deprecated route with Deprecation header
+// and links the GET route of the same path which replaces it.
+func Deprecation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", r.URL.Path))
+
+		next.ServeHTTP(w, r)
+	})
+}
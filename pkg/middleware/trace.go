@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/pkg/tracing"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Trace starts a server span of every request which continues the trace of its traceparent header.
+// The span is put into the request context and named by the route template once the request is served.
+func Trace(tracer trace.Tracer) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			entry, w, r := track(w, r)
+			ctx := tracing.Propagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(semconv.HTTPMethodKey.String(r.Method), semconv.HTTPTargetKey.String(r.URL.Path)),
+			)
+			defer span.End()
+
+			next.ServeHTTP(w, r.WithContext(ctx))
+
+			status := entry.statusCode()
+			if entry.route != "" {
+				span.SetName(r.Method + " " + entry.route)
+				span.SetAttributes(semconv.HTTPRouteKey.String(entry.route))
+			}
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(status))
+			span.SetStatus(semconv.SpanStatusFromHTTPStatusCodeAndSpanKind(status, trace.SpanKindServer))
+			if entry.err != nil && status >= http.StatusInternalServerError {
+				span.RecordError(entry.err)
+			}
+		})
+	}
+}
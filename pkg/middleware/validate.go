@@ -0,0 +1,242 @@
+package middleware
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Validation rules of the validate struct tag:
+//  omitnil      skips validation of a nil pointer
+//  omitempty    skips validation of a nil pointer or a zero value
+//  required     value must not be zero
+//  objectid     string must be a hex of ObjectID
+//  url          string must be an absolute http or https URL
+//  positive     number must be greater than zero
+//  min=N        number must be at least N
+//  max=N        number must be at most N
+//  oneof=A B    string must be one of the space separated values
+//  gtefield=F   time must not be before time of the sibling field F
+//
+// The first failed rule of a field is reported, other rules of the field are skipped.
+
+// FieldError represents a failed validation rule of a request field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error returns message of the field error.
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// ValidationErrors represents all failed validation rules of a request.
+type ValidationErrors []FieldError
+
+// Error returns messages of all field errors.
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Message
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// SwagValidationError represents a struct for swagger validation errors.
+type SwagValidationError struct {
+	Message string       `json:"message"`
+	Errors  []FieldError `json:"errors"`
+}
+
+type validationResponse struct {
+	Message string           `json:"message"`
+	Errors  ValidationErrors `json:"errors"`
+}
+
+// Validate validates fields of the struct pointed to by s by their validate tags.
+// Fields of embedded structs are validated too. It returns ValidationErrors with all failed fields.
+func Validate(s interface{}) error {
+	var errs ValidationErrors
+	validateStruct(reflect.Indirect(reflect.ValueOf(s)), &errs)
+	if len(errs) != 0 {
+		return errs
+	}
+
+	return nil
+}
+
+func validateStruct(v reflect.Value, errs *ValidationErrors) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+		tag, ok := field.Tag.Lookup("validate")
+		if field.Anonymous && !ok && value.Kind() == reflect.Struct {
+			validateStruct(value, errs)
+			continue
+		}
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+
+		err := validateField(v, field, value, strings.Split(tag, ","))
+		if err != nil {
+			*errs = append(*errs, *err)
+		}
+	}
+}
+
+func validateField(parent reflect.Value, field reflect.StructField, value reflect.Value, rules []string) *FieldError {
+	name := fieldName(field)
+	label := fieldLabel(name)
+	params := make(map[string]string, len(rules))
+	for _, rule := range rules {
+		key, param := splitRule(rule)
+		params[key] = param
+	}
+
+	if value.Kind() == reflect.Ptr {
+		_, omitNil := params["omitnil"]
+		_, omitEmpty := params["omitempty"]
+		if value.IsNil() && (omitNil || omitEmpty) {
+			return nil
+		}
+		if !value.IsNil() {
+			value = value.Elem()
+		}
+	}
+
+	for _, rule := range rules {
+		key, param := splitRule(rule)
+		var message string
+		switch key {
+		case "omitnil":
+		case "omitempty":
+			if value.IsZero() {
+				return nil
+			}
+		case "required":
+			if value.IsZero() {
+				message = fmt.Sprintf("%s is required", label)
+			}
+		case "objectid":
+			if !primitive.IsValidObjectID(value.String()) {
+				message = fmt.Sprintf("not correct %s", label)
+			}
+		case "url":
+			u, err := url.Parse(value.String())
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				message = fmt.Sprintf("not correct %s", label)
+			}
+		case "positive":
+			if value.Int() <= 0 {
+				message = fmt.Sprintf("not correct %s", label)
+			}
+		case "min", "max":
+			limit, err := strconv.ParseInt(param, 10, 64)
+			if err != nil {
+				panic(fmt.Sprintf("not correct %s rule of %s", key, field.Name))
+			}
+			if key == "min" && value.Int() >= limit || key == "max" && value.Int() <= limit {
+				continue
+			}
+			min, hasMin := params["min"]
+			max, hasMax := params["max"]
+			switch {
+			case hasMin && hasMax:
+				message = fmt.Sprintf("%s must be between %s and %s", label, min, max)
+			case hasMin:
+				message = fmt.Sprintf("%s must be at least %s", label, min)
+			default:
+				message = fmt.Sprintf("%s must be at most %s", label, max)
+			}
+		case "oneof":
+			values := strings.Fields(param)
+			if !contains(values, value.String()) {
+				message = fmt.Sprintf("%s must be %s", label, strings.Join(values, " or "))
+			}
+		case "gtefield":
+			other, ok := parent.Type().FieldByName(param)
+			if !ok {
+				panic(fmt.Sprintf("no field %s for gtefield rule of %s", param, field.Name))
+			}
+			start, _ := parent.FieldByIndex(other.Index).Interface().(time.Time)
+			end, _ := value.Interface().(time.Time)
+			if end.Before(start) {
+				message = fmt.Sprintf("%s couldn't be before %s", label, fieldLabel(fieldName(other)))
+			}
+		default:
+			panic(fmt.Sprintf("unknown validation rule %s of %s", key, field.Name))
+		}
+
+		if message != "" {
+			return &FieldError{Field: name, Rule: key, Message: message}
+		}
+	}
+
+	return nil
+}
+
+func splitRule(rule string) (string, string) {
+	parts := strings.SplitN(strings.TrimSpace(rule), "=", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// fieldName returns json name of the field or its go name in lower camel case for fields skipped by json.
+func fieldName(field reflect.StructField) string {
+	name := strings.Split(field.Tag.Get("json"), ",")[0]
+	if name != "" && name != "-" {
+		return name
+	}
+
+	runes := []rune(field.Name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		upper--
+	}
+	for i := 0; i < upper; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+
+	return string(runes)
+}
+
+// fieldLabel splits camel case name of the field into lower case words, e.g. userID becomes user id.
+func fieldLabel(name string) string {
+	var label strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) && unicode.IsLower(runes[i-1]) {
+			label.WriteRune(' ')
+		}
+		label.WriteRune(unicode.ToLower(r))
+	}
+
+	return label.String()
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
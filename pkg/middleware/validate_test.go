@@ -0,0 +1,287 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	testAssert "github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestValidate(t *testing.T) {
+	assert := testAssert.New(t)
+	id := primitive.NewObjectID().Hex()
+	start := time.Date(2009, time.November, 10, 23, 0, 0, 0, time.UTC)
+	text := "some"
+	empty := ""
+	zero := 0
+
+	type embedded struct {
+		UserID int `json:"userID" validate:"positive"`
+	}
+
+	type test struct {
+		name   string
+		req    interface{}
+		expErr error
+	}
+
+	tt := []test{
+		{
+			name: "required",
+			req: &struct {
+				Name string `json:"name" validate:"required"`
+			}{},
+			expErr: ValidationErrors{{Field: "name", Rule: "required", Message: "name is required"}},
+		},
+		{
+			name: "required ok",
+			req: &struct {
+				Name string `json:"name" validate:"required"`
+			}{Name: text},
+		},
+		{
+			name: "objectid",
+			req: &struct {
+				ID string `json:"-" validate:"objectid"`
+			}{ID: text},
+			expErr: ValidationErrors{{Field: "id", Rule: "objectid", Message: "not correct id"}},
+		},
+		{
+			name: "objectid ok",
+			req: &struct {
+				ID string `json:"-" validate:"objectid"`
+			}{ID: id},
+		},
+		{
+			name: "url without host",
+			req: &struct {
+				URL string `json:"url" validate:"url"`
+			}{URL: "http://"},
+			expErr: ValidationErrors{{Field: "url", Rule: "url", Message: "not correct url"}},
+		},
+		{
+			name: "url with other scheme",
+			req: &struct {
+				URL string `json:"url" validate:"url"`
+			}{URL: "ftp://example.com"},
+			expErr: ValidationErrors{{Field: "url", Rule: "url", Message: "not correct url"}},
+		},
+		{
+			name: "url ok",
+			req: &struct {
+				URL string `json:"url" validate:"url"`
+			}{URL: "https://example.com/hook"},
+		},
+		{
+			name: "positive",
+			req: &struct {
+				FileID int `json:"fileID" validate:"positive"`
+			}{},
+			expErr: ValidationErrors{{Field: "fileID", Rule: "positive", Message: "not correct file id"}},
+		},
+		{
+			name: "positive ok",
+			req: &struct {
+				FileID int `json:"fileID" validate:"positive"`
+			}{FileID: 1},
+		},
+		{
+			name: "min",
+			req: &struct {
+				Rating int `json:"rating" validate:"min=1"`
+			}{},
+			expErr: ValidationErrors{{Field: "rating", Rule: "min", Message: "rating must be at least 1"}},
+		},
+		{
+			name: "max",
+			req: &struct {
+				Rating int `json:"rating" validate:"max=5"`
+			}{Rating: 6},
+			expErr: ValidationErrors{{Field: "rating", Rule: "max", Message: "rating must be at most 5"}},
+		},
+		{
+			name: "min and max",
+			req: &struct {
+				Rating int `json:"rating" validate:"min=1,max=5"`
+			}{Rating: 6},
+			expErr: ValidationErrors{{Field: "rating", Rule: "max", Message: "rating must be between 1 and 5"}},
+		},
+		{
+			name: "min and max ok",
+			req: &struct {
+				Rating int `json:"rating" validate:"min=1,max=5"`
+			}{Rating: 5},
+		},
+		{
+			name: "oneof",
+			req: &struct {
+				Status string `json:"status" validate:"oneof=approved rejected"`
+			}{Status: text},
+			expErr: ValidationErrors{{Field: "status", Rule: "oneof", Message: "status must be approved or rejected"}},
+		},
+		{
+			name: "oneof ok",
+			req: &struct {
+				Status string `json:"status" validate:"oneof=approved rejected"`
+			}{Status: "rejected"},
+		},
+		{
+			name: "gtefield",
+			req: &struct {
+				Start time.Time `json:"start"`
+				End   time.Time `json:"end" validate:"gtefield=Start"`
+			}{Start: start, End: start.Add(-time.Second)},
+			expErr: ValidationErrors{{Field: "end", Rule: "gtefield", Message: "end couldn't be before start"}},
+		},
+		{
+			name: "gtefield ok",
+			req: &struct {
+				Start time.Time `json:"start"`
+				End   time.Time `json:"end" validate:"gtefield=Start"`
+			}{Start: start, End: start},
+		},
+		{
+			name: "omitnil skips nil",
+			req: &struct {
+				Text *string `json:"text" validate:"omitnil,required"`
+			}{},
+		},
+		{
+			name: "omitnil validates value",
+			req: &struct {
+				Text *string `json:"text" validate:"omitnil,required"`
+			}{Text: &empty},
+			expErr: ValidationErrors{{Field: "text", Rule: "required", Message: "text is required"}},
+		},
+		{
+			name: "omitempty skips zero value",
+			req: &struct {
+				Rating *int `json:"rating" validate:"omitempty,min=1"`
+			}{Rating: &zero},
+		},
+		{
+			name: "omitempty skips nil",
+			req: &struct {
+				Rating *int `json:"rating" validate:"omitempty,min=1"`
+			}{},
+		},
+		{
+			name: "first failed rule",
+			req: &struct {
+				FileID string `json:"fileID" validate:"required,objectid"`
+			}{},
+			expErr: ValidationErrors{{Field: "fileID", Rule: "required", Message: "file id is required"}},
+		},
+		{
+			name: "all failed fields",
+			req: &struct {
+				ID   string `json:"-" validate:"objectid"`
+				Name string `json:"name" validate:"required"`
+			}{},
+			expErr: ValidationErrors{
+				{Field: "id", Rule: "objectid", Message: "not correct id"},
+				{Field: "name", Rule: "required", Message: "name is required"},
+			},
+		},
+		{
+			name: "embedded struct",
+			req: &struct {
+				embedded
+				Name string `json:"name" validate:"required"`
+			}{Name: text},
+			expErr: ValidationErrors{{Field: "userID", Rule: "positive", Message: "not correct user id"}},
+		},
+		{
+			name: "untagged fields",
+			req: &struct {
+				Name   string
+				hidden string `validate:"required"`
+			}{},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := Validate(tc.req)
+			if tc.expErr == nil {
+				assert.NoError(err)
+				return
+			}
+			assert.Equal(tc.expErr, err)
+		})
+	}
+}
+
+func TestValidate_Panics(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name     string
+		req      interface{}
+		expPanic string
+	}
+
+	tt := []test{
+		{
+			name: "unknown rule",
+			req: &struct {
+				Name string `json:"name" validate:"some"`
+			}{},
+			expPanic: "unknown validation rule some of Name",
+		},
+		{
+			name: "not correct limit",
+			req: &struct {
+				Rating int `json:"rating" validate:"min=some"`
+			}{},
+			expPanic: "not correct min rule of Rating",
+		},
+		{
+			name: "no sibling field",
+			req: &struct {
+				End time.Time `json:"end" validate:"gtefield=Start"`
+			}{},
+			expPanic: "no field Start for gtefield rule of End",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.PanicsWithValue(tc.expPanic, func() {
+				_ = Validate(tc.req)
+			})
+		})
+	}
+}
+
+func TestFieldLabel(t *testing.T) {
+	assert := testAssert.New(t)
+
+	type test struct {
+		name     string
+		field    string
+		expLabel string
+	}
+
+	tt := []test{
+		{
+			name:     "single word",
+			field:    "name",
+			expLabel: "name",
+		},
+		{
+			name:     "camel case",
+			field:    "purchaseID",
+			expLabel: "purchase id",
+		},
+		{
+			name:     "several words",
+			field:    "addedPeriodStart",
+			expLabel: "added period start",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(tc.expLabel, fieldLabel(tc.field))
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package storage
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFS is a Backend which stores contents in a mongo GridFS bucket.
+type GridFS struct {
+	bucket *gridfs.Bucket
+}
+
+// NewGridFS is a GridFS constructor.
+func NewGridFS(db *mongo.Database, name string) (*GridFS, error) {
+	opts := options.GridFSBucket()
+	if name != "" {
+		opts.SetName(name)
+	}
+
+	bucket, err := gridfs.NewBucket(db, opts)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create gridfs bucket")
+	}
+
+	return &GridFS{bucket: bucket}, nil
+}
+
+// Save uploads content to the bucket and returns its id as path.
+func (g *GridFS) Save(_ context.Context, name string, content io.Reader) (string, error) {
+	id, err := g.bucket.UploadFromStream(name, content)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't upload content")
+	}
+
+	return id.Hex(), nil
+}
+
+// Open opens uploaded content by path.
+func (g *GridFS) Open(_ context.Context, path string) (io.ReadSeekCloser, error) {
+	id, err := primitive.ObjectIDFromHex(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid path")
+	}
+
+	stream, err := g.open(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gridFSObject{
+		open:   g.open,
+		id:     id,
+		stream: stream,
+		size:   stream.GetFile().Length,
+	}, nil
+}
+
+// Delete deletes uploaded content by path.
+func (g *GridFS) Delete(_ context.Context, path string) error {
+	id, err := primitive.ObjectIDFromHex(path)
+	if err != nil {
+		return errors.Wrap(err, "invalid path")
+	}
+
+	err = g.bucket.Delete(id)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "couldn't delete content")
+	}
+
+	return nil
+}
+
+func (g *GridFS) open(id primitive.ObjectID) (*gridfs.DownloadStream, error) {
+	stream, err := g.bucket.OpenDownloadStream(id)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open content")
+	}
+
+	return stream, nil
+}
+
+// gridFSObject makes GridFS download stream seekable.
+// Seek only moves the position, the stream is reopened or skipped on the next Read.
+type gridFSObject struct {
+	open     func(id primitive.ObjectID) (*gridfs.DownloadStream, error)
+	id       primitive.ObjectID
+	stream   *gridfs.DownloadStream
+	offset   int64
+	position int64
+	size     int64
+}
+
+func (o *gridFSObject) Read(p []byte) (int, error) {
+	if o.position >= o.size {
+		return 0, io.EOF
+	}
+
+	if o.position < o.offset {
+		err := o.stream.Close()
+		if err != nil {
+			return 0, errors.Wrap(err, "couldn't close content")
+		}
+		o.stream, err = o.open(o.id)
+		if err != nil {
+			return 0, err
+		}
+		o.offset = 0
+	}
+
+	if o.position > o.offset {
+		skipped, err := o.stream.Skip(o.position - o.offset)
+		o.offset += skipped
+		if err != nil {
+			return 0, errors.Wrap(err, "couldn't seek content")
+		}
+	}
+
+	n, err := o.stream.Read(p)
+	o.offset += int64(n)
+	o.position = o.offset
+	return n, err
+}
+
+func (o *gridFSObject) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += o.position
+	case io.SeekEnd:
+		offset += o.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+
+	o.position = offset
+	return offset, nil
+}
+
+func (o *gridFSObject) Close() error {
+	return o.stream.Close()
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// Local is a Backend which stores contents in a local directory.
+type Local struct {
+	dir string
+}
+
+// NewLocal is a Local constructor.
+func NewLocal(dir string) (*Local, error) {
+	if dir == "" {
+		return nil, errors.New("empty storage directory")
+	}
+
+	err := os.MkdirAll(dir, 0o750)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create storage directory")
+	}
+
+	return &Local{dir: dir}, nil
+}
+
+// Save writes content to a new file with a random name and returns its path.
+func (l *Local) Save(_ context.Context, _ string, content io.Reader) (string, error) {
+	key := make([]byte, 16)
+	_, err := rand.Read(key)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't generate file name")
+	}
+
+	path := hex.EncodeToString(key)
+	f, err := os.OpenFile(filepath.Join(l.dir, path), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o640)
+	if err != nil {
+		return "", errors.Wrap(err, "couldn't create file")
+	}
+
+	_, err = io.Copy(f, content)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return "", errors.Wrap(err, "couldn't write file")
+	}
+
+	err = f.Close()
+	if err != nil {
+		_ = os.Remove(f.Name())
+		return "", errors.Wrap(err, "couldn't close file")
+	}
+
+	return path, nil
+}
+
+// Open opens stored file by path.
+func (l *Local) Open(_ context.Context, path string) (io.ReadSeekCloser, error) {
+	name, err := l.name(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(name)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't open file")
+	}
+
+	return f, nil
+}
+
+// Delete deletes stored file by path.
+func (l *Local) Delete(_ context.Context, path string) error {
+	name, err := l.name(path)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(name)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return errors.Wrap(err, "couldn't delete file")
+	}
+
+	return nil
+}
+
+// name converts path to a file name inside storage directory.
+func (l *Local) name(path string) (string, error) {
+	if path == "" || path != filepath.Base(path) {
+		return "", errors.Errorf("invalid path %q", path)
+	}
+
+	return filepath.Join(l.dir, path), nil
+}
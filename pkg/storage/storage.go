@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// LocalBackend is a name of the local filesystem backend.
+	LocalBackend = "local"
+	// GridFSBackend is a name of the mongo GridFS backend.
+	GridFSBackend = "gridfs"
+)
+
+// ErrNotFound is returned when content is missing in the backend.
+var ErrNotFound = errors.New("content not found")
+
+// Backend stores file contents.
+type Backend interface {
+	Save(ctx context.Context, name string, content io.Reader) (string, error)
+	Open(ctx context.Context, path string) (io.ReadSeekCloser, error)
+	Delete(ctx context.Context, path string) error
+}
+
+// Info describes stored content.
+type Info struct {
+	Path     string
+	Size     int64
+	Checksum string
+}
+
+// New creates a Backend chosen by config.
+func New(cfg config.StorageConfig, db *mongo.Database) (Backend, error) {
+	switch cfg.Backend {
+	case LocalBackend:
+		return NewLocal(cfg.Dir)
+	case GridFSBackend:
+		return NewGridFS(db, cfg.Bucket)
+	default:
+		return nil, errors.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+}
+
+// Store saves content to the backend and computes its size and SHA-256 checksum.
+func Store(ctx context.Context, backend Backend, name string, content io.Reader) (*Info, error) {
+	hash := sha256.New()
+	counter := &countingWriter{}
+	path, err := backend.Save(ctx, name, io.TeeReader(content, io.MultiWriter(hash, counter)))
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't save content")
+	}
+
+	return &Info{
+		Path:     path,
+		Size:     counter.n,
+		Checksum: hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
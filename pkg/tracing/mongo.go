@@ -0,0 +1,61 @@
+package tracing
+
+import (
+	"context"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/event"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type commandKey struct {
+	connectionID string
+	requestID    int64
+}
+
+// CommandMonitor returns a mongo driver monitor which traces every command as a client span
+// of the operation context, so commands of repositories are children of the request span.
+func CommandMonitor(tracer trace.Tracer) *event.CommandMonitor {
+	var spans sync.Map
+
+	finish := func(evt event.CommandFinishedEvent, failure string) {
+		span, ok := spans.LoadAndDelete(commandKey{evt.ConnectionID, evt.RequestID})
+		if !ok {
+			return
+		}
+		if failure != "" {
+			span.(trace.Span).SetStatus(codes.Error, failure)
+		}
+		span.(trace.Span).End()
+	}
+
+	return &event.CommandMonitor{
+		Started: func(ctx context.Context, evt *event.CommandStartedEvent) {
+			attrs := []trace.SpanStartOption{
+				trace.WithSpanKind(trace.SpanKindClient),
+				trace.WithAttributes(
+					semconv.DBSystemMongoDB,
+					semconv.DBNameKey.String(evt.DatabaseName),
+					semconv.DBOperationKey.String(evt.CommandName),
+				),
+			}
+			// The first element of a command names its collection.
+			if elem, err := evt.Command.IndexErr(0); err == nil {
+				if collection, ok := elem.Value().StringValueOK(); ok {
+					attrs = append(attrs, trace.WithAttributes(semconv.DBMongoDBCollectionKey.String(collection)))
+				}
+			}
+
+			_, span := tracer.Start(ctx, "mongo."+evt.CommandName, attrs...)
+			spans.Store(commandKey{evt.ConnectionID, evt.RequestID}, span)
+		},
+		Succeeded: func(ctx context.Context, evt *event.CommandSucceededEvent) {
+			finish(evt.CommandFinishedEvent, "")
+		},
+		Failed: func(ctx context.Context, evt *event.CommandFailedEvent) {
+			finish(evt.CommandFinishedEvent, evt.Failure)
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/url"
+
+	"github.com/JesusG2000/hexsatisfaction_purchase/internal/config"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.10.0"
+)
+
+const (
+	// NoneExporter is a name of the exporter which drops spans.
+	// Trace context is still propagated to called services.
+	NoneExporter = "none"
+	// StdoutExporter is a name of the exporter which writes spans as JSON.
+	StdoutExporter = "stdout"
+	// OTLPExporter is a name of the exporter which sends spans to an OTLP/HTTP collector.
+	OTLPExporter = "otlp"
+)
+
+// InstrumentationName is a name of the tracer of the service.
+const InstrumentationName = "github.com/JesusG2000/hexsatisfaction_purchase"
+
+// Propagator propagates trace context in W3C traceparent and tracestate headers.
+var Propagator = propagation.TraceContext{}
+
+// New creates a TracerProvider which samples traces by ratio and exports spans with the exporter chosen by config.
+// Stdout exporter writes to out.
+func New(ctx context.Context, cfg config.TraceConfig, out io.Writer) (*sdktrace.TracerProvider, error) {
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceNameKey.String(cfg.ServiceName))),
+	}
+
+	switch cfg.Exporter {
+	case NoneExporter:
+	case StdoutExporter:
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(out))
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't create stdout exporter")
+		}
+		opts = append(opts, sdktrace.WithSyncer(exporter))
+	case OTLPExporter:
+		exporter, err := newOTLP(ctx, cfg.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, sdktrace.WithBatcher(exporter))
+	default:
+		return nil, errors.Errorf("unknown trace exporter %q", cfg.Exporter)
+	}
+
+	return sdktrace.NewTracerProvider(opts...), nil
+}
+
+// newOTLP creates an OTLP/HTTP exporter. The endpoint is a full URL of traces, usually http://collector:4318/v1/traces.
+func newOTLP(ctx context.Context, endpoint string) (sdktrace.SpanExporter, error) {
+	if endpoint == "" {
+		return nil, errors.New("otlp exporter requires endpoint")
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, errors.Errorf("not correct otlp endpoint %q", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	exporter, err := otlptracehttp.New(ctx, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't create otlp exporter")
+	}
+
+	return exporter, nil
+}